@@ -0,0 +1,102 @@
+package agents
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// PromptCache 缓存构建好的 system prompt；工作区文件或技能变化时调用 Invalidate，新的轮次重新构建
+type PromptCache struct {
+	mu     sync.Mutex
+	build  func() string
+	prompt string
+	valid  bool
+}
+
+// NewPromptCache 创建 system prompt 缓存
+func NewPromptCache(build func() string) *PromptCache {
+	return &PromptCache{build: build}
+}
+
+// Get 返回缓存的 prompt，失效后重新构建
+func (c *PromptCache) Get() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.valid {
+		c.prompt = c.build()
+		c.valid = true
+	}
+	return c.prompt
+}
+
+// Invalidate 使缓存失效
+func (c *PromptCache) Invalidate() {
+	c.mu.Lock()
+	c.valid = false
+	c.mu.Unlock()
+}
+
+// WatchPromptFiles 监听工作区中的 AGENTS.md、SOUL.md 等文件，变化时以文件名调用 onChange，直到 ctx 结束
+func WatchPromptFiles(ctx context.Context, workspace string, onChange func(file string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	// 监听目录而不是文件：编辑器保存时常以重命名替换文件
+	if err := watcher.Add(workspace); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	watched := make(map[string]bool, len(WorkspacePromptFiles))
+	for _, name := range WorkspacePromptFiles {
+		watched[name] = true
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		// 合并短时间内的多次写入
+		var timer *time.Timer
+		trigger := make(chan string, 1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Base(event.Name)
+				if !watched[name] || event.Op == fsnotify.Chmod {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(300*time.Millisecond, func() {
+					select {
+					case trigger <- name:
+					default:
+					}
+				})
+			case name := <-trigger:
+				log.Info().Str("file", name).Msg("Workspace prompt file changed")
+				onChange(name)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("Prompt file watcher error")
+			}
+		}
+	}()
+
+	return nil
+}
@@ -7,10 +7,20 @@ import (
 	"runtime"
 	"strings"
 	"time"
+
+	"github.com/z8n24/openclaw-go/internal/skills"
 )
 
+// WorkspacePromptFiles 写入 system prompt 的工作区文件
+var WorkspacePromptFiles = []string{"AGENTS.md", "SOUL.md", "USER.md", "TOOLS.md"}
+
 // BuildSystemPrompt 构建完整的 system prompt
 func BuildSystemPrompt(workspace string, tools []Tool) string {
+	return BuildSystemPromptWithSkills(workspace, tools, nil)
+}
+
+// BuildSystemPromptWithSkills 构建 system prompt，并注入已启用技能的 SKILL.md 使用说明
+func BuildSystemPromptWithSkills(workspace string, tools []Tool, skillList []*skills.Skill) string {
 	var sb strings.Builder
 	
 	sb.WriteString("You are a personal AI assistant with access to tools.\n\n")
@@ -23,14 +33,16 @@ func BuildSystemPrompt(workspace string, tools []Tool) string {
 	}
 	sb.WriteString("\n")
 	
+	// Skills 说明
+	writeSkillGuidance(&sb, skillList)
+	
 	// Workspace
 	sb.WriteString("## Workspace\n\n")
 	sb.WriteString(fmt.Sprintf("Your working directory is: %s\n", workspace))
 	sb.WriteString("Treat this directory as your workspace for file operations.\n\n")
 	
 	// 读取 workspace 文件
-	workspaceFiles := []string{"AGENTS.md", "SOUL.md", "USER.md", "TOOLS.md"}
-	for _, filename := range workspaceFiles {
+	for _, filename := range WorkspacePromptFiles {
 		path := filepath.Join(workspace, filename)
 		content, err := os.ReadFile(path)
 		if err == nil && len(content) > 0 {
@@ -66,3 +78,22 @@ func BuildSystemPrompt(workspace string, tools []Tool) string {
 	
 	return sb.String()
 }
+
+// writeSkillGuidance 写入技能说明，技能的工具名称为 <skillID>__<tool>
+func writeSkillGuidance(sb *strings.Builder, skillList []*skills.Skill) {
+	var written bool
+	for _, skill := range skillList {
+		guidance := skill.Guidance()
+		if guidance == "" {
+			continue
+		}
+		if !written {
+			sb.WriteString("## Skills\n\n")
+			sb.WriteString("The following skills are installed. Their tools are named <skill-id>__<tool>.\n\n")
+			written = true
+		}
+		sb.WriteString(fmt.Sprintf("### Skill: %s (%s)\n\n", skill.Name, skill.ID))
+		sb.WriteString(guidance)
+		sb.WriteString("\n\n")
+	}
+}
@@ -0,0 +1,559 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
+)
+
+// ApplyPatchTool 一次调用修改多个文件的补丁工具。
+// 所有修改先在内存中计算，任何一个 hunk 冲突都不会写入文件；写入失败时回滚已写的文件。
+type ApplyPatchTool struct {
+	workdir     string
+	paths       *PathPolicy
+	checkpoints *checkpoints.Store
+}
+
+// ApplyPatchParams apply_patch 工具参数
+type ApplyPatchParams struct {
+	Patch string `json:"patch"`
+	// 兼容别名
+	Input string `json:"input,omitempty"`
+}
+
+// NewApplyPatchTool 创建 apply_patch 工具
+func NewApplyPatchTool(workdir string) *ApplyPatchTool {
+	return &ApplyPatchTool{
+		workdir: workdir,
+		paths:   DefaultPathPolicy(workdir),
+	}
+}
+
+// SetCheckpoints 应用补丁前保存涉及文件的快照
+func (t *ApplyPatchTool) SetCheckpoints(store *checkpoints.Store) {
+	t.checkpoints = store
+}
+
+// SetPathPolicy 设置路径访问策略
+func (t *ApplyPatchTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
+func (t *ApplyPatchTool) Name() string {
+	return ToolApplyPatch
+}
+
+func (t *ApplyPatchTool) Description() string {
+	return "Apply a patch that adds, deletes, renames or updates multiple files at once. " +
+		"Accepts a unified diff (git diff / diff -u) or the *** Begin Patch format. " +
+		"Context lines are matched tolerantly (line numbers and whitespace may be off); " +
+		"if any hunk does not apply, nothing is written and the conflict is reported."
+}
+
+func (t *ApplyPatchTool) Parameters() json.RawMessage {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"patch": {
+				"type": "string",
+				"description": "Unified diff, or:\n*** Begin Patch\n*** Update File: path\n@@ optional anchor line\n context\n-old\n+new\n*** Add File: path\n+line\n*** Delete File: path\n*** End Patch\n(*** Move to: new/path may follow *** Update File to rename)"
+			},
+			"input": {
+				"type": "string",
+				"description": "Alias for patch"
+			}
+		},
+		"required": ["patch"]
+	}`
+	return json.RawMessage(schema)
+}
+
+func (t *ApplyPatchTool) Execute(ctx context.Context, args json.RawMessage) (*Result, error) {
+	var params ApplyPatchParams
+	if err := json.Unmarshal(args, &params); err != nil {
+		return &Result{Content: "Invalid parameters: " + err.Error(), IsError: true}, nil
+	}
+	if params.Patch == "" {
+		params.Patch = params.Input
+	}
+	if strings.TrimSpace(params.Patch) == "" {
+		return &Result{Content: "patch is required", IsError: true}, nil
+	}
+
+	patches, err := parsePatch(params.Patch)
+	if err != nil {
+		return &Result{Content: "Invalid patch: " + err.Error(), IsError: true}, nil
+	}
+
+	state := newPatchState(ctx, t.workdir, t.paths)
+	var stats []patchStat
+	for _, fp := range patches {
+		stat, err := state.apply(fp)
+		if err != nil {
+			return &Result{Content: "Patch not applied (no files were changed):\n" + err.Error(), IsError: true}, nil
+		}
+		stats = append(stats, stat)
+	}
+
+	if err := snapshotFiles(ctx, t.checkpoints, ToolApplyPatch, state.paths()...); err != nil {
+		return &Result{Content: fmt.Sprintf("Failed to checkpoint files: %v", err), IsError: true}, nil
+	}
+	if err := state.commit(); err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
+	}
+	return &Result{Content: formatPatchStats(stats)}, nil
+}
+
+// patchStat 单个文件的修改统计
+type patchStat struct {
+	op      string
+	path    string
+	moveTo  string
+	added   int
+	removed int
+}
+
+// patchFile 补丁应用过程中文件的内存状态
+type patchFile struct {
+	original []byte // 磁盘上的原始内容 (existed 为 false 时为空)
+	existed  bool
+	mode     os.FileMode
+	content  string
+	exists   bool // 应用补丁后是否存在
+}
+
+// patchState 记录补丁涉及的所有文件，应用结束后一次性写入
+type patchState struct {
+	ctx     context.Context
+	workdir string
+	policy  *PathPolicy
+	files   map[string]*patchFile
+}
+
+func newPatchState(ctx context.Context, workdir string, policy *PathPolicy) *patchState {
+	return &patchState{ctx: ctx, workdir: workdir, policy: policy, files: make(map[string]*patchFile)}
+}
+
+// resolve 解析相对于工作目录的路径并检查写权限
+func (s *patchState) resolve(p string) (string, error) {
+	return s.policy.Resolve(s.ctx, p, true)
+}
+
+// display 输出中使用的路径 (工作目录内为相对路径)
+func (s *patchState) display(p string) string {
+	if rel, err := filepath.Rel(s.workdir, p); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return p
+}
+
+// file 读取文件当前状态 (首次访问时从磁盘加载)
+func (s *patchState) file(path string) (*patchFile, error) {
+	if f, ok := s.files[path]; ok {
+		return f, nil
+	}
+	f := &patchFile{mode: 0644}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		f.original, f.existed, f.exists, f.content = data, true, true, string(data)
+		if info, err := os.Stat(path); err == nil {
+			f.mode = info.Mode().Perm()
+		}
+	case os.IsNotExist(err):
+	default:
+		return nil, fmt.Errorf("%s: %v", s.display(path), err)
+	}
+	s.files[path] = f
+	return f, nil
+}
+
+// apply 在内存中应用单个文件的修改
+func (s *patchState) apply(fp filePatch) (patchStat, error) {
+	stat := patchStat{op: fp.op, path: fp.path}
+	path, err := s.resolve(fp.path)
+	if err != nil {
+		return stat, err
+	}
+	stat.path = s.display(path)
+	f, err := s.file(path)
+	if err != nil {
+		return stat, err
+	}
+
+	switch fp.op {
+	case patchAdd:
+		if f.exists {
+			return stat, fmt.Errorf("%s: cannot add, file already exists", stat.path)
+		}
+		var lines []string
+		for _, h := range fp.hunks {
+			lines = append(lines, h.newLines()...)
+			stat.added += len(h.newLines())
+		}
+		f.content = joinPatchLines(lines, "\n", len(fp.hunks) == 0 || !fp.hunks[len(fp.hunks)-1].newNoEOL)
+		f.exists = true
+		return stat, nil
+
+	case patchDelete:
+		if !f.exists {
+			return stat, fmt.Errorf("%s: cannot delete, file does not exist", stat.path)
+		}
+		stat.removed = len(splitPatchLines(f.content))
+		f.content, f.exists = "", false
+		return stat, nil
+	}
+
+	if !f.exists {
+		return stat, fmt.Errorf("%s: cannot update, file does not exist", stat.path)
+	}
+	content, added, removed, err := applyHunks(f.content, fp.hunks)
+	if err != nil {
+		return stat, fmt.Errorf("%s: %v", stat.path, err)
+	}
+	stat.added, stat.removed = added, removed
+
+	if fp.moveTo == "" {
+		f.content = content
+		return stat, nil
+	}
+
+	dest, err := s.resolve(fp.moveTo)
+	if err != nil {
+		return stat, err
+	}
+	stat.moveTo = s.display(dest)
+	if dest == path {
+		f.content = content
+		stat.moveTo = ""
+		return stat, nil
+	}
+	target, err := s.file(dest)
+	if err != nil {
+		return stat, err
+	}
+	if target.exists {
+		return stat, fmt.Errorf("%s: cannot move to %s, destination already exists", stat.path, stat.moveTo)
+	}
+	target.content, target.exists, target.mode = content, true, f.mode
+	f.content, f.exists = "", false
+	return stat, nil
+}
+
+// paths 补丁涉及的所有文件
+func (s *patchState) paths() []string {
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// commit 写入所有修改；任何一步失败都恢复已修改的文件
+func (s *patchState) commit() error {
+	paths := s.paths()
+
+	var done []string
+	rollback := func() {
+		for _, p := range done {
+			f := s.files[p]
+			if f.existed {
+				os.WriteFile(p, f.original, f.mode)
+			} else {
+				os.Remove(p)
+			}
+		}
+	}
+
+	for _, p := range paths {
+		f := s.files[p]
+		if !f.exists && !f.existed {
+			continue
+		}
+		if f.exists && f.existed && f.content == string(f.original) {
+			continue
+		}
+		var err error
+		if f.exists {
+			err = writeFileAtomic(p, []byte(f.content), f.mode)
+		} else {
+			err = os.Remove(p)
+		}
+		if err != nil {
+			rollback()
+			return fmt.Errorf("Failed to write %s: %v (all changes were rolled back)", s.display(p), err)
+		}
+		done = append(done, p)
+	}
+	return nil
+}
+
+// writeFileAtomic 先写临时文件再重命名
+func writeFileAtomic(path string, data []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".patch-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// applyHunks 依次应用 hunk，返回新内容和增删行数
+func applyHunks(content string, hunks []patchHunk) (string, int, int, error) {
+	eol := "\n"
+	if strings.Contains(content, "\r\n") {
+		eol = "\r\n"
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	finalNewline := content == "" || strings.HasSuffix(content, "\n")
+	lines := splitPatchLines(content)
+
+	var out []string
+	pos := 0 // 原文件中尚未处理的第一行
+	added, removed := 0, 0
+	for i, h := range hunks {
+		from := pos
+		if h.anchor != "" {
+			idx := findAnchor(lines, h.anchor, pos)
+			if idx < 0 {
+				return "", 0, 0, fmt.Errorf("hunk %d: anchor line %q not found", i+1, h.anchor)
+			}
+			from = idx + 1
+		}
+
+		old := h.oldLines()
+		var start int
+		if len(old) == 0 {
+			// 纯插入：按行号、文件末尾或定位行决定位置
+			switch {
+			case h.atEOF:
+				start = len(lines)
+			case h.oldStart > 0:
+				start = min(max(h.oldStart-1, from), len(lines))
+			case h.anchor != "":
+				start = from
+			default:
+				start = len(lines)
+			}
+		} else {
+			start = findLines(lines, old, from, h.oldStart-1, h.atEOF)
+			if start < 0 {
+				return "", 0, 0, hunkConflict(i+1, h, lines, from)
+			}
+		}
+
+		out = append(out, lines[pos:start]...)
+		// 上下文行保留文件中的原样 (可能与补丁有空白差异)
+		k := start
+		for _, l := range h.lines {
+			switch l.op {
+			case ' ':
+				out = append(out, lines[k])
+				k++
+			case '-':
+				k++
+			case '+':
+				out = append(out, l.text)
+			}
+		}
+		pos = start + len(old)
+
+		a, r := h.count()
+		added += a
+		removed += r
+		if pos == len(lines) {
+			if h.newNoEOL {
+				finalNewline = false
+			} else if h.oldNoEOL || a > 0 {
+				finalNewline = true
+			}
+		}
+	}
+	out = append(out, lines[pos:]...)
+	return joinPatchLines(out, eol, finalNewline), added, removed, nil
+}
+
+// 匹配级别：精确、忽略行尾空白、忽略首尾空白
+var lineMatchers = []func(a, b string) bool{
+	func(a, b string) bool { return a == b },
+	func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	func(a, b string) bool { return strings.TrimSpace(a) == strings.TrimSpace(b) },
+}
+
+// findLines 在 lines[from:] 中查找 target，返回起始下标。
+// 从严格到宽松逐级匹配；多处匹配时取离 hint 最近的位置 (hint < 0 时取第一处)。
+func findLines(lines, target []string, from, hint int, atEOF bool) int {
+	if len(target) > len(lines)-from {
+		return -1
+	}
+	for _, match := range lineMatchers {
+		best := -1
+		for i := from; i+len(target) <= len(lines); i++ {
+			if atEOF && i+len(target) != len(lines) {
+				continue
+			}
+			ok := true
+			for j, want := range target {
+				if !match(lines[i+j], want) {
+					ok = false
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+			if hint < 0 {
+				return i
+			}
+			if best < 0 || absInt(i-hint) < absInt(best-hint) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			return best
+		}
+	}
+	return -1
+}
+
+// findAnchor 查找 @@ 定位行
+func findAnchor(lines []string, anchor string, from int) int {
+	for _, match := range lineMatchers {
+		for i := from; i < len(lines); i++ {
+			if match(lines[i], anchor) {
+				return i
+			}
+		}
+	}
+	// 定位行常常只是行的一部分 (如函数签名)
+	for i := from; i < len(lines); i++ {
+		if strings.Contains(lines[i], strings.TrimSpace(anchor)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// hunkConflict 生成冲突报告：期望的内容和最接近的位置
+func hunkConflict(n int, h patchHunk, lines []string, from int) error {
+	old := h.oldLines()
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "hunk %d does not apply", n)
+	if h.oldStart > 0 {
+		fmt.Fprintf(&sb, " (expected near line %d)", h.oldStart)
+	}
+	sb.WriteString("\nExpected:\n")
+	for _, l := range old {
+		sb.WriteString("  | " + l + "\n")
+	}
+
+	best, bestScore := -1, 0
+	for i := from; i < len(lines); i++ {
+		score := 0
+		for j, want := range old {
+			if i+j < len(lines) && strings.TrimSpace(lines[i+j]) == strings.TrimSpace(want) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		sb.WriteString("No similar lines found in the file.")
+		return fmt.Errorf("%s", sb.String())
+	}
+
+	fmt.Fprintf(&sb, "Closest match at line %d (%d/%d lines match):\n", best+1, bestScore, len(old))
+	for j, want := range old {
+		if best+j >= len(lines) {
+			sb.WriteString("  ! <end of file>\n")
+			break
+		}
+		marker := "  | "
+		if strings.TrimSpace(lines[best+j]) != strings.TrimSpace(want) {
+			marker = "  ! "
+		}
+		sb.WriteString(marker + lines[best+j] + "\n")
+	}
+	return fmt.Errorf("%s", strings.TrimRight(sb.String(), "\n"))
+}
+
+// splitPatchLines 按行拆分 (不含末尾换行产生的空行)
+func splitPatchLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// joinPatchLines 按行合并
+func joinPatchLines(lines []string, eol string, finalNewline bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	s := strings.Join(lines, eol)
+	if finalNewline {
+		s += eol
+	}
+	return s
+}
+
+// formatPatchStats 修改摘要
+func formatPatchStats(stats []patchStat) string {
+	totalAdded, totalRemoved := 0, 0
+	var sb strings.Builder
+	for _, st := range stats {
+		totalAdded += st.added
+		totalRemoved += st.removed
+		switch {
+		case st.op == patchAdd:
+			fmt.Fprintf(&sb, "A %s (+%d)\n", st.path, st.added)
+		case st.op == patchDelete:
+			fmt.Fprintf(&sb, "D %s (-%d)\n", st.path, st.removed)
+		case st.moveTo != "":
+			fmt.Fprintf(&sb, "R %s -> %s (+%d -%d)\n", st.path, st.moveTo, st.added, st.removed)
+		default:
+			fmt.Fprintf(&sb, "M %s (+%d -%d)\n", st.path, st.added, st.removed)
+		}
+	}
+	files := "files"
+	if len(stats) == 1 {
+		files = "file"
+	}
+	return fmt.Sprintf("Applied patch to %d %s (+%d -%d):\n%s", len(stats), files, totalAdded, totalRemoved, strings.TrimRight(sb.String(), "\n"))
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
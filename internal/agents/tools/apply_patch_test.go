@@ -0,0 +1,261 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runPatch(t *testing.T, dir, patch string) *Result {
+	t.Helper()
+	tool := NewApplyPatchTool(dir)
+	args, _ := json.Marshal(ApplyPatchParams{Patch: patch})
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	return result
+}
+
+func readPatched(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestApplyPatch_BeginPatchMultiFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "old.txt"), []byte("obsolete\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "util.go"), []byte("package main\n\nfunc helper() int {\n\treturn 1\n}\n"), 0644)
+
+	patch := `*** Begin Patch
+*** Update File: main.go
+@@ func main() {
+-	println("hi")
++	println("hello")
++	println(helper())
+*** Add File: docs/notes.md
++# Notes
++
++patched
+*** Delete File: old.txt
+*** Update File: util.go
+*** Move to: helpers.go
+@@
+ func helper() int {
+-	return 1
++	return 2
+ }
+*** End Patch`
+
+	result := runPatch(t, dir, patch)
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+
+	if got := readPatched(t, filepath.Join(dir, "main.go")); got != "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(helper())\n}\n" {
+		t.Errorf("main.go mismatch:\n%s", got)
+	}
+	if got := readPatched(t, filepath.Join(dir, "docs", "notes.md")); got != "# Notes\n\npatched\n" {
+		t.Errorf("notes.md mismatch:\n%q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected old.txt to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "util.go")); !os.IsNotExist(err) {
+		t.Errorf("Expected util.go to be moved")
+	}
+	if got := readPatched(t, filepath.Join(dir, "helpers.go")); !strings.Contains(got, "return 2") {
+		t.Errorf("helpers.go mismatch:\n%s", got)
+	}
+
+	for _, want := range []string{"Applied patch to 4 files", "M main.go (+2 -1)", "A docs/notes.md (+3)", "D old.txt (-1)", "R util.go -> helpers.go (+1 -1)"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected %q in summary:\n%s", want, result.Content)
+		}
+	}
+}
+
+func TestApplyPatch_UnifiedDiff(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("one\ntwo\nthree\nfour\nfive\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "gone.txt"), []byte("x\ny\n"), 0644)
+
+	patch := `diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+@@ -4,2 +4,3 @@
+ four
+ five
++six
+diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+\ No newline at end of file
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-x
+-y
+`
+
+	result := runPatch(t, dir, patch)
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+	if got := readPatched(t, filepath.Join(dir, "a.txt")); got != "one\nTWO\nthree\nfour\nfive\nsix\n" {
+		t.Errorf("a.txt mismatch:\n%q", got)
+	}
+	if got := readPatched(t, filepath.Join(dir, "new.txt")); got != "hello\nworld" {
+		t.Errorf("new.txt mismatch: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gone.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected gone.txt to be deleted")
+	}
+}
+
+func TestApplyPatch_FuzzyContext(t *testing.T) {
+	dir := t.TempDir()
+	// 实际文件比补丁多了几行，缩进和行尾空白也不同；使用 CRLF
+	content := "header\r\nextra 1\r\nextra 2\r\nfunc f() {\r\n    x := 1   \r\n    return x\r\n}\r\n"
+	os.WriteFile(filepath.Join(dir, "f.go"), []byte(content), 0644)
+
+	patch := `--- a/f.go
++++ b/f.go
+@@ -2,4 +2,4 @@
+ func f() {
+-	x := 1
++	x := 2
+ 	return x
+ }
+`
+	result := runPatch(t, dir, patch)
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+	want := "header\r\nextra 1\r\nextra 2\r\nfunc f() {\r\n\tx := 2\r\n    return x\r\n}\r\n"
+	if got := readPatched(t, filepath.Join(dir, "f.go")); got != want {
+		t.Errorf("f.go mismatch:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestApplyPatch_PrefersMatchNearLineNumber(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "dup.txt"), []byte("a\nsame\nb\nc\nsame\nd\n"), 0644)
+
+	patch := `--- a/dup.txt
++++ b/dup.txt
+@@ -5,1 +5,1 @@
+-same
++changed
+`
+	result := runPatch(t, dir, patch)
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+	if got := readPatched(t, filepath.Join(dir, "dup.txt")); got != "a\nsame\nb\nc\nchanged\nd\n" {
+		t.Errorf("dup.txt mismatch: %q", got)
+	}
+}
+
+func TestApplyPatch_ConflictIsAtomic(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "ok.txt"), []byte("alpha\nbeta\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("one\ntwo\nthree\n"), 0644)
+
+	patch := `*** Begin Patch
+*** Update File: ok.txt
+-alpha
++ALPHA
+*** Update File: bad.txt
+ one
+-deux
++2
+ three
+*** Add File: created.txt
++new
+*** End Patch`
+
+	result := runPatch(t, dir, patch)
+	if !result.IsError {
+		t.Fatalf("Expected conflict, got: %s", result.Content)
+	}
+	for _, want := range []string{"no files were changed", "bad.txt", "hunk 1 does not apply", "Closest match at line 1 (2/3 lines match)", "! two"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("Expected %q in report:\n%s", want, result.Content)
+		}
+	}
+
+	if got := readPatched(t, filepath.Join(dir, "ok.txt")); got != "alpha\nbeta\n" {
+		t.Errorf("ok.txt should be untouched, got: %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "created.txt")); !os.IsNotExist(err) {
+		t.Errorf("created.txt should not exist")
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "exists.txt"), []byte("x\n"), 0644)
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"empty", "", "patch is required"},
+		{"garbage", "just some text", "Invalid patch"},
+		{"missing end", "*** Begin Patch\n*** Delete File: exists.txt", "missing *** End Patch"},
+		{"add existing", "*** Begin Patch\n*** Add File: exists.txt\n+y\n*** End Patch", "already exists"},
+		{"update missing", "*** Begin Patch\n*** Update File: missing.txt\n-a\n+b\n*** End Patch", "does not exist"},
+		{"bad line", "*** Begin Patch\n*** Update File: exists.txt\nx\n*** End Patch", "must start with"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runPatch(t, dir, tt.patch)
+			if !result.IsError || !strings.Contains(result.Content, tt.want) {
+				t.Errorf("Expected error containing %q, got: %s", tt.want, result.Content)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_SameFileTwice(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "f.txt"), []byte("a\nb\nc\n"), 0644)
+
+	// 同一文件的两段修改依次生效
+	patch := `*** Begin Patch
+*** Update File: f.txt
+-a
++A
+*** Update File: f.txt
+ A
+-b
++B
+*** End Patch`
+	result := runPatch(t, dir, patch)
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+	if got := readPatched(t, filepath.Join(dir, "f.txt")); got != "A\nB\nc\n" {
+		t.Errorf("f.txt mismatch: %q", got)
+	}
+}
@@ -5,57 +5,115 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/cdp"
 	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
 	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/cdproto/runtime"
 	"github.com/chromedp/chromedp"
+	"github.com/z8n24/openclaw-go/internal/config"
 )
 
-// BrowserTool 浏览器控制工具
+// BrowserTool 浏览器控制工具。
+// 每个 profile 是一个浏览器进程 (有 stateDir 时 cookie 持久化)，可以有多个标签页；
+// 标签页归属于打开它的会话，每个会话有自己的当前标签页，互不干扰。
 type BrowserTool struct {
-	allocCtx context.Context
-	cancel   context.CancelFunc
-	ctx      context.Context
-	mu       sync.Mutex
-	started  bool
+	mu             sync.Mutex
+	stateDir       string      // profile 的 user-data-dir 位于 <stateDir>/browser/profiles/<name>
+	downloadDir    string      // 为空时不捕获下载；也是 pdf/screenshot 的默认输出目录
+	paths          *PathPolicy // upload 和输出 path 的访问策略
+	defaultProfile string
+	headless       bool
+	profiles       map[string]*browserProfile
+	sessions       map[string]*browserSession
 }
 
 type BrowserParams struct {
-	Action    string            `json:"action"` // start, stop, navigate, snapshot, screenshot, click, type, etc.
+	Action    string            `json:"action"` // status, start, stop, tabs, open, focus, close, navigate, snapshot, screenshot, pdf, console, network, upload, wait, act, downloads
 	URL       string            `json:"url,omitempty"`
 	Selector  string            `json:"selector,omitempty"`
 	Text      string            `json:"text,omitempty"`
 	Ref       string            `json:"ref,omitempty"`
+	Profile   string            `json:"profile,omitempty"`
+	TabID     string            `json:"tabId,omitempty"`
+	Path      string            `json:"path,omitempty"`  // pdf/screenshot 输出路径，upload 的单个文件
+	Paths     []string          `json:"paths,omitempty"` // upload 的文件
+	Level     string            `json:"level,omitempty"` // console 过滤级别
+	Clear     bool              `json:"clear,omitempty"` // console/network 读取后清空
+	State     string            `json:"state,omitempty"` // wait: load, networkidle
+	Landscape bool              `json:"landscape,omitempty"`
 	FullPage  bool              `json:"fullPage,omitempty"`
 	TimeoutMs int               `json:"timeoutMs,omitempty"`
 	Request   *BrowserActParams `json:"request,omitempty"`
 }
 
 type BrowserActParams struct {
-	Kind     string `json:"kind"` // click, type, press, hover, scroll, wait
-	Ref      string `json:"ref,omitempty"`
-	Selector string `json:"selector,omitempty"`
-	Text     string `json:"text,omitempty"`
-	Key      string `json:"key,omitempty"`
-	TimeMs   int    `json:"timeMs,omitempty"`
+	Kind     string   `json:"kind"` // click, type, press, hover, select, scroll, wait
+	Ref      string   `json:"ref,omitempty"`
+	Selector string   `json:"selector,omitempty"`
+	Text     string   `json:"text,omitempty"`
+	Key      string   `json:"key,omitempty"`
+	Values   []string `json:"values,omitempty"` // select 的选项 value 或文本
+	TimeMs   int      `json:"timeMs,omitempty"`
 }
 
-var globalBrowser *BrowserTool
-var browserOnce sync.Once
+// NewBrowserTool 创建 browser 工具 (临时 profile，不捕获下载)
+func NewBrowserTool() *BrowserTool {
+	return &BrowserTool{
+		defaultProfile: DefaultBrowserProfile,
+		headless:       true,
+		profiles:       make(map[string]*browserProfile),
+		sessions:       make(map[string]*browserSession),
+	}
+}
 
-func GetBrowserTool() *BrowserTool {
-	browserOnce.Do(func() {
-		globalBrowser = &BrowserTool{}
-	})
-	return globalBrowser
+// NewBrowserToolFromConfig 根据配置创建 browser 工具；
+// profile 数据保存在 stateDir 下，下载保存到工作区的 downloadDir (默认 downloads)
+func NewBrowserToolFromConfig(cfg config.BrowserConfig, stateDir, workdir string) *BrowserTool {
+	t := NewBrowserTool()
+	t.stateDir = stateDir
+	if cfg.Profile != "" {
+		t.defaultProfile = cfg.Profile
+	}
+	if cfg.Headless != nil {
+		t.headless = *cfg.Headless
+	}
+	dir := cfg.DownloadDir
+	if dir == "" {
+		dir = "downloads"
+	}
+	if !filepath.IsAbs(dir) && workdir != "" {
+		dir = filepath.Join(workdir, dir)
+	}
+	if filepath.IsAbs(dir) {
+		t.downloadDir = dir
+	}
+	if workdir != "" {
+		t.paths = DefaultPathPolicy(workdir)
+	}
+	return t
 }
 
-func NewBrowserTool() *BrowserTool {
-	return GetBrowserTool()
+// ApplyConfig 热应用默认 profile 和 headless 设置：之后新建的会话使用新的默认 profile，
+// headless 在 profile 下次启动时生效；已打开的浏览器不受影响
+func (t *BrowserTool) ApplyConfig(cfg config.BrowserConfig) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.defaultProfile = DefaultBrowserProfile
+	if cfg.Profile != "" {
+		t.defaultProfile = cfg.Profile
+	}
+	t.headless = true
+	if cfg.Headless != nil {
+		t.headless = *cfg.Headless
+	}
 }
 
 func (t *BrowserTool) Name() string {
@@ -63,7 +121,11 @@ func (t *BrowserTool) Name() string {
 }
 
 func (t *BrowserTool) Description() string {
-	return "Control web browser: start/stop, navigate, take screenshots, interact with elements."
+	return "Control web browser: start/stop, navigate, take screenshots, interact with elements. " +
+		"snapshot returns the page's accessibility tree as a role/name outline where interactive elements carry refs like [ref=e12]; " +
+		"use act with request.ref to click, type, select or hover them. Refs stay stable between snapshots of the same page and are reset on navigation. " +
+		"Each conversation has its own tabs (tabs/open/focus/close); named profiles keep cookies and logins between runs. Downloads are saved to the workspace. " +
+		"pdf exports the page, console and network show console errors and failed requests, upload sets workspace files on a file input, wait waits for a selector, text, load or networkidle."
 }
 
 func (t *BrowserTool) Parameters() json.RawMessage {
@@ -72,19 +134,31 @@ func (t *BrowserTool) Parameters() json.RawMessage {
 		"properties": {
 			"action": {
 				"type": "string",
-				"enum": ["status", "start", "stop", "navigate", "snapshot", "screenshot", "act"],
+				"enum": ["status", "start", "stop", "tabs", "open", "focus", "close", "navigate", "snapshot", "screenshot", "pdf", "console", "network", "upload", "wait", "act", "downloads"],
 				"description": "Browser action to perform"
 			},
-			"url": {"type": "string", "description": "URL to navigate to"},
-			"selector": {"type": "string", "description": "CSS selector for element"},
+			"url": {"type": "string", "description": "URL to navigate to (navigate, open)"},
+			"profile": {"type": "string", "description": "Browser profile for start/open (persistent cookies per profile)"},
+			"tabId": {"type": "string", "description": "Tab ID for focus/close (from tabs)"},
+			"selector": {"type": "string", "description": "CSS selector for element (upload, wait)"},
+			"ref": {"type": "string", "description": "Element ref from the last snapshot (e.g. e12)"},
+			"text": {"type": "string", "description": "Text to wait for"},
+			"state": {"type": "string", "enum": ["load", "networkidle"], "description": "Page state to wait for"},
+			"path": {"type": "string", "description": "Output file for pdf/screenshot (default: downloads folder)"},
+			"paths": {"type": "array", "items": {"type": "string"}, "description": "Workspace files to upload"},
+			"level": {"type": "string", "enum": ["error", "warning", "info", "log", "debug"], "description": "Console level filter (warning includes errors)"},
+			"clear": {"type": "boolean", "description": "Clear console/network records after reading"},
+			"landscape": {"type": "boolean", "description": "Landscape PDF"},
 			"fullPage": {"type": "boolean", "description": "Take full page screenshot"},
 			"timeoutMs": {"type": "number", "description": "Timeout in milliseconds"},
 			"request": {
 				"type": "object",
 				"properties": {
-					"kind": {"type": "string", "enum": ["click", "type", "press", "hover", "scroll", "wait"]},
-					"selector": {"type": "string"},
-					"text": {"type": "string"},
+					"kind": {"type": "string", "enum": ["click", "type", "press", "hover", "select", "scroll", "wait"]},
+					"ref": {"type": "string", "description": "Element ref from snapshot (preferred over selector)"},
+					"selector": {"type": "string", "description": "CSS selector (fallback when no ref)"},
+					"text": {"type": "string", "description": "Text to type"},
+					"values": {"type": "array", "items": {"type": "string"}, "description": "Option values or labels for select"},
 					"key": {"type": "string"},
 					"timeMs": {"type": "number"}
 				}
@@ -102,124 +176,256 @@ func (t *BrowserTool) Execute(ctx context.Context, args json.RawMessage) (*Resul
 
 	switch params.Action {
 	case "status":
-		return t.status()
+		return t.status(ctx)
 	case "start":
-		return t.start()
+		return t.start(ctx, params.Profile)
 	case "stop":
-		return t.stop()
+		return t.stop(ctx, params.Profile)
+	case "tabs":
+		return t.tabs(ctx)
+	case "open":
+		return t.open(ctx, params.Profile, params.URL, params.TimeoutMs)
+	case "focus":
+		return t.focus(ctx, params.TabID)
+	case "close":
+		return t.closeTab(ctx, params.TabID)
+	case "downloads":
+		return t.downloads()
+	case "navigate", "snapshot", "screenshot", "pdf", "console", "network", "upload", "wait", "act":
+	default:
+		return &Result{Content: "Unknown action: " + params.Action, IsError: true}, nil
+	}
+
+	tab, err := t.currentTab(ctx)
+	if err != nil {
+		return &Result{Content: "Failed to start browser: " + err.Error(), IsError: true}, nil
+	}
+	switch params.Action {
 	case "navigate":
-		return t.navigate(ctx, params.URL, params.TimeoutMs)
+		return t.navigate(tab, params.URL, params.TimeoutMs)
 	case "snapshot":
-		return t.snapshot(ctx)
+		return t.snapshot(tab)
 	case "screenshot":
-		return t.screenshot(ctx, params.FullPage)
-	case "act":
+		return t.screenshot(ctx, tab, params)
+	case "pdf":
+		return t.pdf(ctx, tab, params)
+	case "console":
+		return t.console(tab, params)
+	case "network":
+		return t.network(tab, params)
+	case "upload":
+		return t.upload(ctx, tab, params)
+	case "wait":
+		return t.wait(tab, params)
+	default:
 		if params.Request == nil {
 			return &Result{Content: "Request is required for act action", IsError: true}, nil
 		}
-		return t.act(ctx, params.Request)
-	default:
-		return &Result{Content: "Unknown action: " + params.Action, IsError: true}, nil
+		if params.Request.Ref == "" {
+			params.Request.Ref = params.Ref
+		}
+		return t.act(tab, params.Request)
 	}
 }
 
-func (t *BrowserTool) status() (*Result, error) {
+func (t *BrowserTool) status(ctx context.Context) (*Result, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.started {
-		return &Result{Content: "Browser is running"}, nil
+	if len(t.profiles) == 0 {
+		return &Result{Content: "Browser is not running"}, nil
+	}
+	key := browserSessionKey(ctx)
+	s := t.sessionLocked(key)
+	var sb strings.Builder
+	for _, name := range sortedKeys(t.profiles) {
+		p := t.profiles[name]
+		persist := "temporary"
+		if p.dataDir != "" {
+			persist = "persistent"
+		}
+		fmt.Fprintf(&sb, "Profile %s: running (%s), %d tab(s)\n", name, persist, len(p.tabs))
+	}
+	if s.tab != "" {
+		fmt.Fprintf(&sb, "Current tab: %s/%s", s.profile, s.tab)
+	} else {
+		fmt.Fprintf(&sb, "No tab open for this session (profile %s)", s.profile)
 	}
-	return &Result{Content: "Browser is not running"}, nil
+	return &Result{Content: sb.String()}, nil
 }
 
-func (t *BrowserTool) start() (*Result, error) {
+// start 启动 profile 并设为会话使用的 profile
+func (t *BrowserTool) start(ctx context.Context, profile string) (*Result, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.started {
-		return &Result{Content: "Browser already running"}, nil
+	s := t.sessionLocked(browserSessionKey(ctx))
+	if profile == "" {
+		profile = s.profile
 	}
-
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.WindowSize(1920, 1080),
-	)
-
-	t.allocCtx, t.cancel = chromedp.NewExecAllocator(context.Background(), opts...)
-	t.ctx, _ = chromedp.NewContext(t.allocCtx)
-
-	// 启动浏览器
-	if err := chromedp.Run(t.ctx); err != nil {
+	_, running := t.profiles[profile]
+	if _, err := t.profileLocked(profile); err != nil {
 		return &Result{Content: "Failed to start browser: " + err.Error(), IsError: true}, nil
 	}
-
-	t.started = true
-	return &Result{Content: "Browser started"}, nil
+	if s.profile != profile {
+		s.profile, s.tab = profile, ""
+	}
+	if running {
+		return &Result{Content: fmt.Sprintf("Browser already running (profile %s)", profile)}, nil
+	}
+	return &Result{Content: fmt.Sprintf("Browser started (profile %s)", profile)}, nil
 }
 
-func (t *BrowserTool) stop() (*Result, error) {
+// stop 关闭 profile 的浏览器 (默认为会话的 profile)
+func (t *BrowserTool) stop(ctx context.Context, profile string) (*Result, error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if !t.started {
+	if profile == "" {
+		profile = t.sessionLocked(browserSessionKey(ctx)).profile
+	}
+	p, ok := t.profiles[profile]
+	if !ok {
 		return &Result{Content: "Browser not running"}, nil
 	}
+	t.stopProfileLocked(p)
+	return &Result{Content: fmt.Sprintf("Browser stopped (profile %s)", profile)}, nil
+}
 
-	if t.cancel != nil {
-		t.cancel()
+// tabs 列出会话的标签页
+func (t *BrowserTool) tabs(ctx context.Context) (*Result, error) {
+	t.mu.Lock()
+	key := browserSessionKey(ctx)
+	s := t.sessionLocked(key)
+	tabs := t.sessionTabsLocked(key)
+	current := s.profile + "/" + s.tab
+	t.mu.Unlock()
+
+	if len(tabs) == 0 {
+		return &Result{Content: "No tabs open"}, nil
+	}
+	var sb strings.Builder
+	for _, tab := range tabs {
+		mark := " "
+		if tab.profile+"/"+tab.id == current {
+			mark = "*"
+		}
+		url, title := tabInfo(tab)
+		fmt.Fprintf(&sb, "%s %s  [%s]  %s  %s\n", mark, tab.id, tab.profile, title, url)
 	}
-	t.started = false
-	return &Result{Content: "Browser stopped"}, nil
+	return &Result{Content: strings.TrimRight(sb.String(), "\n")}, nil
 }
 
-func (t *BrowserTool) ensureStarted() error {
+// open 打开新标签页并设为当前页，可选导航到 url
+func (t *BrowserTool) open(ctx context.Context, profile, url string, timeoutMs int) (*Result, error) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	key := browserSessionKey(ctx)
+	if profile == "" {
+		profile = t.sessionLocked(key).profile
+	}
+	p, err := t.profileLocked(profile)
+	var tab *browserTab
+	if err == nil {
+		tab, err = t.openTabLocked(key, p)
+	}
+	t.mu.Unlock()
+	if err != nil {
+		return &Result{Content: "Failed to open tab: " + err.Error(), IsError: true}, nil
+	}
 
-	if t.started {
-		return nil
+	if url == "" {
+		return &Result{Content: fmt.Sprintf("Opened tab %s (profile %s)", tab.id, tab.profile)}, nil
 	}
+	result, _ := t.navigate(tab, url, timeoutMs)
+	result.Content = fmt.Sprintf("Opened tab %s (profile %s)\n%s", tab.id, tab.profile, result.Content)
+	return result, nil
+}
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-		chromedp.WindowSize(1920, 1080),
-	)
+// focus 切换会话的当前标签页
+func (t *BrowserTool) focus(ctx context.Context, id string) (*Result, error) {
+	if id == "" {
+		return &Result{Content: "tabId is required", IsError: true}, nil
+	}
+	t.mu.Lock()
+	key := browserSessionKey(ctx)
+	tab, err := t.findTabLocked(key, id)
+	if err == nil {
+		s := t.sessionLocked(key)
+		s.profile, s.tab = tab.profile, tab.id
+	}
+	t.mu.Unlock()
+	if err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
+	}
 
-	t.allocCtx, t.cancel = chromedp.NewExecAllocator(context.Background(), opts...)
-	t.ctx, _ = chromedp.NewContext(t.allocCtx)
+	chromedp.Run(tab.ctx, page.BringToFront())
+	url, title := tabInfo(tab)
+	return &Result{Content: fmt.Sprintf("Focused tab %s: %s  %s", tab.id, title, url)}, nil
+}
 
-	if err := chromedp.Run(t.ctx); err != nil {
-		return err
+// closeTab 关闭标签页 (默认为当前页)
+func (t *BrowserTool) closeTab(ctx context.Context, id string) (*Result, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := browserSessionKey(ctx)
+	s := t.sessionLocked(key)
+	if id == "" {
+		if s.tab == "" {
+			return &Result{Content: "No tab open", IsError: true}, nil
+		}
+		id = s.profile + "/" + s.tab
+	}
+	tab, err := t.findTabLocked(key, id)
+	if err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
+	}
+	t.closeTabLocked(tab)
+	msg := "Closed tab " + tab.id
+	if s.tab != "" {
+		msg += "; current tab is now " + s.tab
+	}
+	return &Result{Content: msg}, nil
+}
+
+// downloads 列出已捕获的下载
+func (t *BrowserTool) downloads() (*Result, error) {
+	if t.downloadDir == "" {
+		return &Result{Content: "Download capture is not enabled", IsError: true}, nil
+	}
+	t.mu.Lock()
+	var list []browserDownload
+	for _, name := range sortedKeys(t.profiles) {
+		if d := t.profiles[name].downloads; d != nil {
+			list = append(list, d.list()...)
+		}
 	}
+	t.mu.Unlock()
 
-	t.started = true
-	return nil
+	if len(list) == 0 {
+		return &Result{Content: "No downloads (files are saved to " + t.downloadDir + ")"}, nil
+	}
+	var sb strings.Builder
+	for _, d := range list {
+		fmt.Fprintf(&sb, "%s  %s  %d bytes  (%s)\n", d.State, d.Path, d.Bytes, d.URL)
+	}
+	return &Result{Content: strings.TrimRight(sb.String(), "\n")}, nil
 }
 
-func (t *BrowserTool) navigate(ctx context.Context, url string, timeoutMs int) (*Result, error) {
+func (t *BrowserTool) navigate(tab *browserTab, url string, timeoutMs int) (*Result, error) {
 	if url == "" {
 		return &Result{Content: "URL is required", IsError: true}, nil
 	}
 
-	if err := t.ensureStarted(); err != nil {
-		return &Result{Content: "Failed to start browser: " + err.Error(), IsError: true}, nil
-	}
-
 	timeout := 30 * time.Second
 	if timeoutMs > 0 {
 		timeout = time.Duration(timeoutMs) * time.Millisecond
 	}
 
-	navCtx, cancel := context.WithTimeout(t.ctx, timeout)
+	navCtx, cancel := context.WithTimeout(tab.ctx, timeout)
 	defer cancel()
 
+	tab.refs.reset()
 	var title string
 	err := chromedp.Run(navCtx,
 		chromedp.Navigate(url),
@@ -233,46 +439,55 @@ func (t *BrowserTool) navigate(ctx context.Context, url string, timeoutMs int) (
 	return &Result{Content: fmt.Sprintf("Navigated to: %s\nTitle: %s", url, title)}, nil
 }
 
-func (t *BrowserTool) snapshot(ctx context.Context) (*Result, error) {
-	if err := t.ensureStarted(); err != nil {
-		return &Result{Content: "Failed to start browser: " + err.Error(), IsError: true}, nil
-	}
-
-	var html string
+func (t *BrowserTool) snapshot(tab *browserTab) (*Result, error) {
 	var url string
 	var title string
 
-	err := chromedp.Run(t.ctx,
+	err := chromedp.Run(tab.ctx,
 		chromedp.Location(&url),
 		chromedp.Title(&title),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			node, err := dom.GetDocument().Do(ctx)
-			if err != nil {
-				return err
-			}
-			html, err = dom.GetOuterHTML().WithNodeID(node.NodeID).Do(ctx)
-			return err
-		}),
 	)
 	if err != nil {
 		return &Result{Content: "Snapshot failed: " + err.Error(), IsError: true}, nil
 	}
 
-	// 简化 HTML，只保留主要内容
-	content := extractTextFromHTML(html)
-	if len(content) > 30000 {
-		content = content[:30000] + "\n...[truncated]"
+	content, err := axSnapshot(tab.ctx, tab.refs)
+	if err != nil || content == "" {
+		// 无障碍树不可用时退回到页面文本
+		content, err = textSnapshot(tab.ctx)
+		if err != nil {
+			return &Result{Content: "Snapshot failed: " + err.Error(), IsError: true}, nil
+		}
 	}
 
-	result := fmt.Sprintf("URL: %s\nTitle: %s\n\n%s", url, title, content)
+	result := fmt.Sprintf("Tab: %s\nURL: %s\nTitle: %s\n\n%s", tab.id, url, title, content)
 	return &Result{Content: result}, nil
 }
 
-func (t *BrowserTool) screenshot(ctx context.Context, fullPage bool) (*Result, error) {
-	if err := t.ensureStarted(); err != nil {
-		return &Result{Content: "Failed to start browser: " + err.Error(), IsError: true}, nil
+// textSnapshot 提取页面文本
+func textSnapshot(ctx context.Context) (string, error) {
+	var html string
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		node, err := dom.GetDocument().Do(ctx)
+		if err != nil {
+			return err
+		}
+		html, err = dom.GetOuterHTML().WithNodeID(node.NodeID).Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	// 简化 HTML，只保留主要内容
+	content := extractTextFromHTML(html)
+	if len(content) > maxSnapshotChars {
+		content = content[:maxSnapshotChars] + "\n...[truncated]"
 	}
+	return content, nil
+}
 
+func (t *BrowserTool) screenshot(ctx context.Context, tab *browserTab, params BrowserParams) (*Result, error) {
 	var buf []byte
 	var url string
 
@@ -280,7 +495,7 @@ func (t *BrowserTool) screenshot(ctx context.Context, fullPage bool) (*Result, e
 		chromedp.Location(&url),
 	}
 
-	if fullPage {
+	if params.FullPage {
 		actions = append(actions, chromedp.FullScreenshot(&buf, 90))
 	} else {
 		actions = append(actions, chromedp.ActionFunc(func(ctx context.Context) error {
@@ -290,43 +505,69 @@ func (t *BrowserTool) screenshot(ctx context.Context, fullPage bool) (*Result, e
 		}))
 	}
 
-	if err := chromedp.Run(t.ctx, actions...); err != nil {
+	if err := chromedp.Run(tab.ctx, actions...); err != nil {
 		return &Result{Content: "Screenshot failed: " + err.Error(), IsError: true}, nil
 	}
 
-	// 返回 base64 编码的图片
+	// 全页截图为 JPEG (质量 90)
+	mimeType, ext := "image/png", ".png"
+	if params.FullPage {
+		mimeType, ext = "image/jpeg", ".jpg"
+	}
+
+	// 保存到工作区；没有输出目录时只返回 base64 片段
+	if params.Path != "" || t.downloadDir != "" {
+		out, err := t.outputPath(ctx, params.Path, "screenshot-"+time.Now().Format("20060102-150405")+ext)
+		if err != nil {
+			return &Result{Content: "Screenshot failed: " + err.Error(), IsError: true}, nil
+		}
+		if err := os.WriteFile(out, buf, 0644); err != nil {
+			return &Result{Content: "Screenshot failed: " + err.Error(), IsError: true}, nil
+		}
+		return &Result{
+			Content: fmt.Sprintf("Screenshot saved (%d bytes) to %s\nURL: %s", len(buf), out, url),
+			Media:   []MediaItem{{Type: "image", Path: out, MimeType: mimeType, Caption: url}},
+		}, nil
+	}
+
 	b64 := base64.StdEncoding.EncodeToString(buf)
 	return &Result{
-		Content: fmt.Sprintf("Screenshot taken (%d bytes)\nURL: %s\n[Image data: data:image/png;base64,%s...]", len(buf), url, b64[:100]),
+		Content: fmt.Sprintf("Screenshot taken (%d bytes)\nURL: %s\n[Image data: data:%s;base64,%s...]", len(buf), url, mimeType, b64[:min(len(b64), 100)]),
 		Media: []MediaItem{
-			{Type: "image", MimeType: "image/png"},
+			{Type: "image", MimeType: mimeType},
 		},
 	}, nil
 }
 
-func (t *BrowserTool) act(ctx context.Context, req *BrowserActParams) (*Result, error) {
-	if err := t.ensureStarted(); err != nil {
-		return &Result{Content: "Failed to start browser: " + err.Error(), IsError: true}, nil
-	}
+func (t *BrowserTool) act(tab *browserTab, req *BrowserActParams) (*Result, error) {
+	timeout := 10 * time.Second
+	actCtx, cancel := context.WithTimeout(tab.ctx, timeout)
+	defer cancel()
 
-	selector := req.Selector
-	if selector == "" && req.Ref != "" {
-		// 如果只有 ref，假设是某种选择器格式
-		selector = req.Ref
+	// 优先使用 snapshot 中的 ref
+	if req.Ref != "" && req.Selector == "" {
+		switch req.Kind {
+		case "click", "type", "hover", "select":
+			if err := actOnRef(actCtx, tab.refs, req); err != nil {
+				return &Result{Content: fmt.Sprintf("Action %s on %s failed: %s", req.Kind, req.Ref, err.Error()), IsError: true}, nil
+			}
+			return &Result{Content: fmt.Sprintf("Action %s on %s completed", req.Kind, req.Ref)}, nil
+		}
 	}
 
+	selector := req.Selector
 	var action chromedp.Action
 
 	switch req.Kind {
 	case "click":
 		if selector == "" {
-			return &Result{Content: "Selector is required for click", IsError: true}, nil
+			return &Result{Content: "Ref or selector is required for click", IsError: true}, nil
 		}
 		action = chromedp.Click(selector, chromedp.ByQuery)
 
 	case "type":
 		if selector == "" {
-			return &Result{Content: "Selector is required for type", IsError: true}, nil
+			return &Result{Content: "Ref or selector is required for type", IsError: true}, nil
 		}
 		action = chromedp.SendKeys(selector, req.Text, chromedp.ByQuery)
 
@@ -339,9 +580,25 @@ func (t *BrowserTool) act(ctx context.Context, req *BrowserActParams) (*Result,
 
 	case "hover":
 		if selector == "" {
-			return &Result{Content: "Selector is required for hover", IsError: true}, nil
+			return &Result{Content: "Ref or selector is required for hover", IsError: true}, nil
 		}
-		action = chromedp.MouseClickXY(0, 0) // TODO: proper hover
+		action = chromedp.QueryAfter(selector, func(ctx context.Context, _ runtime.ExecutionContextID, nodes ...*cdp.Node) error {
+			x, y, err := nodeCenter(ctx, nodes[0].BackendNodeID)
+			if err != nil {
+				return err
+			}
+			return input.DispatchMouseEvent(input.MouseMoved, x, y).Do(ctx)
+		}, chromedp.ByQuery)
+
+	case "select":
+		if selector == "" {
+			return &Result{Content: "Ref or selector is required for select", IsError: true}, nil
+		}
+		value := req.Text
+		if len(req.Values) > 0 {
+			value = req.Values[0]
+		}
+		action = chromedp.SetValue(selector, value, chromedp.ByQuery)
 
 	case "scroll":
 		action = chromedp.Evaluate(`window.scrollBy(0, 500)`, nil)
@@ -357,10 +614,6 @@ func (t *BrowserTool) act(ctx context.Context, req *BrowserActParams) (*Result,
 		return &Result{Content: "Unknown action kind: " + req.Kind, IsError: true}, nil
 	}
 
-	timeout := 10 * time.Second
-	actCtx, cancel := context.WithTimeout(t.ctx, timeout)
-	defer cancel()
-
 	if err := chromedp.Run(actCtx, action); err != nil {
 		return &Result{Content: fmt.Sprintf("Action %s failed: %s", req.Kind, err.Error()), IsError: true}, nil
 	}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+)
+
+// maxCaptureEntries 每个标签页保留的控制台/网络记录条数
+const maxCaptureEntries = 200
+
+// consoleEntry 一条控制台消息或未捕获异常
+type consoleEntry struct {
+	Time   time.Time
+	Level  string // log, info, warning, error, debug
+	Text   string
+	Source string // url:line
+}
+
+// networkEntry 一个失败的请求 (HTTP >= 400 或加载失败)
+type networkEntry struct {
+	Time   time.Time
+	Method string
+	URL    string
+	Type   string
+	Status int64
+	Error  string
+}
+
+// pageCapture 记录标签页的控制台输出、失败请求和进行中的请求 (用于等待网络空闲)
+type pageCapture struct {
+	mu           sync.Mutex
+	console      []consoleEntry
+	failed       []networkEntry
+	requests     map[network.RequestID]*network.Request
+	types        map[network.RequestID]string
+	lastActivity time.Time
+}
+
+func newPageCapture() *pageCapture {
+	return &pageCapture{
+		requests:     make(map[network.RequestID]*network.Request),
+		types:        make(map[network.RequestID]string),
+		lastActivity: time.Now(),
+	}
+}
+
+// handle 处理 CDP 事件 (由 chromedp.ListenTarget 调用)
+func (c *pageCapture) handle(ev interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch e := ev.(type) {
+	case *runtime.EventConsoleAPICalled:
+		var parts []string
+		for _, arg := range e.Args {
+			parts = append(parts, remoteObjectText(arg))
+		}
+		source := ""
+		if e.StackTrace != nil && len(e.StackTrace.CallFrames) > 0 {
+			f := e.StackTrace.CallFrames[0]
+			source = fmt.Sprintf("%s:%d", f.URL, f.LineNumber+1)
+		}
+		c.addConsole(consoleLevel(string(e.Type)), strings.Join(parts, " "), source)
+
+	case *runtime.EventExceptionThrown:
+		d := e.ExceptionDetails
+		if d == nil {
+			return
+		}
+		text := d.Text
+		if d.Exception != nil && d.Exception.Description != "" {
+			text = d.Exception.Description
+		}
+		source := ""
+		if d.URL != "" {
+			source = fmt.Sprintf("%s:%d", d.URL, d.LineNumber+1)
+		}
+		c.addConsole("error", text, source)
+
+	case *cdplog.EventEntryAdded:
+		// 浏览器自身的日志 (CSP、混合内容、资源加载错误等)
+		if e.Entry == nil {
+			return
+		}
+		source := e.Entry.URL
+		if source != "" && e.Entry.LineNumber > 0 {
+			source = fmt.Sprintf("%s:%d", source, e.Entry.LineNumber)
+		}
+		c.addConsole(consoleLevel(string(e.Entry.Level)), e.Entry.Text, source)
+
+	case *network.EventRequestWillBeSent:
+		c.requests[e.RequestID] = e.Request
+		c.types[e.RequestID] = string(e.Type)
+		c.lastActivity = time.Now()
+
+	case *network.EventResponseReceived:
+		c.lastActivity = time.Now()
+		if e.Response != nil && e.Response.Status >= 400 {
+			req := c.requests[e.RequestID]
+			entry := networkEntry{Time: time.Now(), URL: e.Response.URL, Type: string(e.Type), Status: e.Response.Status, Error: e.Response.StatusText}
+			if req != nil {
+				entry.Method = req.Method
+			}
+			c.addFailed(entry)
+		}
+
+	case *network.EventLoadingFinished:
+		c.finish(e.RequestID)
+
+	case *network.EventLoadingFailed:
+		if req := c.requests[e.RequestID]; req != nil && !e.Canceled {
+			msg := e.ErrorText
+			if e.BlockedReason != "" {
+				msg += " (blocked: " + string(e.BlockedReason) + ")"
+			}
+			c.addFailed(networkEntry{Time: time.Now(), Method: req.Method, URL: req.URL, Type: string(e.Type), Error: msg})
+		}
+		c.finish(e.RequestID)
+	}
+}
+
+func (c *pageCapture) finish(id network.RequestID) {
+	delete(c.requests, id)
+	delete(c.types, id)
+	c.lastActivity = time.Now()
+}
+
+func (c *pageCapture) addConsole(level, text, source string) {
+	c.console = append(c.console, consoleEntry{Time: time.Now(), Level: level, Text: text, Source: source})
+	if len(c.console) > maxCaptureEntries {
+		c.console = c.console[len(c.console)-maxCaptureEntries:]
+	}
+}
+
+func (c *pageCapture) addFailed(e networkEntry) {
+	c.failed = append(c.failed, e)
+	if len(c.failed) > maxCaptureEntries {
+		c.failed = c.failed[len(c.failed)-maxCaptureEntries:]
+	}
+}
+
+// consoleEntries 返回 (并可选清空) 控制台记录；level 为空时返回全部，"error" 只返回错误，"warning" 返回警告和错误
+func (c *pageCapture) consoleEntries(level string, clear bool) []consoleEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []consoleEntry
+	for _, e := range c.console {
+		if level == "" || e.Level == level || (level == "warning" && e.Level == "error") {
+			out = append(out, e)
+		}
+	}
+	if clear {
+		c.console = nil
+	}
+	return out
+}
+
+// networkFailures 返回 (并可选清空) 失败的请求，以及仍在进行中的请求数
+func (c *pageCapture) networkFailures(clear bool) ([]networkEntry, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := append([]networkEntry(nil), c.failed...)
+	if clear {
+		c.failed = nil
+	}
+	return out, c.pendingLocked()
+}
+
+// pendingLocked 进行中的请求数，不计 WebSocket/EventSource 等长连接
+func (c *pageCapture) pendingLocked() int {
+	n := 0
+	for id := range c.requests {
+		switch c.types[id] {
+		case string(network.ResourceTypeWebSocket), string(network.ResourceTypeEventSource):
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// idleFor 网络已经空闲的时长 (有请求进行中时为 0)
+func (c *pageCapture) idleFor() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pendingLocked() > 0 {
+		return 0
+	}
+	return time.Since(c.lastActivity)
+}
+
+// consoleLevel 统一控制台级别名称
+func consoleLevel(t string) string {
+	switch t {
+	case "warn", "warning":
+		return "warning"
+	case "error", "assert":
+		return "error"
+	case "debug", "verbose", "trace":
+		return "debug"
+	case "info":
+		return "info"
+	default:
+		return "log"
+	}
+}
+
+// remoteObjectText 控制台参数的文本形式
+func remoteObjectText(o *runtime.RemoteObject) string {
+	if o == nil {
+		return ""
+	}
+	if len(o.Value) > 0 {
+		var s string
+		if o.Type == runtime.TypeString && json.Unmarshal(o.Value, &s) == nil {
+			return s
+		}
+		return string(o.Value)
+	}
+	if o.UnserializableValue != "" {
+		return string(o.UnserializableValue)
+	}
+	if o.Description != "" {
+		return o.Description
+	}
+	return string(o.Type)
+}
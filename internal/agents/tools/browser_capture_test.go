@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	cdplog "github.com/chromedp/cdproto/log"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestPageCapture_Console(t *testing.T) {
+	c := newPageCapture()
+	c.handle(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeLog,
+		Args: []*runtime.RemoteObject{
+			{Type: runtime.TypeString, Value: []byte(`"loaded \"app\""`)},
+			{Type: runtime.TypeNumber, Value: []byte(`42`)},
+		},
+	})
+	c.handle(&runtime.EventConsoleAPICalled{
+		Type: runtime.APITypeWarning,
+		Args: []*runtime.RemoteObject{{Type: runtime.TypeString, Value: []byte(`"deprecated"`)}},
+	})
+	c.handle(&runtime.EventExceptionThrown{ExceptionDetails: &runtime.ExceptionDetails{
+		Text:       "Uncaught",
+		URL:        "https://app/main.js",
+		LineNumber: 9,
+		Exception:  &runtime.RemoteObject{Description: "TypeError: x is undefined"},
+	}})
+	c.handle(&cdplog.EventEntryAdded{Entry: &cdplog.Entry{Level: cdplog.LevelError, Text: "Failed to load resource", URL: "https://app/x.png"}})
+
+	all := c.consoleEntries("", false)
+	if len(all) != 4 {
+		t.Fatalf("Expected 4 entries, got %d", len(all))
+	}
+	if all[0].Level != "log" || all[0].Text != `loaded "app" 42` {
+		t.Errorf("Unexpected log entry: %+v", all[0])
+	}
+	if all[2].Text != "TypeError: x is undefined" || all[2].Source != "https://app/main.js:10" {
+		t.Errorf("Unexpected exception entry: %+v", all[2])
+	}
+
+	if errs := c.consoleEntries("error", false); len(errs) != 2 {
+		t.Errorf("Expected 2 errors, got %d", len(errs))
+	}
+	if warns := c.consoleEntries("warning", true); len(warns) != 3 {
+		t.Errorf("Expected warnings and errors, got %d", len(warns))
+	}
+	if left := c.consoleEntries("", false); len(left) != 0 {
+		t.Errorf("Expected console to be cleared, got %d", len(left))
+	}
+}
+
+func TestPageCapture_Network(t *testing.T) {
+	c := newPageCapture()
+	send := func(id, method, url string, typ network.ResourceType) {
+		c.handle(&network.EventRequestWillBeSent{RequestID: network.RequestID(id), Request: &network.Request{Method: method, URL: url}, Type: typ})
+	}
+	send("1", "GET", "https://app/api/items", network.ResourceTypeFetch)
+	send("2", "POST", "https://app/api/save", network.ResourceTypeXHR)
+	send("3", "GET", "https://cdn/lib.js", network.ResourceTypeScript)
+	send("4", "GET", "wss://app/live", network.ResourceTypeWebSocket)
+	send("5", "GET", "https://app/slow", network.ResourceTypeFetch)
+
+	c.handle(&network.EventResponseReceived{RequestID: "1", Type: network.ResourceTypeFetch, Response: &network.Response{URL: "https://app/api/items", Status: 200}})
+	c.handle(&network.EventLoadingFinished{RequestID: "1"})
+	c.handle(&network.EventResponseReceived{RequestID: "2", Type: network.ResourceTypeXHR, Response: &network.Response{URL: "https://app/api/save", Status: 500, StatusText: "Internal Server Error"}})
+	c.handle(&network.EventLoadingFinished{RequestID: "2"})
+	c.handle(&network.EventLoadingFailed{RequestID: "3", Type: network.ResourceTypeScript, ErrorText: "net::ERR_BLOCKED_BY_CLIENT", BlockedReason: network.BlockedReasonInspector})
+
+	failed, pending := c.networkFailures(false)
+	if len(failed) != 2 {
+		t.Fatalf("Expected 2 failures, got %+v", failed)
+	}
+	if failed[0].Method != "POST" || failed[0].Status != 500 {
+		t.Errorf("Unexpected failure: %+v", failed[0])
+	}
+	if !strings.Contains(failed[1].Error, "ERR_BLOCKED_BY_CLIENT") || failed[1].URL != "https://cdn/lib.js" {
+		t.Errorf("Unexpected failure: %+v", failed[1])
+	}
+	// WebSocket 不计入进行中的请求
+	if pending != 1 || c.idleFor() != 0 {
+		t.Errorf("Expected 1 pending request, got %d", pending)
+	}
+
+	c.handle(&network.EventLoadingFailed{RequestID: "5", Canceled: true})
+	if failed, _ := c.networkFailures(true); len(failed) != 2 {
+		t.Errorf("Canceled requests should not be reported, got %d", len(failed))
+	}
+	c.lastActivity = time.Now().Add(-time.Second)
+	if c.idleFor() < networkIdleTime {
+		t.Errorf("Expected network to be idle")
+	}
+	if failed, _ := c.networkFailures(false); len(failed) != 0 {
+		t.Errorf("Expected failures to be cleared")
+	}
+}
+
+func TestBrowserTool_OutputPath(t *testing.T) {
+	workspace := t.TempDir()
+	tool := NewBrowserToolFromConfig(config.BrowserConfig{}, "", workspace)
+	ctx := context.Background()
+
+	out, err := tool.outputPath(ctx, "", "page.pdf")
+	if err != nil || out != filepath.Join(workspace, "downloads", "page.pdf") {
+		t.Errorf("outputPath default = %q, %v", out, err)
+	}
+	out, err = tool.outputPath(ctx, "exports/report.pdf", "page.pdf")
+	if err != nil || out != filepath.Join(workspace, "exports", "report.pdf") {
+		t.Errorf("outputPath relative = %q, %v", out, err)
+	}
+	if _, err := tool.outputPath(ctx, "/etc/report.pdf", "page.pdf"); err == nil || !strings.Contains(err.Error(), "Access denied") {
+		t.Errorf("Expected access denied, got %v", err)
+	}
+
+	if _, err := NewBrowserTool().outputPath(ctx, "", "page.pdf"); err == nil {
+		t.Error("Expected error without output directory")
+	}
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// networkIdleTime 没有进行中的请求持续多久视为网络空闲
+const networkIdleTime = 500 * time.Millisecond
+
+// SetPathPolicy 设置上传文件和输出文件 (pdf/screenshot 的 path) 的路径访问策略
+func (t *BrowserTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
+// outputPath 输出文件路径：指定 path 时按路径策略检查，否则保存到下载目录
+func (t *BrowserTool) outputPath(ctx context.Context, path, name string) (string, error) {
+	var out string
+	switch {
+	case path != "":
+		if t.paths == nil {
+			return "", fmt.Errorf("file access is not configured")
+		}
+		resolved, err := t.paths.Resolve(ctx, path, true)
+		if err != nil {
+			return "", err
+		}
+		out = resolved
+	case t.downloadDir != "":
+		if err := os.MkdirAll(t.downloadDir, 0755); err != nil {
+			return "", err
+		}
+		out = uniqueDownloadPath(t.downloadDir, name)
+	default:
+		return "", fmt.Errorf("path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+		return "", err
+	}
+	return out, nil
+}
+
+// pdf 将页面导出为 PDF
+func (t *BrowserTool) pdf(ctx context.Context, tab *browserTab, params BrowserParams) (*Result, error) {
+	var data []byte
+	var url string
+	err := chromedp.Run(tab.ctx,
+		chromedp.Location(&url),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			data, _, err = page.PrintToPDF().
+				WithPrintBackground(true).
+				WithLandscape(params.Landscape).
+				Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return &Result{Content: "PDF export failed: " + err.Error(), IsError: true}, nil
+	}
+
+	out, err := t.outputPath(ctx, params.Path, "page-"+time.Now().Format("20060102-150405")+".pdf")
+	if err != nil {
+		return &Result{Content: "PDF export failed: " + err.Error(), IsError: true}, nil
+	}
+	if err := os.WriteFile(out, data, 0644); err != nil {
+		return &Result{Content: "PDF export failed: " + err.Error(), IsError: true}, nil
+	}
+	return &Result{
+		Content: fmt.Sprintf("Saved PDF (%d bytes) to %s\nURL: %s", len(data), out, url),
+		Media:   []MediaItem{{Type: "file", Path: out, MimeType: "application/pdf", Caption: url}},
+	}, nil
+}
+
+// console 返回标签页的控制台消息和未捕获异常
+func (t *BrowserTool) console(tab *browserTab, params BrowserParams) (*Result, error) {
+	level := consoleLevel(params.Level)
+	if params.Level == "" {
+		level = ""
+	}
+	entries := tab.capture.consoleEntries(level, params.Clear)
+	if len(entries) == 0 {
+		return &Result{Content: "No console messages"}, nil
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "[%s] %s %s", e.Time.Format("15:04:05"), e.Level, e.Text)
+		if e.Source != "" {
+			fmt.Fprintf(&sb, " (%s)", e.Source)
+		}
+		sb.WriteByte('\n')
+	}
+	return &Result{Content: strings.TrimRight(sb.String(), "\n")}, nil
+}
+
+// network 返回失败的请求 (HTTP >= 400、加载失败或被拦截)
+func (t *BrowserTool) network(tab *browserTab, params BrowserParams) (*Result, error) {
+	failed, pending := tab.capture.networkFailures(params.Clear)
+	var sb strings.Builder
+	for _, e := range failed {
+		status := "failed"
+		if e.Status > 0 {
+			status = fmt.Sprintf("%d", e.Status)
+		}
+		fmt.Fprintf(&sb, "[%s] %s %s %s", e.Time.Format("15:04:05"), e.Method, status, e.URL)
+		if e.Type != "" {
+			fmt.Fprintf(&sb, " (%s)", e.Type)
+		}
+		if e.Error != "" {
+			fmt.Fprintf(&sb, ": %s", e.Error)
+		}
+		sb.WriteByte('\n')
+	}
+	if len(failed) == 0 {
+		sb.WriteString("No failed requests\n")
+	}
+	if pending > 0 {
+		fmt.Fprintf(&sb, "%d request(s) still pending\n", pending)
+	}
+	return &Result{Content: strings.TrimRight(sb.String(), "\n")}, nil
+}
+
+// upload 将工作区文件设置到 <input type=file>
+func (t *BrowserTool) upload(ctx context.Context, tab *browserTab, params BrowserParams) (*Result, error) {
+	paths := params.Paths
+	if len(paths) == 0 && params.Path != "" {
+		paths = []string{params.Path}
+	}
+	if len(paths) == 0 {
+		return &Result{Content: "paths is required for upload", IsError: true}, nil
+	}
+	if params.Ref == "" && params.Selector == "" {
+		return &Result{Content: "Ref or selector of the file input is required", IsError: true}, nil
+	}
+	if t.paths == nil {
+		return &Result{Content: "File access is not configured", IsError: true}, nil
+	}
+
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		resolved, err := t.paths.Resolve(ctx, p, false)
+		if err != nil {
+			return &Result{Content: err.Error(), IsError: true}, nil
+		}
+		info, err := os.Stat(resolved)
+		if err != nil {
+			return &Result{Content: fmt.Sprintf("File not found: %s", p), IsError: true}, nil
+		}
+		if info.IsDir() {
+			return &Result{Content: fmt.Sprintf("%s is a directory", p), IsError: true}, nil
+		}
+		files = append(files, resolved)
+	}
+
+	upCtx, cancel := context.WithTimeout(tab.ctx, 10*time.Second)
+	defer cancel()
+
+	var action chromedp.Action
+	if params.Ref != "" && params.Selector == "" {
+		node, ok := tab.refs.lookup(params.Ref)
+		if !ok {
+			return &Result{Content: fmt.Sprintf("Unknown ref %s; take a new snapshot (refs are reset on navigation)", params.Ref), IsError: true}, nil
+		}
+		action = dom.SetFileInputFiles(files).WithBackendNodeID(node)
+	} else {
+		action = chromedp.SetUploadFiles(params.Selector, files, chromedp.ByQuery)
+	}
+	if err := chromedp.Run(upCtx, action); err != nil {
+		return &Result{Content: "Upload failed: " + err.Error(), IsError: true}, nil
+	}
+	return &Result{Content: fmt.Sprintf("Set %d file(s) on the file input: %s", len(files), strings.Join(paths, ", "))}, nil
+}
+
+// wait 等待元素可见、页面出现文本、页面加载完成或网络空闲
+func (t *BrowserTool) wait(tab *browserTab, params BrowserParams) (*Result, error) {
+	if params.Selector == "" && params.Text == "" && params.State == "" {
+		return &Result{Content: "selector, text or state (load, networkidle) is required for wait", IsError: true}, nil
+	}
+	timeout := 30 * time.Second
+	if params.TimeoutMs > 0 {
+		timeout = time.Duration(params.TimeoutMs) * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(tab.ctx, timeout)
+	defer cancel()
+	start := time.Now()
+
+	var done []string
+	if params.Selector != "" {
+		if err := chromedp.Run(ctx, chromedp.WaitVisible(params.Selector, chromedp.ByQuery)); err != nil {
+			return &Result{Content: fmt.Sprintf("Timed out waiting for %s: %v", params.Selector, err), IsError: true}, nil
+		}
+		done = append(done, params.Selector+" visible")
+	}
+	if params.Text != "" {
+		expr := fmt.Sprintf(`!!document.body && document.body.innerText.includes(%s)`, mustJSON(params.Text))
+		err := pollUntil(ctx, func(ctx context.Context) (bool, error) {
+			var ok bool
+			err := chromedp.Run(ctx, chromedp.Evaluate(expr, &ok))
+			return ok, err
+		})
+		if err != nil {
+			return &Result{Content: fmt.Sprintf("Timed out waiting for text %q: %v", params.Text, err), IsError: true}, nil
+		}
+		done = append(done, fmt.Sprintf("text %q present", params.Text))
+	}
+	switch params.State {
+	case "":
+	case "load":
+		err := pollUntil(ctx, func(ctx context.Context) (bool, error) {
+			var state string
+			err := chromedp.Run(ctx, chromedp.Evaluate(`document.readyState`, &state))
+			return state == "complete", err
+		})
+		if err != nil {
+			return &Result{Content: "Timed out waiting for page load: " + err.Error(), IsError: true}, nil
+		}
+		done = append(done, "page loaded")
+	case "networkidle":
+		err := pollUntil(ctx, func(ctx context.Context) (bool, error) {
+			return tab.capture.idleFor() >= networkIdleTime, nil
+		})
+		if err != nil {
+			_, pending := tab.capture.networkFailures(false)
+			return &Result{Content: fmt.Sprintf("Timed out waiting for network idle (%d request(s) pending)", pending), IsError: true}, nil
+		}
+		done = append(done, "network idle")
+	default:
+		return &Result{Content: "Unknown state: " + params.State + " (use load or networkidle)", IsError: true}, nil
+	}
+	return &Result{Content: fmt.Sprintf("Waited %s: %s", time.Since(start).Round(time.Millisecond), strings.Join(done, ", "))}, nil
+}
+
+// pollUntil 每 200ms 检查一次直到条件满足或超时
+func pollUntil(ctx context.Context, check func(ctx context.Context) (bool, error)) error {
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		ok, err := check(ctx)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func mustJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,338 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+	"github.com/chromedp/cdproto/dom"
+	"github.com/chromedp/cdproto/input"
+	"github.com/chromedp/cdproto/runtime"
+	"github.com/chromedp/chromedp"
+)
+
+// maxSnapshotChars 快照大纲的最大长度
+const maxSnapshotChars = 30000
+
+// 分配 ref 的可交互角色
+var interactiveRoles = map[string]bool{
+	"button": true, "link": true, "textbox": true, "searchbox": true,
+	"combobox": true, "listbox": true, "option": true, "checkbox": true,
+	"radio": true, "switch": true, "slider": true, "spinbutton": true,
+	"menuitem": true, "menuitemcheckbox": true, "menuitemradio": true,
+	"tab": true, "treeitem": true,
+}
+
+// 没有名称时不输出、直接展开子节点的角色
+var transparentRoles = map[string]bool{
+	"generic": true, "none": true, "presentation": true, "group": true,
+	"InlineTextBox": true, "LineBreak": true, "LayoutTable": true,
+	"LayoutTableRow": true, "LayoutTableCell": true, "paragraph": true,
+	"section": true, "div": true,
+}
+
+// 快照中显示的状态属性
+var snapshotProperties = []accessibility.PropertyName{
+	accessibility.PropertyNameChecked,
+	accessibility.PropertyNamePressed,
+	accessibility.PropertyNameSelected,
+	accessibility.PropertyNameExpanded,
+	accessibility.PropertyNameDisabled,
+	accessibility.PropertyNameRequired,
+	accessibility.PropertyNameLevel,
+}
+
+// refTable 快照中元素 ref (e1, e2...) 到 DOM 节点的映射。
+// 同一文档内多次快照时同一元素保持相同 ref；主框架导航后清空。
+type refTable struct {
+	mu     sync.Mutex
+	byRef  map[string]cdp.BackendNodeID
+	byNode map[cdp.BackendNodeID]string
+	next   int
+}
+
+func newRefTable() *refTable {
+	return &refTable{
+		byRef:  make(map[string]cdp.BackendNodeID),
+		byNode: make(map[cdp.BackendNodeID]string),
+	}
+}
+
+// assign 返回节点的 ref，没有时分配新的
+func (r *refTable) assign(node cdp.BackendNodeID) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ref, ok := r.byNode[node]; ok {
+		return ref
+	}
+	r.next++
+	ref := "e" + strconv.Itoa(r.next)
+	r.byRef[ref] = node
+	r.byNode[node] = ref
+	return ref
+}
+
+// lookup 查找 ref 对应的节点
+func (r *refTable) lookup(ref string) (cdp.BackendNodeID, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	node, ok := r.byRef[strings.TrimPrefix(ref, "ref=")]
+	return node, ok
+}
+
+// reset 页面导航后所有 ref 失效
+func (r *refTable) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byRef = make(map[string]cdp.BackendNodeID)
+	r.byNode = make(map[cdp.BackendNodeID]string)
+}
+
+// buildAXOutline 将无障碍树转为紧凑的 role "name" 大纲，可交互元素标注 [ref=eN]
+func buildAXOutline(nodes []*accessibility.Node, refs *refTable) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	byID := make(map[accessibility.NodeID]*accessibility.Node, len(nodes))
+	for _, n := range nodes {
+		byID[n.NodeID] = n
+	}
+
+	var sb strings.Builder
+	var walk func(n *accessibility.Node, depth int, parentName string)
+	walk = func(n *accessibility.Node, depth int, parentName string) {
+		if sb.Len() > maxSnapshotChars {
+			return
+		}
+		role := axString(n.Role)
+		name := strings.TrimSpace(axString(n.Name))
+		childDepth, childParentName := depth, parentName
+
+		emit := !n.Ignored && role != "RootWebArea" && role != "WebArea"
+		if emit && name == "" && transparentRoles[role] {
+			emit = false
+		}
+		if emit && (role == "StaticText" || role == "text") {
+			// 文本已包含在父元素的名称中时省略
+			emit = name != "" && !strings.Contains(parentName, name)
+		}
+		if emit {
+			line := strings.Repeat("  ", depth) + "- " + role
+			if role == "StaticText" {
+				line = strings.Repeat("  ", depth) + "- text"
+			}
+			if name != "" {
+				line += " " + strconv.Quote(truncateAX(name, 200))
+			}
+			var attrs []string
+			if interactiveRoles[role] && n.BackendDOMNodeID != 0 {
+				attrs = append(attrs, "ref="+refs.assign(n.BackendDOMNodeID))
+			}
+			for _, want := range snapshotProperties {
+				for _, p := range n.Properties {
+					if p.Name != want {
+						continue
+					}
+					if v := axString(p.Value); v != "" && v != "false" {
+						attrs = append(attrs, string(p.Name)+"="+v)
+					}
+				}
+			}
+			if len(attrs) > 0 {
+				line += " [" + strings.Join(attrs, ", ") + "]"
+			}
+			if v := axString(n.Value); v != "" && v != name {
+				line += ": " + strconv.Quote(truncateAX(v, 200))
+			}
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+			childDepth = depth + 1
+			if name != "" {
+				childParentName = name
+			}
+		}
+		for _, id := range n.ChildIDs {
+			if child, ok := byID[id]; ok {
+				walk(child, childDepth, childParentName)
+			}
+		}
+	}
+
+	// 根节点：没有父节点的节点
+	for _, n := range nodes {
+		if n.ParentID == "" {
+			walk(n, 0, "")
+		}
+	}
+	out := strings.TrimRight(sb.String(), "\n")
+	if sb.Len() > maxSnapshotChars {
+		out += "\n...[truncated]"
+	}
+	return out
+}
+
+// axString 无障碍属性值的文本形式
+func axString(v *accessibility.Value) string {
+	if v == nil || len(v.Value) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v.Value, &s); err == nil {
+		return s
+	}
+	return strings.Trim(string(v.Value), `"`)
+}
+
+func truncateAX(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// axSnapshot 获取当前页面的无障碍树大纲
+func axSnapshot(ctx context.Context, refs *refTable) (string, error) {
+	var nodes []*accessibility.Node
+	err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+		var err error
+		nodes, err = accessibility.GetFullAXTree().Do(ctx)
+		return err
+	}))
+	if err != nil {
+		return "", err
+	}
+	return buildAXOutline(nodes, refs), nil
+}
+
+// actOnRef 通过快照 ref 操作元素
+func actOnRef(ctx context.Context, refs *refTable, req *BrowserActParams) error {
+	node, ok := refs.lookup(req.Ref)
+	if !ok {
+		return fmt.Errorf("unknown ref %s; take a new snapshot (refs are reset on navigation)", req.Ref)
+	}
+
+	switch req.Kind {
+	case "click", "hover":
+		return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			x, y, err := nodeCenter(ctx, node)
+			if err != nil {
+				return err
+			}
+			if req.Kind == "hover" {
+				return input.DispatchMouseEvent(input.MouseMoved, x, y).Do(ctx)
+			}
+			return chromedp.MouseClickXY(x, y).Do(ctx)
+		}))
+
+	case "type":
+		return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(node).Do(ctx); err != nil {
+				return err
+			}
+			if err := dom.Focus().WithBackendNodeID(node).Do(ctx); err != nil {
+				return err
+			}
+			// 先清空已有内容
+			if _, err := callOnNode(ctx, node, `function() { if ("value" in this) { this.value = ""; this.dispatchEvent(new Event("input", {bubbles: true})); } else if (this.isContentEditable) { this.textContent = ""; } }`); err != nil {
+				return err
+			}
+			if err := input.InsertText(req.Text).Do(ctx); err != nil {
+				return err
+			}
+			if req.Key != "" {
+				return chromedp.KeyEvent(req.Key).Do(ctx)
+			}
+			return nil
+		}))
+
+	case "select":
+		values := req.Values
+		if len(values) == 0 && req.Text != "" {
+			values = []string{req.Text}
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("values are required for select")
+		}
+		return chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+			matched, err := callOnNode(ctx, node, `function(values) {
+				if (this.tagName !== "SELECT") { throw new Error("element is not a <select>"); }
+				let n = 0;
+				for (const o of this.options) {
+					o.selected = values.includes(o.value) || values.includes(o.label);
+					if (o.selected) n++;
+				}
+				this.dispatchEvent(new Event("input", {bubbles: true}));
+				this.dispatchEvent(new Event("change", {bubbles: true}));
+				return n;
+			}`, values)
+			if err != nil {
+				return err
+			}
+			if matched == "0" {
+				return fmt.Errorf("no option matches %v", values)
+			}
+			return nil
+		}))
+
+	default:
+		return fmt.Errorf("action %s does not support ref", req.Kind)
+	}
+}
+
+// nodeCenter 滚动到元素并返回其中心坐标
+func nodeCenter(ctx context.Context, node cdp.BackendNodeID) (float64, float64, error) {
+	if err := dom.ScrollIntoViewIfNeeded().WithBackendNodeID(node).Do(ctx); err != nil {
+		return 0, 0, err
+	}
+	box, err := dom.GetBoxModel().WithBackendNodeID(node).Do(ctx)
+	if err != nil {
+		return 0, 0, fmt.Errorf("element is not visible: %v", err)
+	}
+	q := box.Border
+	if len(q) < 8 {
+		return 0, 0, fmt.Errorf("element has no box")
+	}
+	return (q[0] + q[2] + q[4] + q[6]) / 4, (q[1] + q[3] + q[5] + q[7]) / 4, nil
+}
+
+// callOnNode 以元素为 this 调用 JS 函数，返回结果的 JSON 文本
+func callOnNode(ctx context.Context, node cdp.BackendNodeID, fn string, args ...any) (string, error) {
+	obj, err := dom.ResolveNode().WithBackendNodeID(node).Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer runtime.ReleaseObject(obj.ObjectID).Do(ctx)
+
+	var callArgs []*runtime.CallArgument
+	for _, a := range args {
+		raw, err := json.Marshal(a)
+		if err != nil {
+			return "", err
+		}
+		callArgs = append(callArgs, &runtime.CallArgument{Value: raw})
+	}
+	res, exc, err := runtime.CallFunctionOn(fn).
+		WithObjectID(obj.ObjectID).
+		WithArguments(callArgs).
+		WithReturnByValue(true).
+		Do(ctx)
+	if err != nil {
+		return "", err
+	}
+	if exc != nil {
+		msg := exc.Text
+		if exc.Exception != nil && exc.Exception.Description != "" {
+			msg = exc.Exception.Description
+		}
+		return "", fmt.Errorf("%s", msg)
+	}
+	if res == nil {
+		return "", nil
+	}
+	return string(res.Value), nil
+}
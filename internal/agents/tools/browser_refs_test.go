@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chromedp/cdproto/accessibility"
+	"github.com/chromedp/cdproto/cdp"
+)
+
+func axValue(s string) *accessibility.Value {
+	return &accessibility.Value{Type: accessibility.ValueTypeString, Value: []byte(`"` + s + `"`)}
+}
+
+func axNode(id, parent, role, name string, backend cdp.BackendNodeID, children ...string) *accessibility.Node {
+	n := &accessibility.Node{
+		NodeID:           accessibility.NodeID(id),
+		ParentID:         accessibility.NodeID(parent),
+		Role:             axValue(role),
+		BackendDOMNodeID: backend,
+	}
+	if name != "" {
+		n.Name = axValue(name)
+	}
+	for _, c := range children {
+		n.ChildIDs = append(n.ChildIDs, accessibility.NodeID(c))
+	}
+	return n
+}
+
+func sampleAXTree() []*accessibility.Node {
+	heading := axNode("2", "1", "heading", "Sign in", 10, "3")
+	heading.Properties = []*accessibility.Property{{Name: accessibility.PropertyNameLevel, Value: &accessibility.Value{Type: accessibility.ValueTypeInteger, Value: []byte("1")}}}
+	email := axNode("5", "4", "textbox", "Email", 12)
+	email.Value = axValue("me@example.com")
+	ignored := axNode("8", "4", "generic", "", 15, "9")
+	ignored.Ignored = true
+	return []*accessibility.Node{
+		axNode("1", "", "RootWebArea", "Login", 1, "2", "4"),
+		heading,
+		axNode("3", "2", "StaticText", "Sign in", 11),
+		axNode("4", "1", "generic", "", 0, "5", "6", "8"),
+		email,
+		axNode("6", "4", "button", "Continue", 13, "7"),
+		axNode("7", "6", "StaticText", "Continue", 14),
+		ignored,
+		axNode("9", "8", "link", "Forgot password?", 16),
+	}
+}
+
+func TestBuildAXOutline(t *testing.T) {
+	refs := newRefTable()
+	out := buildAXOutline(sampleAXTree(), refs)
+
+	want := strings.Join([]string{
+		`- heading "Sign in" [level=1]`,
+		`- textbox "Email" [ref=e1]: "me@example.com"`,
+		`- button "Continue" [ref=e2]`,
+		`- link "Forgot password?" [ref=e3]`,
+	}, "\n")
+	if out != want {
+		t.Errorf("outline mismatch:\n%s\nwant:\n%s", out, want)
+	}
+
+	if node, ok := refs.lookup("e2"); !ok || node != 13 {
+		t.Errorf("lookup(e2) = %d, %v", node, ok)
+	}
+	if node, ok := refs.lookup("ref=e3"); !ok || node != 16 {
+		t.Errorf("lookup(ref=e3) = %d, %v", node, ok)
+	}
+}
+
+func TestRefTable_StableAcrossSnapshots(t *testing.T) {
+	refs := newRefTable()
+	buildAXOutline(sampleAXTree(), refs)
+
+	// 页面变化后重新快照：已有元素保持 ref，新元素得到新编号
+	nodes := sampleAXTree()
+	nodes[0].ChildIDs = append([]accessibility.NodeID{"20"}, nodes[0].ChildIDs...)
+	nodes = append(nodes, axNode("20", "1", "button", "Dismiss", 30))
+	out := buildAXOutline(nodes, refs)
+
+	for _, want := range []string{`button "Dismiss" [ref=e4]`, `button "Continue" [ref=e2]`, `textbox "Email" [ref=e1]`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected %q in:\n%s", want, out)
+		}
+	}
+
+	refs.reset()
+	if _, ok := refs.lookup("e1"); ok {
+		t.Error("Expected refs to be invalid after reset")
+	}
+}
@@ -0,0 +1,343 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultBrowserProfile 未指定 profile 时使用的名称
+const DefaultBrowserProfile = "default"
+
+var browserProfileName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// browserProfile 一个浏览器进程；有 stateDir 时 user-data-dir 持久化 (cookie、登录状态)
+type browserProfile struct {
+	name      string
+	dataDir   string
+	cancel    context.CancelFunc // 关闭浏览器进程
+	rootCtx   context.Context    // 浏览器级上下文，各标签页从它派生
+	tabs      map[string]*browserTab
+	nextTab   int
+	downloads *downloadTracker
+}
+
+// browserTab 一个标签页，归属于创建它的会话
+type browserTab struct {
+	id      string
+	profile string
+	session string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	refs    *refTable
+	capture *pageCapture // 控制台和网络记录
+	created time.Time
+}
+
+// browserSession 会话当前使用的 profile 和标签页
+type browserSession struct {
+	profile string
+	tab     string
+}
+
+// browserSessionKey 会话 key，没有会话信息 (如 CLI) 时为 main
+func browserSessionKey(ctx context.Context) string {
+	if s, ok := SessionFromContext(ctx); ok && s.Key != "" {
+		return s.Key
+	}
+	return "main"
+}
+
+// sessionLocked 返回会话状态 (调用者持有 mu)
+func (t *BrowserTool) sessionLocked(key string) *browserSession {
+	s, ok := t.sessions[key]
+	if !ok {
+		s = &browserSession{profile: t.defaultProfile}
+		t.sessions[key] = s
+	}
+	return s
+}
+
+// profileLocked 返回已启动的 profile，未启动时启动 (调用者持有 mu)
+func (t *BrowserTool) profileLocked(name string) (*browserProfile, error) {
+	if p, ok := t.profiles[name]; ok {
+		return p, nil
+	}
+	if !browserProfileName.MatchString(name) {
+		return nil, fmt.Errorf("invalid profile name %q (letters, digits, - and _ only)", name)
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", t.headless),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+		chromedp.WindowSize(1920, 1080),
+	)
+	p := &browserProfile{name: name, tabs: make(map[string]*browserTab)}
+	if t.stateDir != "" {
+		p.dataDir = filepath.Join(t.stateDir, "browser", "profiles", name)
+		if err := os.MkdirAll(p.dataDir, 0700); err != nil {
+			return nil, err
+		}
+		opts = append(opts, chromedp.UserDataDir(p.dataDir))
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	rootCtx, cancelRoot := chromedp.NewContext(allocCtx)
+	p.cancel = func() {
+		cancelRoot()
+		cancelAlloc()
+	}
+	p.rootCtx = rootCtx
+
+	if err := chromedp.Run(rootCtx); err != nil {
+		p.cancel()
+		return nil, err
+	}
+
+	if t.downloadDir != "" {
+		p.downloads = newDownloadTracker(t.downloadDir)
+		if err := p.downloads.enable(rootCtx); err != nil {
+			p.cancel()
+			return nil, fmt.Errorf("enable downloads: %w", err)
+		}
+	}
+
+	t.profiles[name] = p
+	return p, nil
+}
+
+// openTabLocked 在 profile 中为会话打开新标签页并设为当前页 (调用者持有 mu)
+func (t *BrowserTool) openTabLocked(session string, p *browserProfile) (*browserTab, error) {
+	ctx, cancel := chromedp.NewContext(p.rootCtx)
+	if err := chromedp.Run(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+	p.nextTab++
+	tab := &browserTab{
+		id:      "t" + strconv.Itoa(p.nextTab),
+		profile: p.name,
+		session: session,
+		ctx:     ctx,
+		cancel:  cancel,
+		refs:    newRefTable(),
+		capture: newPageCapture(),
+		created: time.Now(),
+	}
+
+	chromedp.ListenTarget(ctx, func(ev interface{}) {
+		// 主框架导航后旧的 ref 失效 (包括点击链接、表单提交引起的导航)
+		if e, ok := ev.(*page.EventFrameNavigated); ok && e.Frame.ParentID == "" {
+			tab.refs.reset()
+		}
+		tab.capture.handle(ev)
+	})
+
+	p.tabs[tab.id] = tab
+	s := t.sessionLocked(session)
+	s.profile, s.tab = p.name, tab.id
+	return tab, nil
+}
+
+// currentTab 返回会话的当前标签页，没有时在会话的 profile 中打开一个
+func (t *BrowserTool) currentTab(ctx context.Context) (*browserTab, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := browserSessionKey(ctx)
+	s := t.sessionLocked(key)
+	if p, ok := t.profiles[s.profile]; ok {
+		if tab, ok := p.tabs[s.tab]; ok {
+			return tab, nil
+		}
+	}
+	p, err := t.profileLocked(s.profile)
+	if err != nil {
+		return nil, err
+	}
+	return t.openTabLocked(key, p)
+}
+
+// sessionTabsLocked 会话拥有的标签页 (按 profile 和编号排序)
+func (t *BrowserTool) sessionTabsLocked(key string) []*browserTab {
+	var tabs []*browserTab
+	for _, p := range t.profiles {
+		for _, tab := range p.tabs {
+			if tab.session == key {
+				tabs = append(tabs, tab)
+			}
+		}
+	}
+	sort.Slice(tabs, func(i, j int) bool {
+		if tabs[i].profile != tabs[j].profile {
+			return tabs[i].profile < tabs[j].profile
+		}
+		return tabs[i].created.Before(tabs[j].created)
+	})
+	return tabs
+}
+
+// findTabLocked 查找会话拥有的标签页 (其他会话的标签页不可见)
+func (t *BrowserTool) findTabLocked(key, id string) (*browserTab, error) {
+	for _, tab := range t.sessionTabsLocked(key) {
+		if tab.id == id || tab.profile+"/"+tab.id == id {
+			return tab, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown tab %q; use action tabs to list open tabs", id)
+}
+
+// closeTabLocked 关闭标签页；是会话当前页时切换到该会话的其他标签页
+func (t *BrowserTool) closeTabLocked(tab *browserTab) {
+	tab.cancel()
+	if p, ok := t.profiles[tab.profile]; ok {
+		delete(p.tabs, tab.id)
+	}
+	s := t.sessionLocked(tab.session)
+	if s.profile == tab.profile && s.tab == tab.id {
+		s.tab = ""
+		if rest := t.sessionTabsLocked(tab.session); len(rest) > 0 {
+			s.profile, s.tab = rest[len(rest)-1].profile, rest[len(rest)-1].id
+		}
+	}
+}
+
+// stopProfileLocked 关闭 profile 的浏览器进程和所有标签页
+func (t *BrowserTool) stopProfileLocked(p *browserProfile) {
+	for _, tab := range p.tabs {
+		tab.cancel()
+	}
+	p.cancel()
+	delete(t.profiles, p.name)
+	for _, s := range t.sessions {
+		if s.profile == p.name {
+			s.tab = ""
+		}
+	}
+}
+
+// tabInfo 标签页的 URL 和标题
+func tabInfo(tab *browserTab) (string, string) {
+	ctx, cancel := context.WithTimeout(tab.ctx, 2*time.Second)
+	defer cancel()
+	var url, title string
+	if err := chromedp.Run(ctx, chromedp.Location(&url), chromedp.Title(&title)); err != nil {
+		return "", ""
+	}
+	return url, title
+}
+
+// browserDownload 一次下载
+type browserDownload struct {
+	URL   string
+	Path  string
+	State string // inProgress, completed, canceled
+	Bytes int64
+}
+
+// downloadTracker 将下载保存到下载目录，完成后按建议文件名重命名
+type downloadTracker struct {
+	dir   string
+	mu    sync.Mutex
+	byID  map[string]*browserDownload
+	order []string
+}
+
+func newDownloadTracker(dir string) *downloadTracker {
+	return &downloadTracker{dir: dir, byID: make(map[string]*browserDownload)}
+}
+
+// enable 开启浏览器级下载事件
+func (d *downloadTracker) enable(ctx context.Context) error {
+	if err := os.MkdirAll(d.dir, 0755); err != nil {
+		return err
+	}
+	chromedp.ListenBrowser(ctx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *browser.EventDownloadWillBegin:
+			d.begin(e.GUID, e.URL, e.SuggestedFilename)
+		case *browser.EventDownloadProgress:
+			d.progress(e.GUID, string(e.State), int64(e.ReceivedBytes))
+		}
+	})
+	return chromedp.Run(ctx, browser.SetDownloadBehavior(browser.SetDownloadBehaviorBehaviorAllowAndName).
+		WithDownloadPath(d.dir).
+		WithEventsEnabled(true))
+}
+
+func (d *downloadTracker) begin(guid, url, suggested string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	name := filepath.Base(suggested)
+	if name == "." || name == string(filepath.Separator) || name == "" {
+		name = "download"
+	}
+	d.byID[guid] = &browserDownload{URL: url, Path: name, State: "inProgress"}
+	d.order = append(d.order, guid)
+}
+
+func (d *downloadTracker) progress(guid, state string, received int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dl, ok := d.byID[guid]
+	if !ok || dl.State != "inProgress" {
+		return
+	}
+	dl.State, dl.Bytes = state, received
+	if state != "completed" {
+		return
+	}
+	// allowAndName 模式下文件以 GUID 命名
+	dest := uniqueDownloadPath(d.dir, dl.Path)
+	if err := os.Rename(filepath.Join(d.dir, guid), dest); err != nil {
+		dest = filepath.Join(d.dir, guid)
+	}
+	dl.Path = dest
+}
+
+// list 已捕获的下载
+func (d *downloadTracker) list() []browserDownload {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]browserDownload, 0, len(d.order))
+	for _, id := range d.order {
+		out = append(out, *d.byID[id])
+	}
+	return out
+}
+
+// uniqueDownloadPath 目标已存在时追加序号：report.pdf -> report (1).pdf
+func uniqueDownloadPath(dir, name string) string {
+	path := filepath.Join(dir, name)
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 1; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", base, i, ext))
+	}
+}
+
+// sortedKeys 按名称排序的 profile 名
+func sortedKeys(m map[string]*browserProfile) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,112 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func runBrowser(t *testing.T, tool *BrowserTool, ctx context.Context, params BrowserParams) *Result {
+	t.Helper()
+	args, _ := json.Marshal(params)
+	result, err := tool.Execute(ctx, args)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	return result
+}
+
+func TestBrowserTool_FromConfig(t *testing.T) {
+	headful := false
+	tool := NewBrowserToolFromConfig(config.BrowserConfig{Profile: "work", Headless: &headful}, "/state", "/ws")
+	if tool.defaultProfile != "work" || tool.headless || tool.stateDir != "/state" {
+		t.Errorf("Unexpected tool config: %+v", tool)
+	}
+	if tool.downloadDir != filepath.Join("/ws", "downloads") {
+		t.Errorf("downloadDir = %q", tool.downloadDir)
+	}
+
+	tool = NewBrowserToolFromConfig(config.BrowserConfig{DownloadDir: "/tmp/dl"}, "", "/ws")
+	if tool.downloadDir != "/tmp/dl" || tool.defaultProfile != DefaultBrowserProfile {
+		t.Errorf("Unexpected tool config: %+v", tool)
+	}
+}
+
+func TestBrowserTool_ApplyConfig(t *testing.T) {
+	headful := false
+	tool := NewBrowserToolFromConfig(config.BrowserConfig{Profile: "work", Headless: &headful}, "/state", "/ws")
+
+	tool.ApplyConfig(config.BrowserConfig{Profile: "personal"})
+	if tool.defaultProfile != "personal" || !tool.headless {
+		t.Errorf("Unexpected tool config: %+v", tool)
+	}
+	if tool.stateDir != "/state" || tool.downloadDir != filepath.Join("/ws", "downloads") {
+		t.Errorf("ApplyConfig should keep directories: %+v", tool)
+	}
+
+	tool.ApplyConfig(config.BrowserConfig{})
+	if tool.defaultProfile != DefaultBrowserProfile {
+		t.Errorf("defaultProfile = %q", tool.defaultProfile)
+	}
+}
+
+func TestBrowserTool_TabsWithoutBrowser(t *testing.T) {
+	tool := NewBrowserTool()
+	ctx := WithSession(context.Background(), SessionContext{Key: "telegram:1"})
+
+	tests := []struct {
+		params  BrowserParams
+		want    string
+		isError bool
+	}{
+		{BrowserParams{Action: "status"}, "not running", false},
+		{BrowserParams{Action: "tabs"}, "No tabs open", false},
+		{BrowserParams{Action: "focus"}, "tabId is required", true},
+		{BrowserParams{Action: "focus", TabID: "t1"}, `unknown tab "t1"`, true},
+		{BrowserParams{Action: "close"}, "No tab open", true},
+		{BrowserParams{Action: "stop"}, "not running", false},
+		{BrowserParams{Action: "downloads"}, "not enabled", true},
+		{BrowserParams{Action: "start", Profile: "../etc"}, "invalid profile name", true},
+	}
+	for _, tt := range tests {
+		result := runBrowser(t, tool, ctx, tt.params)
+		if result.IsError != tt.isError || !strings.Contains(result.Content, tt.want) {
+			t.Errorf("%s: got (%v) %s, want %q", tt.params.Action, result.IsError, result.Content, tt.want)
+		}
+	}
+}
+
+func TestDownloadTracker(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "report.pdf"), []byte("old"), 0644)
+	d := newDownloadTracker(dir)
+
+	// allowAndName 模式下文件先以 GUID 命名
+	d.begin("guid-1", "https://example.com/report.pdf", "report.pdf")
+	os.WriteFile(filepath.Join(dir, "guid-1"), []byte("new"), 0644)
+	d.progress("guid-1", "inProgress", 1)
+	d.progress("guid-1", "completed", 3)
+
+	d.begin("guid-2", "https://example.com/x", "../../evil.sh")
+	d.progress("guid-2", "canceled", 0)
+
+	list := d.list()
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 downloads, got %d", len(list))
+	}
+	want := filepath.Join(dir, "report (1).pdf")
+	if list[0].State != "completed" || list[0].Path != want || list[0].Bytes != 3 {
+		t.Errorf("Unexpected download: %+v", list[0])
+	}
+	if data, _ := os.ReadFile(want); string(data) != "new" {
+		t.Errorf("Download content = %q", data)
+	}
+	if list[1].State != "canceled" || list[1].Path != "evil.sh" {
+		t.Errorf("Unexpected download: %+v", list[1])
+	}
+}
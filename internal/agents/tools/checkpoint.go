@@ -0,0 +1,176 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
+)
+
+// snapshotFiles 在修改文件前保存快照 (store 为空时不做任何事)。
+// 没有会话信息的调用 (如 CLI) 记在 main 会话下。
+func snapshotFiles(ctx context.Context, store *checkpoints.Store, tool string, paths ...string) error {
+	if store == nil || len(paths) == 0 {
+		return nil
+	}
+	session, _ := SessionFromContext(ctx)
+	key := session.Key
+	if key == "" {
+		key = "main"
+	}
+	_, err := store.Snapshot(key, session.Turn, tool, paths...)
+	return err
+}
+
+// CheckpointTool 查看和恢复文件检查点
+type CheckpointTool struct {
+	store *checkpoints.Store
+	paths *PathPolicy // 恢复时检查写入目标，为空时只检查父目录
+}
+
+// CheckpointParams checkpoint 工具参数
+type CheckpointParams struct {
+	Action  string `json:"action"` // list, diff, restore
+	ID      int    `json:"id,omitempty"`
+	Session string `json:"session,omitempty"`
+}
+
+// NewCheckpointTool 创建 checkpoint 工具
+func NewCheckpointTool(store *checkpoints.Store) *CheckpointTool {
+	return &CheckpointTool{store: store}
+}
+
+// SetPathPolicy 设置恢复文件时使用的路径访问策略 (与文件工具相同)
+func (t *CheckpointTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
+func (t *CheckpointTool) Name() string {
+	return ToolCheckpoint
+}
+
+func (t *CheckpointTool) Description() string {
+	return "List, diff and restore file checkpoints. Every write, edit and apply_patch call saves the previous content of the files it changes, grouped per conversation turn. " +
+		"diff shows what restoring checkpoint N would change; restore reverts all changes made at checkpoint N and later (the restore itself can be undone)."
+}
+
+func (t *CheckpointTool) Parameters() json.RawMessage {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"action": {
+				"type": "string",
+				"enum": ["list", "diff", "restore"],
+				"description": "Action to perform"
+			},
+			"id": {
+				"type": "number",
+				"description": "Checkpoint ID (for diff/restore)"
+			},
+			"session": {
+				"type": "string",
+				"description": "Session key (defaults to the current session; other sessions only from the main session)"
+			}
+		},
+		"required": ["action"]
+	}`
+	return json.RawMessage(schema)
+}
+
+func (t *CheckpointTool) Execute(ctx context.Context, args json.RawMessage) (*Result, error) {
+	var params CheckpointParams
+	if err := json.Unmarshal(args, &params); err != nil {
+		return &Result{Content: "Invalid parameters: " + err.Error(), IsError: true}, nil
+	}
+	if t.store == nil {
+		return &Result{Content: "Checkpoints are not enabled", IsError: true}, nil
+	}
+
+	// 默认为调用者的会话；只有主会话可以访问其他会话的检查点
+	caller, _ := SessionFromContext(ctx)
+	own := caller.Key
+	if own == "" {
+		own = "main"
+	}
+	if params.Session == "" {
+		params.Session = own
+	}
+	if params.Session != own && caller.Kind != "" && caller.Kind != "main" {
+		return &Result{Content: "Checkpoints of other sessions can only be accessed from the main session", IsError: true}, nil
+	}
+
+	switch params.Action {
+	case "list":
+		list, err := t.store.List(params.Session)
+		if err != nil {
+			return &Result{Content: "Failed to list checkpoints: " + err.Error(), IsError: true}, nil
+		}
+		if len(list) == 0 {
+			return &Result{Content: "No checkpoints for session " + params.Session}, nil
+		}
+		return &Result{Content: FormatCheckpoints(list)}, nil
+	case "diff", "restore":
+		if params.ID <= 0 {
+			return &Result{Content: "id is required for " + params.Action, IsError: true}, nil
+		}
+		var changes []checkpoints.FileChange
+		var err error
+		if params.Action == "diff" {
+			changes, err = t.store.Diff(params.Session, params.ID)
+		} else {
+			var check checkpoints.PathCheck
+			if t.paths != nil {
+				check = t.paths.RestoreCheck(params.Session)
+			}
+			changes, err = t.store.Restore(params.Session, params.ID, check)
+		}
+		if err != nil {
+			return &Result{Content: fmt.Sprintf("Failed to %s checkpoint: %v", params.Action, err), IsError: true}, nil
+		}
+		return &Result{Content: FormatCheckpointChanges(changes, params.Action == "diff")}, nil
+	default:
+		return &Result{Content: "Unknown action: " + params.Action, IsError: true}, nil
+	}
+}
+
+// FormatCheckpoints 检查点列表的文本形式 (工具和 CLI 共用)
+func FormatCheckpoints(list []checkpoints.Checkpoint) string {
+	var sb strings.Builder
+	for _, cp := range list {
+		turn := "-"
+		if cp.Turn > 0 {
+			turn = fmt.Sprintf("%d", cp.Turn)
+		}
+		fmt.Fprintf(&sb, "#%d  turn %s  %s  %s  %d file(s)\n",
+			cp.ID, turn, cp.CreatedAt.Format("2006-01-02 15:04:05"), strings.Join(cp.Tools, ","), len(cp.Files))
+		for _, f := range cp.Files {
+			state := ""
+			if !f.Exists {
+				state = " (new)"
+			}
+			fmt.Fprintf(&sb, "    %s%s\n", f.Path, state)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// FormatCheckpointChanges diff/restore 结果的文本形式
+func FormatCheckpointChanges(changes []checkpoints.FileChange, withDiff bool) string {
+	if len(changes) == 0 {
+		return "No changes: files already match the checkpoint"
+	}
+	var sb strings.Builder
+	for _, c := range changes {
+		if c.Skipped != "" {
+			fmt.Fprintf(&sb, "skipped %s: %s\n", c.Path, c.Skipped)
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %s\n", c.Action, c.Path)
+		if withDiff && c.Diff != "" {
+			sb.WriteString(c.Diff)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
+)
+
+func TestCheckpointTool_UndoAgentEdits(t *testing.T) {
+	ws := t.TempDir()
+	store := checkpoints.NewStore(t.TempDir())
+	os.WriteFile(filepath.Join(ws, "app.txt"), []byte("version 1\n"), 0644)
+
+	writeTool := NewWriteTool(ws)
+	writeTool.SetCheckpoints(store)
+	editTool := NewEditTool(ws)
+	editTool.SetCheckpoints(store)
+	patchTool := NewApplyPatchTool(ws)
+	patchTool.SetCheckpoints(store)
+	checkpointTool := NewCheckpointTool(store)
+
+	call := func(ctx context.Context, tool Tool, params interface{}) *Result {
+		t.Helper()
+		args, _ := json.Marshal(params)
+		result, err := tool.Execute(ctx, args)
+		if err != nil {
+			t.Fatalf("%s: %v", tool.Name(), err)
+		}
+		if result.IsError {
+			t.Fatalf("%s: %s", tool.Name(), result.Content)
+		}
+		return result
+	}
+
+	// 第 1 轮：edit + 新建文件
+	turn1 := WithSession(context.Background(), SessionContext{Key: "telegram:7", Turn: 1})
+	call(turn1, editTool, EditParams{Path: "app.txt", OldText: "version 1", NewText: "version 2"})
+	call(turn1, writeTool, WriteParams{Path: "notes.txt", Content: "notes\n"})
+
+	// 第 2 轮：补丁再次修改
+	turn2 := WithSession(context.Background(), SessionContext{Key: "telegram:7", Turn: 2})
+	call(turn2, patchTool, ApplyPatchParams{Patch: "*** Begin Patch\n*** Update File: app.txt\n-version 2\n+version 3\n*** End Patch"})
+
+	result := call(turn2, checkpointTool, CheckpointParams{Action: "list"})
+	if !strings.Contains(result.Content, "#1  turn 1") || !strings.Contains(result.Content, "edit,write") ||
+		!strings.Contains(result.Content, "#2  turn 2") || !strings.Contains(result.Content, "notes.txt (new)") {
+		t.Errorf("Unexpected list:\n%s", result.Content)
+	}
+
+	result = call(turn2, checkpointTool, CheckpointParams{Action: "diff", ID: 2})
+	if !strings.Contains(result.Content, "-version 3") || !strings.Contains(result.Content, "+version 2") {
+		t.Errorf("Unexpected diff:\n%s", result.Content)
+	}
+
+	// 回滚到第 1 轮之前
+	call(turn2, checkpointTool, CheckpointParams{Action: "restore", ID: 1})
+	data, _ := os.ReadFile(filepath.Join(ws, "app.txt"))
+	if string(data) != "version 1\n" {
+		t.Errorf("app.txt after restore: %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(ws, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected notes.txt removed")
+	}
+
+	// 其他会话看不到这些检查点
+	result = call(context.Background(), checkpointTool, CheckpointParams{Action: "list"})
+	if !strings.Contains(result.Content, "No checkpoints for session main") {
+		t.Errorf("Unexpected list for main: %s", result.Content)
+	}
+}
+
+func TestCheckpointTool_Errors(t *testing.T) {
+	tool := NewCheckpointTool(checkpoints.NewStore(t.TempDir()))
+	for _, params := range []CheckpointParams{
+		{Action: "restore"},
+		{Action: "diff", ID: 5},
+		{Action: "bogus"},
+	} {
+		args, _ := json.Marshal(params)
+		result, _ := tool.Execute(context.Background(), args)
+		if !result.IsError {
+			t.Errorf("Expected error for %+v, got: %s", params, result.Content)
+		}
+	}
+}
+
+func TestCheckpointTool_SessionScope(t *testing.T) {
+	ws := t.TempDir()
+	store := checkpoints.NewStore(t.TempDir())
+	os.WriteFile(filepath.Join(ws, "app.txt"), []byte("main edit\n"), 0644)
+	if _, err := store.Snapshot("main", 1, "write", filepath.Join(ws, "app.txt")); err != nil {
+		t.Fatal(err)
+	}
+	tool := NewCheckpointTool(store)
+	execute := func(ctx context.Context, params CheckpointParams) *Result {
+		args, _ := json.Marshal(params)
+		result, _ := tool.Execute(ctx, args)
+		return result
+	}
+
+	// 群聊会话不能查看或恢复主会话的检查点
+	group := WithSession(context.Background(), SessionContext{Key: "telegram:-100", Kind: "group"})
+	for _, params := range []CheckpointParams{
+		{Action: "list", Session: "main"},
+		{Action: "restore", ID: 1, Session: "main"},
+	} {
+		if result := execute(group, params); !result.IsError {
+			t.Errorf("Expected %+v to be refused for a group session, got: %s", params, result.Content)
+		}
+	}
+	if result := execute(group, CheckpointParams{Action: "list"}); result.IsError || !strings.Contains(result.Content, "telegram:-100") {
+		t.Errorf("Group session should list its own checkpoints: %s", result.Content)
+	}
+
+	// 主会话可以访问其他会话
+	main := WithSession(context.Background(), SessionContext{Key: "main", Kind: "main"})
+	if result := execute(main, CheckpointParams{Action: "list", Session: "telegram:-100"}); result.IsError {
+		t.Errorf("Main session should access other sessions: %s", result.Content)
+	}
+	if result := execute(main, CheckpointParams{Action: "list"}); !strings.Contains(result.Content, "#1") {
+		t.Errorf("Unexpected list for main: %s", result.Content)
+	}
+}
+
+func TestCheckpointTool_RestoreRespectsPathPolicy(t *testing.T) {
+	ws := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	os.WriteFile(outside, []byte("outside\n"), 0644)
+	store := checkpoints.NewStore(t.TempDir())
+
+	writeTool := NewWriteTool(ws)
+	writeTool.SetCheckpoints(store)
+	tool := NewCheckpointTool(store)
+	tool.SetPathPolicy(DefaultPathPolicy(ws))
+
+	ctx := WithSession(context.Background(), SessionContext{Key: "telegram:-100", Kind: "group", Turn: 1})
+	args, _ := json.Marshal(WriteParams{Path: "app.txt", Content: "v1\n"})
+	writeTool.Execute(ctx, args)
+	args, _ = json.Marshal(WriteParams{Path: "app.txt", Content: "v2\n"})
+	writeTool.Execute(WithSession(ctx, SessionContext{Key: "telegram:-100", Kind: "group", Turn: 2}), args)
+
+	// 把工作区内的文件换成指向工作区外的符号链接，恢复不能写穿
+	os.Remove(filepath.Join(ws, "app.txt"))
+	if err := os.Symlink(outside, filepath.Join(ws, "app.txt")); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	args, _ = json.Marshal(CheckpointParams{Action: "restore", ID: 2})
+	result, _ := tool.Execute(ctx, args)
+	if result.IsError || !strings.Contains(result.Content, "outside the allowed directories") {
+		t.Errorf("Expected restore target to be skipped, got: %s", result.Content)
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "outside\n" {
+		t.Errorf("File outside the workspace was overwritten: %q", data)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/z8n24/openclaw-go/internal/cron"
 )
@@ -18,6 +19,7 @@ type CronParams struct {
 	Action          string          `json:"action"` // status, list, add, update, remove, run, runs
 	JobID           string          `json:"jobId,omitempty"`
 	IncludeDisabled bool            `json:"includeDisabled,omitempty"`
+	Limit           int             `json:"limit,omitempty"`
 	Job             *cron.Job       `json:"job,omitempty"`
 	Patch           json.RawMessage `json:"patch,omitempty"`
 }
@@ -31,18 +33,26 @@ func (t *CronTool) Name() string {
 }
 
 func (t *CronTool) Description() string {
-	return `Manage cron jobs: status/list/add/update/remove/run.
+	return `Manage cron jobs: status/list/add/update/remove/run/runs ("runs" shows a job's recent run history).
 
 SCHEDULE TYPES:
 - "at": One-shot at absolute time { "kind": "at", "atMs": <unix-ms-timestamp> }
-- "every": Recurring interval { "kind": "every", "everyMs": <interval-ms> }
-- "cron": Cron expression { "kind": "cron", "expr": "<cron-expression>" }
+  Disabled after it fires; set "deleteAfterRun": true on the job to delete it instead.
+- "every": Recurring interval { "kind": "every", "everyMs": <interval-ms>, "anchorMs": <optional start unix-ms> }
+- "cron": Cron expression { "kind": "cron", "expr": "0 9 * * 1-5", "tz": "<optional IANA zone, e.g. Asia/Shanghai>" }
+  5 fields (minute precision) or 6 fields with leading seconds.
 
 PAYLOAD TYPES:
 - "systemEvent": { "kind": "systemEvent", "text": "<message>" }
-- "agentTurn": { "kind": "agentTurn", "message": "<prompt>" }
+- "agentTurn": { "kind": "agentTurn", "message": "<prompt>", "model": "<optional model>" }
+  The result is delivered to "channel" + "chatId" in the payload (e.g. "telegram", "123456789"), or to the configured default target; set "deliver": false to skip delivery.
 
-sessionTarget must be "main" for systemEvent, "isolated" for agentTurn.`
+sessionTarget must be "main" for systemEvent, "isolated" for agentTurn.
+
+RELIABILITY (optional job fields):
+- "overlap": what to do when the previous run is still going: "skip" (default), "queue" (run once more afterwards) or "allow"
+- "timeoutMs": abort the run after this many milliseconds
+- "catchUp": true to run once at startup if a run was missed while the gateway was down`
 }
 
 func (t *CronTool) Parameters() json.RawMessage {
@@ -51,10 +61,11 @@ func (t *CronTool) Parameters() json.RawMessage {
 		"properties": {
 			"action": {
 				"type": "string",
-				"enum": ["status", "list", "add", "update", "remove", "run"],
+				"enum": ["status", "list", "add", "update", "remove", "run", "runs"],
 				"description": "Cron action"
 			},
-			"jobId": {"type": "string", "description": "Job ID for update/remove/run"},
+			"jobId": {"type": "string", "description": "Job ID for update/remove/run/runs"},
+			"limit": {"type": "integer", "description": "Number of runs to show (runs, default 10)"},
 			"includeDisabled": {"type": "boolean", "description": "Include disabled jobs in list"},
 			"job": {
 				"type": "object",
@@ -63,7 +74,11 @@ func (t *CronTool) Parameters() json.RawMessage {
 					"schedule": {"type": "object"},
 					"payload": {"type": "object"},
 					"sessionTarget": {"type": "string"},
-					"enabled": {"type": "boolean"}
+					"enabled": {"type": "boolean"},
+					"overlap": {"type": "string", "enum": ["skip", "queue", "allow"]},
+					"timeoutMs": {"type": "integer"},
+					"catchUp": {"type": "boolean"},
+					"deleteAfterRun": {"type": "boolean"}
 				}
 			},
 			"patch": {"type": "object", "description": "Fields to update"}
@@ -95,6 +110,8 @@ func (t *CronTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 		return t.remove(params.JobID)
 	case "run":
 		return t.run(params.JobID)
+	case "runs":
+		return t.runs(params.JobID, params.Limit)
 	default:
 		return &Result{Content: "Unknown action: " + params.Action, IsError: true}, nil
 	}
@@ -210,22 +227,69 @@ func (t *CronTool) run(jobID string) (*Result, error) {
 	return &Result{Content: "Job triggered: " + jobID}, nil
 }
 
+func (t *CronTool) runs(jobID string, limit int) (*Result, error) {
+	if jobID == "" {
+		return &Result{Content: "Job ID is required", IsError: true}, nil
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+
+	runs, err := t.scheduler.Runs(jobID, limit)
+	if err != nil {
+		return &Result{Content: "Failed to get runs: " + err.Error(), IsError: true}, nil
+	}
+	if len(runs) == 0 {
+		return &Result{Content: "No runs recorded for job " + jobID}, nil
+	}
+	return &Result{Content: FormatCronRuns(runs)}, nil
+}
+
+// FormatCronRuns 格式化运行记录 (工具与 CLI 共用)
+func FormatCronRuns(runs []cron.RunRecord) string {
+	var sb strings.Builder
+	for _, run := range runs {
+		sb.WriteString(fmt.Sprintf("%s  %-7s  %6s  %s",
+			run.StartedAt.Local().Format("2006-01-02 15:04:05"),
+			run.Status,
+			(time.Duration(run.DurationMs) * time.Millisecond).Round(time.Millisecond),
+			run.Trigger))
+		if run.SessionKey != "" {
+			sb.WriteString("  " + run.SessionKey)
+		}
+		sb.WriteString("\n")
+		if run.Error != "" {
+			sb.WriteString("    error: " + run.Error + "\n")
+		}
+		if run.Output != "" {
+			sb.WriteString("    " + strings.ReplaceAll(run.Output, "\n", " ") + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
 func formatSchedule(s cron.Schedule) string {
 	switch s.Kind {
 	case "cron":
+		if s.Tz != "" {
+			return fmt.Sprintf("cron(%s, %s)", s.Expr, s.Tz)
+		}
 		return fmt.Sprintf("cron(%s)", s.Expr)
 	case "every":
-		sec := s.EveryMs / 1000
-		if sec < 60 {
-			return fmt.Sprintf("every %ds", sec)
-		} else if sec < 3600 {
-			return fmt.Sprintf("every %dm", sec/60)
-		} else if sec < 86400 {
-			return fmt.Sprintf("every %dh", sec/3600)
+		d := time.Duration(s.EveryMs) * time.Millisecond
+		if d%(24*time.Hour) == 0 {
+			return fmt.Sprintf("every %dd", d/(24*time.Hour))
+		}
+		text := d.String()
+		if strings.HasSuffix(text, "m0s") {
+			text = strings.TrimSuffix(text, "0s")
+		}
+		if strings.HasSuffix(text, "h0m") {
+			text = strings.TrimSuffix(text, "0m")
 		}
-		return fmt.Sprintf("every %dd", sec/86400)
+		return "every " + text
 	case "at":
-		return fmt.Sprintf("at %d", s.AtMs)
+		return "at " + time.UnixMilli(s.AtMs).Format("2006-01-02 15:04:05")
 	default:
 		return "unknown"
 	}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"strings"
 	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/cron"
 )
 
 // 由于 CronTool 依赖 cron.Scheduler，这里测试基本的参数验证
@@ -97,3 +99,45 @@ func TestCronTool_Parameters(t *testing.T) {
 		t.Error("Schema should have 'jobId' property")
 	}
 }
+
+func TestCronTool_Runs(t *testing.T) {
+	scheduler := cron.NewScheduler(t.TempDir(), func(job *cron.Job) error { return nil })
+	tool := NewCronTool(scheduler)
+
+	job := &cron.Job{Name: "digest", Schedule: cron.Schedule{Kind: "every", EveryMs: 3600000}}
+	scheduler.AddJob(job)
+
+	result, _ := tool.Execute(context.Background(), json.RawMessage(`{"action":"runs","jobId":"`+job.ID+`"}`))
+	if result.IsError || !strings.Contains(result.Content, "No runs") {
+		t.Errorf("Expected no runs, got %s", result.Content)
+	}
+
+	scheduler.RunJob(job.ID)
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"action":"runs","jobId":"`+job.ID+`"}`))
+	if result.IsError || !strings.Contains(result.Content, "ok") || !strings.Contains(result.Content, "manual") {
+		t.Errorf("Unexpected runs output: %s", result.Content)
+	}
+
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"action":"runs","jobId":"missing"}`))
+	if !result.IsError {
+		t.Error("Expected error for unknown job")
+	}
+}
+
+func TestFormatSchedule(t *testing.T) {
+	tests := []struct {
+		schedule cron.Schedule
+		want     string
+	}{
+		{cron.Schedule{Kind: "every", EveryMs: 10000}, "every 10s"},
+		{cron.Schedule{Kind: "every", EveryMs: 90 * 60000}, "every 1h30m"},
+		{cron.Schedule{Kind: "every", EveryMs: 3600000}, "every 1h"},
+		{cron.Schedule{Kind: "every", EveryMs: 2 * 86400000}, "every 2d"},
+		{cron.Schedule{Kind: "cron", Expr: "0 9 * * *", Tz: "Asia/Shanghai"}, "cron(0 9 * * *, Asia/Shanghai)"},
+	}
+	for _, tt := range tests {
+		if got := formatSchedule(tt.schedule); got != tt.want {
+			t.Errorf("formatSchedule(%+v) = %q, want %q", tt.schedule, got, tt.want)
+		}
+	}
+}
@@ -5,13 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
 )
 
 // EditTool 编辑文件的工具
 type EditTool struct {
-	workdir string
+	workdir     string
+	paths       *PathPolicy
+	checkpoints *checkpoints.Store
 }
 
 // EditParams edit 工具参数
@@ -28,9 +31,20 @@ type EditParams struct {
 func NewEditTool(workdir string) *EditTool {
 	return &EditTool{
 		workdir: workdir,
+		paths:   DefaultPathPolicy(workdir),
 	}
 }
 
+// SetCheckpoints 修改前保存文件快照
+func (t *EditTool) SetCheckpoints(store *checkpoints.Store) {
+	t.checkpoints = store
+}
+
+// SetPathPolicy 设置路径访问策略
+func (t *EditTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
 func (t *EditTool) Name() string {
 	return ToolEdit
 }
@@ -91,9 +105,9 @@ func (t *EditTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 	}
 
 	// 解析路径
-	path := params.Path
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(t.workdir, path)
+	path, err := t.paths.Resolve(ctx, params.Path, true)
+	if err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
 	}
 
 	// 读取文件
@@ -132,6 +146,10 @@ func (t *EditTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 	// 执行替换
 	newContent := strings.Replace(contentStr, params.OldText, params.NewText, 1)
 
+	if err := snapshotFiles(ctx, t.checkpoints, ToolEdit, path); err != nil {
+		return &Result{Content: fmt.Sprintf("Failed to checkpoint file: %v", err), IsError: true}, nil
+	}
+
 	// 写回文件
 	if err := os.WriteFile(path, []byte(newContent), 0644); err != nil {
 		return &Result{Content: fmt.Sprintf("Failed to write file: %v", err), IsError: true}, nil
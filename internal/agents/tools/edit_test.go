@@ -231,7 +231,7 @@ func TestEditTool_AbsolutePath(t *testing.T) {
 	testFile := filepath.Join(tmpDir, "abs.txt")
 	os.WriteFile(testFile, []byte("absolute"), 0644)
 
-	tool := NewEditTool("/different/path")
+	tool := NewEditTool(tmpDir)
 	params := EditParams{
 		Path:    testFile,
 		OldText: "absolute",
@@ -4,19 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"os/exec"
 	"strings"
+	"sync"
 	"time"
 )
 
 // ExecTool 执行 shell 命令的工具
 type ExecTool struct {
 	workdir     string
-	allowlist   []string // 允许的命令前缀
+	mu          sync.RWMutex
+	allowlist   []string // 允许的命令前缀，为空时不限制
 	timeout     time.Duration
 	yieldMs     time.Duration // 默认 yield 时间
 	processTool *ProcessTool  // 用于后台进程
+	sandbox     *Sandbox      // 为空时直接在主机执行
 }
 
 // ExecParams exec 工具参数
@@ -44,6 +45,51 @@ func (t *ExecTool) SetProcessTool(pt *ProcessTool) {
 	t.processTool = pt
 }
 
+// SetAllowlist 设置允许的命令前缀 (tools.exec.allowlist)，为空时不限制；可在运行中替换
+func (t *ExecTool) SetAllowlist(prefixes []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.allowlist = append([]string(nil), prefixes...)
+}
+
+// commandAllowed 检查命令是否匹配允许的前缀；设置了 allowlist 时不允许串联命令、重定向和命令替换
+func (t *ExecTool) commandAllowed(command string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.allowlist) == 0 {
+		return true
+	}
+	command = strings.TrimSpace(command)
+	if strings.ContainsAny(command, ";&|<>`\n") || strings.Contains(command, "$(") {
+		return false
+	}
+	for _, prefix := range t.allowlist {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" || !strings.HasPrefix(command, prefix) {
+			continue
+		}
+		// 按词匹配："git" 允许 "git status"，不允许 "gitx"
+		if rest := command[len(prefix):]; rest == "" || rest[0] == ' ' || rest[0] == '\t' {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSandbox 设置沙箱 (按会话决定是否启用)
+func (t *ExecTool) SetSandbox(sb *Sandbox) {
+	t.sandbox = sb
+}
+
+// sandboxFor 返回当前会话应使用的沙箱，直接在主机执行时返回 nil
+func (t *ExecTool) sandboxFor(ctx context.Context) *Sandbox {
+	session, _ := SessionFromContext(ctx)
+	if t.sandbox.Applies(session) {
+		return t.sandbox
+	}
+	return nil
+}
+
 func (t *ExecTool) Name() string {
 	return ToolExec
 }
@@ -99,6 +145,9 @@ func (t *ExecTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 	if params.Command == "" {
 		return &Result{Content: "Command is required", IsError: true}, nil
 	}
+	if !t.commandAllowed(params.Command) {
+		return &Result{Content: "Command not allowed by tools.exec.allowlist: " + params.Command, IsError: true}, nil
+	}
 
 	// 确定工作目录
 	workdir := t.workdir
@@ -106,17 +155,19 @@ func (t *ExecTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 		workdir = params.Workdir
 	}
 
+	sb := t.sandboxFor(ctx)
+
 	// 如果需要后台或 PTY，使用 ProcessTool
 	if params.Background || params.PTY {
-		return t.executeBackground(ctx, params, workdir)
+		return t.executeBackground(ctx, params, workdir, sb)
 	}
 
 	// 普通同步执行
-	return t.executeSync(ctx, params, workdir)
+	return t.executeSync(ctx, params, workdir, sb)
 }
 
 // executeSync 同步执行命令
-func (t *ExecTool) executeSync(ctx context.Context, params ExecParams, workdir string) (*Result, error) {
+func (t *ExecTool) executeSync(ctx context.Context, params ExecParams, workdir string, sb *Sandbox) (*Result, error) {
 	// 确定超时
 	timeout := t.timeout
 	if params.Timeout > 0 {
@@ -129,62 +180,81 @@ func (t *ExecTool) executeSync(ctx context.Context, params ExecParams, workdir s
 		yieldMs = time.Duration(params.YieldMs) * time.Millisecond
 	}
 
-	// 创建带超时的 context
-	execCtx, cancel := context.WithTimeout(ctx, timeout)
-	defer cancel()
+	// 启动命令 (作为尚未登记的会话，转入后台时由 ProcessTool 直接接管)
+	owner, _ := SessionFromContext(ctx)
+	session, err := newProcessSession(processSpec{
+		Command: params.Command,
+		Workdir: workdir,
+		Env:     params.Env,
+		Sandbox: sb,
+		Owner:   owner.Key,
+	})
+	if err != nil {
+		return &Result{Content: "Sandbox error: " + err.Error(), IsError: true}, nil
+	}
+	if err := session.start(); err != nil {
+		return &Result{Content: "Failed to start command: " + err.Error(), IsError: true}, nil
+	}
 
-	// 创建命令
-	cmd := exec.CommandContext(execCtx, "sh", "-c", params.Command)
-	cmd.Dir = workdir
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
 
-	// 设置环境变量
-	if len(params.Env) > 0 {
-		env := os.Environ()
-		for k, v := range params.Env {
-			env = append(env, fmt.Sprintf("%s=%s", k, v))
-		}
-		cmd.Env = env
+	// 没有 processTool 时不转后台，一直等到完成或超时
+	var yield <-chan time.Time
+	if t.processTool != nil {
+		yieldTimer := time.NewTimer(yieldMs)
+		defer yieldTimer.Stop()
+		yield = yieldTimer.C
 	}
 
-	// 使用 channel 来处理超时后转后台
-	type execResult struct {
-		output []byte
-		err    error
-	}
-	resultChan := make(chan execResult, 1)
+	// 等待完成、超时或转入后台
+	select {
+	case <-session.done:
+		return t.formatSyncResult(session, false, timeout)
 
-	go func() {
-		output, err := cmd.CombinedOutput()
-		resultChan <- execResult{output, err}
-	}()
+	case <-ctx.Done():
+		session.terminate()
+		<-session.done
+		return t.formatSyncResult(session, false, timeout)
 
-	// 等待完成或超时
-	select {
-	case result := <-resultChan:
-		return t.formatSyncResult(result.output, result.err, execCtx, timeout, cmd)
-
-	case <-time.After(yieldMs):
-		// 超时，转入后台
-		if t.processTool != nil {
-			sessionID, err := t.processTool.StartSession(params.Command, workdir, false, params.Env)
-			if err != nil {
-				return &Result{
-					Content: fmt.Sprintf("Command still running, failed to background: %v", err),
-					IsError: true,
-				}, nil
-			}
-			return &Result{
-				Content: fmt.Sprintf("Command still running after %v, backgrounded as session: %s\nUse process tool to check status.", yieldMs, sessionID),
-			}, nil
+	case <-deadline.C:
+		session.terminate()
+		<-session.done
+		return t.formatSyncResult(session, true, timeout)
+
+	case <-yield:
+		// 仍在运行：把正在运行的进程交给 ProcessTool，而不是重新执行一遍
+		sessionID := t.processTool.adopt(session)
+
+		// 显式指定的 timeout 在后台继续生效
+		if params.Timeout > 0 {
+			remaining := timeout - time.Since(session.StartedAt)
+			go func() {
+				select {
+				case <-session.done:
+				case <-time.After(remaining):
+					session.mu.Lock()
+					if session.Status == "running" {
+						session.terminate()
+						session.Status = "killed"
+					}
+					session.mu.Unlock()
+				}
+			}()
 		}
-		// 没有 processTool，等待完成
-		result := <-resultChan
-		return t.formatSyncResult(result.output, result.err, execCtx, timeout, cmd)
+
+		var result strings.Builder
+		fmt.Fprintf(&result, "Command still running after %v, backgrounded as session: %s (pid %d)\nUse process tool to check status.", yieldMs, sessionID, session.PID)
+		if output := session.snapshot(); len(output) > 0 {
+			result.WriteString("\n\nOutput so far:\n")
+			result.Write(output)
+		}
+		return &Result{Content: result.String()}, nil
 	}
 }
 
 // executeBackground 后台执行命令
-func (t *ExecTool) executeBackground(ctx context.Context, params ExecParams, workdir string) (*Result, error) {
+func (t *ExecTool) executeBackground(ctx context.Context, params ExecParams, workdir string, sb *Sandbox) (*Result, error) {
 	if t.processTool == nil {
 		return &Result{
 			Content: "Background execution not available (process tool not configured)",
@@ -192,7 +262,15 @@ func (t *ExecTool) executeBackground(ctx context.Context, params ExecParams, wor
 		}, nil
 	}
 
-	sessionID, err := t.processTool.StartSession(params.Command, workdir, params.PTY, params.Env)
+	owner, _ := SessionFromContext(ctx)
+	sessionID, err := t.processTool.startSession(processSpec{
+		Command: params.Command,
+		Workdir: workdir,
+		PTY:     params.PTY,
+		Env:     params.Env,
+		Sandbox: sb,
+		Owner:   owner.Key,
+	})
 	if err != nil {
 		return &Result{
 			Content: fmt.Sprintf("Failed to start background session: %v", err),
@@ -204,6 +282,9 @@ func (t *ExecTool) executeBackground(ctx context.Context, params ExecParams, wor
 	if params.PTY {
 		mode = "PTY"
 	}
+	if sb != nil {
+		mode = "sandboxed " + mode
+	}
 
 	return &Result{
 		Content: fmt.Sprintf("Started %s session: %s\nPID: check with process tool\nUse process(action=\"poll\", sessionId=\"%s\") to check status.\nUse process(action=\"log\", sessionId=\"%s\") to get output.", mode, sessionID, sessionID, sessionID),
@@ -211,29 +292,30 @@ func (t *ExecTool) executeBackground(ctx context.Context, params ExecParams, wor
 }
 
 // formatSyncResult 格式化同步执行结果
-func (t *ExecTool) formatSyncResult(output []byte, err error, execCtx context.Context, timeout time.Duration, cmd *exec.Cmd) (*Result, error) {
+func (t *ExecTool) formatSyncResult(session *ProcessSession, timedOut bool, timeout time.Duration) (*Result, error) {
 	var result strings.Builder
-	if len(output) > 0 {
-		result.Write(output)
-	}
+	result.Write(session.snapshot())
 
+	session.mu.Lock()
+	err := session.waitErr
+	session.mu.Unlock()
+
+	if timedOut {
+		result.WriteString("\n[Command timed out after ")
+		result.WriteString(timeout.String())
+		result.WriteString("]")
+		return &Result{Content: result.String(), IsError: true}, nil
+	}
 	if err != nil {
-		if execCtx.Err() == context.DeadlineExceeded {
-			result.WriteString("\n[Command timed out after ")
-			result.WriteString(timeout.String())
-			result.WriteString("]")
-		} else {
-			result.WriteString("\n[Exit error: ")
-			result.WriteString(err.Error())
-			result.WriteString("]")
-		}
+		result.WriteString("\n[Exit error: ")
+		result.WriteString(err.Error())
+		result.WriteString("]")
 		return &Result{Content: result.String(), IsError: true}, nil
 	}
 
 	// 添加退出状态
-	if cmd.ProcessState != nil {
-		exitCode := cmd.ProcessState.ExitCode()
-		if exitCode != 0 {
+	if state := session.cmd.ProcessState; state != nil {
+		if exitCode := state.ExitCode(); exitCode != 0 {
 			result.WriteString(fmt.Sprintf("\n[Exit code: %d]", exitCode))
 		}
 	}
@@ -140,6 +140,43 @@ func TestExecTool_EmptyCommand(t *testing.T) {
 	}
 }
 
+func TestExecTool_Allowlist(t *testing.T) {
+	tool := NewExecTool(os.TempDir())
+	tool.SetAllowlist([]string{"echo", "git status"})
+
+	tests := []struct {
+		command string
+		allowed bool
+	}{
+		{"echo hello", true},
+		{"echo", true},
+		{"git status --short", true},
+		{"git push", false},
+		{"echox", false},
+		{"echo hi; rm -rf /", false},
+		{"echo $(id)", false},
+		{"echo hi > out.txt", false},
+		{"ls", false},
+	}
+	for _, tt := range tests {
+		if got := tool.commandAllowed(tt.command); got != tt.allowed {
+			t.Errorf("commandAllowed(%q) = %v, want %v", tt.command, got, tt.allowed)
+		}
+	}
+
+	args, _ := json.Marshal(ExecParams{Command: "ls"})
+	result, _ := tool.Execute(context.Background(), args)
+	if !result.IsError || !strings.Contains(result.Content, "allowlist") {
+		t.Errorf("Expected allowlist error, got: %s", result.Content)
+	}
+
+	// 运行中清空 allowlist 后不再限制
+	tool.SetAllowlist(nil)
+	if !tool.commandAllowed("ls") {
+		t.Error("empty allowlist should allow all commands")
+	}
+}
+
 func TestExecTool_InvalidParams(t *testing.T) {
 	tool := NewExecTool(os.TempDir())
 
@@ -203,3 +240,101 @@ func TestExecTool_PTY(t *testing.T) {
 		t.Errorf("Expected PTY mode message, got: %s", result.Content)
 	}
 }
+
+func TestExecTool_YieldAdoptsRunningProcess(t *testing.T) {
+	dir := t.TempDir()
+	tool := NewExecTool(dir)
+	processTool := NewProcessTool(dir)
+	tool.SetProcessTool(processTool)
+
+	// 每次执行都会在 runs.txt 追加一行，用来确认命令只运行了一次
+	params := ExecParams{
+		Command: "echo run >> runs.txt; echo started; sleep 1; echo finished",
+		YieldMs: 300,
+	}
+	args, _ := json.Marshal(params)
+
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "backgrounded as session: session-1") {
+		t.Fatalf("Expected backgrounded session, got: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "Output so far:\nstarted") {
+		t.Errorf("Expected buffered output in result, got: %s", result.Content)
+	}
+
+	session := processTool.sessions["session-1"]
+	if session == nil {
+		t.Fatal("Session was not adopted by process tool")
+	}
+	select {
+	case <-session.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Adopted session did not finish")
+	}
+
+	logResult, _ := processTool.Execute(context.Background(), json.RawMessage(`{"action":"log","sessionId":"session-1"}`))
+	if !strings.Contains(logResult.Content, "started\nfinished") {
+		t.Errorf("Expected full output in session log, got: %q", logResult.Content)
+	}
+
+	runs, _ := os.ReadFile(dir + "/runs.txt")
+	if string(runs) != "run\n" {
+		t.Errorf("Command should run exactly once, runs.txt = %q", runs)
+	}
+}
+
+func TestExecTool_YieldKillAdoptedProcess(t *testing.T) {
+	tool := NewExecTool(os.TempDir())
+	processTool := NewProcessTool(os.TempDir())
+	tool.SetProcessTool(processTool)
+
+	args, _ := json.Marshal(ExecParams{Command: "sleep 30", YieldMs: 100})
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil || result.IsError {
+		t.Fatalf("Unexpected result: %v %v", err, result)
+	}
+
+	session := processTool.sessions["session-1"]
+	if session == nil || session.PID == 0 {
+		t.Fatal("Expected adopted session with PID")
+	}
+
+	killResult, _ := processTool.Execute(context.Background(), json.RawMessage(`{"action":"kill","sessionId":"session-1"}`))
+	if killResult.IsError {
+		t.Fatalf("Kill failed: %s", killResult.Content)
+	}
+	select {
+	case <-session.done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Killing the session should stop the original process")
+	}
+}
+
+func TestExecTool_YieldKeepsExplicitTimeout(t *testing.T) {
+	tool := NewExecTool(os.TempDir())
+	processTool := NewProcessTool(os.TempDir())
+	tool.SetProcessTool(processTool)
+
+	args, _ := json.Marshal(ExecParams{Command: "sleep 30", YieldMs: 100, Timeout: 1})
+	if _, err := tool.Execute(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+
+	session := processTool.sessions["session-1"]
+	select {
+	case <-session.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Explicit timeout should still apply after backgrounding")
+	}
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.Status != "killed" {
+		t.Errorf("Expected killed status, got %s", session.Status)
+	}
+}
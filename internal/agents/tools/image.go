@@ -15,6 +15,7 @@ import (
 // ImageTool 图片视觉分析工具
 type ImageTool struct {
 	workdir string
+	paths   *PathPolicy
 	// 视觉分析函数回调 (由 Provider 注入)
 	AnalyzeFunc func(ctx context.Context, imageData []byte, mimeType, prompt, model string) (string, error)
 	maxSizeMB   int
@@ -32,10 +33,16 @@ type ImageParams struct {
 func NewImageTool(workdir string) *ImageTool {
 	return &ImageTool{
 		workdir:   workdir,
+		paths:     DefaultPathPolicy(workdir),
 		maxSizeMB: 20, // 默认 20MB 限制
 	}
 }
 
+// SetPathPolicy 设置本地图片文件的路径访问策略
+func (t *ImageTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
 func (t *ImageTool) Name() string {
 	return ToolImage
 }
@@ -123,19 +130,14 @@ func (t *ImageTool) loadImage(ctx context.Context, source string, maxMB int) ([]
 	}
 
 	// 当作文件路径处理
-	return t.loadImageFromFile(source, maxBytes)
+	return t.loadImageFromFile(ctx, source, maxBytes)
 }
 
-func (t *ImageTool) loadImageFromFile(path string, maxBytes int64) ([]byte, string, error) {
-	// 扩展路径
-	if strings.HasPrefix(path, "~") {
-		home, _ := os.UserHomeDir()
-		path = filepath.Join(home, path[1:])
-	}
-
-	// 如果是相对路径，相对于工作目录
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(t.workdir, path)
+func (t *ImageTool) loadImageFromFile(ctx context.Context, path string, maxBytes int64) ([]byte, string, error) {
+	// 解析路径 (~ 和相对路径) 并检查访问权限
+	path, err := t.paths.Resolve(ctx, path, false)
+	if err != nil {
+		return nil, "", err
 	}
 
 	// 检查文件存在
@@ -3,6 +3,11 @@ package tools
 import (
 	"context"
 	"encoding/json"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/memory"
+	"github.com/z8n24/openclaw-go/internal/skills"
 )
 
 // Tool 是工具的抽象接口
@@ -103,6 +108,8 @@ const (
 	ToolRead         = "read"
 	ToolWrite        = "write"
 	ToolEdit         = "edit"
+	ToolApplyPatch   = "apply_patch"
+	ToolCheckpoint   = "checkpoint"
 	ToolExec         = "exec"
 	ToolProcess      = "process"
 	ToolBrowser      = "browser"
@@ -111,6 +118,8 @@ const (
 	ToolImage        = "image"
 	ToolMemorySearch = "memory_search"
 	ToolMemoryGet    = "memory_get"
+	ToolMemoryAppend = "memory_append"
+	ToolMemoryUpdate = "memory_update"
 	ToolCron         = "cron"
 	ToolMessage      = "message"
 	ToolTTS          = "tts"
@@ -130,29 +139,71 @@ type ToolsConfig struct {
 	Workdir       string
 	ConfigPath    string
 	CronScheduler interface{} // *cron.Scheduler
+	MemoryIndex   *memory.Index // 为空时 memory_search 使用关键词匹配
+	WebSearch     config.WebSearchConfig
+	WebFetch      config.WebFetchConfig
+	CacheDir      string // web_fetch 缓存目录，为空时不缓存
+	Sandbox       config.SandboxConfig // exec/process 沙箱
+	Checkpoints   *checkpoints.Store   // 文件修改前的快照，为空时不保存
+	Files         config.PathPolicyConfig // 文件工具的路径访问策略
+	Browser       config.BrowserConfig
+	StateDir      string // 浏览器 profile 等持久数据目录，为空时不持久化
+	TTS           config.TTSConfig
+	Skills        []*skills.Skill     // 已加载的技能，其工具以 <skillID>__<tool> 注册
+	SkillsConfig  config.SkillsConfig // 授予技能的权限
 }
 
 // RegisterAllTools 注册所有内置工具
 func RegisterAllTools(registry *Registry, cfg ToolsConfig) {
 	// 文件操作工具
-	registry.Register(NewReadTool(cfg.Workdir))
-	registry.Register(NewWriteTool(cfg.Workdir))
-	registry.Register(NewEditTool(cfg.Workdir))
+	paths := NewPathPolicy(cfg.Files, cfg.Workdir)
+	readTool := NewReadTool(cfg.Workdir)
+	readTool.SetPathPolicy(paths)
+	registry.Register(readTool)
+	writeTool := NewWriteTool(cfg.Workdir)
+	writeTool.SetPathPolicy(paths)
+	editTool := NewEditTool(cfg.Workdir)
+	editTool.SetPathPolicy(paths)
+	applyPatchTool := NewApplyPatchTool(cfg.Workdir)
+	applyPatchTool.SetPathPolicy(paths)
+	if cfg.Checkpoints != nil {
+		writeTool.SetCheckpoints(cfg.Checkpoints)
+		editTool.SetCheckpoints(cfg.Checkpoints)
+		applyPatchTool.SetCheckpoints(cfg.Checkpoints)
+		registry.Register(NewCheckpointTool(cfg.Checkpoints))
+	}
+	registry.Register(writeTool)
+	registry.Register(editTool)
+	registry.Register(applyPatchTool)
 	
 	// 命令执行
-	registry.Register(NewExecTool(cfg.Workdir))
-	registry.Register(NewProcessTool(cfg.Workdir))
+	execTool := NewExecTool(cfg.Workdir)
+	processTool := NewProcessTool(cfg.Workdir)
+	execTool.SetProcessTool(processTool)
+	if cfg.Sandbox.Mode != "" && cfg.Sandbox.Mode != SandboxModeOff {
+		execTool.SetSandbox(NewSandbox(cfg.Sandbox, cfg.Workdir))
+	}
+	registry.Register(execTool)
+	registry.Register(processTool)
 	
 	// Web 工具
-	registry.Register(NewWebSearchTool())
-	registry.Register(NewWebFetchTool())
+	registry.Register(NewWebSearchToolFromConfig(cfg.WebSearch))
+	registry.Register(NewWebFetchToolFromConfig(cfg.WebFetch, cfg.CacheDir))
 	
 	// 浏览器
-	registry.Register(NewBrowserTool())
+	browserTool := NewBrowserToolFromConfig(cfg.Browser, cfg.StateDir, cfg.Workdir)
+	browserTool.SetPathPolicy(paths)
+	registry.Register(browserTool)
 	
 	// 记忆
-	registry.Register(NewMemorySearchTool(cfg.Workdir))
+	memorySearch := NewMemorySearchTool(cfg.Workdir)
+	if cfg.MemoryIndex != nil {
+		memorySearch.SetIndex(cfg.MemoryIndex)
+	}
+	registry.Register(memorySearch)
 	registry.Register(NewMemoryGetTool(cfg.Workdir))
+	registry.Register(NewMemoryAppendTool(cfg.Workdir))
+	registry.Register(NewMemoryUpdateTool(cfg.Workdir))
 	
 	// 定时任务 (需要 scheduler，由调用者单独注册)
 	// 使用: registry.Register(NewCronTool(scheduler))
@@ -161,10 +212,12 @@ func RegisterAllTools(registry *Registry, cfg ToolsConfig) {
 	registry.Register(NewMessageTool())
 	
 	// 图像
-	registry.Register(NewImageTool(cfg.Workdir))
+	imageTool := NewImageTool(cfg.Workdir)
+	imageTool.SetPathPolicy(paths)
+	registry.Register(imageTool)
 	
 	// TTS
-	registry.Register(NewTTSTool(cfg.Workdir))
+	registry.Register(NewTTSToolFromConfig(cfg.TTS, cfg.StateDir, cfg.Workdir))
 	
 	// 节点
 	registry.Register(NewNodesTool())
@@ -180,6 +233,11 @@ func RegisterAllTools(registry *Registry, cfg ToolsConfig) {
 	
 	// Agents list
 	registry.Register(NewAgentsListTool())
+	
+	// 技能声明的工具
+	for _, skillTool := range NewSkillTools(cfg.Skills, cfg.SkillsConfig, cfg.Workdir) {
+		registry.Register(skillTool)
+	}
 }
 
 // RegisterCoreTools 只注册核心工具 (无外部依赖)
@@ -188,6 +246,7 @@ func RegisterCoreTools(registry *Registry, workdir string) {
 	registry.Register(NewReadTool(workdir))
 	registry.Register(NewWriteTool(workdir))
 	registry.Register(NewEditTool(workdir))
+	registry.Register(NewApplyPatchTool(workdir))
 	
 	// 命令执行
 	registry.Register(NewExecTool(workdir))
@@ -203,6 +262,8 @@ func RegisterCoreTools(registry *Registry, workdir string) {
 	// 记忆
 	registry.Register(NewMemorySearchTool(workdir))
 	registry.Register(NewMemoryGetTool(workdir))
+	registry.Register(NewMemoryAppendTool(workdir))
+	registry.Register(NewMemoryUpdateTool(workdir))
 	
 	// 图像
 	registry.Register(NewImageTool(workdir))
@@ -9,11 +9,14 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/memory"
 )
 
-// MemorySearchTool 语义搜索工具 (简化版本，使用关键词匹配)
+// MemorySearchTool 记忆搜索工具 (配置索引时使用 BM25 + 向量混合检索，否则回退到关键词匹配)
 type MemorySearchTool struct {
 	workspace string
+	index     *memory.Index
 }
 
 type MemorySearchParams struct {
@@ -33,12 +36,17 @@ func NewMemorySearchTool(workspace string) *MemorySearchTool {
 	return &MemorySearchTool{workspace: workspace}
 }
 
+// SetIndex 设置语义索引
+func (t *MemorySearchTool) SetIndex(index *memory.Index) {
+	t.index = index
+}
+
 func (t *MemorySearchTool) Name() string {
 	return "memory_search"
 }
 
 func (t *MemorySearchTool) Description() string {
-	return "Search MEMORY.md and memory/*.md files for relevant content. Use before answering questions about prior work, decisions, or preferences."
+	return "Semantically search MEMORY.md and memory/*.md files (and session transcripts if enabled) for relevant content. Use before answering questions about prior work, decisions, or preferences. Results include citations (path#Lstart-Lend) usable with memory_get."
 }
 
 func (t *MemorySearchTool) Parameters() json.RawMessage {
@@ -68,6 +76,10 @@ func (t *MemorySearchTool) Execute(ctx context.Context, args json.RawMessage) (*
 		maxResults = 10
 	}
 
+	if t.index != nil {
+		return t.searchIndex(ctx, params, maxResults)
+	}
+
 	minScore := params.MinScore
 	if minScore <= 0 {
 		minScore = 0.1
@@ -133,6 +145,42 @@ func (t *MemorySearchTool) Execute(ctx context.Context, args json.RawMessage) (*
 	return &Result{Content: sb.String()}, nil
 }
 
+// searchIndex 使用语义索引检索
+func (t *MemorySearchTool) searchIndex(ctx context.Context, params MemorySearchParams, maxResults int) (*Result, error) {
+	minScore := params.MinScore
+	if minScore <= 0 {
+		minScore = 0.05
+	}
+
+	caller, _ := SessionFromContext(ctx)
+	results, err := t.index.Search(ctx, params.Query, memory.SearchOptions{
+		MaxResults: maxResults,
+		MinScore:   minScore,
+		Filter:     func(path string) bool { return transcriptVisible(caller, path) },
+	})
+	if err != nil {
+		return &Result{Content: "Memory search failed: " + err.Error(), IsError: true}, nil
+	}
+
+	if len(results) == 0 {
+		return &Result{Content: "No matching results found"}, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Found %d results:\n\n", len(results)))
+
+	for i, r := range results {
+		sb.WriteString(fmt.Sprintf("### Result %d (score: %.2f)\n", i+1, r.Score))
+		sb.WriteString(fmt.Sprintf("Source: %s\n", r.Citation))
+		if r.Heading != "" {
+			sb.WriteString(fmt.Sprintf("Section: %s\n", r.Heading))
+		}
+		sb.WriteString(fmt.Sprintf("```\n%s\n```\n\n", r.Content))
+	}
+
+	return &Result{Content: sb.String()}, nil
+}
+
 // extractKeywords 提取搜索关键词
 func extractKeywords(query string) []string {
 	// 简单分词
@@ -222,7 +270,8 @@ func searchFile(path string, keywords []string, workspace string) []MemorySearch
 
 // MemoryGetTool 读取记忆文件片段
 type MemoryGetTool struct {
-	workspace string
+	workspace   string
+	sessionsDir string // 会话记录目录，为空时不能读取 sessions/ 引用
 }
 
 type MemoryGetParams struct {
@@ -235,19 +284,24 @@ func NewMemoryGetTool(workspace string) *MemoryGetTool {
 	return &MemoryGetTool{workspace: workspace}
 }
 
+// SetSessionsDir 设置会话记录目录，memory_search 返回的 sessions/<key>.json 引用从这里读取
+func (t *MemoryGetTool) SetSessionsDir(dir string) {
+	t.sessionsDir = dir
+}
+
 func (t *MemoryGetTool) Name() string {
 	return "memory_get"
 }
 
 func (t *MemoryGetTool) Description() string {
-	return "Read a snippet from MEMORY.md or memory/*.md files. Use after memory_search to get specific content."
+	return "Read a snippet from MEMORY.md, memory/*.md files or session transcripts (sessions/*.json, one line per message). Use after memory_search to get specific content."
 }
 
 func (t *MemoryGetTool) Parameters() json.RawMessage {
 	return json.RawMessage(`{
 		"type": "object",
 		"properties": {
-			"path": {"type": "string", "description": "Path to the memory file (relative to workspace)"},
+			"path": {"type": "string", "description": "Path to the memory file (relative to workspace), or a citation like memory/2025-01-15.md#L3-L7"},
 			"from": {"type": "number", "description": "Starting line number (1-indexed)"},
 			"lines": {"type": "number", "description": "Number of lines to read (default 50)"}
 		},
@@ -265,13 +319,45 @@ func (t *MemoryGetTool) Execute(ctx context.Context, args json.RawMessage) (*Res
 		return &Result{Content: "Path is required", IsError: true}, nil
 	}
 
+	// 支持 memory_search 返回的引用格式: path#L3-L7
+	if i := strings.Index(params.Path, "#L"); i >= 0 {
+		var start, end int
+		n, _ := fmt.Sscanf(params.Path[i:], "#L%d-L%d", &start, &end)
+		if n >= 1 && params.From == 0 {
+			params.From = start
+			if n == 2 && end >= start && params.Lines == 0 {
+				params.Lines = end - start + 1
+			}
+		}
+		params.Path = params.Path[:i]
+	}
+
+	from := params.From
+	if from < 1 {
+		from = 1
+	}
+
+	lines := params.Lines
+	if lines <= 0 {
+		lines = 50
+	}
+
+	// 会话记录按消息读取，行号为消息序号
+	if strings.HasPrefix(params.Path, "sessions/") {
+		caller, _ := SessionFromContext(ctx)
+		if !transcriptVisible(caller, params.Path) {
+			return &Result{Content: "Transcripts of other sessions can only be read from the main session", IsError: true}, nil
+		}
+		return t.readTranscript(strings.TrimPrefix(params.Path, "sessions/"), from, lines), nil
+	}
+
 	// 安全检查：只允许读取 MEMORY.md 和 memory/ 下的文件
 	allowed := false
 	if params.Path == "MEMORY.md" || strings.HasPrefix(params.Path, "memory/") {
 		allowed = true
 	}
 	if !allowed {
-		return &Result{Content: "Can only read MEMORY.md or memory/*.md files", IsError: true}, nil
+		return &Result{Content: "Can only read MEMORY.md, memory/*.md or sessions/*.json files", IsError: true}, nil
 	}
 
 	fullPath := filepath.Join(t.workspace, params.Path)
@@ -282,16 +368,6 @@ func (t *MemoryGetTool) Execute(ctx context.Context, args json.RawMessage) (*Res
 	}
 	defer file.Close()
 
-	from := params.From
-	if from < 1 {
-		from = 1
-	}
-
-	lines := params.Lines
-	if lines <= 0 {
-		lines = 50
-	}
-
 	scanner := bufio.NewScanner(file)
 	var content []string
 	lineNum := 0
@@ -312,3 +388,41 @@ func (t *MemoryGetTool) Execute(ctx context.Context, args json.RawMessage) (*Res
 
 	return &Result{Content: strings.Join(content, "\n")}, nil
 }
+
+// transcriptVisible 会话记录 sessions/<key>.json 只对该会话和主会话可见，其他记忆文件不受限制
+func transcriptVisible(caller SessionContext, path string) bool {
+	if !strings.HasPrefix(path, "sessions/") || caller.Kind == "" || caller.Kind == "main" {
+		return true
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(path, "sessions/"), ".json") == caller.Key
+}
+
+// readTranscript 读取会话记录中第 from 条起的 lines 条消息
+func (t *MemoryGetTool) readTranscript(name string, from, lines int) *Result {
+	if t.sessionsDir == "" {
+		return &Result{Content: "Session transcripts are not available", IsError: true}
+	}
+	if name == "" || name != filepath.Base(name) || !strings.HasSuffix(name, ".json") {
+		return &Result{Content: "Invalid session transcript path: sessions/" + name, IsError: true}
+	}
+
+	data, err := os.ReadFile(filepath.Join(t.sessionsDir, name))
+	if err != nil {
+		return &Result{Content: "Failed to open file: " + err.Error(), IsError: true}
+	}
+	messages, err := memory.TranscriptLines(data)
+	if err != nil {
+		return &Result{Content: "Invalid session transcript: " + err.Error(), IsError: true}
+	}
+
+	var content []string
+	for i := from - 1; i < len(messages) && i < from-1+lines; i++ {
+		if messages[i] != "" {
+			content = append(content, messages[i])
+		}
+	}
+	if len(content) == 0 {
+		return &Result{Content: "No content found at specified location"}
+	}
+	return &Result{Content: strings.Join(content, "\n")}
+}
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/memory"
 )
 
 func TestMemorySearchTool_BasicSearch(t *testing.T) {
@@ -236,3 +238,160 @@ func TestMemoryGetTool_SecurityPathTraversal(t *testing.T) {
 		t.Error("Should block path traversal attacks")
 	}
 }
+
+func TestMemorySearchTool_WithIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "memory"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "memory", "2025-02-01.md"),
+		[]byte("## Travel\nBooked flights to Lisbon for the conference in March.\n"), 0644)
+
+	tool := NewMemorySearchTool(tmpDir)
+	tool.SetIndex(memory.NewIndex(memory.IndexConfig{Workspace: tmpDir}))
+
+	args, _ := json.Marshal(MemorySearchParams{Query: "conference flights"})
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatalf("Execute returned error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Unexpected error: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "Source: memory/2025-02-01.md#L1-L2") {
+		t.Errorf("Expected citation in result: %s", result.Content)
+	}
+}
+
+func TestMemoryGetTool_Citation(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "memory"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "memory", "notes.md"), []byte("one\ntwo\nthree\nfour\n"), 0644)
+
+	tool := NewMemoryGetTool(tmpDir)
+	args, _ := json.Marshal(MemoryGetParams{Path: "memory/notes.md#L2-L3"})
+	result, _ := tool.Execute(context.Background(), args)
+	if result.IsError || result.Content != "two\nthree" {
+		t.Errorf("Expected lines 2-3, got: %q", result.Content)
+	}
+}
+
+func TestMemoryGetTool_SessionTranscript(t *testing.T) {
+	workspace := t.TempDir()
+	sessionsDir := t.TempDir()
+	os.WriteFile(filepath.Join(sessionsDir, "main.json"), []byte(`{"session":{"key":"main"},"messages":[
+		{"role":"user","content":"what is the wifi password?"},
+		{"role":"assistant","content":[{"type":"text","text":"It is hunter2."}]},
+		{"role":"user","content":"thanks"}
+	]}`), 0644)
+
+	tool := NewMemoryGetTool(workspace)
+	args, _ := json.Marshal(MemoryGetParams{Path: "sessions/main.json#L2-L2"})
+	if result, _ := tool.Execute(context.Background(), args); !result.IsError {
+		t.Errorf("transcripts should not be readable without a sessions dir: %s", result.Content)
+	}
+
+	tool.SetSessionsDir(sessionsDir)
+	result, _ := tool.Execute(context.Background(), args)
+	if result.IsError || result.Content != "assistant: It is hunter2." {
+		t.Errorf("Expected message 2, got: %q", result.Content)
+	}
+
+	args, _ = json.Marshal(MemoryGetParams{Path: "sessions/../main.json"})
+	if result, _ := tool.Execute(context.Background(), args); !result.IsError {
+		t.Errorf("path traversal should be rejected: %s", result.Content)
+	}
+}
+
+func TestMemoryTools_TranscriptScope(t *testing.T) {
+	workspace := t.TempDir()
+	sessionsDir := t.TempDir()
+	os.WriteFile(filepath.Join(sessionsDir, "main.json"), []byte(`{"session":{"key":"main"},"messages":[
+		{"role":"user","content":"the wifi password is hunter2"}
+	]}`), 0644)
+	os.WriteFile(filepath.Join(sessionsDir, "telegram:-100.json"), []byte(`{"session":{"key":"telegram:-100"},"messages":[
+		{"role":"user","content":"who knows the wifi password?"}
+	]}`), 0644)
+
+	search := NewMemorySearchTool(workspace)
+	search.SetIndex(memory.NewIndex(memory.IndexConfig{Workspace: workspace, IncludeSessions: true, SessionsDir: sessionsDir}))
+	get := NewMemoryGetTool(workspace)
+	get.SetSessionsDir(sessionsDir)
+	execute := func(ctx context.Context, tool Tool, params interface{}) *Result {
+		args, _ := json.Marshal(params)
+		result, _ := tool.Execute(ctx, args)
+		return result
+	}
+
+	// 群聊会话只能看到自己的会话记录
+	group := WithSession(context.Background(), SessionContext{Key: "telegram:-100", Kind: "group"})
+	result := execute(group, search, MemorySearchParams{Query: "wifi password"})
+	if strings.Contains(result.Content, "sessions/main.json") || !strings.Contains(result.Content, "sessions/telegram:-100.json") {
+		t.Errorf("Group search should only return its own transcript: %s", result.Content)
+	}
+	if result := execute(group, get, MemoryGetParams{Path: "sessions/main.json#L1-L1"}); !result.IsError {
+		t.Errorf("Group session should not read the main transcript: %s", result.Content)
+	}
+	if result := execute(group, get, MemoryGetParams{Path: "sessions/telegram:-100.json#L1-L1"}); result.IsError {
+		t.Errorf("Group session should read its own transcript: %s", result.Content)
+	}
+
+	// 主会话可以看到所有会话记录
+	main := WithSession(context.Background(), SessionContext{Key: "main", Kind: "main"})
+	result = execute(main, search, MemorySearchParams{Query: "wifi password"})
+	if !strings.Contains(result.Content, "sessions/main.json") || !strings.Contains(result.Content, "sessions/telegram:-100.json") {
+		t.Errorf("Main search should return all transcripts: %s", result.Content)
+	}
+	if result := execute(main, get, MemoryGetParams{Path: "sessions/telegram:-100.json#L1-L1"}); result.IsError {
+		t.Errorf("Main session should read other transcripts: %s", result.Content)
+	}
+}
+
+func TestMemoryAppendTool(t *testing.T) {
+	tmpDir := t.TempDir()
+	tool := NewMemoryAppendTool(tmpDir)
+
+	args, _ := json.Marshal(MemoryAppendParams{Content: "Deployed v2\nRotated keys", Date: "2025-01-15"})
+	result, _ := tool.Execute(context.Background(), args)
+	if result.IsError || !strings.Contains(result.Content, "added 2") {
+		t.Fatalf("unexpected result: %s", result.Content)
+	}
+
+	// 重复内容被跳过
+	args, _ = json.Marshal(MemoryAppendParams{Content: "deployed v2", Date: "2025-01-15"})
+	result, _ = tool.Execute(context.Background(), args)
+	if !strings.Contains(result.Content, "No changes") || !strings.Contains(result.Content, "1 duplicate") {
+		t.Errorf("expected duplicate to be skipped: %s", result.Content)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "memory", "2025-01-15.md"))
+	if strings.Count(string(data), "Deployed v2") != 1 {
+		t.Errorf("unexpected journal: %s", data)
+	}
+}
+
+func TestMemoryUpdateTool(t *testing.T) {
+	tmpDir := t.TempDir()
+	tool := NewMemoryUpdateTool(tmpDir)
+
+	args, _ := json.Marshal(MemoryUpdateParams{Section: "Preferences", Content: "Prefers dark mode"})
+	result, _ := tool.Execute(context.Background(), args)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content)
+	}
+
+	args, _ = json.Marshal(MemoryUpdateParams{Section: "Preferences", Content: "Prefers light mode", Mode: "replace"})
+	result, _ = tool.Execute(context.Background(), args)
+	if !strings.Contains(result.Content, "MEMORY.md ## Preferences: added 1, removed 1") {
+		t.Errorf("unexpected result: %s", result.Content)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(tmpDir, "MEMORY.md"))
+	if strings.Contains(string(data), "dark mode") || !strings.Contains(string(data), "- Prefers light mode") {
+		t.Errorf("unexpected MEMORY.md: %s", data)
+	}
+
+	args, _ = json.Marshal(MemoryUpdateParams{Section: "Preferences", Mode: "bogus", Content: "x"})
+	result, _ = tool.Execute(context.Background(), args)
+	if !result.IsError {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/memory"
+)
+
+// MemoryAppendTool 向每日日志 memory/YYYY-MM-DD.md 追加条目
+type MemoryAppendTool struct {
+	store *memory.Store
+}
+
+type MemoryAppendParams struct {
+	Content string `json:"content"`
+	Date    string `json:"date,omitempty"`
+	Section string `json:"section,omitempty"`
+}
+
+func NewMemoryAppendTool(workspace string) *MemoryAppendTool {
+	return &MemoryAppendTool{store: memory.NewStore(config.NewPaths(workspace))}
+}
+
+func (t *MemoryAppendTool) Name() string {
+	return "memory_append"
+}
+
+func (t *MemoryAppendTool) Description() string {
+	return "Append notes to today's daily memory journal (memory/YYYY-MM-DD.md). Each line becomes a list entry; entries already in the file are skipped. Use for events, context and things worth remembering from this session."
+}
+
+func (t *MemoryAppendTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"content": {"type": "string", "description": "Notes to append, one entry per line"},
+			"date": {"type": "string", "description": "Journal date YYYY-MM-DD (default today)"},
+			"section": {"type": "string", "description": "Optional heading to group entries under (e.g. 'Project X')"}
+		},
+		"required": ["content"]
+	}`)
+}
+
+func (t *MemoryAppendTool) Execute(ctx context.Context, args json.RawMessage) (*Result, error) {
+	var params MemoryAppendParams
+	if err := json.Unmarshal(args, &params); err != nil {
+		return &Result{Content: "Invalid parameters: " + err.Error(), IsError: true}, nil
+	}
+
+	if strings.TrimSpace(params.Content) == "" {
+		return &Result{Content: "Content is required", IsError: true}, nil
+	}
+
+	result, err := t.store.AppendDaily(params.Date, params.Section, []string{params.Content})
+	if err != nil {
+		return &Result{Content: "Failed to write memory: " + err.Error(), IsError: true}, nil
+	}
+
+	return &Result{Content: formatMemoryWrite(t.store.Paths().Root, result)}, nil
+}
+
+// MemoryUpdateTool 更新 MEMORY.md 中的长期记忆分节
+type MemoryUpdateTool struct {
+	store *memory.Store
+}
+
+type MemoryUpdateParams struct {
+	Section string `json:"section"`
+	Content string `json:"content,omitempty"`
+	Mode    string `json:"mode,omitempty"`
+}
+
+func NewMemoryUpdateTool(workspace string) *MemoryUpdateTool {
+	return &MemoryUpdateTool{store: memory.NewStore(config.NewPaths(workspace))}
+}
+
+func (t *MemoryUpdateTool) Name() string {
+	return "memory_update"
+}
+
+func (t *MemoryUpdateTool) Description() string {
+	return "Update a section of long-term memory (MEMORY.md), e.g. 'Preferences' or 'People'. Modes: append (default, skips duplicates), replace (rewrite the section), remove (delete matching entries, or the whole section if content is empty). Missing sections are created."
+}
+
+func (t *MemoryUpdateTool) Parameters() json.RawMessage {
+	return json.RawMessage(`{
+		"type": "object",
+		"properties": {
+			"section": {"type": "string", "description": "Section heading in MEMORY.md (without ##)"},
+			"content": {"type": "string", "description": "Entries, one per line"},
+			"mode": {"type": "string", "enum": ["append", "replace", "remove"], "description": "How to apply the content (default append)"}
+		},
+		"required": ["section"]
+	}`)
+}
+
+func (t *MemoryUpdateTool) Execute(ctx context.Context, args json.RawMessage) (*Result, error) {
+	var params MemoryUpdateParams
+	if err := json.Unmarshal(args, &params); err != nil {
+		return &Result{Content: "Invalid parameters: " + err.Error(), IsError: true}, nil
+	}
+
+	if strings.TrimSpace(params.Section) == "" {
+		return &Result{Content: "Section is required", IsError: true}, nil
+	}
+	mode := memory.SectionMode(params.Mode)
+	if strings.TrimSpace(params.Content) == "" && mode != memory.SectionRemove {
+		return &Result{Content: "Content is required", IsError: true}, nil
+	}
+
+	var entries []string
+	if params.Content != "" {
+		entries = []string{params.Content}
+	}
+	result, err := t.store.UpdateSection(params.Section, entries, mode)
+	if err != nil {
+		return &Result{Content: "Failed to update memory: " + err.Error(), IsError: true}, nil
+	}
+
+	return &Result{Content: formatMemoryWrite(t.store.Paths().Root, result)}, nil
+}
+
+// formatMemoryWrite 生成写入结果摘要
+func formatMemoryWrite(root string, r *memory.WriteResult) string {
+	target, err := filepath.Rel(root, r.Path)
+	if err != nil {
+		target = r.Path
+	}
+	if r.Section != "" {
+		target += " ## " + r.Section
+	}
+
+	var sb strings.Builder
+	switch {
+	case len(r.Added) == 0 && len(r.Removed) == 0:
+		sb.WriteString(fmt.Sprintf("No changes to %s", target))
+	default:
+		var parts []string
+		if len(r.Added) > 0 {
+			parts = append(parts, fmt.Sprintf("added %d", len(r.Added)))
+		}
+		if len(r.Removed) > 0 {
+			parts = append(parts, fmt.Sprintf("removed %d", len(r.Removed)))
+		}
+		sb.WriteString(fmt.Sprintf("Updated %s: %s", target, strings.Join(parts, ", ")))
+	}
+	if len(r.Skipped) > 0 {
+		sb.WriteString(fmt.Sprintf(" (%d duplicate entries skipped)", len(r.Skipped)))
+	}
+	return sb.String()
+}
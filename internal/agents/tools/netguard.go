@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// netGuard 阻止访问内网、回环和链路本地地址 (SSRF 防护)。
+// 检查发生在 DNS 解析之后的实际连接阶段，重定向和 DNS rebinding 也会被拦截。
+type netGuard struct {
+	allowPrivate bool
+	allowHosts   map[string]bool // 允许访问的主机名 (不区分大小写)
+	allowNets    []*net.IPNet    // 允许访问的网段
+}
+
+// 额外屏蔽的网段 (net.IP 的内置判断未覆盖的部分)
+var blockedNets = mustParseCIDRs(
+	"0.0.0.0/8",     // "本网络"
+	"100.64.0.0/10", // 运营商级 NAT
+	"192.0.0.0/24",  // IETF 协议分配
+	"198.18.0.0/15", // 基准测试
+	"64:ff9b::/96",  // NAT64 (可映射到内网 IPv4)
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// newNetGuard 创建防护规则，allow 中可以是主机名、IP 或 CIDR
+func newNetGuard(allowPrivate bool, allow []string) *netGuard {
+	g := &netGuard{allowPrivate: allowPrivate, allowHosts: make(map[string]bool)}
+	for _, entry := range allow {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if _, n, err := net.ParseCIDR(entry); err == nil {
+			g.allowNets = append(g.allowNets, n)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			g.allowNets = append(g.allowNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		g.allowHosts[strings.ToLower(entry)] = true
+	}
+	return g
+}
+
+// checkIP 检查 IP 是否允许访问
+func (g *netGuard) checkIP(ip net.IP) error {
+	if g.allowPrivate {
+		return nil
+	}
+	for _, n := range g.allowNets {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if isPrivateIP(ip) {
+		return fmt.Errorf("blocked request to private address %s (allow it in tools.web.fetch.allowHosts)", ip)
+	}
+	return nil
+}
+
+// isPrivateIP 内网、回环、链路本地、组播等非公网地址
+func isPrivateIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, n := range blockedNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost 请求前的快速检查：字面 IP 和 localhost 直接判断，其余主机名在连接时检查
+func (g *netGuard) checkHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if g.allowPrivate || g.allowHosts[host] {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("blocked request to %s (allow it in tools.web.fetch.allowHosts)", host)
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return g.checkIP(ip)
+	}
+	return nil
+}
+
+// httpClient 创建带防护的 HTTP 客户端 (不使用环境代理，否则检查的是代理地址)
+func (g *netGuard) httpClient(timeout time.Duration) *http.Client {
+	guarded := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("blocked request to unresolved address %s", address)
+			}
+			return g.checkIP(ip)
+		},
+	}
+	plain := &net.Dialer{Timeout: 10 * time.Second}
+
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			host, _, err := net.SplitHostPort(addr)
+			if err == nil && g.allowHosts[strings.ToLower(host)] {
+				return plain.DialContext(ctx, network, addr)
+			}
+			return guarded.DialContext(ctx, network, addr)
+		},
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("blocked redirect to %s", req.URL.Scheme)
+			}
+			return g.checkHost(req.URL.Hostname())
+		},
+	}
+}
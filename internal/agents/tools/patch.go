@@ -0,0 +1,397 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 补丁解析：支持两种格式
+//
+//  1. 统一 diff (git diff / diff -u)，包括 /dev/null 新增删除和 git 的 rename from/to
+//  2. 简单的 begin/end 格式：
+//
+//     *** Begin Patch
+//     *** Update File: path/to/file
+//     *** Move to: path/to/new
+//     @@ func anchor()
+//      context
+//     -old line
+//     +new line
+//     *** Add File: path/to/new
+//     +content
+//     *** Delete File: path/to/old
+//     *** End Patch
+
+// 文件操作类型
+const (
+	patchAdd    = "add"
+	patchDelete = "delete"
+	patchUpdate = "update"
+)
+
+// filePatch 单个文件的修改
+type filePatch struct {
+	op     string
+	path   string
+	moveTo string
+	hunks  []patchHunk
+}
+
+// patchHunk 一段连续的修改
+type patchHunk struct {
+	oldStart int    // 原文件中的起始行号 (1 起)，0 表示未知
+	anchor   string // begin/end 格式中 @@ 后的定位行
+	atEOF    bool   // 必须匹配到文件末尾
+	lines    []patchLine
+
+	oldNoEOL bool // 原文件末尾没有换行
+	newNoEOL bool // 新文件末尾没有换行
+}
+
+// patchLine 补丁行，op 为 ' '、'-' 或 '+'
+type patchLine struct {
+	op   byte
+	text string
+}
+
+// oldLines 修改前的行 (上下文 + 删除)
+func (h patchHunk) oldLines() []string {
+	var lines []string
+	for _, l := range h.lines {
+		if l.op != '+' {
+			lines = append(lines, l.text)
+		}
+	}
+	return lines
+}
+
+// newLines 修改后的行 (上下文 + 新增)
+func (h patchHunk) newLines() []string {
+	var lines []string
+	for _, l := range h.lines {
+		if l.op != '-' {
+			lines = append(lines, l.text)
+		}
+	}
+	return lines
+}
+
+// count 新增和删除的行数
+func (h patchHunk) count() (added, removed int) {
+	for _, l := range h.lines {
+		switch l.op {
+		case '+':
+			added++
+		case '-':
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// parsePatch 解析补丁文本
+func parsePatch(text string) ([]filePatch, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.TrimRight(text, "\n")
+	lines := strings.Split(text, "\n")
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "*** Begin Patch" {
+			return parseBeginPatch(lines)
+		}
+	}
+	return parseUnifiedDiff(lines)
+}
+
+// parseBeginPatch 解析 begin/end 格式
+func parseBeginPatch(lines []string) ([]filePatch, error) {
+	var patches []filePatch
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "*** Begin Patch" {
+		i++
+	}
+	i++
+
+	ended := false
+	for i < len(lines) && !ended {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "*** End Patch":
+			ended = true
+			i++
+		case strings.TrimSpace(line) == "":
+			i++
+		case strings.HasPrefix(line, "*** Add File: "):
+			fp := filePatch{op: patchAdd, path: strings.TrimSpace(strings.TrimPrefix(line, "*** Add File: "))}
+			hunk := patchHunk{}
+			i++
+			for i < len(lines) && !strings.HasPrefix(lines[i], "*** ") {
+				if !strings.HasPrefix(lines[i], "+") {
+					return nil, fmt.Errorf("line %d: added file lines must start with '+': %q", i+1, lines[i])
+				}
+				hunk.lines = append(hunk.lines, patchLine{op: '+', text: lines[i][1:]})
+				i++
+			}
+			fp.hunks = []patchHunk{hunk}
+			patches = append(patches, fp)
+		case strings.HasPrefix(line, "*** Delete File: "):
+			patches = append(patches, filePatch{op: patchDelete, path: strings.TrimSpace(strings.TrimPrefix(line, "*** Delete File: "))})
+			i++
+		case strings.HasPrefix(line, "*** Update File: "):
+			fp := filePatch{op: patchUpdate, path: strings.TrimSpace(strings.TrimPrefix(line, "*** Update File: "))}
+			i++
+			if i < len(lines) && strings.HasPrefix(lines[i], "*** Move to: ") {
+				fp.moveTo = strings.TrimSpace(strings.TrimPrefix(lines[i], "*** Move to: "))
+				i++
+			}
+			var hunk *patchHunk
+			for i < len(lines) {
+				line := lines[i]
+				if strings.TrimSpace(line) == "*** End of File" {
+					if hunk == nil {
+						return nil, fmt.Errorf("line %d: *** End of File outside a hunk", i+1)
+					}
+					hunk.atEOF = true
+					i++
+					continue
+				}
+				if strings.HasPrefix(line, "*** ") {
+					break
+				}
+				if strings.HasPrefix(line, "@@") {
+					fp.hunks = append(fp.hunks, patchHunk{anchor: strings.TrimSpace(strings.TrimPrefix(line, "@@"))})
+					hunk = &fp.hunks[len(fp.hunks)-1]
+					i++
+					continue
+				}
+				if hunk == nil {
+					fp.hunks = append(fp.hunks, patchHunk{})
+					hunk = &fp.hunks[len(fp.hunks)-1]
+				}
+				pl, err := parsePatchLine(line)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", i+1, err)
+				}
+				hunk.lines = append(hunk.lines, pl)
+				i++
+			}
+			if len(fp.hunks) == 0 && fp.moveTo == "" {
+				return nil, fmt.Errorf("update of %s has no hunks", fp.path)
+			}
+			patches = append(patches, fp)
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q (expected *** Add File, *** Update File, *** Delete File or *** End Patch)", i+1, line)
+		}
+	}
+	if !ended {
+		return nil, fmt.Errorf("missing *** End Patch")
+	}
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("patch contains no file changes")
+	}
+	return patches, nil
+}
+
+// parsePatchLine 解析 hunk 中的一行；空行视为空的上下文行
+func parsePatchLine(line string) (patchLine, error) {
+	if line == "" {
+		return patchLine{op: ' '}, nil
+	}
+	switch line[0] {
+	case ' ', '-', '+':
+		return patchLine{op: line[0], text: line[1:]}, nil
+	}
+	return patchLine{}, fmt.Errorf("hunk lines must start with ' ', '-' or '+': %q", line)
+}
+
+var hunkHeaderRe = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// parseUnifiedDiff 解析统一 diff
+func parseUnifiedDiff(lines []string) ([]filePatch, error) {
+	var patches []filePatch
+	var cur *filePatch
+	headerDone := false // 当前文件的 ---/+++ 已解析
+	gitPaths := false   // 路径带 a/ b/ 前缀
+
+	flush := func() {
+		if cur != nil {
+			patches = append(patches, *cur)
+		}
+		cur = nil
+		headerDone = false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			gitPaths = true
+			cur = &filePatch{op: patchUpdate}
+			if fields := strings.Fields(strings.TrimPrefix(line, "diff --git ")); len(fields) == 2 {
+				cur.path = stripDiffPrefix(fields[0], true)
+				if to := stripDiffPrefix(fields[1], true); to != cur.path {
+					cur.moveTo = to
+				}
+			}
+		case cur != nil && !headerDone && strings.HasPrefix(line, "new file mode"):
+			cur.op = patchAdd
+		case cur != nil && !headerDone && strings.HasPrefix(line, "deleted file mode"):
+			cur.op = patchDelete
+		case cur != nil && !headerDone && strings.HasPrefix(line, "rename from "):
+			cur.path = strings.TrimPrefix(line, "rename from ")
+		case cur != nil && !headerDone && strings.HasPrefix(line, "rename to "):
+			cur.moveTo = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if cur == nil || headerDone {
+				flush()
+				gitPaths = false
+				cur = &filePatch{op: patchUpdate}
+			}
+			oldPath := diffPath(line[4:])
+			newPath := diffPath(lines[i+1][4:])
+			i++
+			strip := gitPaths || (gitStylePath(oldPath, "a/") && gitStylePath(newPath, "b/"))
+			switch {
+			case oldPath == "/dev/null":
+				cur.op = patchAdd
+				cur.path = stripDiffPrefix(newPath, strip)
+				cur.moveTo = ""
+			case newPath == "/dev/null":
+				cur.op = patchDelete
+				cur.path = stripDiffPrefix(oldPath, strip)
+				cur.moveTo = ""
+			default:
+				cur.path = stripDiffPrefix(oldPath, strip)
+				cur.moveTo = ""
+				if to := stripDiffPrefix(newPath, strip); to != cur.path {
+					cur.moveTo = to
+				}
+			}
+			headerDone = true
+		case strings.HasPrefix(line, "@@"):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk without a file header", i+1)
+			}
+			headerDone = true
+			hunk := patchHunk{}
+			oldCount, newCount := -1, -1
+			if m := hunkHeaderRe.FindStringSubmatch(line); m != nil {
+				hunk.oldStart, _ = strconv.Atoi(m[1])
+				oldCount, newCount = 1, 1
+				if m[2] != "" {
+					oldCount, _ = strconv.Atoi(m[2])
+				}
+				if m[4] != "" {
+					newCount, _ = strconv.Atoi(m[4])
+				}
+				// -n,0 表示在第 n 行之后插入
+				if oldCount == 0 {
+					hunk.oldStart++
+				}
+			}
+			for i+1 < len(lines) {
+				next := lines[i+1]
+				if oldCount == 0 && newCount == 0 {
+					// 行数已读完，只接受紧随的 "\ No newline" 标记
+					if !strings.HasPrefix(next, `\`) {
+						break
+					}
+				} else if oldCount < 0 && isDiffHeader(lines, i+1) {
+					break
+				}
+				i++
+				if strings.HasPrefix(next, `\`) {
+					if len(hunk.lines) > 0 {
+						switch hunk.lines[len(hunk.lines)-1].op {
+						case '-':
+							hunk.oldNoEOL = true
+						case '+':
+							hunk.newNoEOL = true
+						default:
+							hunk.oldNoEOL, hunk.newNoEOL = true, true
+						}
+					}
+					continue
+				}
+				pl, err := parsePatchLine(next)
+				if err != nil {
+					if oldCount >= 0 {
+						return nil, fmt.Errorf("line %d: %w", i+1, err)
+					}
+					i--
+					break
+				}
+				hunk.lines = append(hunk.lines, pl)
+				if oldCount >= 0 {
+					if pl.op != '+' {
+						oldCount--
+					}
+					if pl.op != '-' {
+						newCount--
+					}
+					if oldCount < 0 || newCount < 0 {
+						return nil, fmt.Errorf("line %d: hunk is longer than its header says", i+1)
+					}
+				}
+			}
+			// 补丁末尾的空上下文行可能在复制时被去掉
+			if oldCount > 0 && oldCount == newCount && i == len(lines)-1 {
+				for ; oldCount > 0; oldCount-- {
+					hunk.lines = append(hunk.lines, patchLine{op: ' '})
+				}
+				newCount = 0
+			}
+			if oldCount > 0 || newCount > 0 {
+				return nil, fmt.Errorf("%s: hunk %q is truncated", cur.path, line)
+			}
+			cur.hunks = append(cur.hunks, hunk)
+		}
+	}
+	flush()
+
+	if len(patches) == 0 {
+		return nil, fmt.Errorf("no file changes found (expected a unified diff or *** Begin Patch)")
+	}
+	for _, fp := range patches {
+		if fp.path == "" {
+			return nil, fmt.Errorf("file header without a path")
+		}
+		if fp.op == patchUpdate && len(fp.hunks) == 0 && fp.moveTo == "" {
+			return nil, fmt.Errorf("update of %s has no hunks", fp.path)
+		}
+	}
+	return patches, nil
+}
+
+// isDiffHeader 第 i 行是否开始新的文件或 hunk
+func isDiffHeader(lines []string, i int) bool {
+	line := lines[i]
+	if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff --git ") {
+		return true
+	}
+	return strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ ")
+}
+
+// diffPath 去掉 ---/+++ 行中的时间戳
+func diffPath(s string) string {
+	if idx := strings.Index(s, "\t"); idx >= 0 {
+		s = s[:idx]
+	}
+	return strings.TrimSpace(s)
+}
+
+// gitStylePath 路径是否带 git 前缀 (或为 /dev/null)
+func gitStylePath(p, prefix string) bool {
+	return p == "/dev/null" || strings.HasPrefix(p, prefix)
+}
+
+// stripDiffPrefix 去掉 git 的 a/ b/ 前缀
+func stripDiffPrefix(p string, strip bool) string {
+	if strip && (strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/")) {
+		return p[2:]
+	}
+	return p
+}
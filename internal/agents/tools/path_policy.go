@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// 路径策略模式
+const (
+	PathModeWorkspace = "workspace" // 只允许工作区和配置的根目录 (默认)
+	PathModeOff       = "off"       // 不限制根目录，拒绝规则仍然生效
+)
+
+// 默认拒绝的文件：凭据和私钥
+var defaultDenyGlobs = []string{
+	"**/.env", "**/.env.local", "**/.env.*.local",
+	"**/*.pem", "**/*.key", "**/*.p12", "**/*.pfx",
+	"**/id_rsa", "**/id_dsa", "**/id_ecdsa", "**/id_ed25519",
+	"**/.ssh/**", "**/.gnupg/**", "**/.aws/credentials",
+	"**/.netrc", "**/.git-credentials",
+}
+
+// PathPolicy 文件工具的路径访问策略：
+// 默认只能访问工作区，可配置额外的只读/读写根目录、拒绝 glob 和按会话的覆盖；
+// 判断基于解析符号链接后的真实路径，防止通过符号链接逃出允许的目录。
+type PathPolicy struct {
+	cfg       config.PathPolicyConfig
+	workspace string
+}
+
+// NewPathPolicy 创建路径策略
+func NewPathPolicy(cfg config.PathPolicyConfig, workspace string) *PathPolicy {
+	if abs, err := filepath.Abs(workspace); err == nil {
+		workspace = abs
+	}
+	return &PathPolicy{cfg: cfg, workspace: workspace}
+}
+
+// DefaultPathPolicy 只允许访问工作区的默认策略
+func DefaultPathPolicy(workspace string) *PathPolicy {
+	return NewPathPolicy(config.PathPolicyConfig{}, workspace)
+}
+
+// pathRules 某个会话生效的规则
+type pathRules struct {
+	mode     string
+	readOnly []string
+	writable []string
+	deny     []string
+}
+
+// rules 合并全局配置和第一条匹配的会话覆盖
+func (p *PathPolicy) rules(session SessionContext) pathRules {
+	r := pathRules{
+		mode:     p.cfg.Mode,
+		readOnly: p.cfg.ReadOnlyPaths,
+		writable: append([]string{p.workspace}, p.cfg.WritablePaths...),
+		deny:     append(append([]string{}, defaultDenyGlobs...), p.cfg.Deny...),
+	}
+	for _, rule := range p.cfg.Sessions {
+		if ok, _ := path.Match(rule.Match, session.Key); !ok {
+			continue
+		}
+		if rule.Mode != "" {
+			r.mode = rule.Mode
+		}
+		r.readOnly = append(append([]string{}, r.readOnly...), rule.ReadOnlyPaths...)
+		r.writable = append(r.writable, rule.WritablePaths...)
+		r.deny = append(r.deny, rule.Deny...)
+		break
+	}
+	if r.mode == "" {
+		r.mode = PathModeWorkspace
+	}
+	return r
+}
+
+// Resolve 将工具参数中的路径解析为绝对路径并检查访问权限 (write 表示需要写入)。
+// 被拒绝时返回的错误信息可以直接作为工具结果。
+func (p *PathPolicy) Resolve(ctx context.Context, name string, write bool) (string, error) {
+	abs := p.expand(name)
+	real, err := realPath(abs)
+	if err != nil {
+		return "", fmt.Errorf("Access denied: %s: %v", name, err)
+	}
+
+	session, _ := SessionFromContext(ctx)
+	rules := p.rules(session)
+
+	for _, pattern := range rules.deny {
+		if matchPathGlob(pattern, abs) || matchPathGlob(pattern, real) {
+			return "", fmt.Errorf("Access denied: %s matches deny pattern %q", name, pattern)
+		}
+	}
+	if rules.mode == PathModeOff {
+		return abs, nil
+	}
+
+	for _, root := range rules.writable {
+		if withinDir(real, p.realRoot(root)) {
+			return abs, nil
+		}
+	}
+	for _, root := range rules.readOnly {
+		if withinDir(real, p.realRoot(root)) {
+			if write {
+				return "", fmt.Errorf("Access denied: %s is in read-only directory %s", name, root)
+			}
+			return abs, nil
+		}
+	}
+
+	// 路径字面上在允许的目录内，真实路径却在外面：符号链接逃逸
+	for _, root := range append(append([]string{}, rules.writable...), rules.readOnly...) {
+		if withinDir(abs, p.expand(root)) {
+			return "", fmt.Errorf("Access denied: %s resolves through a symlink to %s, outside the allowed directories", name, real)
+		}
+	}
+	return "", fmt.Errorf("Access denied: %s is outside the workspace (%s)", name, p.workspace)
+}
+
+// RestoreCheck 恢复检查点时按会话 session 的规则检查写入目标
+func (p *PathPolicy) RestoreCheck(session string) checkpoints.PathCheck {
+	ctx := WithSession(context.Background(), SessionContext{Key: session})
+	return func(path string) error {
+		_, err := p.Resolve(ctx, path, true)
+		return err
+	}
+}
+
+// expand 展开 ~ 并转为绝对路径 (相对路径基于工作区)
+func (p *PathPolicy) expand(name string) string {
+	if name == "~" || strings.HasPrefix(name, "~/") {
+		home, _ := os.UserHomeDir()
+		name = filepath.Join(home, name[1:])
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(p.workspace, name)
+	}
+	return filepath.Clean(name)
+}
+
+// realRoot 根目录的真实路径
+func (p *PathPolicy) realRoot(root string) string {
+	root = p.expand(root)
+	if real, err := realPath(root); err == nil {
+		return real
+	}
+	return root
+}
+
+// realPath 解析符号链接后的真实路径；文件不存在时解析最长的已存在前缀
+func realPath(name string) (string, error) {
+	existing := name
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if info, lerr := os.Lstat(existing); lerr == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("dangling symlink %s", existing)
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return name, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// matchPathGlob 匹配拒绝规则：不含 / 的模式匹配文件名，
+// 否则匹配完整路径 (** 匹配任意层目录，非 / 开头的模式可出现在任意层级)
+func matchPathGlob(pattern, name string) bool {
+	name = filepath.ToSlash(name)
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(name))
+		return ok
+	}
+	re, err := globRegexp(pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// globRegexp 将 glob 转为正则
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !strings.HasPrefix(pattern, "/") {
+		sb.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
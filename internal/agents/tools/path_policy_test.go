@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestPathPolicy_WorkspaceOnly(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	policy := DefaultPathPolicy(workspace)
+	ctx := context.Background()
+
+	if got, err := policy.Resolve(ctx, "sub/file.txt", true); err != nil || got != filepath.Join(workspace, "sub", "file.txt") {
+		t.Errorf("Resolve(relative) = %q, %v", got, err)
+	}
+	if _, err := policy.Resolve(ctx, filepath.Join(outside, "x.txt"), false); err == nil || !strings.Contains(err.Error(), "outside the workspace") {
+		t.Errorf("Expected outside-workspace error, got %v", err)
+	}
+	if _, err := policy.Resolve(ctx, "../escape.txt", false); err == nil {
+		t.Error("Expected ../ to be rejected")
+	}
+}
+
+func TestPathPolicy_SymlinkEscape(t *testing.T) {
+	workspace := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("s"), 0644)
+	if err := os.Symlink(outside, filepath.Join(workspace, "link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	policy := DefaultPathPolicy(workspace)
+
+	for _, p := range []string{"link/secret.txt", "link/new.txt"} {
+		if _, err := policy.Resolve(context.Background(), p, true); err == nil || !strings.Contains(err.Error(), "symlink") {
+			t.Errorf("%s: expected symlink escape error, got %v", p, err)
+		}
+	}
+
+	// 指向工作区外的悬空链接也不能写入
+	os.Symlink(filepath.Join(outside, "missing.txt"), filepath.Join(workspace, "dangling"))
+	if _, err := policy.Resolve(context.Background(), "dangling", true); err == nil {
+		t.Error("Expected dangling symlink to be rejected")
+	}
+}
+
+func TestPathPolicy_DenyGlobs(t *testing.T) {
+	workspace := t.TempDir()
+	policy := NewPathPolicy(config.PathPolicyConfig{Deny: []string{"secrets/**", "*.sqlite"}}, workspace)
+	ctx := context.Background()
+
+	for _, p := range []string{".env", "app/.env", "certs/server.pem", "secrets/a/b.txt", "data/db.sqlite"} {
+		if _, err := policy.Resolve(ctx, p, false); err == nil || !strings.Contains(err.Error(), "deny pattern") {
+			t.Errorf("%s: expected deny error, got %v", p, err)
+		}
+	}
+	for _, p := range []string{".env.example", "env.go", "notsecrets/a.txt"} {
+		if _, err := policy.Resolve(ctx, p, false); err != nil {
+			t.Errorf("%s: unexpected error %v", p, err)
+		}
+	}
+}
+
+func TestPathPolicy_ExtraRootsAndSessions(t *testing.T) {
+	workspace := t.TempDir()
+	docs := t.TempDir()
+	shared := t.TempDir()
+	policy := NewPathPolicy(config.PathPolicyConfig{
+		ReadOnlyPaths: []string{docs},
+		Sessions: []config.PathPolicySessionRule{
+			{Match: "telegram:*", WritablePaths: []string{shared}, Deny: []string{"*.md"}},
+			{Match: "admin", Mode: PathModeOff},
+		},
+	}, workspace)
+
+	ctx := context.Background()
+	if _, err := policy.Resolve(ctx, filepath.Join(docs, "a.txt"), false); err != nil {
+		t.Errorf("Expected read from read-only root, got %v", err)
+	}
+	if _, err := policy.Resolve(ctx, filepath.Join(docs, "a.txt"), true); err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("Expected read-only error, got %v", err)
+	}
+	if _, err := policy.Resolve(ctx, filepath.Join(shared, "a.txt"), true); err == nil {
+		t.Error("Expected shared dir to be outside for default session")
+	}
+
+	tg := WithSession(ctx, SessionContext{Key: "telegram:42"})
+	if _, err := policy.Resolve(tg, filepath.Join(shared, "a.txt"), true); err != nil {
+		t.Errorf("Expected session writable root, got %v", err)
+	}
+	if _, err := policy.Resolve(tg, "README.md", false); err == nil {
+		t.Error("Expected session deny pattern to apply")
+	}
+
+	admin := WithSession(ctx, SessionContext{Key: "admin"})
+	if _, err := policy.Resolve(admin, filepath.Join(shared, "a.txt"), true); err != nil {
+		t.Errorf("Expected mode off to allow any root, got %v", err)
+	}
+	if _, err := policy.Resolve(admin, filepath.Join(shared, "id_rsa"), false); err == nil {
+		t.Error("Expected deny patterns to apply with mode off")
+	}
+}
+
+func TestPathPolicy_FileToolsReject(t *testing.T) {
+	workspace := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "x.txt")
+	os.WriteFile(outside, []byte("old"), 0644)
+
+	calls := []struct {
+		tool Tool
+		args any
+	}{
+		{NewReadTool(workspace), ReadParams{Path: outside}},
+		{NewWriteTool(workspace), WriteParams{Path: outside, Content: "new"}},
+		{NewEditTool(workspace), EditParams{Path: outside, OldText: "old", NewText: "new"}},
+		{NewApplyPatchTool(workspace), ApplyPatchParams{Patch: "*** Begin Patch\n*** Delete File: " + outside + "\n*** End Patch"}},
+		{NewImageTool(workspace), ImageParams{Image: outside}},
+	}
+	for _, c := range calls {
+		args, _ := json.Marshal(c.args)
+		result, err := c.tool.Execute(context.Background(), args)
+		if err != nil {
+			t.Fatalf("%s: Execute returned error: %v", c.tool.Name(), err)
+		}
+		if !result.IsError || !strings.Contains(result.Content, "Access denied") {
+			t.Errorf("%s: expected access denied, got: %s", c.tool.Name(), result.Content)
+		}
+	}
+	if data, _ := os.ReadFile(outside); string(data) != "old" {
+		t.Errorf("File outside workspace was modified: %q", data)
+	}
+}
@@ -0,0 +1,275 @@
+package tools
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// 简单的 PDF 文本提取：解压内容流 (FlateDecode) 并解析 BT/ET 之间的文本操作符。
+// 覆盖常见的文本型 PDF；扫描件或使用自定义字体编码的 PDF 可能提取不到文本。
+
+var pdfStreamRe = regexp.MustCompile(`(?s)<<(.*?)>>\s*stream\r?\n`)
+
+// extractPDFText 提取 PDF 中的文本
+func extractPDFText(data []byte) string {
+	var pages []string
+
+	for _, loc := range pdfStreamRe.FindAllSubmatchIndex(data, -1) {
+		dict := string(data[loc[2]:loc[3]])
+		// 跳过图片、字体等非内容流
+		if strings.Contains(dict, "/Image") || strings.Contains(dict, "/FontFile") ||
+			strings.Contains(dict, "/Length1") || strings.Contains(dict, "/XRef") || strings.Contains(dict, "/ObjStm") {
+			continue
+		}
+
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		raw := data[start : start+end]
+
+		content := raw
+		if strings.Contains(dict, "/FlateDecode") {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			decoded, err := io.ReadAll(r)
+			r.Close()
+			if len(decoded) == 0 && err != nil {
+				continue
+			}
+			content = decoded
+		} else if strings.Contains(dict, "/Filter") {
+			// 其他编码 (DCT、LZW 等) 不支持
+			continue
+		}
+
+		if text := strings.TrimSpace(pdfContentText(content)); text != "" {
+			pages = append(pages, text)
+		}
+	}
+
+	return strings.Join(pages, "\n\n")
+}
+
+// pdfContentText 解析内容流中的文本操作符
+func pdfContentText(content []byte) string {
+	var sb strings.Builder
+	var operands []string // 最近的字符串操作数
+	inText := false
+
+	newline := func() {
+		if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "\n") {
+			sb.WriteString("\n")
+		}
+	}
+
+	i := 0
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == '(':
+			s, next := pdfLiteralString(content, i)
+			operands = append(operands, s)
+			i = next
+		case c == '<' && i+1 < len(content) && content[i+1] != '<':
+			end := bytes.IndexByte(content[i:], '>')
+			if end < 0 {
+				return sb.String()
+			}
+			operands = append(operands, pdfHexString(string(content[i+1:i+end])))
+			i += end + 1
+		case c == '[':
+			// TJ 数组：字符串之间的大间距视为空格
+			end := i + 1
+			var parts []string
+			for end < len(content) && content[end] != ']' {
+				switch content[end] {
+				case '(':
+					s, next := pdfLiteralString(content, end)
+					parts = append(parts, s)
+					end = next
+				case '<':
+					close := bytes.IndexByte(content[end:], '>')
+					if close < 0 {
+						end = len(content)
+						break
+					}
+					parts = append(parts, pdfHexString(string(content[end+1:end+close])))
+					end += close + 1
+				default:
+					numEnd := end
+					for numEnd < len(content) && (content[numEnd] == '-' || content[numEnd] == '.' || (content[numEnd] >= '0' && content[numEnd] <= '9')) {
+						numEnd++
+					}
+					if numEnd > end {
+						if n, err := strconv.ParseFloat(string(content[end:numEnd]), 64); err == nil && n < -200 {
+							parts = append(parts, " ")
+						}
+						end = numEnd
+					} else {
+						end++
+					}
+				}
+			}
+			operands = append(operands, strings.Join(parts, ""))
+			i = end + 1
+		case isPDFDelimiter(c):
+			i++
+		default:
+			end := i
+			for end < len(content) && !isPDFDelimiter(content[end]) && content[end] != '(' && content[end] != '<' && content[end] != '[' {
+				end++
+			}
+			if end == i {
+				// 字典等不处理的结构
+				i++
+				continue
+			}
+			op := string(content[i:end])
+			i = end
+
+			switch op {
+			case "BT":
+				inText = true
+			case "ET":
+				inText = false
+				newline()
+			case "Tj", "TJ":
+				if inText {
+					for _, s := range operands {
+						sb.WriteString(s)
+					}
+				}
+			case "'", "\"":
+				if inText {
+					newline()
+					for _, s := range operands {
+						sb.WriteString(s)
+					}
+				}
+			case "Td", "TD", "T*", "Tm":
+				if inText {
+					newline()
+				}
+			}
+			if op != "" && !isPDFNumber(op) && !strings.HasPrefix(op, "/") {
+				operands = operands[:0]
+			}
+		}
+	}
+	return sb.String()
+}
+
+func isPDFDelimiter(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0 || c == ']' || c == '>' || c == ')' || c == '{' || c == '}'
+}
+
+func isPDFNumber(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+// pdfLiteralString 解析 (...) 字符串，处理转义和嵌套括号
+func pdfLiteralString(content []byte, start int) (string, int) {
+	var buf []byte
+	depth := 0
+	i := start
+	for i < len(content) {
+		c := content[i]
+		switch {
+		case c == '\\' && i+1 < len(content):
+			i++
+			switch e := content[i]; e {
+			case 'n':
+				buf = append(buf, '\n')
+			case 'r':
+				buf = append(buf, '\r')
+			case 't':
+				buf = append(buf, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// 续行
+			default:
+				if e >= '0' && e <= '7' {
+					n := 0
+					j := 0
+					for j < 3 && i < len(content) && content[i] >= '0' && content[i] <= '7' {
+						n = n*8 + int(content[i]-'0')
+						i++
+						j++
+					}
+					buf = append(buf, byte(n))
+					continue
+				}
+				buf = append(buf, e)
+			}
+		case c == '(':
+			depth++
+			if depth > 1 {
+				buf = append(buf, c)
+			}
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return pdfDecodeBytes(buf), i + 1
+			}
+			buf = append(buf, c)
+		default:
+			buf = append(buf, c)
+		}
+		i++
+	}
+	return pdfDecodeBytes(buf), i
+}
+
+// pdfHexString 解析 <...> 十六进制字符串
+func pdfHexString(hex string) string {
+	hex = strings.Join(strings.Fields(hex), "")
+	if len(hex)%2 == 1 {
+		hex += "0"
+	}
+	buf := make([]byte, 0, len(hex)/2)
+	for i := 0; i+1 < len(hex); i += 2 {
+		b, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return ""
+		}
+		buf = append(buf, byte(b))
+	}
+	return pdfDecodeBytes(buf)
+}
+
+// pdfDecodeBytes UTF-16BE (带 BOM 或双字节编码) 转为 UTF-8，否则按 Latin-1 处理
+func pdfDecodeBytes(b []byte) string {
+	if len(b) >= 2 && b[0] == 0xFE && b[1] == 0xFF {
+		return decodeUTF16BE(b[2:])
+	}
+	if len(b) >= 2 && len(b)%2 == 0 && b[0] == 0 {
+		return decodeUTF16BE(b)
+	}
+	if utf8.Valid(b) {
+		return string(b)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func decodeUTF16BE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
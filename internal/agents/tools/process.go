@@ -7,6 +7,8 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -19,24 +21,84 @@ type ProcessTool struct {
 	sessions map[string]*ProcessSession
 	mu       sync.RWMutex
 	counter  int
+	store    *processStore      // 为空时输出只保存在内存中
+	onExit   ProcessExitHandler // 后台会话结束时的回调
+	sandbox  *Sandbox           // 判断调用者是否处于沙箱中，与 exec 使用同一个
+	saveMu   sync.Mutex         // 串行化索引快照和写入，避免旧快照覆盖新快照
 }
 
 // ProcessSession 表示一个后台进程会话
 type ProcessSession struct {
 	ID        string    `json:"sessionId"`
 	Command   string    `json:"command"`
+	Workdir   string    `json:"workdir,omitempty"`
+	Owner     string    `json:"owner,omitempty"` // 启动该进程的 agent 会话
 	PID       int       `json:"pid"`
 	PTY       bool      `json:"pty"`
 	StartedAt time.Time `json:"startedAt"`
-	Status    string    `json:"status"` // running, exited, killed
+	EndedAt   time.Time `json:"endedAt,omitempty"`
+	Status    string    `json:"status"` // running, exited, killed, orphaned
+	ExitCode  *int      `json:"exitCode,omitempty"`
+	Sandboxed bool      `json:"sandboxed,omitempty"`
+
+	cmd     *exec.Cmd
+	ptmx    *os.File
+	output  []byte      // 最近的输出 (内存中最多 maxSessionOutput)
+	written int64       // 输出总字节数
+	log     *sessionLog // 持久化日志，登记到 ProcessTool 后才创建
+	tool    *ProcessTool
+	waitErr error  // cmd.Wait 的返回值
+	cleanup func() // 进程结束后释放沙箱资源
+	mu      sync.Mutex
+	done    chan struct{}
+}
+
+// processSpec 启动会话的参数
+type processSpec struct {
+	Command string
+	Workdir string
+	PTY     bool
+	Env     map[string]string
+	Sandbox *Sandbox
+	Owner   string
+}
 
-	cmd    *exec.Cmd
-	ptmx   *os.File
-	output []byte
-	mu     sync.Mutex
-	done   chan struct{}
+// ProcessEvent 后台会话结束事件
+type ProcessEvent struct {
+	SessionID string
+	Owner     string
+	Command   string
+	Status    string
+	ExitCode  *int
+	Tail      string // 最后几行输出
 }
 
+// ProcessExitHandler 后台会话结束时调用 (在独立的 goroutine 中)
+type ProcessExitHandler func(event ProcessEvent)
+
+// Message 用于注入 agent 会话的系统事件文本
+func (e ProcessEvent) Message() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Background process %s (%s) ", e.SessionID, truncateString(e.Command, 80))
+	switch {
+	case e.Status == "killed":
+		sb.WriteString("was killed")
+	case e.ExitCode != nil:
+		fmt.Fprintf(&sb, "exited with code %d", *e.ExitCode)
+	default:
+		sb.WriteString(e.Status)
+	}
+	sb.WriteString(".")
+	if e.Tail != "" {
+		sb.WriteString(" Last output:\n")
+		sb.WriteString(e.Tail)
+	}
+	return sb.String()
+}
+
+// maxSessionOutput 每个会话保留的最大输出字节数
+const maxSessionOutput = 1024 * 1024
+
 // ProcessParams process 工具参数
 type ProcessParams struct {
 	Action    string `json:"action"` // list, poll, log, write, send-keys, kill
@@ -117,164 +179,409 @@ func (t *ProcessTool) Execute(ctx context.Context, args json.RawMessage) (*Resul
 
 	switch params.Action {
 	case "list":
-		return t.list()
+		return t.list(ctx)
 	case "poll":
-		return t.poll(params.SessionID)
+		return t.poll(ctx, params.SessionID)
 	case "log":
-		return t.log(params.SessionID, params.Offset, params.Limit)
+		return t.log(ctx, params.SessionID, params.Offset, params.Limit)
 	case "write":
-		return t.write(params.SessionID, params.Data, params.EOF)
+		return t.write(ctx, params.SessionID, params.Data, params.EOF)
 	case "send-keys":
-		return t.sendKeys(params.SessionID, params.Literal, params.Keys)
+		return t.sendKeys(ctx, params.SessionID, params.Literal, params.Keys)
 	case "kill":
-		return t.kill(params.SessionID)
+		return t.kill(ctx, params.SessionID)
 	default:
 		return &Result{Content: "Unknown action: " + params.Action, IsError: true}, nil
 	}
 }
 
+// SetSandbox 设置 exec 使用的沙箱，用于判断调用者能否操作未沙箱化的进程
+func (t *ProcessTool) SetSandbox(sb *Sandbox) {
+	t.sandbox = sb
+}
+
+// canAccess 会话只能操作自己启动的进程；主会话 (以及没有会话信息的直接调用) 可以操作所有进程。
+// 处于沙箱中的调用者不能操作未沙箱化的进程，否则可以借助其 PTY 逃出沙箱
+func (t *ProcessTool) canAccess(ctx context.Context, session *ProcessSession) bool {
+	caller, ok := SessionFromContext(ctx)
+	if !ok {
+		return true
+	}
+	if t.sandbox.Applies(caller) && !session.Sandboxed {
+		return false
+	}
+	if caller.Key != "" && caller.Key == session.Owner {
+		return true
+	}
+	return caller.Kind == "" || caller.Kind == "main"
+}
+
+// lookup 返回调用者可以访问的会话；无权访问的会话同样视为不存在
+func (t *ProcessTool) lookup(ctx context.Context, sessionID string) (*ProcessSession, bool) {
+	t.mu.RLock()
+	session, ok := t.sessions[sessionID]
+	t.mu.RUnlock()
+	if !ok || !t.canAccess(ctx, session) {
+		return nil, false
+	}
+	return session, true
+}
+
+// SetStateDir 启用持久化：输出写入 dir 下的日志文件，会话索引在重启后恢复。
+// 重启前仍在运行的会话无法重新接管，标记为 orphaned。
+func (t *ProcessTool) SetStateDir(dir string) error {
+	store, err := newProcessStore(dir)
+	if err != nil {
+		return err
+	}
+	records, err := store.load()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store = store
+	orphaned := false
+	for _, r := range records {
+		if _, ok := t.sessions[r.ID]; ok {
+			continue
+		}
+		session := &ProcessSession{
+			ID:        r.ID,
+			Command:   r.Command,
+			Workdir:   r.Workdir,
+			Owner:     r.Owner,
+			PID:       r.PID,
+			PTY:       r.PTY,
+			StartedAt: r.StartedAt,
+			EndedAt:   r.EndedAt,
+			Status:    r.Status,
+			ExitCode:  r.ExitCode,
+			Sandboxed: r.Sandboxed,
+			written:   r.Bytes,
+			tool:      t,
+			done:      make(chan struct{}),
+		}
+		close(session.done)
+		if session.Status == "running" {
+			session.Status = "orphaned"
+			orphaned = true
+		}
+		t.sessions[r.ID] = session
+		if n := sessionNumber(r.ID); n > t.counter {
+			t.counter = n
+		}
+	}
+	if orphaned {
+		return store.save(t.recordsLocked())
+	}
+	return nil
+}
+
+// SetExitHandler 设置后台会话结束时的回调 (如向所属 agent 会话注入系统事件)
+func (t *ProcessTool) SetExitHandler(handler ProcessExitHandler) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onExit = handler
+}
+
 // StartSession 启动一个新的后台会话 (由 exec 工具调用)
 func (t *ProcessTool) StartSession(command, workdir string, usePTY bool, env map[string]string) (string, error) {
+	return t.startSession(processSpec{Command: command, Workdir: workdir, PTY: usePTY, Env: env})
+}
+
+// startSession 启动后台会话，spec.Sandbox 不为空时在沙箱中运行
+func (t *ProcessTool) startSession(spec processSpec) (string, error) {
+	session, err := newProcessSession(spec)
+	if err != nil {
+		return "", err
+	}
+	if err := session.start(); err != nil {
+		return "", err
+	}
+	return t.adopt(session), nil
+}
+
+// adopt 接管一个已在运行的会话 (进程、输出缓冲和 PTY 保持不变)，返回会话 ID
+func (t *ProcessTool) adopt(session *ProcessSession) string {
 	t.mu.Lock()
 	t.counter++
-	sessionID := fmt.Sprintf("session-%d", t.counter)
+	id := fmt.Sprintf("session-%d", t.counter)
+
+	session.mu.Lock()
+	session.ID = id
+	session.tool = t
+	if t.store != nil {
+		// 之前缓冲的输出先写入日志，之后的输出直接追加
+		if log, err := t.store.openLog(id); err == nil {
+			log.Write(session.output)
+			session.log = log
+		}
+	}
+	exited := session.Status != "running"
+	if exited && session.log != nil {
+		session.log.Close()
+	}
+	session.mu.Unlock()
+
+	t.sessions[id] = session
 	t.mu.Unlock()
 
-	cmd := exec.Command("sh", "-c", command)
-	cmd.Dir = workdir
+	// 在登记前已经结束的会话同样需要记录和通知
+	if exited {
+		t.sessionExited(session)
+	} else {
+		t.saveIndex()
+	}
+	return id
+}
+
+// sessionExited 持久化并通知后台会话结束
+func (t *ProcessTool) sessionExited(session *ProcessSession) {
+	t.saveIndex()
 
-	// 设置环境变量
-	if len(env) > 0 {
-		cmdEnv := os.Environ()
-		for k, v := range env {
-			cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", k, v))
-		}
-		cmd.Env = cmdEnv
+	t.mu.RLock()
+	handler := t.onExit
+	t.mu.RUnlock()
+	if handler == nil {
+		return
 	}
 
-	session := &ProcessSession{
-		ID:        sessionID,
-		Command:   command,
-		PTY:       usePTY,
-		StartedAt: time.Now(),
+	session.mu.Lock()
+	event := ProcessEvent{
+		SessionID: session.ID,
+		Owner:     session.Owner,
+		Command:   session.Command,
+		Status:    session.Status,
+		ExitCode:  session.ExitCode,
+		Tail:      outputTail(session.output, 20, 2000),
+	}
+	session.mu.Unlock()
+	go handler(event)
+}
+
+// saveIndex 保存会话索引 (未启用持久化时忽略)
+func (t *ProcessTool) saveIndex() {
+	t.saveMu.Lock()
+	defer t.saveMu.Unlock()
+
+	t.mu.RLock()
+	store := t.store
+	var records []processRecord
+	if store != nil {
+		records = t.recordsLocked()
+	}
+	t.mu.RUnlock()
+	if store != nil {
+		store.save(records)
+	}
+}
+
+// recordsLocked 所有会话的索引记录，调用者需持有 t.mu
+func (t *ProcessTool) recordsLocked() []processRecord {
+	records := make([]processRecord, 0, len(t.sessions))
+	for _, s := range t.sessions {
+		s.mu.Lock()
+		records = append(records, processRecord{
+			ID:        s.ID,
+			Command:   s.Command,
+			Workdir:   s.Workdir,
+			Owner:     s.Owner,
+			PID:       s.PID,
+			PTY:       s.PTY,
+			Sandboxed: s.Sandboxed,
+			Status:    s.Status,
+			ExitCode:  s.ExitCode,
+			StartedAt: s.StartedAt,
+			EndedAt:   s.EndedAt,
+			Bytes:     s.written,
+		})
+		s.mu.Unlock()
+	}
+	return records
+}
+
+// newProcessSession 创建会话 (尚未启动)
+func newProcessSession(spec processSpec) (*ProcessSession, error) {
+	cmd := hostCommand(context.Background(), spec.Command, spec.Workdir, spec.Env)
+	cleanup := func() {}
+	if spec.Sandbox != nil {
+		var err error
+		cmd, cleanup, err = spec.Sandbox.Command(context.Background(), spec.Command, spec.Workdir, spec.Env)
+		if err != nil {
+			return nil, fmt.Errorf("sandbox: %w", err)
+		}
+	}
+	// 进程退出后最多再等 1 秒读取残余输出，避免被遗留的子进程挂住
+	cmd.WaitDelay = time.Second
+
+	return &ProcessSession{
+		Command:   spec.Command,
+		Workdir:   spec.Workdir,
+		Owner:     spec.Owner,
+		PTY:       spec.PTY,
 		Status:    "running",
+		Sandboxed: spec.Sandbox != nil,
 		cmd:       cmd,
+		cleanup:   cleanup,
 		done:      make(chan struct{}),
-	}
+	}, nil
+}
 
-	if usePTY {
+// start 启动进程并在后台收集输出，进程结束后关闭 done
+func (s *ProcessSession) start() error {
+	cmd := s.cmd
+	s.StartedAt = time.Now()
+
+	if s.PTY {
 		// 使用 PTY
 		ptmx, err := pty.Start(cmd)
 		if err != nil {
-			return "", fmt.Errorf("pty start: %w", err)
+			s.cleanup()
+			return fmt.Errorf("pty start: %w", err)
 		}
-		session.ptmx = ptmx
-		session.PID = cmd.Process.Pid
+		s.ptmx = ptmx
+		s.PID = cmd.Process.Pid
 
 		// 后台读取输出
 		go func() {
-			defer close(session.done)
 			buf := make([]byte, 4096)
 			for {
 				n, err := ptmx.Read(buf)
 				if n > 0 {
-					session.mu.Lock()
-					session.output = append(session.output, buf[:n]...)
-					// 限制输出缓冲区大小 (保留最后 1MB)
-					if len(session.output) > 1024*1024 {
-						session.output = session.output[len(session.output)-1024*1024:]
-					}
-					session.mu.Unlock()
+					s.appendOutput(buf[:n])
 				}
 				if err != nil {
 					break
 				}
 			}
-			session.mu.Lock()
-			session.Status = "exited"
-			session.mu.Unlock()
+			s.finish(cmd.Wait())
 		}()
-	} else {
-		// 非 PTY 模式
-		stdout, _ := cmd.StdoutPipe()
-		stderr, _ := cmd.StderrPipe()
+		return nil
+	}
 
-		if err := cmd.Start(); err != nil {
-			return "", fmt.Errorf("start: %w", err)
-		}
-		session.PID = cmd.Process.Pid
+	// 非 PTY 模式：stdout 和 stderr 写入同一个缓冲区
+	cmd.Stdout = (*sessionOutput)(s)
+	cmd.Stderr = (*sessionOutput)(s)
+	setProcessGroup(cmd)
 
-		// 后台读取输出
-		go func() {
-			defer close(session.done)
-			// 读取 stdout 和 stderr
-			go func() {
-				buf := make([]byte, 4096)
-				for {
-					n, err := stdout.Read(buf)
-					if n > 0 {
-						session.mu.Lock()
-						session.output = append(session.output, buf[:n]...)
-						session.mu.Unlock()
-					}
-					if err != nil {
-						break
-					}
-				}
-			}()
-			buf := make([]byte, 4096)
-			for {
-				n, err := stderr.Read(buf)
-				if n > 0 {
-					session.mu.Lock()
-					session.output = append(session.output, buf[:n]...)
-					session.mu.Unlock()
-				}
-				if err != nil {
-					break
-				}
-			}
-			cmd.Wait()
-			session.mu.Lock()
-			session.Status = "exited"
-			session.mu.Unlock()
-		}()
+	if err := cmd.Start(); err != nil {
+		s.cleanup()
+		return fmt.Errorf("start: %w", err)
 	}
+	s.PID = cmd.Process.Pid
 
-	t.mu.Lock()
-	t.sessions[sessionID] = session
-	t.mu.Unlock()
+	go func() {
+		s.finish(cmd.Wait())
+	}()
+	return nil
+}
+
+// sessionOutput 将进程输出追加到会话缓冲区
+type sessionOutput ProcessSession
+
+func (o *sessionOutput) Write(p []byte) (int, error) {
+	(*ProcessSession)(o).appendOutput(p)
+	return len(p), nil
+}
+
+// appendOutput 追加输出 (内存中保留最后 1MB，完整输出写入日志)
+func (s *ProcessSession) appendOutput(p []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = append(s.output, p...)
+	if len(s.output) > maxSessionOutput {
+		s.output = s.output[len(s.output)-maxSessionOutput:]
+	}
+	s.written += int64(len(p))
+	if s.log != nil {
+		s.log.Write(p)
+	}
+}
+
+// finish 记录退出状态并释放资源
+func (s *ProcessSession) finish(err error) {
+	s.cleanup()
+	s.mu.Lock()
+	s.waitErr = err
+	s.EndedAt = time.Now()
+	if state := s.cmd.ProcessState; state != nil {
+		code := state.ExitCode()
+		s.ExitCode = &code
+	}
+	if s.Status == "running" {
+		s.Status = "exited"
+	}
+	if s.log != nil {
+		s.log.Close()
+	}
+	tool := s.tool
+	s.mu.Unlock()
+
+	// 先持久化再通知等待者，done 关闭时索引已是最新状态
+	if tool != nil {
+		tool.sessionExited(s)
+	}
+	close(s.done)
+}
 
-	return sessionID, nil
+// terminate 终止整个进程组
+func (s *ProcessSession) terminate() {
+	if s.cmd != nil {
+		killProcessGroup(s.cmd)
+	}
+	if s.ptmx != nil {
+		s.ptmx.Close()
+	}
 }
 
-func (t *ProcessTool) list() (*Result, error) {
+// snapshot 当前输出的副本
+func (s *ProcessSession) snapshot() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte(nil), s.output...)
+}
+
+func (t *ProcessTool) list(ctx context.Context) (*Result, error) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
 	sessions := make([]map[string]interface{}, 0, len(t.sessions))
 	for _, s := range t.sessions {
+		if !t.canAccess(ctx, s) {
+			continue
+		}
 		s.mu.Lock()
-		sessions = append(sessions, map[string]interface{}{
+		entry := map[string]interface{}{
 			"sessionId": s.ID,
 			"command":   s.Command,
 			"pid":       s.PID,
 			"pty":       s.PTY,
 			"status":    s.Status,
 			"startedAt": s.StartedAt.Format(time.RFC3339),
-			"outputLen": len(s.output),
-		})
+			"outputLen": s.written,
+		}
+		if s.ExitCode != nil {
+			entry["exitCode"] = *s.ExitCode
+		}
+		if !s.EndedAt.IsZero() {
+			entry["endedAt"] = s.EndedAt.Format(time.RFC3339)
+		}
+		sessions = append(sessions, entry)
 		s.mu.Unlock()
 	}
 
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessionNumber(sessions[i]["sessionId"].(string)) < sessionNumber(sessions[j]["sessionId"].(string))
+	})
+
 	data, _ := json.MarshalIndent(sessions, "", "  ")
 	return &Result{Content: string(data)}, nil
 }
 
-func (t *ProcessTool) poll(sessionID string) (*Result, error) {
-	t.mu.RLock()
-	session, ok := t.sessions[sessionID]
-	t.mu.RUnlock()
+func (t *ProcessTool) poll(ctx context.Context, sessionID string) (*Result, error) {
+	session, ok := t.lookup(ctx, sessionID)
 
 	if !ok {
 		return &Result{Content: "Session not found: " + sessionID, IsError: true}, nil
@@ -286,16 +593,20 @@ func (t *ProcessTool) poll(sessionID string) (*Result, error) {
 	result := map[string]interface{}{
 		"sessionId": session.ID,
 		"status":    session.Status,
-		"outputLen": len(session.output),
+		"outputLen": session.written,
+	}
+	if session.ExitCode != nil {
+		result["exitCode"] = *session.ExitCode
 	}
 
 	data, _ := json.Marshal(result)
 	return &Result{Content: string(data)}, nil
 }
 
-func (t *ProcessTool) log(sessionID string, offset, limit int) (*Result, error) {
+func (t *ProcessTool) log(ctx context.Context, sessionID string, offset, limit int) (*Result, error) {
+	session, ok := t.lookup(ctx, sessionID)
 	t.mu.RLock()
-	session, ok := t.sessions[sessionID]
+	store := t.store
 	t.mu.RUnlock()
 
 	if !ok {
@@ -303,9 +614,14 @@ func (t *ProcessTool) log(sessionID string, offset, limit int) (*Result, error)
 	}
 
 	session.mu.Lock()
-	defer session.mu.Unlock()
-
 	output := session.output
+	// 有日志文件时读取完整输出 (包括重启前的会话)
+	if store != nil && (session.log != nil || session.cmd == nil) {
+		if data, err := store.readLog(sessionID); err == nil {
+			output = data
+		}
+	}
+	session.mu.Unlock()
 
 	// 应用 offset
 	if offset > 0 && offset < len(output) {
@@ -320,10 +636,8 @@ func (t *ProcessTool) log(sessionID string, offset, limit int) (*Result, error)
 	return &Result{Content: string(output)}, nil
 }
 
-func (t *ProcessTool) write(sessionID, data string, eof bool) (*Result, error) {
-	t.mu.RLock()
-	session, ok := t.sessions[sessionID]
-	t.mu.RUnlock()
+func (t *ProcessTool) write(ctx context.Context, sessionID, data string, eof bool) (*Result, error) {
+	session, ok := t.lookup(ctx, sessionID)
 
 	if !ok {
 		return &Result{Content: "Session not found: " + sessionID, IsError: true}, nil
@@ -352,10 +666,8 @@ func (t *ProcessTool) write(sessionID, data string, eof bool) (*Result, error) {
 	return &Result{Content: fmt.Sprintf("Wrote %d bytes", len(data))}, nil
 }
 
-func (t *ProcessTool) sendKeys(sessionID, literal string, keys []string) (*Result, error) {
-	t.mu.RLock()
-	session, ok := t.sessions[sessionID]
-	t.mu.RUnlock()
+func (t *ProcessTool) sendKeys(ctx context.Context, sessionID, literal string, keys []string) (*Result, error) {
+	session, ok := t.lookup(ctx, sessionID)
 
 	if !ok {
 		return &Result{Content: "Session not found: " + sessionID, IsError: true}, nil
@@ -427,10 +739,8 @@ func (t *ProcessTool) sendKeys(sessionID, literal string, keys []string) (*Resul
 	return &Result{Content: "Keys sent"}, nil
 }
 
-func (t *ProcessTool) kill(sessionID string) (*Result, error) {
-	t.mu.RLock()
-	session, ok := t.sessions[sessionID]
-	t.mu.RUnlock()
+func (t *ProcessTool) kill(ctx context.Context, sessionID string) (*Result, error) {
+	session, ok := t.lookup(ctx, sessionID)
 
 	if !ok {
 		return &Result{Content: "Session not found: " + sessionID, IsError: true}, nil
@@ -439,32 +749,59 @@ func (t *ProcessTool) kill(sessionID string) (*Result, error) {
 	session.mu.Lock()
 	defer session.mu.Unlock()
 
+	if session.Status == "orphaned" {
+		return &Result{Content: fmt.Sprintf("Session %s is orphaned (started before a restart); its process (pid %d) is no longer managed", sessionID, session.PID), IsError: true}, nil
+	}
 	if session.Status != "running" {
 		return &Result{Content: "Session already " + session.Status}, nil
 	}
 
-	if session.cmd.Process != nil {
-		session.cmd.Process.Kill()
-	}
-	if session.ptmx != nil {
-		session.ptmx.Close()
-	}
+	session.terminate()
 	session.Status = "killed"
 
 	return &Result{Content: "Session killed"}, nil
 }
 
-// Cleanup 清理已结束的会话
+// Cleanup 清理已结束的会话 (同时删除日志文件)
 func (t *ProcessTool) Cleanup(maxAge time.Duration) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
-
 	now := time.Now()
+	removed := false
 	for id, session := range t.sessions {
 		session.mu.Lock()
 		if session.Status != "running" && now.Sub(session.StartedAt) > maxAge {
 			delete(t.sessions, id)
+			if t.store != nil {
+				t.store.removeLog(id)
+			}
+			removed = true
 		}
 		session.mu.Unlock()
 	}
+	t.mu.Unlock()
+
+	if removed {
+		t.saveIndex()
+	}
+}
+
+// outputTail 输出的最后 maxLines 行 (最多 maxBytes 字节)
+func outputTail(output []byte, maxLines, maxBytes int) string {
+	text := strings.TrimRight(string(output), "\r\n ")
+	if len(text) > maxBytes {
+		text = text[len(text)-maxBytes:]
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateString 截断过长的字符串
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
 }
@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 后台进程的持久化：每个会话的输出写入 <stateDir>/processes/<id>.log，
+// 超过大小上限时滚动到 <id>.log.1 (只保留一份旧日志)；会话元数据保存在 index.json。
+
+// defaultMaxProcessLog 每个会话日志的默认大小上限 (含滚动的旧日志)
+const defaultMaxProcessLog = 8 * 1024 * 1024
+
+// processRecord index.json 中的会话记录
+type processRecord struct {
+	ID        string    `json:"sessionId"`
+	Command   string    `json:"command"`
+	Workdir   string    `json:"workdir,omitempty"`
+	Owner     string    `json:"owner,omitempty"`
+	PID       int       `json:"pid"`
+	PTY       bool      `json:"pty,omitempty"`
+	Sandboxed bool      `json:"sandboxed,omitempty"`
+	Status    string    `json:"status"`
+	ExitCode  *int      `json:"exitCode,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt,omitempty"`
+	Bytes     int64     `json:"bytes"`
+}
+
+// processStore 会话索引和日志文件
+type processStore struct {
+	dir         string
+	maxLogBytes int64
+	mu          sync.Mutex // 保护 index.json 的写入
+}
+
+func newProcessStore(dir string) (*processStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create process dir: %w", err)
+	}
+	return &processStore{dir: dir, maxLogBytes: defaultMaxProcessLog}, nil
+}
+
+func (s *processStore) indexPath() string {
+	return filepath.Join(s.dir, "index.json")
+}
+
+func (s *processStore) logPath(id string) string {
+	return filepath.Join(s.dir, id+".log")
+}
+
+// load 读取会话索引
+func (s *processStore) load() ([]processRecord, error) {
+	data, err := os.ReadFile(s.indexPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []processRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", s.indexPath(), err)
+	}
+	return records, nil
+}
+
+// save 原子写入会话索引
+func (s *processStore) save(records []processRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return sessionNumber(records[i].ID) < sessionNumber(records[j].ID)
+	})
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.indexPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.indexPath())
+}
+
+// openLog 创建会话日志
+func (s *processStore) openLog(id string) (*sessionLog, error) {
+	path := s.logPath(id)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open process log: %w", err)
+	}
+	return &sessionLog{path: path, f: f, segment: s.maxLogBytes / 2}, nil
+}
+
+// readLog 读取会话的完整日志 (旧日志 + 当前日志)
+func (s *processStore) readLog(id string) ([]byte, error) {
+	path := s.logPath(id)
+	old, _ := os.ReadFile(path + ".1")
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if os.IsNotExist(err) && old == nil {
+		return nil, err
+	}
+	return append(old, current...), nil
+}
+
+// removeLog 删除会话日志
+func (s *processStore) removeLog(id string) {
+	os.Remove(s.logPath(id))
+	os.Remove(s.logPath(id) + ".1")
+}
+
+// sessionLog 带大小上限的日志文件，当前文件超过 segment 时滚动
+type sessionLog struct {
+	path    string
+	f       *os.File
+	size    int64
+	segment int64
+}
+
+func (l *sessionLog) Write(p []byte) (int, error) {
+	if l.f == nil {
+		return 0, os.ErrClosed
+	}
+	n := len(p)
+	if l.segment <= 0 {
+		_, err := l.f.Write(p)
+		return n, err
+	}
+	// 超过两个分段的部分最终都会被滚动掉，直接丢弃
+	if int64(len(p)) > 2*l.segment {
+		p = p[int64(len(p))-2*l.segment:]
+	}
+	for len(p) > 0 {
+		if l.size >= l.segment {
+			if err := l.rotate(); err != nil {
+				return 0, err
+			}
+		}
+		chunk := p
+		if room := l.segment - l.size; int64(len(chunk)) > room {
+			chunk = p[:room]
+		}
+		written, err := l.f.Write(chunk)
+		l.size += int64(written)
+		if err != nil {
+			return 0, err
+		}
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (l *sessionLog) rotate() error {
+	l.f.Close()
+	if err := os.Rename(l.path, l.path+".1"); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		l.f = nil
+		return err
+	}
+	l.f = f
+	l.size = 0
+	return nil
+}
+
+func (l *sessionLog) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// sessionNumber 会话 ID 的序号 (session-12 -> 12)
+func sessionNumber(id string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(id, "session-"))
+	return n
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
 )
 
 func TestProcessTool_StartSession(t *testing.T) {
@@ -146,7 +148,7 @@ func TestProcessTool_SendKeys(t *testing.T) {
 	}
 
 	// Kill the session
-	tool.kill(sessionID)
+	tool.kill(context.Background(), sessionID)
 }
 
 func TestProcessTool_Write(t *testing.T) {
@@ -174,7 +176,56 @@ func TestProcessTool_Write(t *testing.T) {
 		t.Errorf("Write error: %s", result.Content)
 	}
 
-	tool.kill(sessionID)
+	tool.kill(context.Background(), sessionID)
+}
+
+func TestProcessTool_OwnerAccess(t *testing.T) {
+	tool := NewProcessTool(os.TempDir())
+	tool.SetSandbox(NewSandbox(config.SandboxConfig{Mode: SandboxModeGroups}, os.TempDir()))
+
+	mainID, err := tool.startSession(processSpec{Command: "cat", PTY: true, Owner: "main"})
+	if err != nil {
+		t.Skipf("PTY not available: %v", err)
+	}
+	defer tool.kill(context.Background(), mainID)
+
+	run := func(ctx context.Context, params ProcessParams) *Result {
+		t.Helper()
+		args, _ := json.Marshal(params)
+		result, err := tool.Execute(ctx, args)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		return result
+	}
+
+	// 沙箱中的群组会话不能写入主会话未沙箱化的 PTY
+	group := WithSession(context.Background(), SessionContext{Key: "telegram:-100", Kind: "group"})
+	for _, action := range []string{"write", "send-keys", "log", "poll", "kill"} {
+		result := run(group, ProcessParams{Action: action, SessionID: mainID, Data: "id\n", Literal: "id"})
+		if !result.IsError || !strings.Contains(result.Content, "not found") {
+			t.Errorf("%s from group session should be refused, got: %s", action, result.Content)
+		}
+	}
+	if result := run(group, ProcessParams{Action: "list"}); strings.Contains(result.Content, mainID) {
+		t.Errorf("group session should not see other sessions' processes: %s", result.Content)
+	}
+
+	// 其他非主会话同样只能操作自己的进程
+	dm := WithSession(context.Background(), SessionContext{Key: "telegram:42", Kind: "telegram"})
+	if result := run(dm, ProcessParams{Action: "write", SessionID: mainID, Data: "id\n"}); !result.IsError {
+		t.Errorf("write from another session should be refused, got: %s", result.Content)
+	}
+
+	main := WithSession(context.Background(), SessionContext{Key: "main", Kind: "main"})
+	if result := run(main, ProcessParams{Action: "write", SessionID: mainID, Data: "hello\n"}); result.IsError {
+		t.Errorf("owner should be able to write: %s", result.Content)
+	}
+
+	dmID, _ := tool.startSession(processSpec{Command: "sleep 5", Owner: "telegram:42"})
+	if result := run(dm, ProcessParams{Action: "kill", SessionID: dmID}); result.IsError {
+		t.Errorf("owner should be able to kill its process: %s", result.Content)
+	}
 }
 
 func TestProcessTool_SessionNotFound(t *testing.T) {
@@ -223,3 +274,139 @@ func TestProcessTool_UnknownAction(t *testing.T) {
 		t.Error("Expected error for unknown action")
 	}
 }
+
+// waitStatus 等待会话离开 running 状态
+func waitStatus(t *testing.T, tool *ProcessTool, sessionID string) map[string]interface{} {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		result, _ := tool.poll(context.Background(), sessionID)
+		var status map[string]interface{}
+		json.Unmarshal([]byte(result.Content), &status)
+		if status["status"] != "running" || time.Now().After(deadline) {
+			return status
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProcessTool_ExitCode(t *testing.T) {
+	tool := NewProcessTool(t.TempDir())
+
+	sessionID, err := tool.StartSession("echo failing; exit 3", "", false, nil)
+	if err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	status := waitStatus(t, tool, sessionID)
+	if status["status"] != "exited" {
+		t.Fatalf("Expected exited, got: %v", status)
+	}
+	if code, ok := status["exitCode"].(float64); !ok || code != 3 {
+		t.Errorf("Expected exitCode 3, got: %v", status["exitCode"])
+	}
+}
+
+func TestProcessTool_PersistAcrossRestart(t *testing.T) {
+	stateDir := t.TempDir()
+	tool := NewProcessTool(t.TempDir())
+	if err := tool.SetStateDir(stateDir); err != nil {
+		t.Fatalf("SetStateDir failed: %v", err)
+	}
+
+	doneID, _ := tool.StartSession("echo finished-output", "", false, nil)
+	waitStatus(t, tool, doneID)
+	runningID, _ := tool.StartSession("echo still-running; sleep 30", "", false, nil)
+	defer func() {
+		tool.kill(context.Background(), runningID)
+		<-tool.sessions[runningID].done
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	// 模拟网关重启：新的 ProcessTool 从同一目录加载
+	restarted := NewProcessTool(t.TempDir())
+	if err := restarted.SetStateDir(stateDir); err != nil {
+		t.Fatalf("SetStateDir after restart failed: %v", err)
+	}
+
+	result, _ := restarted.list(context.Background())
+	if !strings.Contains(result.Content, doneID) || !strings.Contains(result.Content, runningID) {
+		t.Fatalf("Expected both sessions after restart, got: %s", result.Content)
+	}
+
+	result, _ = restarted.log(context.Background(), doneID, 0, 0)
+	if !strings.Contains(result.Content, "finished-output") {
+		t.Errorf("Expected log from disk, got: %s", result.Content)
+	}
+	result, _ = restarted.log(context.Background(), runningID, 0, 0)
+	if !strings.Contains(result.Content, "still-running") {
+		t.Errorf("Expected log of orphaned session, got: %s", result.Content)
+	}
+
+	status := waitStatus(t, restarted, runningID)
+	if status["status"] != "orphaned" {
+		t.Errorf("Expected orphaned status, got: %v", status)
+	}
+	result, _ = restarted.kill(context.Background(), runningID)
+	if !result.IsError || !strings.Contains(result.Content, "orphaned") {
+		t.Errorf("Expected orphaned kill error, got: %s", result.Content)
+	}
+
+	// 新会话编号接着之前的继续
+	newID, _ := restarted.StartSession("true", "", false, nil)
+	if sessionNumber(newID) <= sessionNumber(runningID) {
+		t.Errorf("Expected new session number after %s, got %s", runningID, newID)
+	}
+	waitStatus(t, restarted, newID)
+}
+
+func TestProcessTool_LogRotation(t *testing.T) {
+	tool := NewProcessTool(t.TempDir())
+	if err := tool.SetStateDir(t.TempDir()); err != nil {
+		t.Fatalf("SetStateDir failed: %v", err)
+	}
+	tool.store.maxLogBytes = 4096
+
+	sessionID, _ := tool.StartSession("for i in $(seq 1 2000); do echo line-$i; done", "", false, nil)
+	waitStatus(t, tool, sessionID)
+
+	data, err := tool.store.readLog(sessionID)
+	if err != nil {
+		t.Fatalf("readLog failed: %v", err)
+	}
+	if len(data) > 4096 {
+		t.Errorf("Expected log capped at 4096 bytes, got %d", len(data))
+	}
+	if !strings.Contains(string(data), "line-2000") {
+		t.Errorf("Expected log to keep the latest output")
+	}
+	if strings.Contains(string(data), "line-1\nline-2\n") {
+		t.Errorf("Expected oldest output to be rotated away, got %d bytes: %.200q", len(data), data)
+	}
+}
+
+func TestProcessTool_ExitHandler(t *testing.T) {
+	tool := NewProcessTool(t.TempDir())
+	events := make(chan ProcessEvent, 4)
+	tool.SetExitHandler(func(event ProcessEvent) { events <- event })
+
+	sessionID, _ := tool.startSession(processSpec{Command: "echo build done; exit 1", Owner: "telegram:42"})
+
+	select {
+	case event := <-events:
+		if event.SessionID != sessionID || event.Owner != "telegram:42" {
+			t.Errorf("Unexpected event: %+v", event)
+		}
+		msg := event.Message()
+		if !strings.Contains(msg, "exited with code 1") || !strings.Contains(msg, "build done") {
+			t.Errorf("Unexpected message: %s", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Exit handler not called")
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("Exit handler called twice: %+v", event)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
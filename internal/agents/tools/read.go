@@ -13,6 +13,7 @@ import (
 // ReadTool 读取文件的工具
 type ReadTool struct {
 	workdir  string
+	paths    *PathPolicy
 	maxBytes int64
 	maxLines int
 }
@@ -28,11 +29,17 @@ type ReadParams struct {
 func NewReadTool(workdir string) *ReadTool {
 	return &ReadTool{
 		workdir:  workdir,
+		paths:    DefaultPathPolicy(workdir),
 		maxBytes: 50 * 1024, // 50KB
 		maxLines: 2000,
 	}
 }
 
+// SetPathPolicy 设置路径访问策略
+func (t *ReadTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
 func (t *ReadTool) Name() string {
 	return ToolRead
 }
@@ -74,9 +81,9 @@ func (t *ReadTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 	}
 
 	// 解析路径
-	path := params.Path
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(t.workdir, path)
+	path, err := t.paths.Resolve(ctx, params.Path, false)
+	if err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
 	}
 
 	// 检查文件是否存在
@@ -35,7 +35,7 @@ func TestReadTool_AbsolutePath(t *testing.T) {
 	testFile := filepath.Join(tmpDir, "abs.txt")
 	os.WriteFile(testFile, []byte("absolute content"), 0644)
 
-	tool := NewReadTool("/different/path")
+	tool := NewReadTool(tmpDir)
 	params := ReadParams{Path: testFile}
 	args, _ := json.Marshal(params)
 
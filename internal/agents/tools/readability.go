@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// 正文提取 (参考 Mozilla Readability 的打分思路)：
+// 段落按文本长度和逗号数打分，分数累加到父节点和祖父节点，
+// 再按 class/id 特征和链接密度修正，得分最高的节点视为正文。
+
+var (
+	// 明显不是正文的元素
+	unlikelyCandidates = regexp.MustCompile(`(?i)banner|breadcrumb|combx|comment|community|cookie|disqus|extra|foot|header|legends|menu|modal|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|supplemental|ad-break|agegate|pagination|pager|popup|newsletter|subscribe|promo`)
+	// 可能是正文的元素 (即使匹配了上面的规则也保留)
+	maybeCandidate = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+
+	positiveWeight = regexp.MustCompile(`(?i)article|body|content|entry|hentry|h-entry|main|page|pagination|post|text|blog|story`)
+	negativeWeight = regexp.MustCompile(`(?i)-ad-|hidden|^hid$| hid$| hid |^hid |banner|combx|comment|com-|contact|foot|footer|footnote|masthead|media|meta|outbrain|promo|related|scroll|share|shoutbox|sidebar|skyscraper|sponsor|shopping|tags|tool|widget`)
+)
+
+// readableDoc 提取结果
+type readableDoc struct {
+	Title   string
+	Content *goquery.Selection
+}
+
+// extractReadable 从 HTML 文档中提取标题和正文节点
+func extractReadable(doc *goquery.Document) readableDoc {
+	title := pageTitle(doc)
+
+	// 移除无关元素
+	doc.Find("script, style, noscript, template, iframe, svg, canvas, form, button, input, select, textarea, nav, footer, aside, dialog").Remove()
+	doc.Find(".ad, .ads, .advertisement, [aria-hidden=true], [hidden]").Remove()
+	doc.Find("body > header").Remove()
+	doc.Find("div, section, span, ul, header, table").Each(func(_ int, s *goquery.Selection) {
+		match := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if unlikelyCandidates.MatchString(match) && !maybeCandidate.MatchString(match) && s.Find("article, main").Length() == 0 {
+			s.Remove()
+		}
+	})
+
+	if best := bestCandidate(doc); best != nil {
+		return readableDoc{Title: title, Content: best}
+	}
+
+	// 没有足够的段落可打分时，回退到语义标签
+	for _, selector := range []string{"article", "main", "[role=main]", "#content", ".content", "#main", ".main", "body"} {
+		if s := doc.Find(selector).First(); s.Length() > 0 {
+			return readableDoc{Title: title, Content: s}
+		}
+	}
+	return readableDoc{Title: title, Content: doc.Selection}
+}
+
+// pageTitle 页面标题：og:title > <title> > 第一个 h1
+func pageTitle(doc *goquery.Document) string {
+	if t, ok := doc.Find(`meta[property="og:title"]`).Attr("content"); ok && strings.TrimSpace(t) != "" {
+		return strings.TrimSpace(t)
+	}
+	if t := strings.TrimSpace(doc.Find("title").First().Text()); t != "" {
+		return t
+	}
+	return strings.TrimSpace(doc.Find("h1").First().Text())
+}
+
+// bestCandidate 对段落打分并返回得分最高的容器
+func bestCandidate(doc *goquery.Document) *goquery.Selection {
+	scores := make(map[*html.Node]float64)
+	selections := make(map[*html.Node]*goquery.Selection)
+
+	addScore := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 {
+			return
+		}
+		node := s.Get(0)
+		if node.Type != html.ElementNode || node.Data == "html" || node.Data == "body" {
+			return
+		}
+		if _, ok := scores[node]; !ok {
+			scores[node] = initialScore(s)
+			selections[node] = s
+		}
+		scores[node] += score
+	}
+
+	doc.Find("p, pre, td, blockquote").Each(func(_ int, p *goquery.Selection) {
+		text := strings.TrimSpace(p.Text())
+		if len(text) < 25 {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，"))
+		score += math.Min(float64(len(text))/100, 3)
+
+		parent := p.Parent()
+		addScore(parent, score)
+		addScore(parent.Parent(), score/2)
+	})
+
+	var best *goquery.Selection
+	bestScore := 0.0
+	for node, score := range scores {
+		s := selections[node]
+		score *= 1 - linkDensity(s)
+		if score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	// 正文被拆成多个兄弟容器时，父节点同样得分很高，选择父节点以保留全部内容
+	if parent := best.Parent(); parent.Length() > 0 {
+		if score, ok := scores[parent.Get(0)]; ok && score*(1-linkDensity(parent)) >= bestScore*0.75 {
+			best = parent
+		}
+	}
+	return best
+}
+
+// initialScore 按标签和 class/id 给出的初始分
+func initialScore(s *goquery.Selection) float64 {
+	score := 0.0
+	switch goquery.NodeName(s) {
+	case "article":
+		score += 10
+	case "div", "main", "section":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	for _, attr := range []string{"class", "id"} {
+		value := s.AttrOr(attr, "")
+		if value == "" {
+			continue
+		}
+		if negativeWeight.MatchString(value) {
+			score -= 25
+		}
+		if positiveWeight.MatchString(value) {
+			score += 25
+		}
+	}
+	return score
+}
+
+// linkDensity 链接文本占全部文本的比例
+func linkDensity(s *goquery.Selection) float64 {
+	textLen := len(strings.TrimSpace(s.Text()))
+	if textLen == 0 {
+		return 0
+	}
+	linkLen := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkLen += len(strings.TrimSpace(a.Text()))
+	})
+	return float64(linkLen) / float64(textLen)
+}
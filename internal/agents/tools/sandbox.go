@@ -0,0 +1,285 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// 沙箱模式
+const (
+	SandboxModeOff     = "off"      // 不使用沙箱
+	SandboxModeAll     = "all"      // 所有会话
+	SandboxModeNonMain = "non-main" // 除主会话 (所有者私聊) 外的所有会话
+	SandboxModeGroups  = "groups"   // 仅群组会话
+)
+
+// 沙箱后端
+const (
+	SandboxBackendNamespaces = "namespaces" // Linux 命名空间 + cgroups v2 (内置)
+	SandboxBackendCommand    = "command"    // 外部沙箱程序 (如 bwrap、nsjail、firejail)
+)
+
+// sandboxPath 沙箱内的 PATH
+const sandboxPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// 沙箱内默认只读可见的主机路径 (不存在的会被跳过)。
+// /etc 只暴露运行命令所需的文件，避免泄露主机上的凭据。
+var defaultSandboxReadOnly = []string{
+	"/usr", "/bin", "/sbin", "/lib", "/lib32", "/lib64", "/libx32",
+	"/etc/alternatives", "/etc/ca-certificates", "/etc/ssl", "/etc/pki",
+	"/etc/ld.so.cache", "/etc/ld.so.conf", "/etc/ld.so.conf.d",
+	"/etc/localtime", "/etc/passwd", "/etc/group", "/etc/nsswitch.conf",
+	"/etc/hosts", "/etc/resolv.conf", "/etc/mime.types",
+}
+
+// 默认透传的环境变量 (其余全部丢弃)
+var defaultSandboxEnv = []string{"TERM", "LANG", "LC_ALL", "LC_CTYPE", "TZ"}
+
+// SessionContext 当前工具调用所属的会话
+type SessionContext struct {
+	Key     string
+	Kind    string // main, group, isolated, 或渠道名
+	Channel string
+	Turn    int // 会话内的轮次 (从 1 开始)
+}
+
+type sessionContextKey struct{}
+
+// WithSession 将会话信息附加到 context，供 exec 等工具按会话决定行为
+func WithSession(ctx context.Context, session SessionContext) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// SessionFromContext 读取 context 中的会话信息
+func SessionFromContext(ctx context.Context) (SessionContext, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(SessionContext)
+	return session, ok
+}
+
+// Sandbox 将命令限制在工作区内运行：
+// 主机文件系统只读可见，工作区可写，默认无网络，资源受 cgroups v2 限制，环境变量被清理。
+type Sandbox struct {
+	cfg       config.SandboxConfig
+	workspace string
+}
+
+// NewSandbox 创建沙箱
+func NewSandbox(cfg config.SandboxConfig, workspace string) *Sandbox {
+	if abs, err := filepath.Abs(workspace); err == nil {
+		workspace = abs
+	}
+	return &Sandbox{cfg: cfg, workspace: workspace}
+}
+
+// Mode 当前沙箱模式
+func (s *Sandbox) Mode() string {
+	if s == nil || s.cfg.Mode == "" {
+		return SandboxModeOff
+	}
+	return s.cfg.Mode
+}
+
+// Backend 当前沙箱后端
+func (s *Sandbox) Backend() string {
+	if s.cfg.Backend == "" {
+		return SandboxBackendNamespaces
+	}
+	return s.cfg.Backend
+}
+
+// Applies 判断某个会话的命令是否需要在沙箱中运行。
+// 没有会话信息的调用 (如 CLI) 视为主会话。
+func (s *Sandbox) Applies(session SessionContext) bool {
+	if s == nil {
+		return false
+	}
+	for _, rule := range s.cfg.Sessions {
+		if ok, _ := path.Match(rule.Match, session.Key); ok {
+			return rule.Sandbox
+		}
+	}
+
+	kind := session.Kind
+	if kind == "" {
+		kind = "main"
+	}
+	switch s.Mode() {
+	case SandboxModeAll:
+		return true
+	case SandboxModeNonMain:
+		return kind != "main"
+	case SandboxModeGroups:
+		return kind == "group"
+	default:
+		return false
+	}
+}
+
+// Validate 检查配置是否可用
+func (s *Sandbox) Validate() error {
+	switch s.Mode() {
+	case SandboxModeOff, SandboxModeAll, SandboxModeNonMain, SandboxModeGroups:
+	default:
+		return fmt.Errorf("unknown sandbox mode %q", s.cfg.Mode)
+	}
+	switch s.Backend() {
+	case SandboxBackendNamespaces:
+		return namespacesSupported()
+	case SandboxBackendCommand:
+		if len(s.cfg.Command) == 0 {
+			return fmt.Errorf("sandbox backend %q requires a command", SandboxBackendCommand)
+		}
+		if _, err := exec.LookPath(s.cfg.Command[0]); err != nil {
+			return fmt.Errorf("sandbox command: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sandbox backend %q", s.cfg.Backend)
+	}
+}
+
+// ForSkill 派生运行技能工具的沙箱。skillDir 作为工作区，只有它可见；授予 filesystem 权限时
+// 工作区为 workspace (技能目录只读可见)。只有授予 network 权限且会话沙箱允许网络时才能访问网络。
+// sessionSandboxed 为 false 时不继承会话沙箱的额外路径和网络设置，只沿用后端和资源限制；s 为空时使用命名空间后端
+func (s *Sandbox) ForSkill(skillDir, workspace string, sessionSandboxed, filesystem, network bool) *Sandbox {
+	var cfg config.SandboxConfig
+	if s != nil {
+		cfg = s.cfg
+		if sessionSandboxed {
+			network = network && s.cfg.Network
+			workspace = s.workspace
+		} else {
+			cfg.ReadOnlyPaths, cfg.WritablePaths = nil, nil
+		}
+	}
+	cfg.Mode = SandboxModeAll
+	cfg.Sessions = nil
+	cfg.Network = network
+	if !filesystem {
+		cfg.ReadOnlyPaths, cfg.WritablePaths = nil, nil
+		return NewSandbox(cfg, skillDir)
+	}
+	cfg.ReadOnlyPaths = append(append([]string{}, cfg.ReadOnlyPaths...), skillDir)
+	return NewSandbox(cfg, workspace)
+}
+
+// Command 构建在沙箱中运行 command 的 *exec.Cmd (未启动)。
+// 进程结束后必须调用返回的 cleanup 释放 cgroup 等资源。
+func (s *Sandbox) Command(ctx context.Context, command, workdir string, env map[string]string) (*exec.Cmd, func(), error) {
+	workdir, err := s.resolveWorkdir(workdir)
+	if err != nil {
+		return nil, nil, err
+	}
+	cmdEnv := s.environ(workdir, env)
+
+	switch s.Backend() {
+	case SandboxBackendCommand:
+		if len(s.cfg.Command) == 0 {
+			return nil, nil, fmt.Errorf("sandbox backend %q requires a command", SandboxBackendCommand)
+		}
+		replacer := strings.NewReplacer("{workspace}", s.workspace, "{workdir}", workdir)
+		args := make([]string, 0, len(s.cfg.Command)+3)
+		for _, arg := range s.cfg.Command {
+			args = append(args, replacer.Replace(arg))
+		}
+		args = append(args, "sh", "-c", command)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = workdir
+		cmd.Env = cmdEnv
+		return cmd, func() {}, nil
+	case SandboxBackendNamespaces:
+		return s.namespaceCommand(ctx, command, workdir, cmdEnv)
+	default:
+		return nil, nil, fmt.Errorf("unknown sandbox backend %q", s.cfg.Backend)
+	}
+}
+
+// resolveWorkdir 工作目录必须位于工作区或可写路径内
+func (s *Sandbox) resolveWorkdir(workdir string) (string, error) {
+	if workdir == "" {
+		workdir = s.workspace
+	}
+	if !filepath.IsAbs(workdir) {
+		workdir = filepath.Join(s.workspace, workdir)
+	}
+	workdir = filepath.Clean(workdir)
+
+	for _, root := range append([]string{s.workspace}, s.cfg.WritablePaths...) {
+		if withinDir(workdir, root) {
+			return workdir, nil
+		}
+	}
+	return "", fmt.Errorf("workdir %s is outside the sandbox workspace", workdir)
+}
+
+// environ 清理后的环境变量：只保留白名单中的主机变量和显式传入的变量
+func (s *Sandbox) environ(workdir string, extra map[string]string) []string {
+	env := []string{
+		"PATH=" + sandboxPath,
+		"HOME=" + s.workspace,
+		"PWD=" + workdir,
+		"TMPDIR=/tmp",
+	}
+	for _, name := range append(append([]string{}, defaultSandboxEnv...), s.cfg.Env...) {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	for k, v := range extra {
+		env = append(env, k+"="+v)
+	}
+	return env
+}
+
+// readOnlyPaths 沙箱内只读可见的主机路径
+func (s *Sandbox) readOnlyPaths() []string {
+	paths := append(append([]string{}, defaultSandboxReadOnly...), s.cfg.ReadOnlyPaths...)
+	existing := paths[:0]
+	for _, p := range paths {
+		if _, err := os.Lstat(p); err == nil {
+			existing = append(existing, filepath.Clean(p))
+		}
+	}
+	return existing
+}
+
+// writablePaths 沙箱内可写的主机路径
+func (s *Sandbox) writablePaths() []string {
+	paths := []string{s.workspace}
+	for _, p := range s.cfg.WritablePaths {
+		if _, err := os.Stat(p); err == nil {
+			paths = append(paths, filepath.Clean(p))
+		}
+	}
+	return paths
+}
+
+// withinDir 判断 p 是否在 dir 内 (含 dir 本身)
+func withinDir(p, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), p)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// hostCommand 直接在主机上运行 command
+func hostCommand(ctx context.Context, command, workdir string, env map[string]string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = workdir
+	if len(env) > 0 {
+		cmdEnv := os.Environ()
+		for k, v := range env {
+			cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", k, v))
+		}
+		cmd.Env = cmdEnv
+	}
+	return cmd
+}
@@ -0,0 +1,598 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// 沙箱通过重新执行当前程序实现 (与 runc 的 reexec 相同)：
+// 子进程在新的 user/mount/pid/uts/ipc/net 命名空间中以 sandboxInitArg 启动，
+// 搭建只读根文件系统后 pivot_root，丢弃全部 capabilities，再 exec sh -c。
+
+const (
+	sandboxInitArg  = "openclaw-sandbox-init"
+	sandboxProbeArg = "openclaw-sandbox-probe"
+)
+
+func init() {
+	if len(os.Args) == 0 {
+		return
+	}
+	switch os.Args[0] {
+	case sandboxInitArg:
+		runSandboxInit()
+	case sandboxProbeArg:
+		os.Exit(0)
+	}
+}
+
+// sandboxSpec 传给沙箱 init 进程的参数 (通过 fd 3)
+type sandboxSpec struct {
+	Root     string   `json:"root"`
+	Workdir  string   `json:"workdir"`
+	ReadOnly []string `json:"readOnly"`
+	Writable []string `json:"writable"`
+	Command  string   `json:"command"`
+}
+
+var nsProbe struct {
+	once sync.Once
+	err  error
+}
+
+// namespacesSupported 检查当前系统能否创建沙箱所需的命名空间
+func namespacesSupported() error {
+	nsProbe.once.Do(func() {
+		cmd := exec.Command("/proc/self/exe")
+		cmd.Args = []string{sandboxProbeArg}
+		cmd.Env = []string{}
+		cmd.SysProcAttr = namespaceAttrs(false)
+		if err := cmd.Run(); err != nil {
+			nsProbe.err = fmt.Errorf("linux namespaces unavailable: %w", err)
+		}
+	})
+	return nsProbe.err
+}
+
+// namespaceAttrs 命名空间配置：沙箱内的 root 映射为当前用户
+func namespaceAttrs(network bool) *syscall.SysProcAttr {
+	flags := syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+		syscall.CLONE_NEWUTS | syscall.CLONE_NEWIPC
+	if !network {
+		flags |= syscall.CLONE_NEWNET
+	}
+	return &syscall.SysProcAttr{
+		Cloneflags:  uintptr(flags),
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}},
+		Pdeathsig:   syscall.SIGKILL,
+	}
+}
+
+// namespaceCommand 构建通过命名空间隔离运行的命令
+func (s *Sandbox) namespaceCommand(ctx context.Context, command, workdir string, env []string) (*exec.Cmd, func(), error) {
+	if err := namespacesSupported(); err != nil {
+		return nil, nil, err
+	}
+
+	root, err := os.MkdirTemp("", "openclaw-sandbox-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("sandbox root: %w", err)
+	}
+	specFile, err := writeSandboxSpec(sandboxSpec{
+		Root:     root,
+		Workdir:  workdir,
+		ReadOnly: s.readOnlyPaths(),
+		Writable: s.writablePaths(),
+		Command:  command,
+	})
+	if err != nil {
+		os.Remove(root)
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "/proc/self/exe")
+	cmd.Args = []string{sandboxInitArg}
+	cmd.Dir = "/"
+	cmd.Env = env
+	cmd.ExtraFiles = []*os.File{specFile}
+	cmd.SysProcAttr = namespaceAttrs(s.cfg.Network)
+
+	// 配置了资源限制但无法应用时不运行命令，避免在没有限制的情况下执行
+	cg, err := newSandboxCgroup(s.cfg)
+	if err != nil {
+		specFile.Close()
+		os.Remove(root)
+		return nil, nil, fmt.Errorf("sandbox resource limits: %w", err)
+	}
+	if cg != nil {
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = cg.fd
+	}
+
+	cleanup := func() {
+		specFile.Close()
+		if cg != nil {
+			cg.remove()
+		}
+		os.Remove(root)
+	}
+	return cmd, cleanup, nil
+}
+
+// writeSandboxSpec 写入已删除的临时文件，子进程从继承的 fd 读取
+func writeSandboxSpec(spec sandboxSpec) (*os.File, error) {
+	f, err := os.CreateTemp("", "openclaw-sandbox-spec-")
+	if err != nil {
+		return nil, fmt.Errorf("sandbox spec: %w", err)
+	}
+	os.Remove(f.Name())
+	if err := json.NewEncoder(f).Encode(spec); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("sandbox spec: %w", err)
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("sandbox spec: %w", err)
+	}
+	return f, nil
+}
+
+// runSandboxInit 沙箱内的 init：搭建文件系统后 exec 目标命令，失败时以 126 退出
+func runSandboxInit() {
+	runtime.LockOSThread()
+	if err := sandboxInit(); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: %v\n", err)
+		os.Exit(126)
+	}
+}
+
+func sandboxInit() error {
+	f := os.NewFile(3, "sandbox-spec")
+	var spec sandboxSpec
+	err := json.NewDecoder(f).Decode(&spec)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("read spec: %w", err)
+	}
+	root := spec.Root
+
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("make mounts private: %w", err)
+	}
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=0755"); err != nil {
+		return fmt.Errorf("mount root: %w", err)
+	}
+
+	// /proc /dev /tmp
+	if err := os.MkdirAll(filepath.Join(root, "proc"), 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("proc", filepath.Join(root, "proc"), "proc", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, ""); err != nil {
+		return fmt.Errorf("mount /proc: %w", err)
+	}
+	if err := setupSandboxDev(root); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(root, "tmp"), 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", filepath.Join(root, "tmp"), "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777"); err != nil {
+		return fmt.Errorf("mount /tmp: %w", err)
+	}
+
+	for _, p := range spec.ReadOnly {
+		if err := bindIntoRoot(root, p, true); err != nil {
+			return err
+		}
+	}
+	for _, p := range spec.Writable {
+		if err := bindIntoRoot(root, p, false); err != nil {
+			return err
+		}
+	}
+
+	// 切换根目录并卸载主机文件系统
+	oldRoot := filepath.Join(root, ".oldroot")
+	if err := os.MkdirAll(oldRoot, 0700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, oldRoot); err != nil {
+		return fmt.Errorf("pivot_root: %w", err)
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Unmount("/.oldroot", syscall.MNT_DETACH); err != nil {
+		return fmt.Errorf("detach host root: %w", err)
+	}
+	os.Remove("/.oldroot")
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return fmt.Errorf("remount root read-only: %w", err)
+	}
+
+	syscall.Sethostname([]byte("sandbox"))
+	if err := syscall.Chdir(spec.Workdir); err != nil {
+		return fmt.Errorf("chdir %s: %w", spec.Workdir, err)
+	}
+	if err := dropCapabilities(); err != nil {
+		return err
+	}
+	return syscall.Exec("/bin/sh", []string{"sh", "-c", spec.Command}, os.Environ())
+}
+
+// setupSandboxDev 最小的 /dev
+func setupSandboxDev(root string) error {
+	dev := filepath.Join(root, "dev")
+	if err := os.MkdirAll(dev, 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", dev, "tmpfs", syscall.MS_NOSUID|syscall.MS_NOEXEC, "mode=0755"); err != nil {
+		return fmt.Errorf("mount /dev: %w", err)
+	}
+	for _, name := range []string{"null", "zero", "full", "random", "urandom", "tty"} {
+		src := "/dev/" + name
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		dst := filepath.Join(dev, name)
+		if err := os.WriteFile(dst, nil, 0666); err != nil {
+			return err
+		}
+		if err := syscall.Mount(src, dst, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind %s: %w", src, err)
+		}
+	}
+	links := map[string]string{
+		"fd":     "/proc/self/fd",
+		"stdin":  "/proc/self/fd/0",
+		"stdout": "/proc/self/fd/1",
+		"stderr": "/proc/self/fd/2",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dev, name)); err != nil {
+			return err
+		}
+	}
+	// PTY 会话的终端来自主机的 devpts
+	if info, err := os.Stat("/dev/pts"); err == nil && info.IsDir() {
+		pts := filepath.Join(dev, "pts")
+		if err := os.MkdirAll(pts, 0755); err != nil {
+			return err
+		}
+		if err := syscall.Mount("/dev/pts", pts, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind /dev/pts: %w", err)
+		}
+		if err := os.Symlink("pts/ptmx", filepath.Join(dev, "ptmx")); err != nil {
+			return err
+		}
+	}
+	shm := filepath.Join(dev, "shm")
+	if err := os.MkdirAll(shm, 01777); err != nil {
+		return err
+	}
+	return syscall.Mount("tmpfs", shm, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "mode=1777")
+}
+
+// bindIntoRoot 将主机路径 src 绑定到沙箱根目录下的同一位置
+func bindIntoRoot(root, src string, readOnly bool) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return nil
+	}
+	dst := filepath.Join(root, src)
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		// 相对链接 (如 /bin -> usr/bin) 原样保留，绝对链接绑定其目标
+		target, err := os.Readlink(src)
+		if err == nil && !filepath.IsAbs(target) {
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return err
+			}
+			if _, err := os.Lstat(dst); err == nil {
+				return nil
+			}
+			return os.Symlink(target, dst)
+		}
+		if src, err = filepath.EvalSymlinks(src); err != nil {
+			return nil
+		}
+		if info, err = os.Stat(src); err != nil {
+			return nil
+		}
+	}
+
+	if info.IsDir() {
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			if err := os.WriteFile(dst, nil, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := syscall.Mount(src, dst, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("bind %s: %w", src, err)
+	}
+	if !readOnly {
+		return nil
+	}
+
+	// 重新挂载为只读时必须保留原有的 nosuid/nodev 等标志，否则在 user namespace 中会被拒绝
+	var st syscall.Statfs_t
+	flags := uintptr(syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY)
+	if err := syscall.Statfs(dst, &st); err == nil {
+		keep := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+			syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME)
+		flags |= uintptr(st.Flags) & keep
+	}
+	if err := syscall.Mount("", dst, "", flags, ""); err != nil {
+		return fmt.Errorf("remount %s read-only: %w", src, err)
+	}
+	return nil
+}
+
+// Linux capability 相关常量
+const (
+	prCapBSetDrop        = 24
+	prSetNoNewPrivs      = 38
+	prCapAmbient         = 47
+	prCapAmbientClearAll = 4
+	linuxCapabilityV3    = 0x20080522
+	maxCapability        = 63
+)
+
+// dropCapabilities 清空 bounding/ambient/inheritable 集合并设置 no_new_privs，
+// exec 后沙箱内的 root 不再拥有任何 capability (无法重新挂载或逃逸)
+func dropCapabilities() error {
+	for c := 0; c <= maxCapability; c++ {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapBSetDrop, uintptr(c), 0); errno != 0 && errno != syscall.EINVAL {
+			return fmt.Errorf("drop capability %d: %w", c, errno)
+		}
+	}
+	syscall.RawSyscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientClearAll, 0, 0, 0, 0)
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0, 0, 0, 0); errno != 0 {
+		return fmt.Errorf("set no_new_privs: %w", errno)
+	}
+
+	header := struct {
+		version uint32
+		pid     int32
+	}{version: linuxCapabilityV3}
+	var data [2]struct {
+		effective   uint32
+		permitted   uint32
+		inheritable uint32
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return fmt.Errorf("capset: %w", errno)
+	}
+	return nil
+}
+
+// sandboxCgroup 单次运行的 cgroup v2
+type sandboxCgroup struct {
+	dir string
+	fd  int
+}
+
+// newSandboxCgroup 按配置创建带资源限制的 cgroup；未配置限制时返回 nil
+func newSandboxCgroup(cfg config.SandboxConfig) (*sandboxCgroup, error) {
+	var controllers []string
+	if cfg.MemoryMB > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if cfg.CPUs > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if cfg.Pids > 0 {
+		controllers = append(controllers, "pids")
+	}
+	if len(controllers) == 0 {
+		return nil, nil
+	}
+
+	mountinfo, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	mnt := cgroup2Mount(string(mountinfo))
+	if mnt == "" {
+		return nil, fmt.Errorf("cgroup v2 is not mounted")
+	}
+
+	base, err := delegatedCgroup(mnt)
+	if err != nil {
+		return nil, err
+	}
+	parent := filepath.Join(base, "openclaw-sandbox")
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+	if err := enableDelegatedControllers(base, controllers); err != nil {
+		return nil, err
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(parent, "run-")
+	if err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+	cg := &sandboxCgroup{dir: dir, fd: -1}
+	for file, value := range cgroupLimits(cfg) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			cg.remove()
+			return nil, fmt.Errorf("set %s: %w", file, err)
+		}
+	}
+	// 禁止使用 swap 绕过内存限制 (内核未启用 swap 记账时忽略)
+	if cfg.MemoryMB > 0 {
+		os.WriteFile(filepath.Join(dir, "memory.swap.max"), []byte("0"), 0644)
+	}
+
+	fd, err := syscall.Open(dir, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		cg.remove()
+		return nil, fmt.Errorf("open cgroup: %w", err)
+	}
+	cg.fd = fd
+	return cg, nil
+}
+
+// ownCgroup 记录进程启动时所在的 cgroup；启用控制器时进程可能被移到子 cgroup 中，之后不能再从 /proc/self/cgroup 读取
+var ownCgroup struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// delegatedCgroup 返回当前进程所在的 cgroup 目录。非 root 用户只能写入委派给自己的 cgroup
+// (如 systemd-run --user -p Delegate=yes 启动的服务)，沙箱的 cgroup 创建在其下
+func delegatedCgroup(mnt string) (string, error) {
+	ownCgroup.once.Do(func() {
+		data, err := os.ReadFile("/proc/self/cgroup")
+		if err != nil {
+			ownCgroup.err = err
+			return
+		}
+		path, ok := cgroup2Path(string(data))
+		if !ok {
+			ownCgroup.err = fmt.Errorf("process is not in a cgroup v2 hierarchy")
+			return
+		}
+		ownCgroup.path = filepath.Join(mnt, path)
+	})
+	return ownCgroup.path, ownCgroup.err
+}
+
+// cgroup2Path 从 /proc/self/cgroup 中读取 cgroup v2 的路径 ("0::/user.slice/...")
+func cgroup2Path(data string) (string, bool) {
+	for _, line := range strings.Split(data, "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// enableDelegatedControllers 在进程自己的 cgroup 中启用控制器。cgroup v2 不允许在包含进程的非根 cgroup 中
+// 启用子树控制器，此时先将其中的进程 (即 gateway 自身) 移到叶子 cgroup openclaw-gateway 中
+func enableDelegatedControllers(dir string, controllers []string) error {
+	err := enableControllers(dir, controllers)
+	if !errors.Is(err, syscall.EBUSY) {
+		return err
+	}
+	leaf := filepath.Join(dir, "openclaw-gateway")
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		return fmt.Errorf("create cgroup: %w", err)
+	}
+	procs, err := os.ReadFile(filepath.Join(dir, "cgroup.procs"))
+	if err != nil {
+		return fmt.Errorf("read cgroup processes: %w", err)
+	}
+	for _, pid := range strings.Fields(string(procs)) {
+		if err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(pid), 0644); err != nil {
+			return fmt.Errorf("move process %s to %s: %w", pid, leaf, err)
+		}
+	}
+	return enableControllers(dir, controllers)
+}
+
+// cgroupLimits 配置对应的 cgroup v2 接口文件
+func cgroupLimits(cfg config.SandboxConfig) map[string]string {
+	limits := make(map[string]string)
+	if cfg.MemoryMB > 0 {
+		limits["memory.max"] = strconv.FormatInt(int64(cfg.MemoryMB)*1024*1024, 10)
+	}
+	if cfg.CPUs > 0 {
+		const period = 100000
+		quota := int64(cfg.CPUs * period)
+		if quota < 1000 {
+			quota = 1000
+		}
+		limits["cpu.max"] = fmt.Sprintf("%d %d", quota, period)
+	}
+	if cfg.Pids > 0 {
+		limits["pids.max"] = strconv.Itoa(cfg.Pids)
+	}
+	return limits
+}
+
+// enableControllers 在 dir 的子树中启用控制器
+func enableControllers(dir string, controllers []string) error {
+	available, err := os.ReadFile(filepath.Join(dir, "cgroup.controllers"))
+	if err != nil {
+		return fmt.Errorf("read cgroup controllers: %w", err)
+	}
+	have := make(map[string]bool)
+	for _, c := range strings.Fields(string(available)) {
+		have[c] = true
+	}
+	var enable []string
+	for _, c := range controllers {
+		if !have[c] {
+			return fmt.Errorf("cgroup controller %q is not available in %s", c, dir)
+		}
+		enable = append(enable, "+"+c)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(strings.Join(enable, " ")), 0644); err != nil {
+		return fmt.Errorf("enable cgroup controllers in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// cgroup2Mount 从 /proc/self/mountinfo 中找到 cgroup2 的挂载点
+func cgroup2Mount(mountinfo string) string {
+	scanner := bufio.NewScanner(strings.NewReader(mountinfo))
+	for scanner.Scan() {
+		line := scanner.Text()
+		sep := strings.Index(line, " - ")
+		if sep < 0 {
+			continue
+		}
+		fields := strings.Fields(line[:sep])
+		post := strings.Fields(line[sep+3:])
+		if len(fields) >= 5 && len(post) > 0 && post[0] == "cgroup2" {
+			return fields[4]
+		}
+	}
+	return ""
+}
+
+// remove 杀死 cgroup 中残留的进程并删除 cgroup
+func (c *sandboxCgroup) remove() {
+	if c.fd >= 0 {
+		syscall.Close(c.fd)
+		c.fd = -1
+	}
+	os.WriteFile(filepath.Join(c.dir, "cgroup.kill"), []byte("1"), 0644)
+	for i := 0; i < 50; i++ {
+		if err := os.Remove(c.dir); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func newTestNamespaceSandbox(t *testing.T, cfg config.SandboxConfig) (*ExecTool, string) {
+	t.Helper()
+	if err := namespacesSupported(); err != nil {
+		t.Skipf("namespaces unavailable: %v", err)
+	}
+	workspace := t.TempDir()
+	cfg.Mode = SandboxModeAll
+	tool := NewExecTool(workspace)
+	tool.SetSandbox(NewSandbox(cfg, workspace))
+	return tool, workspace
+}
+
+func runExec(t *testing.T, tool *ExecTool, params ExecParams) *Result {
+	t.Helper()
+	args, _ := json.Marshal(params)
+	result, err := tool.Execute(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestNamespaceSandbox_Filesystem(t *testing.T) {
+	tool, workspace := newTestNamespaceSandbox(t, config.SandboxConfig{})
+
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("top secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 工作区可写
+	result := runExec(t, tool, ExecParams{Command: "echo hello > out.txt && cat out.txt"})
+	if result.IsError || !strings.Contains(result.Content, "hello") {
+		t.Fatalf("workspace should be writable: %s", result.Content)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workspace, "out.txt")); string(data) != "hello\n" {
+		t.Errorf("write should reach the host workspace, got %q", data)
+	}
+
+	// 工作区外的主机文件不可见
+	result = runExec(t, tool, ExecParams{Command: "cat " + outside})
+	if !result.IsError || strings.Contains(result.Content, "top secret") {
+		t.Errorf("files outside the workspace should be hidden: %s", result.Content)
+	}
+
+	// 系统目录只读
+	result = runExec(t, tool, ExecParams{Command: "touch /usr/openclaw-test"})
+	if !result.IsError {
+		t.Errorf("/usr should be read-only: %s", result.Content)
+	}
+	if _, err := os.Stat("/usr/openclaw-test"); err == nil {
+		os.Remove("/usr/openclaw-test")
+		t.Error("sandbox wrote to the host /usr")
+	}
+}
+
+func TestNamespaceSandbox_Isolation(t *testing.T) {
+	tool, _ := newTestNamespaceSandbox(t, config.SandboxConfig{})
+
+	result := runExec(t, tool, ExecParams{Command: "hostname; grep CapEff /proc/self/status; cat /proc/net/dev | tail -n +3 | cut -d: -f1"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "sandbox") {
+		t.Errorf("expected sandbox hostname: %s", result.Content)
+	}
+	if !strings.Contains(result.Content, "CapEff:\t0000000000000000") {
+		t.Errorf("capabilities should be dropped: %s", result.Content)
+	}
+	for _, line := range strings.Split(result.Content, "\n") {
+		if iface := strings.TrimSpace(line); iface != "" && !strings.HasPrefix(iface, "CapEff") && iface != "sandbox" && iface != "lo" {
+			t.Errorf("unexpected network interface %q without network permission", iface)
+		}
+	}
+
+	// 超时后整个命名空间中的进程都被终止
+	start := time.Now()
+	result = runExec(t, tool, ExecParams{Command: "sleep 10 & sleep 10", Timeout: 1})
+	if !result.IsError || time.Since(start) > 5*time.Second {
+		t.Errorf("expected timeout within seconds, took %v: %s", time.Since(start), result.Content)
+	}
+}
+
+func TestNamespaceSandbox_Background(t *testing.T) {
+	tool, _ := newTestNamespaceSandbox(t, config.SandboxConfig{})
+	pt := NewProcessTool(tool.workdir)
+	tool.SetProcessTool(pt)
+
+	result := runExec(t, tool, ExecParams{Command: "echo $OPENCLAW_TEST_SECRET; hostname", Background: true})
+	if result.IsError || !strings.Contains(result.Content, "sandboxed background") {
+		t.Fatalf("unexpected result: %s", result.Content)
+	}
+
+	var session *ProcessSession
+	for _, s := range pt.sessions {
+		session = s
+	}
+	select {
+	case <-session.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("background session did not finish")
+	}
+	if !session.Sandboxed || !strings.Contains(string(session.output), "sandbox") {
+		t.Errorf("background session should run in the sandbox: %q", session.output)
+	}
+}
+
+func TestCgroup2Mount(t *testing.T) {
+	mountinfo := `32 24 0:28 / /sys/fs/cgroup rw,relatime - tmpfs tmpfs rw,mode=755
+36 32 0:32 / /sys/fs/cgroup/memory rw,relatime - cgroup cgroup rw,memory
+42 32 0:38 / /sys/fs/cgroup/unified rw,relatime shared:9 - cgroup2 cgroup2 rw
+`
+	if got := cgroup2Mount(mountinfo); got != "/sys/fs/cgroup/unified" {
+		t.Errorf("got %q", got)
+	}
+	if got := cgroup2Mount("24 1 0:22 / /proc rw - proc proc rw\n"); got != "" {
+		t.Errorf("expected no cgroup2 mount, got %q", got)
+	}
+}
+
+func TestCgroup2Path(t *testing.T) {
+	data := "12:memory:/user.slice\n1:name=systemd:/user.slice/user-1000.slice/user@1000.service/app.slice/openclaw.service\n0::/user.slice/user-1000.slice/user@1000.service/app.slice/openclaw.service\n"
+	if got, ok := cgroup2Path(data); !ok || got != "/user.slice/user-1000.slice/user@1000.service/app.slice/openclaw.service" {
+		t.Errorf("got %q, %v", got, ok)
+	}
+	if _, ok := cgroup2Path("4:memory:/docker/abc\n"); ok {
+		t.Error("expected no cgroup v2 path for a v1-only hierarchy")
+	}
+}
+
+func TestCgroupLimits(t *testing.T) {
+	limits := cgroupLimits(config.SandboxConfig{MemoryMB: 256, CPUs: 1.5, Pids: 64})
+	want := map[string]string{
+		"memory.max": "268435456",
+		"cpu.max":    "150000 100000",
+		"pids.max":   "64",
+	}
+	for k, v := range want {
+		if limits[k] != v {
+			t.Errorf("%s = %q, want %q", k, limits[k], v)
+		}
+	}
+	if len(cgroupLimits(config.SandboxConfig{})) != 0 {
+		t.Error("no limits expected without configuration")
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// namespacesSupported 命名空间沙箱仅支持 Linux
+func namespacesSupported() error {
+	return fmt.Errorf("namespace sandbox is not supported on %s (use backend \"command\")", runtime.GOOS)
+}
+
+func (s *Sandbox) namespaceCommand(ctx context.Context, command, workdir string, env []string) (*exec.Cmd, func(), error) {
+	return nil, nil, namespacesSupported()
+}
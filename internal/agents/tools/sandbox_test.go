@@ -0,0 +1,154 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestSandbox_Applies(t *testing.T) {
+	main := SessionContext{Key: "main", Kind: "main"}
+	group := SessionContext{Key: "telegram:-1001", Kind: "group"}
+	dm := SessionContext{Key: "telegram:42", Kind: "telegram"}
+
+	tests := []struct {
+		mode    string
+		session SessionContext
+		want    bool
+	}{
+		{"", group, false},
+		{SandboxModeOff, group, false},
+		{SandboxModeAll, main, true},
+		{SandboxModeNonMain, main, false},
+		{SandboxModeNonMain, SessionContext{}, false},
+		{SandboxModeNonMain, dm, true},
+		{SandboxModeNonMain, group, true},
+		{SandboxModeGroups, dm, false},
+		{SandboxModeGroups, group, true},
+	}
+	for _, tt := range tests {
+		sb := NewSandbox(config.SandboxConfig{Mode: tt.mode}, t.TempDir())
+		if got := sb.Applies(tt.session); got != tt.want {
+			t.Errorf("mode %q session %q: got %v, want %v", tt.mode, tt.session.Key, got, tt.want)
+		}
+	}
+
+	var nilSandbox *Sandbox
+	if nilSandbox.Applies(group) {
+		t.Error("nil sandbox should never apply")
+	}
+}
+
+func TestSandbox_SessionOverrides(t *testing.T) {
+	sb := NewSandbox(config.SandboxConfig{
+		Mode: SandboxModeNonMain,
+		Sessions: []config.SandboxSessionRule{
+			{Match: "telegram:42", Sandbox: false},
+			{Match: "cli*", Sandbox: true},
+		},
+	}, t.TempDir())
+
+	if sb.Applies(SessionContext{Key: "telegram:42", Kind: "telegram"}) {
+		t.Error("owner DM override should run on the host")
+	}
+	if !sb.Applies(SessionContext{Key: "telegram:43", Kind: "telegram"}) {
+		t.Error("other DMs should be sandboxed")
+	}
+	if !sb.Applies(SessionContext{Key: "cli", Kind: "main"}) {
+		t.Error("override should force the sandbox for main sessions")
+	}
+}
+
+func TestSandbox_Environ(t *testing.T) {
+	t.Setenv("OPENCLAW_TEST_SECRET", "hunter2")
+	t.Setenv("OPENCLAW_TEST_PASS", "visible")
+	workspace := t.TempDir()
+	sb := NewSandbox(config.SandboxConfig{Env: []string{"OPENCLAW_TEST_PASS"}}, workspace)
+
+	env := strings.Join(sb.environ(workspace, map[string]string{"EXTRA": "1"}), "\n")
+	if strings.Contains(env, "hunter2") {
+		t.Error("host environment should be scrubbed")
+	}
+	for _, want := range []string{"OPENCLAW_TEST_PASS=visible", "EXTRA=1", "HOME=" + workspace, "PATH=" + sandboxPath} {
+		if !strings.Contains(env, want) {
+			t.Errorf("expected %q in environment:\n%s", want, env)
+		}
+	}
+}
+
+func TestSandbox_ResolveWorkdir(t *testing.T) {
+	workspace := t.TempDir()
+	extra := t.TempDir()
+	sb := NewSandbox(config.SandboxConfig{WritablePaths: []string{extra}}, workspace)
+
+	for _, dir := range []string{"", "sub", workspace, filepath.Join(workspace, "a/b"), extra} {
+		if _, err := sb.resolveWorkdir(dir); err != nil {
+			t.Errorf("workdir %q should be allowed: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{"/", "..", filepath.Join(workspace, "../other"), workspace + "-evil"} {
+		if _, err := sb.resolveWorkdir(dir); err == nil {
+			t.Errorf("workdir %q should be rejected", dir)
+		}
+	}
+}
+
+func TestSandbox_Validate(t *testing.T) {
+	if err := NewSandbox(config.SandboxConfig{Mode: "sometimes"}, t.TempDir()).Validate(); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if err := NewSandbox(config.SandboxConfig{Mode: SandboxModeAll, Backend: SandboxBackendCommand}, t.TempDir()).Validate(); err == nil {
+		t.Error("expected error for command backend without command")
+	}
+	if err := NewSandbox(config.SandboxConfig{Mode: SandboxModeAll, Backend: "vm"}, t.TempDir()).Validate(); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestExecTool_SandboxPerSession(t *testing.T) {
+	t.Setenv("OPENCLAW_TEST_SECRET", "hunter2")
+	workspace := t.TempDir()
+
+	// 外部沙箱命令后端：用 env 作为最简单的“沙箱程序”验证命令组装和环境清理
+	tool := NewExecTool(workspace)
+	tool.SetSandbox(NewSandbox(config.SandboxConfig{
+		Mode:    SandboxModeGroups,
+		Backend: SandboxBackendCommand,
+		Command: []string{"env", "SANDBOX_ROOT={workspace}"},
+	}, workspace))
+
+	args, _ := json.Marshal(ExecParams{Command: "echo secret=$OPENCLAW_TEST_SECRET root=$SANDBOX_ROOT"})
+
+	groupCtx := WithSession(context.Background(), SessionContext{Key: "telegram:-1001", Kind: "group"})
+	result, err := tool.Execute(groupCtx, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content)
+	}
+	if strings.Contains(result.Content, "hunter2") || !strings.Contains(result.Content, "root="+workspace) {
+		t.Errorf("group session should run in the sandbox, got: %s", result.Content)
+	}
+
+	mainCtx := WithSession(context.Background(), SessionContext{Key: "main", Kind: "main"})
+	result, err = tool.Execute(mainCtx, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(result.Content, "secret=hunter2") {
+		t.Errorf("main session should run on the host, got: %s", result.Content)
+	}
+
+	// 沙箱内不允许离开工作区
+	args, _ = json.Marshal(ExecParams{Command: "pwd", Workdir: os.TempDir()})
+	result, _ = tool.Execute(groupCtx, args)
+	if !result.IsError || !strings.Contains(result.Content, "outside the sandbox workspace") {
+		t.Errorf("expected workdir rejection, got: %s", result.Content)
+	}
+}
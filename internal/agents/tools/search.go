@@ -0,0 +1,475 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// SearchProvider 网页搜索后端
+type SearchProvider interface {
+	// Name 返回后端名称 (用于日志和结果标注)
+	Name() string
+
+	// Search 执行搜索
+	Search(ctx context.Context, query SearchQuery) ([]SearchResult, error)
+}
+
+// SearchQuery 搜索请求
+type SearchQuery struct {
+	Query     string
+	Count     int
+	Country   string
+	Language  string
+	Freshness string // pd | pw | pm | py
+}
+
+// SearchResult 单条搜索结果
+type SearchResult struct {
+	Title     string `json:"title"`
+	URL       string `json:"url"`
+	Snippet   string `json:"snippet"`
+	Published string `json:"published,omitempty"`
+}
+
+// NewSearchProvider 根据配置创建搜索后端
+func NewSearchProvider(cfg config.SearchProviderConfig) (SearchProvider, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	apiKey := cfg.APIKey
+	if apiKey == "" && cfg.APIKeyEnv != "" {
+		apiKey = os.Getenv(cfg.APIKeyEnv)
+	}
+
+	switch cfg.Type {
+	case "brave":
+		if apiKey == "" {
+			apiKey = braveAPIKeyFromEnv()
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("brave: BRAVE_API_KEY not set")
+		}
+		p := NewBraveProvider(apiKey)
+		if cfg.BaseURL != "" {
+			p.baseURL = cfg.BaseURL
+		}
+		return p, nil
+	case "searxng":
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("searxng: baseUrl is required")
+		}
+		return &SearXNGProvider{baseURL: strings.TrimRight(cfg.BaseURL, "/"), apiKey: apiKey, httpClient: client}, nil
+	case "tavily":
+		if apiKey == "" {
+			apiKey = os.Getenv("TAVILY_API_KEY")
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("tavily: TAVILY_API_KEY not set")
+		}
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.tavily.com"
+		}
+		return &TavilyProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, httpClient: client}, nil
+	case "json":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("json: url is required")
+		}
+		return &JSONSearchProvider{cfg: cfg, apiKey: apiKey, httpClient: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown search provider type: %q", cfg.Type)
+	}
+}
+
+func braveAPIKeyFromEnv() string {
+	apiKey := os.Getenv("BRAVE_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("BRAVE_SEARCH_API_KEY")
+	}
+	return apiKey
+}
+
+// ==================== Brave ====================
+
+// BraveProvider Brave Search API
+type BraveProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+type BraveSearchResponse struct {
+	Web struct {
+		Results []struct {
+			Title       string `json:"title"`
+			URL         string `json:"url"`
+			Description string `json:"description"`
+			Age         string `json:"age,omitempty"`
+		} `json:"results"`
+	} `json:"web"`
+}
+
+func NewBraveProvider(apiKey string) *BraveProvider {
+	return &BraveProvider{
+		baseURL:    "https://api.search.brave.com/res/v1/web/search",
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *BraveProvider) Name() string {
+	return "brave"
+}
+
+func (p *BraveProvider) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	u, err := url.Parse(p.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("q", query.Query)
+	q.Set("count", fmt.Sprintf("%d", query.Count))
+	if query.Country != "" {
+		q.Set("country", query.Country)
+	}
+	if query.Language != "" {
+		q.Set("search_lang", query.Language)
+	}
+	if query.Freshness != "" {
+		q.Set("freshness", query.Freshness)
+	}
+	u.RawQuery = q.Encode()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Subscription-Token", p.apiKey)
+
+	var searchResp BraveSearchResponse
+	if err := doSearchRequest(p.httpClient, req, &searchResp); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(searchResp.Web.Results))
+	for _, r := range searchResp.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description, Published: r.Age})
+	}
+	return results, nil
+}
+
+// ==================== SearXNG ====================
+
+// SearXNGProvider 自建 SearXNG 实例 (需开启 json 输出格式)
+type SearXNGProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func (p *SearXNGProvider) Name() string {
+	return "searxng"
+}
+
+func (p *SearXNGProvider) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	q := url.Values{}
+	q.Set("q", query.Query)
+	q.Set("format", "json")
+	if query.Language != "" {
+		q.Set("language", query.Language)
+	}
+	if timeRange := freshnessName(query.Freshness); timeRange != "" {
+		q.Set("time_range", timeRange)
+	}
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/search?"+q.Encode(), nil)
+	req.Header.Set("Accept", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	var searchResp struct {
+		Results []struct {
+			Title         string `json:"title"`
+			URL           string `json:"url"`
+			Content       string `json:"content"`
+			PublishedDate string `json:"publishedDate,omitempty"`
+		} `json:"results"`
+	}
+	if err := doSearchRequest(p.httpClient, req, &searchResp); err != nil {
+		return nil, err
+	}
+
+	var results []SearchResult
+	for _, r := range searchResp.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content, Published: r.PublishedDate})
+		if len(results) >= query.Count {
+			break
+		}
+	}
+	return results, nil
+}
+
+// ==================== Tavily ====================
+
+// TavilyProvider Tavily 风格的 JSON 搜索 API (POST /search)
+type TavilyProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func (p *TavilyProvider) Name() string {
+	return "tavily"
+}
+
+func (p *TavilyProvider) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	body := map[string]interface{}{
+		"query":       query.Query,
+		"max_results": query.Count,
+	}
+	if days := freshnessDays(query.Freshness); days > 0 {
+		body["days"] = days
+		body["topic"] = "news"
+	}
+	data, _ := json.Marshal(body)
+
+	req, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/search", bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	var searchResp struct {
+		Results []struct {
+			Title         string `json:"title"`
+			URL           string `json:"url"`
+			Content       string `json:"content"`
+			PublishedDate string `json:"published_date,omitempty"`
+		} `json:"results"`
+	}
+	if err := doSearchRequest(p.httpClient, req, &searchResp); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(searchResp.Results))
+	for _, r := range searchResp.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content, Published: r.PublishedDate})
+	}
+	return results, nil
+}
+
+// ==================== Generic JSON ====================
+
+// JSONSearchProvider 通用 JSON 搜索接口，URL/请求体模板和结果字段路径由配置给出。
+// 模板变量: {query} {count} {country} {language} {freshness}
+type JSONSearchProvider struct {
+	cfg        config.SearchProviderConfig
+	apiKey     string
+	httpClient *http.Client
+}
+
+func (p *JSONSearchProvider) Name() string {
+	if p.cfg.Name != "" {
+		return p.cfg.Name
+	}
+	return "json"
+}
+
+func (p *JSONSearchProvider) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	expand := func(tmpl string, escape func(string) string) string {
+		return strings.NewReplacer(
+			"{query}", escape(query.Query),
+			"{count}", fmt.Sprintf("%d", query.Count),
+			"{country}", escape(query.Country),
+			"{language}", escape(query.Language),
+			"{freshness}", escape(query.Freshness),
+		).Replace(tmpl)
+	}
+	jsonEscape := func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data[1 : len(data)-1])
+	}
+
+	method := strings.ToUpper(p.cfg.Method)
+	if method == "" {
+		method = "GET"
+	}
+	var body io.Reader
+	if p.cfg.Body != "" {
+		body = strings.NewReader(expand(p.cfg.Body, jsonEscape))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, expand(p.cfg.URL, url.QueryEscape), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, strings.ReplaceAll(v, "{apiKey}", p.apiKey))
+	}
+
+	var raw interface{}
+	if err := doSearchRequest(p.httpClient, req, &raw); err != nil {
+		return nil, err
+	}
+
+	items, _ := jsonPath(raw, p.cfg.ResultsPath).([]interface{})
+	field := func(item interface{}, path, fallback string) string {
+		if path == "" {
+			path = fallback
+		}
+		if s, ok := jsonPath(item, path).(string); ok {
+			return s
+		}
+		return ""
+	}
+
+	var results []SearchResult
+	for _, item := range items {
+		r := SearchResult{
+			Title:   field(item, p.cfg.TitleField, "title"),
+			URL:     field(item, p.cfg.URLField, "url"),
+			Snippet: field(item, p.cfg.SnippetField, "snippet"),
+		}
+		if r.URL == "" {
+			continue
+		}
+		results = append(results, r)
+		if len(results) >= query.Count {
+			break
+		}
+	}
+	return results, nil
+}
+
+// jsonPath 按点分路径取值 (如 "data.items")，空路径返回自身
+func jsonPath(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+// ==================== 公共辅助 ====================
+
+// doSearchRequest 发送请求并解析 JSON 响应
+func doSearchRequest(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("API error %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse search results: %w", err)
+	}
+	return nil
+}
+
+// freshnessName Brave 风格的时间过滤 (pd/pw/pm/py) 转为 day/week/month/year
+func freshnessName(freshness string) string {
+	switch freshness {
+	case "pd":
+		return "day"
+	case "pw":
+		return "week"
+	case "pm":
+		return "month"
+	case "py":
+		return "year"
+	}
+	return ""
+}
+
+// freshnessDays 时间过滤转为天数
+func freshnessDays(freshness string) int {
+	switch freshness {
+	case "pd":
+		return 1
+	case "pw":
+		return 7
+	case "pm":
+		return 30
+	case "py":
+		return 365
+	}
+	return 0
+}
+
+// ==================== 结果缓存 ====================
+
+// searchCache 按查询参数缓存搜索结果
+type searchCache struct {
+	ttl     time.Duration
+	entries map[string]searchCacheEntry
+	mu      sync.Mutex
+}
+
+type searchCacheEntry struct {
+	provider  string
+	results   []SearchResult
+	expiresAt time.Time
+}
+
+func newSearchCache(ttl time.Duration) *searchCache {
+	return &searchCache{ttl: ttl, entries: make(map[string]searchCacheEntry)}
+}
+
+// cacheKey 缓存键：查询词 (忽略大小写和多余空白) + 过滤条件
+func (q SearchQuery) cacheKey() string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(q.Query), " "))
+	return fmt.Sprintf("%s|%d|%s|%s|%s", normalized, q.Count, q.Country, q.Language, q.Freshness)
+}
+
+func (c *searchCache) get(key string) (searchCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return entry, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return entry, false
+	}
+	return entry, true
+}
+
+func (c *searchCache) put(key, provider string, results []SearchResult) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// 顺便清理过期条目，避免无限增长
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = searchCacheEntry{provider: provider, results: results, expiresAt: now.Add(c.ttl)}
+}
@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestSearXNGProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" || r.URL.Query().Get("time_range") != "week" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"results": [
+			{"title": "Go 1.24", "url": "https://go.dev/blog", "content": "Release notes"},
+			{"title": "Other", "url": "https://example.com", "content": "..."}
+		]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewSearchProvider(config.SearchProviderConfig{Type: "searxng", BaseURL: server.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := provider.Search(context.Background(), SearchQuery{Query: "go release", Count: 1, Freshness: "pw"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].URL != "https://go.dev/blog" || results[0].Snippet != "Release notes" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestTavilyProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != "POST" || r.Header.Get("Authorization") != "Bearer tvly-key" || body["query"] != "weather" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"results": [{"title": "Forecast", "url": "https://weather.example", "content": "Sunny"}]}`))
+	}))
+	defer server.Close()
+
+	provider, _ := NewSearchProvider(config.SearchProviderConfig{Type: "tavily", BaseURL: server.URL, APIKey: "tvly-key"})
+	results, err := provider.Search(context.Background(), SearchQuery{Query: "weather", Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Title != "Forecast" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+func TestJSONSearchProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("q") != "a b" || r.Header.Get("X-Key") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data": {"items": [{"name": "Result", "link": {"href": "https://r.example"}, "summary": "text"}]}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewSearchProvider(config.SearchProviderConfig{
+		Type:         "json",
+		Name:         "internal",
+		URL:          server.URL + "/api?q={query}&n={count}",
+		APIKey:       "secret",
+		Headers:      map[string]string{"X-Key": "{apiKey}"},
+		ResultsPath:  "data.items",
+		TitleField:   "name",
+		URLField:     "link.href",
+		SnippetField: "summary",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := provider.Search(context.Background(), SearchQuery{Query: "a b", Count: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "internal" || len(results) != 1 || results[0].URL != "https://r.example" || results[0].Title != "Result" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+}
+
+// countingProvider 记录调用次数的搜索后端
+type countingProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *countingProvider) Name() string { return p.name }
+func (p *countingProvider) Search(ctx context.Context, query SearchQuery) ([]SearchResult, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []SearchResult{{Title: "Hit from " + p.name, URL: "https://example.com", Snippet: query.Query}}, nil
+}
+
+func TestWebSearchTool_FallbackAndCache(t *testing.T) {
+	failing := &countingProvider{name: "primary", err: context.DeadlineExceeded}
+	backup := &countingProvider{name: "backup"}
+
+	tool := NewWebSearchTool()
+	tool.SetProviders(failing, backup)
+
+	args, _ := json.Marshal(WebSearchParams{Query: "Go  Generics", Freshness: "pm"})
+	result, _ := tool.Execute(context.Background(), args)
+	if result.IsError || !strings.Contains(result.Content, "Hit from backup") || !strings.Contains(result.Content, "Source: backup") {
+		t.Fatalf("expected fallback result, got: %s", result.Content)
+	}
+
+	// 相同查询 (忽略大小写和空白) 命中缓存
+	args, _ = json.Marshal(WebSearchParams{Query: "go generics", Freshness: "pm"})
+	result, _ = tool.Execute(context.Background(), args)
+	if !strings.Contains(result.Content, "(cached)") || backup.calls != 1 || failing.calls != 1 {
+		t.Errorf("expected cached result, got %q (calls %d/%d)", result.Content, failing.calls, backup.calls)
+	}
+
+	// 不同 freshness 不共享缓存
+	args, _ = json.Marshal(WebSearchParams{Query: "go generics", Freshness: "pd"})
+	tool.Execute(context.Background(), args)
+	if backup.calls != 2 {
+		t.Errorf("expected freshness to be part of cache key, calls %d", backup.calls)
+	}
+}
+
+func TestWebSearchTool_AllProvidersFail(t *testing.T) {
+	tool := NewWebSearchTool()
+	tool.SetProviders(&countingProvider{name: "a", err: context.Canceled}, &countingProvider{name: "b", err: context.Canceled})
+
+	args, _ := json.Marshal(WebSearchParams{Query: "x"})
+	result, _ := tool.Execute(context.Background(), args)
+	if !result.IsError || !strings.Contains(result.Content, "a: context canceled") || !strings.Contains(result.Content, "b: context canceled") {
+		t.Errorf("expected combined error, got: %s", result.Content)
+	}
+}
+
+func TestNewWebSearchToolFromConfig_SkipsInvalid(t *testing.T) {
+	tool := NewWebSearchToolFromConfig(config.WebSearchConfig{
+		Providers: []config.SearchProviderConfig{
+			{Type: "searxng"}, // 缺少 baseUrl
+			{Type: "searxng", BaseURL: "http://searx.local"},
+			{Type: "unknown"},
+		},
+		CacheTTLSeconds: -1,
+	})
+	if len(tool.providers) != 1 || tool.providers[0].Name() != "searxng" {
+		t.Errorf("expected one valid provider, got %d", len(tool.providers))
+	}
+}
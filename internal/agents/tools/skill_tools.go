@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/skills"
+)
+
+const (
+	defaultSkillToolTimeout = 60 * time.Second
+	maxSkillOutput          = 64 * 1024 // 输出的最大字节数
+)
+
+// skillPlaceholderRe 匹配 command 中的 {param} 占位符
+var skillPlaceholderRe = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// baseSkillEnv 未授予 env 权限时传递给技能的环境变量
+var baseSkillEnv = []string{"PATH", "HOME", "USER", "LANG", "LC_ALL", "TMPDIR", "TZ"}
+
+// SkillTool 将技能声明的工具暴露给 agent，名称为 <skillID>__<tool>
+type SkillTool struct {
+	skill   *skills.Skill
+	spec    skills.ToolSpec
+	workdir string
+	timeout time.Duration
+	sandbox *Sandbox // exec 的沙箱配置 (可为空)，见 sandboxFor
+}
+
+// NewSkillTool 创建技能工具；workdir 为工作区 (filesystem 权限时作为运行目录)
+func NewSkillTool(skill *skills.Skill, spec skills.ToolSpec, workdir string) *SkillTool {
+	return &SkillTool{
+		skill:   skill,
+		spec:    spec,
+		workdir: workdir,
+		timeout: defaultSkillToolTimeout,
+	}
+}
+
+// NewSkillTools 为可用的技能 (见 PermittedSkills) 创建工具
+func NewSkillTools(list []*skills.Skill, cfg config.SkillsConfig, workdir string) []*SkillTool {
+	var result []*SkillTool
+	for _, skill := range PermittedSkills(list, cfg) {
+		for _, spec := range skill.Tools {
+			if spec.Binary == "" && spec.Command == "" {
+				continue
+			}
+			result = append(result, NewSkillTool(skill, spec, workdir))
+		}
+	}
+	return result
+}
+
+// PermittedSkills 返回已启用且声明的权限均已授予的技能
+func PermittedSkills(list []*skills.Skill, cfg config.SkillsConfig) []*skills.Skill {
+	var result []*skills.Skill
+	for _, skill := range list {
+		if !skill.Enabled || skill.Path == "" {
+			continue
+		}
+		if missing := skill.MissingPermissions(cfg.GrantedPermissions(skill.ID)); len(missing) > 0 {
+			log.Warn().Str("skill", skill.ID).Strs("missing", missing).
+				Msg("Skill permissions not granted, skill disabled (see skills.permissions)")
+			continue
+		}
+		result = append(result, skill)
+	}
+	return result
+}
+
+// SkillToolName 返回技能工具在注册表中的名称
+func SkillToolName(skillID, tool string) string {
+	return sanitizeToolName(skillID) + "__" + sanitizeToolName(tool)
+}
+
+func sanitizeToolName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// SetSandbox 设置 tools.exec.sandbox：会话处于沙箱中时技能同样在沙箱中运行，并沿用其后端和资源限制
+func (t *SkillTool) SetSandbox(sb *Sandbox) {
+	t.sandbox = sb
+}
+
+// sandboxFor 返回运行技能的沙箱，按权限限制技能：没有 network 权限时禁用网络，
+// 没有 filesystem 权限时只能访问技能目录。技能拥有这两项权限且会话不在沙箱中时返回 nil，直接在主机运行
+func (t *SkillTool) sandboxFor(ctx context.Context) *Sandbox {
+	session, _ := SessionFromContext(ctx)
+	sandboxed := t.sandbox.Applies(session)
+	network := t.skill.HasPermission(skills.PermissionNetwork)
+	filesystem := t.skill.HasPermission(skills.PermissionFilesystem) && t.workdir != ""
+	if !sandboxed && network && filesystem {
+		return nil
+	}
+	return t.sandbox.ForSkill(t.skillDir(), t.workdir, sandboxed, filesystem, network)
+}
+
+// skillDir 技能目录的实际路径 (版本化安装时为符号链接指向的版本目录)
+func (t *SkillTool) skillDir() string {
+	if dir, err := filepath.EvalSymlinks(t.skill.Path); err == nil {
+		return dir
+	}
+	return t.skill.Path
+}
+
+// SetTimeout 设置运行时限
+func (t *SkillTool) SetTimeout(d time.Duration) {
+	t.timeout = d
+}
+
+// Skill 返回工具所属的技能
+func (t *SkillTool) Skill() *skills.Skill {
+	return t.skill
+}
+
+func (t *SkillTool) Name() string {
+	return SkillToolName(t.skill.ID, t.spec.Name)
+}
+
+func (t *SkillTool) Description() string {
+	desc := t.spec.Description
+	if desc == "" {
+		desc = t.spec.Name
+	}
+	return fmt.Sprintf("[skill: %s] %s", t.skill.Name, desc)
+}
+
+func (t *SkillTool) Parameters() json.RawMessage {
+	if t.spec.Parameters != nil {
+		if data, err := json.Marshal(t.spec.Parameters); err == nil {
+			return data
+		}
+	}
+	return json.RawMessage(`{"type": "object", "properties": {}, "additionalProperties": true}`)
+}
+
+func (t *SkillTool) Execute(ctx context.Context, args json.RawMessage) (*Result, error) {
+	params := map[string]interface{}{}
+	if len(bytes.TrimSpace(args)) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return &Result{Content: fmt.Sprintf("Invalid parameters: %v", err), IsError: true}, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+
+	cmd, cleanup, err := t.command(ctx, params)
+	if err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
+	}
+	defer cleanup()
+	setProcessGroup(cmd)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	output := strings.TrimSpace(stdout.String())
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &Result{Content: fmt.Sprintf("Skill tool %s timed out after %s", t.Name(), t.timeout), IsError: true}, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Skill tool %s failed: %v", t.Name(), err)
+		if errText := strings.TrimSpace(stderr.String()); errText != "" {
+			msg += "\n" + errText
+		}
+		if output != "" {
+			msg += "\n" + output
+		}
+		return &Result{Content: truncateOutput(msg), IsError: true}, nil
+	}
+	if output == "" {
+		output = "(no output)"
+	}
+	return &Result{Content: truncateOutput(output)}, nil
+}
+
+// command 构造命令：binary 通过 stdin (JSON) 或 --key=value 接收参数，command 由 sh 执行并替换 {param}。
+// 需要沙箱时 (见 sandboxFor) 在沙箱中运行，进程结束后必须调用返回的 cleanup
+func (t *SkillTool) command(ctx context.Context, params map[string]interface{}) (*exec.Cmd, func(), error) {
+	var argv []string
+	var script string
+	argsMode := t.spec.Input == skills.InputArgs
+	switch {
+	case t.spec.Binary != "":
+		bin, err := t.skill.GetBinary(t.spec.Name)
+		if err != nil {
+			// 不在技能目录中时按 PATH 查找
+			if strings.ContainsRune(t.spec.Binary, os.PathSeparator) {
+				return nil, nil, err
+			}
+			if bin, err = exec.LookPath(t.spec.Binary); err != nil {
+				return nil, nil, fmt.Errorf("binary %q not found for skill %s", t.spec.Binary, t.skill.ID)
+			}
+		}
+		argv = []string{bin}
+		if argsMode {
+			argv = append(argv, paramFlags(params)...)
+		}
+	case t.spec.Command != "":
+		script = skillPlaceholderRe.ReplaceAllStringFunc(t.spec.Command, func(m string) string {
+			return shellQuote(paramString(params[m[1:len(m)-1]]))
+		})
+	default:
+		return nil, nil, fmt.Errorf("skill tool %s has no binary or command", t.Name())
+	}
+
+	var cmd *exec.Cmd
+	cleanup := func() {}
+	if sb := t.sandboxFor(ctx); sb != nil {
+		if argv != nil {
+			quoted := make([]string, len(argv))
+			for i, arg := range argv {
+				quoted[i] = shellQuote(arg)
+			}
+			script = "exec " + strings.Join(quoted, " ")
+		}
+		var err error
+		cmd, cleanup, err = sb.Command(ctx, script, "", t.sandboxEnv(sb))
+		if err != nil {
+			return nil, nil, fmt.Errorf("skill %s must run in a sandbox (grant it network and filesystem to run on the host): %w", t.skill.ID, err)
+		}
+	} else {
+		if argv != nil {
+			cmd = exec.CommandContext(ctx, argv[0], argv[1:]...)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", script)
+		}
+		cmd.Dir = t.workdir
+		cmd.Env = t.env()
+	}
+
+	if !argsMode {
+		input, err := json.Marshal(params)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	return cmd, cleanup, nil
+}
+
+// env 主机上运行时的环境：未授予 env 权限时只传递基本变量，另附加技能目录与配置
+func (t *SkillTool) env() []string {
+	var env []string
+	if t.skill.HasPermission(skills.PermissionEnv) {
+		env = os.Environ()
+	} else {
+		for _, key := range baseSkillEnv {
+			if v, ok := os.LookupEnv(key); ok {
+				env = append(env, key+"="+v)
+			}
+		}
+	}
+	env = append(env, "SKILL_ID="+t.skill.ID, "SKILL_DIR="+t.skill.Path)
+	if t.workdir != "" {
+		env = append(env, "OPENCLAW_WORKSPACE="+t.workdir)
+	}
+	for key, value := range t.skill.Config {
+		env = append(env, "SKILL_CONFIG_"+strings.ToUpper(sanitizeToolName(key))+"="+value)
+	}
+	return env
+}
+
+// sandboxEnv 沙箱中运行时额外传递的环境变量 (沙箱本身只保留 PATH、HOME 等基本变量)
+func (t *SkillTool) sandboxEnv(sb *Sandbox) map[string]string {
+	env := make(map[string]string)
+	if t.skill.HasPermission(skills.PermissionEnv) {
+		for _, kv := range os.Environ() {
+			if key, value, ok := strings.Cut(kv, "="); ok && key != "PATH" && key != "HOME" && key != "PWD" {
+				env[key] = value
+			}
+		}
+	}
+	env["SKILL_ID"] = t.skill.ID
+	env["SKILL_DIR"] = t.skillDir()
+	if sb.workspace != t.skillDir() {
+		env["OPENCLAW_WORKSPACE"] = sb.workspace
+	}
+	for key, value := range t.skill.Config {
+		env["SKILL_CONFIG_"+strings.ToUpper(sanitizeToolName(key))] = value
+	}
+	return env
+}
+
+// paramFlags 将参数转为按名称排序的 --key=value
+func paramFlags(params map[string]interface{}) []string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	flags := make([]string, 0, len(keys))
+	for _, k := range keys {
+		flags = append(flags, "--"+k+"="+paramString(params[k]))
+	}
+	return flags
+}
+
+// paramString 字符串原样返回，其它值编码为 JSON
+func paramString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// shellQuote 用单引号包裹，供 sh 安全使用
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// truncateOutput 截断过长的输出，保证不截断 UTF-8 字符
+func truncateOutput(s string) string {
+	if len(s) <= maxSkillOutput {
+		return s
+	}
+	cut := maxSkillOutput
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "\n... (output truncated)"
+}
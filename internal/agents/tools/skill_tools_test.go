@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/skills"
+)
+
+// newTestSkill 创建包含 bin/<name> 脚本的技能目录；未授予 network 和 filesystem 的技能在命名空间沙箱中运行
+func newTestSkill(t *testing.T, scripts map[string]string) *skills.Skill {
+	t.Helper()
+	if err := namespacesSupported(); err != nil {
+		t.Skipf("namespaces unavailable: %v", err)
+	}
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, "bin", name), []byte("#!/bin/sh\n"+body), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &skills.Skill{ID: "demo", Name: "Demo", Path: dir, Enabled: true}
+}
+
+func TestSkillTool_BinaryStdin(t *testing.T) {
+	skill := newTestSkill(t, map[string]string{"echo-input": `cat; echo " $SKILL_ID $(pwd)"`})
+	skill.Tools = []skills.ToolSpec{{Name: "echo", Binary: "echo-input"}}
+
+	tool := NewSkillTool(skill, skill.Tools[0], t.TempDir())
+	if tool.Name() != "demo__echo" {
+		t.Errorf("Name = %q", tool.Name())
+	}
+
+	result, err := tool.Execute(context.Background(), []byte(`{"query":"hi"}`))
+	if err != nil || result.IsError {
+		t.Fatalf("Execute failed: %v %+v", err, result)
+	}
+	if !strings.HasPrefix(result.Content, `{"query":"hi"} demo `) {
+		t.Errorf("unexpected output: %q", result.Content)
+	}
+	// 未授予 filesystem 权限时在技能目录中运行
+	if !strings.HasSuffix(result.Content, filepath.Base(skill.Path)) {
+		t.Errorf("should run in skill dir: %q", result.Content)
+	}
+}
+
+func TestSkillTool_ArgsMode(t *testing.T) {
+	skill := newTestSkill(t, map[string]string{"flags": `echo "$@"`})
+	skill.Tools = []skills.ToolSpec{{Name: "flags", Binary: "flags", Input: skills.InputArgs}}
+
+	tool := NewSkillTool(skill, skill.Tools[0], "")
+	result, _ := tool.Execute(context.Background(), []byte(`{"city":"Paris","days":3}`))
+	if result.IsError || result.Content != "--city=Paris --days=3" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestSkillTool_CommandTemplate(t *testing.T) {
+	skill := newTestSkill(t, nil)
+	skill.Tools = []skills.ToolSpec{{Name: "say", Command: "printf '%s|%s' {text} {missing}"}}
+
+	tool := NewSkillTool(skill, skill.Tools[0], "")
+	result, _ := tool.Execute(context.Background(), []byte(`{"text":"it's $(whoami); ok"}`))
+	if result.IsError || result.Content != "it's $(whoami); ok|" {
+		t.Errorf("parameters should be shell-quoted: %+v", result)
+	}
+}
+
+func TestSkillTool_FailureAndTimeout(t *testing.T) {
+	skill := newTestSkill(t, map[string]string{
+		"fail": "echo boom >&2; exit 3",
+		"slow": "sleep 5",
+	})
+	skill.Tools = []skills.ToolSpec{{Name: "fail", Binary: "fail"}, {Name: "slow", Binary: "slow"}}
+
+	result, _ := NewSkillTool(skill, skill.Tools[0], "").Execute(context.Background(), nil)
+	if !result.IsError || !strings.Contains(result.Content, "boom") {
+		t.Errorf("expected error with stderr: %+v", result)
+	}
+
+	slow := NewSkillTool(skill, skill.Tools[1], "")
+	slow.SetTimeout(100 * time.Millisecond)
+	start := time.Now()
+	result, _ = slow.Execute(context.Background(), nil)
+	if !result.IsError || !strings.Contains(result.Content, "timed out") {
+		t.Errorf("expected timeout: %+v", result)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("timeout should kill the process")
+	}
+}
+
+func TestNewSkillTools_Permissions(t *testing.T) {
+	skill := newTestSkill(t, nil)
+	skill.Permissions = []string{"network"}
+	skill.Tools = []skills.ToolSpec{{Name: "fetch", Command: "true"}, {Name: "docs"}}
+	disabled := &skills.Skill{ID: "off", Path: skill.Path, Tools: skill.Tools}
+
+	if got := NewSkillTools([]*skills.Skill{skill, disabled}, config.SkillsConfig{}, ""); len(got) != 0 {
+		t.Errorf("ungranted skill should not register tools, got %d", len(got))
+	}
+
+	cfg := config.SkillsConfig{Entries: map[string]config.SkillEntryConfig{
+		"demo": {Permissions: []string{"network"}},
+	}}
+	got := NewSkillTools([]*skills.Skill{skill, disabled}, cfg, "")
+	if len(got) != 1 || got[0].Name() != "demo__fetch" {
+		t.Errorf("expected demo__fetch only, got %v", got)
+	}
+}
+
+// interfaces 返回技能看到的网络接口 (不含 lo)
+func interfaces(t *testing.T, tool *SkillTool, ctx context.Context) []string {
+	t.Helper()
+	result, _ := tool.Execute(ctx, nil)
+	if result.IsError {
+		t.Fatalf("Execute failed: %s", result.Content)
+	}
+	var names []string
+	for _, name := range strings.Fields(result.Content) {
+		if name != "lo" && name != "(no" && name != "output)" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestSkillTool_NetworkPermission(t *testing.T) {
+	skill := newTestSkill(t, nil)
+	skill.Tools = []skills.ToolSpec{{Name: "net", Command: "cat /proc/net/dev | tail -n +3 | cut -d: -f1"}}
+	workspace := t.TempDir()
+	tool := NewSkillTool(skill, skill.Tools[0], workspace)
+
+	if got := interfaces(t, tool, context.Background()); len(got) != 0 {
+		t.Errorf("skill without network permission should have no network, got %v", got)
+	}
+
+	skill.Permissions = []string{skills.PermissionNetwork, skills.PermissionFilesystem}
+	host := interfaces(t, tool, context.Background())
+	if len(host) == 0 {
+		t.Skip("host has no network interfaces besides lo")
+	}
+
+	// 会话沙箱禁止网络时，即使授予了 network 权限也没有网络
+	tool.SetSandbox(NewSandbox(config.SandboxConfig{Mode: SandboxModeGroups}, workspace))
+	group := WithSession(context.Background(), SessionContext{Key: "telegram:-100", Kind: "group"})
+	if got := interfaces(t, tool, group); len(got) != 0 {
+		t.Errorf("sandboxed group session should have no network, got %v", got)
+	}
+	if got := interfaces(t, tool, context.Background()); len(got) == 0 {
+		t.Error("main session with network permission should run on the host")
+	}
+}
+
+func TestSkillTool_FilesystemPermission(t *testing.T) {
+	skill := newTestSkill(t, map[string]string{"read": `cat "$1"; echo; echo written > "$2" && echo ok`})
+	skill.Tools = []skills.ToolSpec{{Name: "read", Command: "sh bin/read {file} {out} 2>&1 || true"}}
+	workspace := t.TempDir()
+	os.WriteFile(filepath.Join(workspace, "notes.txt"), []byte("workspace notes"), 0644)
+	outside := filepath.Join(t.TempDir(), "out.txt")
+	args := []byte(`{"file":"` + filepath.Join(workspace, "notes.txt") + `","out":"` + outside + `"}`)
+
+	// 没有 filesystem 权限时只能访问技能目录
+	tool := NewSkillTool(skill, skill.Tools[0], workspace)
+	result, _ := tool.Execute(context.Background(), args)
+	if strings.Contains(result.Content, "workspace notes") {
+		t.Errorf("skill without filesystem permission should not see the workspace: %s", result.Content)
+	}
+	if _, err := os.Stat(outside); err == nil {
+		t.Error("skill without filesystem permission wrote outside its directory")
+	}
+
+	// 授予 filesystem 后可以访问工作区，但仍然不能写入工作区以外
+	skill.Permissions = []string{skills.PermissionFilesystem}
+	skill.Tools[0].Command = "sh " + filepath.Join(skill.Path, "bin", "read") + " {file} {out} 2>&1 || true"
+	tool = NewSkillTool(skill, skill.Tools[0], workspace)
+	result, _ = tool.Execute(context.Background(), args)
+	if !strings.Contains(result.Content, "workspace notes") {
+		t.Errorf("skill with filesystem permission should see the workspace: %s", result.Content)
+	}
+	if _, err := os.Stat(outside); err == nil {
+		t.Error("sandboxed skill wrote outside the workspace")
+	}
+}
@@ -4,9 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
-	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
 )
 
 // TTSTool 文字转语音工具
@@ -32,6 +31,29 @@ func NewTTSTool(workdir string) *TTSTool {
 	}
 }
 
+// NewTTSToolFromConfig 根据 tts 配置创建工具；未启用或配置错误时调用返回原因
+func NewTTSToolFromConfig(cfg config.TTSConfig, stateDir, workdir string) *TTSTool {
+	t := NewTTSTool(workdir)
+	if cfg.Provider != "" && cfg.Provider != "openai" {
+		t.defaultVoice = "" // nova 只是 OpenAI 的声音
+	}
+	if cfg.Voice != "" {
+		t.defaultVoice = cfg.Voice
+	}
+	if !cfg.Enabled {
+		return t
+	}
+	service, err := NewTTSServiceFromConfig(cfg, stateDir)
+	if err != nil {
+		t.SynthesizeFunc = func(ctx context.Context, text, voice, channel string) (string, error) {
+			return "", err
+		}
+		return t
+	}
+	t.SetSynthesizeFunc(service.Synthesize)
+	return t
+}
+
 func (t *TTSTool) Name() string {
 	return ToolTTS
 }
@@ -76,6 +98,13 @@ func (t *TTSTool) Execute(ctx context.Context, args json.RawMessage) (*Result, e
 	if voice == "" {
 		voice = t.defaultVoice
 	}
+	// 未指定渠道时按当前会话的渠道选择输出格式
+	channel := params.Channel
+	if channel == "" {
+		if s, ok := SessionFromContext(ctx); ok {
+			channel = s.Channel
+		}
+	}
 
 	// 检查 TTS 函数是否已注入
 	if t.SynthesizeFunc == nil {
@@ -83,7 +112,7 @@ func (t *TTSTool) Execute(ctx context.Context, args json.RawMessage) (*Result, e
 	}
 
 	// 调用 TTS 服务
-	audioPath, err := t.SynthesizeFunc(ctx, params.Text, voice, params.Channel)
+	audioPath, err := t.SynthesizeFunc(ctx, params.Text, voice, channel)
 	if err != nil {
 		return &Result{Content: "TTS failed: " + err.Error(), IsError: true}, nil
 	}
@@ -95,7 +124,7 @@ func (t *TTSTool) Execute(ctx context.Context, args json.RawMessage) (*Result, e
 			{
 				Type:     "audio",
 				Path:     audioPath,
-				MimeType: "audio/mpeg", // 默认 MP3
+				MimeType: AudioMimeType(audioPath),
 			},
 		},
 	}, nil
@@ -111,30 +140,10 @@ func (t *TTSTool) SetDefaultVoice(voice string) {
 	t.defaultVoice = voice
 }
 
-// SimpleTTSProvider 简单的 TTS 提供者 (使用系统 say 命令或 espeak)
-type SimpleTTSProvider struct {
-	outputDir string
-}
-
-// NewSimpleTTSProvider 创建简单 TTS 提供者
-func NewSimpleTTSProvider(outputDir string) *SimpleTTSProvider {
-	return &SimpleTTSProvider{outputDir: outputDir}
-}
-
-// Synthesize 使用系统命令合成语音
-func (p *SimpleTTSProvider) Synthesize(ctx context.Context, text, voice, channel string) (string, error) {
-	// 创建输出目录
-	if err := os.MkdirAll(p.outputDir, 0755); err != nil {
-		return "", fmt.Errorf("create output dir: %w", err)
+// Speak 使用默认声音合成语音 (用于自动语音回复)
+func (t *TTSTool) Speak(ctx context.Context, text, channel string) (string, error) {
+	if t.SynthesizeFunc == nil {
+		return "", fmt.Errorf("TTS not configured")
 	}
-
-	// 生成输出文件名
-	filename := fmt.Sprintf("tts_%d.aiff", time.Now().UnixNano())
-	outputPath := filepath.Join(p.outputDir, filename)
-
-	// 尝试使用 macOS say 命令
-	// say -v voice -o output.aiff "text"
-	// 注意：这只是一个简单的示例，实际应该使用更好的 TTS 服务
-
-	return outputPath, fmt.Errorf("TTS synthesis not implemented - please configure an external TTS service")
+	return t.SynthesizeFunc(ctx, text, t.defaultVoice, channel)
 }
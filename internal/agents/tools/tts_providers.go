@@ -0,0 +1,382 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// 音频格式
+const (
+	AudioFormatMP3  = "mp3"
+	AudioFormatOpus = "opus" // OGG 容器的 Opus (Telegram/WhatsApp 语音消息)
+	AudioFormatWAV  = "wav"
+	AudioFormatAAC  = "aac"
+	AudioFormatFLAC = "flac"
+)
+
+// TTSProvider 文字转语音后端
+type TTSProvider interface {
+	// Name 返回后端名称
+	Name() string
+
+	// Synthesize 合成语音；Format 只是期望格式，后端不支持时返回其他格式
+	Synthesize(ctx context.Context, req TTSRequest) (*TTSAudio, error)
+}
+
+// TTSRequest 合成请求
+type TTSRequest struct {
+	Text   string
+	Voice  string
+	Format string
+}
+
+// TTSAudio 合成结果
+type TTSAudio struct {
+	Data   []byte
+	Format string
+}
+
+// NewTTSProvider 根据配置创建 TTS 后端
+func NewTTSProvider(cfg config.TTSConfig) (TTSProvider, error) {
+	client := &http.Client{Timeout: 60 * time.Second}
+	apiKey := cfg.APIKey
+	if apiKey == "" && cfg.APIKeyEnv != "" {
+		apiKey = os.Getenv(cfg.APIKeyEnv)
+	}
+
+	switch cfg.Provider {
+	case "", "openai":
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+			if apiKey == "" {
+				apiKey = os.Getenv("OPENAI_API_KEY")
+			}
+			if apiKey == "" {
+				return nil, fmt.Errorf("openai: OPENAI_API_KEY not set")
+			}
+		}
+		model := cfg.Model
+		if model == "" {
+			model = "tts-1"
+		}
+		return &OpenAITTSProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, model: model, httpClient: client}, nil
+	case "elevenlabs":
+		if apiKey == "" {
+			apiKey = os.Getenv("ELEVENLABS_API_KEY")
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("elevenlabs: ELEVENLABS_API_KEY not set")
+		}
+		baseURL := cfg.BaseURL
+		if baseURL == "" {
+			baseURL = "https://api.elevenlabs.io"
+		}
+		model := cfg.Model
+		if model == "" {
+			model = "eleven_multilingual_v2"
+		}
+		return &ElevenLabsTTSProvider{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, model: model, httpClient: client}, nil
+	case "command":
+		if len(cfg.Command) == 0 {
+			return nil, fmt.Errorf("command: command is required")
+		}
+		format := cfg.Format
+		if format == "" {
+			format = AudioFormatWAV
+		}
+		return &CommandTTSProvider{command: cfg.Command, format: format}, nil
+	default:
+		return nil, fmt.Errorf("unknown tts provider: %q", cfg.Provider)
+	}
+}
+
+// ==================== OpenAI ====================
+
+// OpenAITTSProvider OpenAI 兼容的 /v1/audio/speech 接口
+type OpenAITTSProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+func (p *OpenAITTSProvider) Name() string {
+	return "openai"
+}
+
+func (p *OpenAITTSProvider) Synthesize(ctx context.Context, req TTSRequest) (*TTSAudio, error) {
+	format := req.Format
+	switch format {
+	case AudioFormatMP3, AudioFormatOpus, AudioFormatWAV, AudioFormatAAC, AudioFormatFLAC:
+	default:
+		format = AudioFormatMP3
+	}
+	body, _ := json.Marshal(map[string]string{
+		"model":           p.model,
+		"input":           req.Text,
+		"voice":           req.Voice,
+		"response_format": format,
+	})
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/audio/speech", bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	data, err := doTTSRequest(p.httpClient, httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return &TTSAudio{Data: data, Format: format}, nil
+}
+
+// ==================== ElevenLabs ====================
+
+// ElevenLabsTTSProvider ElevenLabs text-to-speech 接口 (voice 为 voice_id)
+type ElevenLabsTTSProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+func (p *ElevenLabsTTSProvider) Name() string {
+	return "elevenlabs"
+}
+
+func (p *ElevenLabsTTSProvider) Synthesize(ctx context.Context, req TTSRequest) (*TTSAudio, error) {
+	if req.Voice == "" {
+		return nil, fmt.Errorf("elevenlabs: voice id is required")
+	}
+	format, outputFormat := AudioFormatMP3, "mp3_44100_128"
+	if req.Format == AudioFormatOpus {
+		format, outputFormat = AudioFormatOpus, "opus_48000_64"
+	}
+	body, _ := json.Marshal(map[string]string{
+		"text":     req.Text,
+		"model_id": p.model,
+	})
+	u := fmt.Sprintf("%s/v1/text-to-speech/%s?output_format=%s", p.baseURL, url.PathEscape(req.Voice), outputFormat)
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("xi-api-key", p.apiKey)
+	data, err := doTTSRequest(p.httpClient, httpReq)
+	if err != nil {
+		return nil, err
+	}
+	return &TTSAudio{Data: data, Format: format}, nil
+}
+
+// ==================== Command ====================
+
+// CommandTTSProvider 本地命令 (piper、espeak 等)，文本从 stdin 传入
+//
+// 例: ["piper", "--model", "zh_CN-huayan-medium.onnx", "--output_file", "{output}"]
+// 或: ["espeak-ng", "-v", "{voice}", "-w", "{output}", "--stdin"]
+type CommandTTSProvider struct {
+	command []string
+	format  string
+}
+
+func (p *CommandTTSProvider) Name() string {
+	return "command"
+}
+
+func (p *CommandTTSProvider) Synthesize(ctx context.Context, req TTSRequest) (*TTSAudio, error) {
+	out, err := os.CreateTemp("", "tts-*."+audioExtension(p.format))
+	if err != nil {
+		return nil, err
+	}
+	out.Close()
+	defer os.Remove(out.Name())
+
+	replacer := strings.NewReplacer("{output}", out.Name(), "{voice}", req.Voice, "{text}", req.Text)
+	args := make([]string, len(p.command))
+	for i, arg := range p.command {
+		args[i] = replacer.Replace(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(req.Text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %v: %s", filepath.Base(args[0]), err, strings.TrimSpace(stderr.String()))
+	}
+
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s produced no audio (does the command write to {output}?)", filepath.Base(args[0]))
+	}
+	return &TTSAudio{Data: data, Format: p.format}, nil
+}
+
+func doTTSRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 50<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, truncateString(string(data), 200))
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty audio response")
+	}
+	return data, nil
+}
+
+// ==================== 合成与转码 ====================
+
+// TTSService 调用后端合成语音，保存到输出目录并转码成渠道需要的格式
+type TTSService struct {
+	provider  TTSProvider
+	outputDir string
+	ffmpeg    string
+}
+
+// NewTTSService 创建 TTS 服务
+func NewTTSService(provider TTSProvider, outputDir string) *TTSService {
+	return &TTSService{provider: provider, outputDir: outputDir, ffmpeg: "ffmpeg"}
+}
+
+// NewTTSServiceFromConfig 根据配置创建 TTS 服务；输出目录默认为 <stateDir>/tts
+func NewTTSServiceFromConfig(cfg config.TTSConfig, stateDir string) (*TTSService, error) {
+	provider, err := NewTTSProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+	outputDir := cfg.OutputDir
+	if outputDir == "" {
+		if stateDir == "" {
+			stateDir = os.TempDir()
+		}
+		outputDir = filepath.Join(stateDir, "tts")
+	}
+	s := NewTTSService(provider, outputDir)
+	if cfg.FFmpeg != "" {
+		s.ffmpeg = cfg.FFmpeg
+	}
+	return s, nil
+}
+
+// Provider 返回使用的后端
+func (s *TTSService) Provider() TTSProvider {
+	return s.provider
+}
+
+// Synthesize 合成语音并返回音频文件路径 (签名与 TTSTool.SetSynthesizeFunc 一致)
+func (s *TTSService) Synthesize(ctx context.Context, text, voice, channel string) (string, error) {
+	format := TTSFormatForChannel(channel)
+	audio, err := s.provider.Synthesize(ctx, TTSRequest{Text: text, Voice: voice, Format: format})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", s.provider.Name(), err)
+	}
+	if err := os.MkdirAll(s.outputDir, 0755); err != nil {
+		return "", fmt.Errorf("create output dir: %w", err)
+	}
+
+	base := filepath.Join(s.outputDir, fmt.Sprintf("tts_%d", time.Now().UnixNano()))
+	path := base + "." + audioExtension(audio.Format)
+	if err := os.WriteFile(path, audio.Data, 0644); err != nil {
+		return "", err
+	}
+	if audio.Format == format {
+		return path, nil
+	}
+
+	out := base + "." + audioExtension(format)
+	if err := s.transcode(ctx, path, out, format); err != nil {
+		os.Remove(out)
+		return "", err
+	}
+	os.Remove(path)
+	return out, nil
+}
+
+// transcode 使用 ffmpeg 转换音频格式
+func (s *TTSService) transcode(ctx context.Context, in, out, format string) error {
+	ffmpeg, err := exec.LookPath(s.ffmpeg)
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found: needed to convert audio to %s", format)
+	}
+	cmd := exec.CommandContext(ctx, ffmpeg, ffmpegArgs(in, out, format)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %v: %s", err, truncateString(strings.TrimSpace(stderr.String()), 300))
+	}
+	return nil
+}
+
+// ffmpegArgs 转码参数；语音消息使用单声道 48kHz Opus
+func ffmpegArgs(in, out, format string) []string {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", in, "-vn"}
+	switch format {
+	case AudioFormatOpus:
+		args = append(args, "-c:a", "libopus", "-b:a", "32k", "-ar", "48000", "-ac", "1", "-application", "voip", "-f", "ogg")
+	case AudioFormatMP3:
+		args = append(args, "-c:a", "libmp3lame", "-b:a", "128k")
+	case AudioFormatAAC:
+		args = append(args, "-c:a", "aac", "-b:a", "128k")
+	}
+	return append(args, out)
+}
+
+// TTSFormatForChannel 渠道需要的音频格式：支持语音消息的渠道使用 OGG/Opus，其他为 MP3
+func TTSFormatForChannel(channel string) string {
+	switch channel {
+	case "telegram", "whatsapp", "signal":
+		return AudioFormatOpus
+	default:
+		return AudioFormatMP3
+	}
+}
+
+// audioExtension 格式对应的文件扩展名
+func audioExtension(format string) string {
+	switch format {
+	case AudioFormatOpus:
+		return "ogg"
+	case "":
+		return "bin"
+	default:
+		return format
+	}
+}
+
+// AudioMimeType 根据扩展名返回音频 MIME 类型
+func AudioMimeType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ogg", ".opus":
+		return "audio/ogg"
+	case ".wav":
+		return "audio/wav"
+	case ".aac":
+		return "audio/aac"
+	case ".flac":
+		return "audio/flac"
+	default:
+		return "audio/mpeg"
+	}
+}
@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestOpenAITTSProvider(t *testing.T) {
+	var got map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("Unexpected request: %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte("OggS-audio"))
+	}))
+	defer server.Close()
+
+	provider, err := NewTTSProvider(config.TTSConfig{BaseURL: server.URL + "/v1/", APIKey: "sk-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := provider.Synthesize(context.Background(), TTSRequest{Text: "你好", Voice: "nova", Format: AudioFormatOpus})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audio.Format != AudioFormatOpus || string(audio.Data) != "OggS-audio" {
+		t.Errorf("Unexpected audio: %s %q", audio.Format, audio.Data)
+	}
+	if got["model"] != "tts-1" || got["input"] != "你好" || got["voice"] != "nova" || got["response_format"] != "opus" {
+		t.Errorf("Unexpected request body: %v", got)
+	}
+}
+
+func TestElevenLabsTTSProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/text-to-speech/voice-1" || r.Header.Get("xi-api-key") != "el-key" {
+			t.Errorf("Unexpected request: %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("output_format") != "mp3_44100_128" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"detail":"bad format"}`))
+			return
+		}
+		w.Write([]byte("ID3-audio"))
+	}))
+	defer server.Close()
+
+	provider, err := NewTTSProvider(config.TTSConfig{Provider: "elevenlabs", BaseURL: server.URL, APIKey: "el-key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err := provider.Synthesize(ctx, TTSRequest{Text: "hi"}); err == nil || !strings.Contains(err.Error(), "voice id") {
+		t.Errorf("Expected voice id error, got %v", err)
+	}
+	audio, err := provider.Synthesize(ctx, TTSRequest{Text: "hi", Voice: "voice-1", Format: AudioFormatWAV})
+	if err != nil || audio.Format != AudioFormatMP3 {
+		t.Fatalf("Synthesize = %+v, %v", audio, err)
+	}
+	if _, err := provider.Synthesize(ctx, TTSRequest{Text: "hi", Voice: "voice-1", Format: AudioFormatOpus}); err == nil || !strings.Contains(err.Error(), "422") {
+		t.Errorf("Expected API error, got %v", err)
+	}
+}
+
+func TestCommandTTSProvider(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	provider, err := NewTTSProvider(config.TTSConfig{
+		Provider: "command",
+		Command:  []string{"sh", "-c", `printf '%s:' "$1" > "$2"; cat >> "$2"`, "sh", "{voice}", "{output}"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio, err := provider.Synthesize(context.Background(), TTSRequest{Text: "hello", Voice: "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audio.Format != AudioFormatWAV || string(audio.Data) != "en:hello" {
+		t.Errorf("Unexpected audio: %s %q", audio.Format, audio.Data)
+	}
+
+	provider, _ = NewTTSProvider(config.TTSConfig{Provider: "command", Command: []string{"sh", "-c", "echo boom >&2; exit 1"}})
+	if _, err := provider.Synthesize(context.Background(), TTSRequest{Text: "x"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected command error, got %v", err)
+	}
+}
+
+func TestNewTTSProvider_Errors(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("ELEVENLABS_API_KEY", "")
+	tests := []struct {
+		cfg  config.TTSConfig
+		want string
+	}{
+		{config.TTSConfig{}, "OPENAI_API_KEY"},
+		{config.TTSConfig{Provider: "elevenlabs"}, "ELEVENLABS_API_KEY"},
+		{config.TTSConfig{Provider: "command"}, "command is required"},
+		{config.TTSConfig{Provider: "polly"}, "unknown tts provider"},
+	}
+	for _, tt := range tests {
+		if _, err := NewTTSProvider(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: got %v, want %q", tt.cfg.Provider, err, tt.want)
+		}
+	}
+}
+
+// fakeTTSProvider 返回固定格式的音频
+type fakeTTSProvider struct {
+	format string
+	req    TTSRequest
+}
+
+func (p *fakeTTSProvider) Name() string { return "fake" }
+
+func (p *fakeTTSProvider) Synthesize(ctx context.Context, req TTSRequest) (*TTSAudio, error) {
+	p.req = req
+	return &TTSAudio{Data: []byte("audio"), Format: p.format}, nil
+}
+
+func TestTTSService_Synthesize(t *testing.T) {
+	dir := t.TempDir()
+	provider := &fakeTTSProvider{format: AudioFormatOpus}
+	service := NewTTSService(provider, dir)
+
+	path, err := service.Synthesize(context.Background(), "hi", "nova", "telegram")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.req.Format != AudioFormatOpus || filepath.Dir(path) != dir || filepath.Ext(path) != ".ogg" {
+		t.Errorf("Unexpected output %s for request %+v", path, provider.req)
+	}
+
+	// 格式不同时需要 ffmpeg 转码
+	provider.format = AudioFormatWAV
+	service.ffmpeg = "ffmpeg-does-not-exist"
+	if _, err := service.Synthesize(context.Background(), "hi", "", "webchat"); err == nil || !strings.Contains(err.Error(), "ffmpeg not found") {
+		t.Errorf("Expected ffmpeg error, got %v", err)
+	}
+	if provider.req.Format != AudioFormatMP3 {
+		t.Errorf("Expected mp3 for webchat, got %s", provider.req.Format)
+	}
+}
+
+func TestTTSService_Transcode(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("ffmpeg not available")
+	}
+	wav := filepath.Join(t.TempDir(), "in.wav")
+	if err := exec.Command("ffmpeg", "-hide_banner", "-loglevel", "error", "-f", "lavfi", "-i", "sine=duration=0.2", wav).Run(); err != nil {
+		t.Skip("ffmpeg cannot generate audio")
+	}
+	data, _ := os.ReadFile(wav)
+	service := NewTTSService(&staticTTSProvider{data: data}, t.TempDir())
+
+	path, err := service.Synthesize(context.Background(), "hi", "", "whatsapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := os.ReadFile(path)
+	if !strings.HasPrefix(string(out), "OggS") {
+		t.Errorf("Expected OGG output, got %q", out[:min(len(out), 8)])
+	}
+}
+
+type staticTTSProvider struct{ data []byte }
+
+func (p *staticTTSProvider) Name() string { return "static" }
+
+func (p *staticTTSProvider) Synthesize(ctx context.Context, req TTSRequest) (*TTSAudio, error) {
+	return &TTSAudio{Data: p.data, Format: AudioFormatWAV}, nil
+}
+
+func TestFFmpegArgs(t *testing.T) {
+	args := strings.Join(ffmpegArgs("in.wav", "out.ogg", AudioFormatOpus), " ")
+	if !strings.Contains(args, "-i in.wav") || !strings.Contains(args, "-c:a libopus") || !strings.HasSuffix(args, "-f ogg out.ogg") {
+		t.Errorf("Unexpected opus args: %s", args)
+	}
+	if args := strings.Join(ffmpegArgs("in.wav", "out.mp3", AudioFormatMP3), " "); !strings.Contains(args, "libmp3lame") {
+		t.Errorf("Unexpected mp3 args: %s", args)
+	}
+	if AudioMimeType("/tmp/a.ogg") != "audio/ogg" || AudioMimeType("/tmp/a.mp3") != "audio/mpeg" {
+		t.Error("Unexpected mime types")
+	}
+}
+
+func TestTTSTool_FromConfig(t *testing.T) {
+	tool := NewTTSToolFromConfig(config.TTSConfig{}, "", "")
+	result, _ := tool.Execute(context.Background(), json.RawMessage(`{"text":"hi"}`))
+	if !result.IsError || !strings.Contains(result.Content, "not configured") {
+		t.Errorf("Expected not configured, got %s", result.Content)
+	}
+
+	t.Setenv("ELEVENLABS_API_KEY", "")
+	tool = NewTTSToolFromConfig(config.TTSConfig{Enabled: true, Provider: "elevenlabs"}, "", "")
+	if tool.defaultVoice != "" {
+		t.Errorf("defaultVoice = %q", tool.defaultVoice)
+	}
+	result, _ = tool.Execute(context.Background(), json.RawMessage(`{"text":"hi"}`))
+	if !result.IsError || !strings.Contains(result.Content, "ELEVENLABS_API_KEY") {
+		t.Errorf("Expected configuration error, got %s", result.Content)
+	}
+
+	// 输出格式按会话渠道选择
+	var channel string
+	tool.SetSynthesizeFunc(func(ctx context.Context, text, voice, ch string) (string, error) {
+		channel = ch
+		return "/tmp/reply.ogg", nil
+	})
+	ctx := WithSession(context.Background(), SessionContext{Key: "telegram:1", Channel: "telegram"})
+	result, _ = tool.Execute(ctx, json.RawMessage(`{"text":"hi"}`))
+	if result.IsError || channel != "telegram" || result.Media[0].MimeType != "audio/ogg" {
+		t.Errorf("Unexpected result %+v (channel %q)", result, channel)
+	}
+}
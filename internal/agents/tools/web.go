@@ -1,27 +1,37 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown"
+	"github.com/JohannesKaufmann/html-to-markdown/plugin"
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+	"github.com/rs/zerolog/log"
+	"github.com/z8n24/openclaw-go/internal/config"
 )
 
 // ==================== Web Search ====================
 
-// WebSearchTool 网页搜索工具 (使用 Brave Search API)
+// WebSearchTool 网页搜索工具 (按配置顺序尝试各搜索后端，默认使用 Brave Search API)
 type WebSearchTool struct {
-	apiKey     string
-	httpClient *http.Client
+	apiKey    string // 未配置后端时使用的 Brave API key
+	providers []SearchProvider
+	cache     *searchCache
 }
 
 type WebSearchParams struct {
@@ -32,25 +42,34 @@ type WebSearchParams struct {
 	Freshness  string `json:"freshness,omitempty"`
 }
 
-type BraveSearchResponse struct {
-	Web struct {
-		Results []struct {
-			Title       string `json:"title"`
-			URL         string `json:"url"`
-			Description string `json:"description"`
-		} `json:"results"`
-	} `json:"web"`
+func NewWebSearchTool() *WebSearchTool {
+	return &WebSearchTool{
+		apiKey: braveAPIKeyFromEnv(),
+		cache:  newSearchCache(15 * time.Minute),
+	}
 }
 
-func NewWebSearchTool() *WebSearchTool {
-	apiKey := os.Getenv("BRAVE_API_KEY")
-	if apiKey == "" {
-		apiKey = os.Getenv("BRAVE_SEARCH_API_KEY")
+// NewWebSearchToolFromConfig 根据 tools.web.search 配置创建搜索工具，
+// 无法创建的后端会被跳过并记录日志
+func NewWebSearchToolFromConfig(cfg config.WebSearchConfig) *WebSearchTool {
+	t := NewWebSearchTool()
+	for _, pc := range cfg.Providers {
+		provider, err := NewSearchProvider(pc)
+		if err != nil {
+			log.Warn().Err(err).Str("type", pc.Type).Msg("Skipping web search provider")
+			continue
+		}
+		t.providers = append(t.providers, provider)
 	}
-	return &WebSearchTool{
-		apiKey:     apiKey,
-		httpClient: &http.Client{Timeout: 30 * time.Second},
+	if cfg.CacheTTLSeconds != 0 {
+		t.cache = newSearchCache(time.Duration(cfg.CacheTTLSeconds) * time.Second)
 	}
+	return t
+}
+
+// SetProviders 设置搜索后端 (按顺序回退)
+func (t *WebSearchTool) SetProviders(providers ...SearchProvider) {
+	t.providers = providers
 }
 
 func (t *WebSearchTool) Name() string {
@@ -58,7 +77,7 @@ func (t *WebSearchTool) Name() string {
 }
 
 func (t *WebSearchTool) Description() string {
-	return "Search the web using Brave Search API. Returns titles, URLs, and snippets."
+	return "Search the web. Returns titles, URLs, and snippets."
 }
 
 func (t *WebSearchTool) Parameters() json.RawMessage {
@@ -85,8 +104,12 @@ func (t *WebSearchTool) Execute(ctx context.Context, args json.RawMessage) (*Res
 		return &Result{Content: "Query is required", IsError: true}, nil
 	}
 
-	if t.apiKey == "" {
-		return &Result{Content: "BRAVE_API_KEY not set", IsError: true}, nil
+	providers := t.providers
+	if len(providers) == 0 {
+		if t.apiKey == "" {
+			return &Result{Content: "BRAVE_API_KEY not set (or configure tools.web.search.providers)", IsError: true}, nil
+		}
+		providers = []SearchProvider{NewBraveProvider(t.apiKey)}
 	}
 
 	count := params.Count
@@ -94,77 +117,122 @@ func (t *WebSearchTool) Execute(ctx context.Context, args json.RawMessage) (*Res
 		count = 5
 	}
 
-	// 构建请求 URL
-	u, _ := url.Parse("https://api.search.brave.com/res/v1/web/search")
-	q := u.Query()
-	q.Set("q", params.Query)
-	q.Set("count", fmt.Sprintf("%d", count))
-	if params.Country != "" {
-		q.Set("country", params.Country)
-	}
-	if params.SearchLang != "" {
-		q.Set("search_lang", params.SearchLang)
+	query := SearchQuery{
+		Query:     params.Query,
+		Count:     count,
+		Country:   params.Country,
+		Language:  params.SearchLang,
+		Freshness: params.Freshness,
 	}
-	if params.Freshness != "" {
-		q.Set("freshness", params.Freshness)
-	}
-	u.RawQuery = q.Encode()
-
-	req, _ := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("X-Subscription-Token", t.apiKey)
 
-	resp, err := t.httpClient.Do(req)
-	if err != nil {
-		return &Result{Content: "Search failed: " + err.Error(), IsError: true}, nil
+	key := query.cacheKey()
+	if t.cache != nil {
+		if entry, ok := t.cache.get(key); ok {
+			return &Result{Content: formatSearchResults(entry.results, entry.provider, true)}, nil
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return &Result{Content: fmt.Sprintf("Search API error %d: %s", resp.StatusCode, string(body)), IsError: true}, nil
+	// 按顺序尝试，失败时回退到下一个后端
+	var errs []string
+	for _, provider := range providers {
+		results, err := provider.Search(ctx, query)
+		if err != nil {
+			log.Warn().Err(err).Str("provider", provider.Name()).Msg("Web search provider failed")
+			errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+		if t.cache != nil {
+			t.cache.put(key, provider.Name(), results)
+		}
+		return &Result{Content: formatSearchResults(results, provider.Name(), false)}, nil
 	}
 
-	var searchResp BraveSearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return &Result{Content: "Failed to parse search results: " + err.Error(), IsError: true}, nil
+	return &Result{Content: "Search failed: " + strings.Join(errs, "; "), IsError: true}, nil
+}
+
+// formatSearchResults 格式化搜索结果
+func formatSearchResults(results []SearchResult, provider string, cached bool) string {
+	if len(results) == 0 {
+		return "No results found"
 	}
 
-	// 格式化结果
 	var sb strings.Builder
-	for i, result := range searchResp.Web.Results {
+	for i, result := range results {
 		sb.WriteString(fmt.Sprintf("%d. **%s**\n", i+1, result.Title))
 		sb.WriteString(fmt.Sprintf("   URL: %s\n", result.URL))
-		sb.WriteString(fmt.Sprintf("   %s\n\n", result.Description))
+		if result.Published != "" {
+			sb.WriteString(fmt.Sprintf("   Published: %s\n", result.Published))
+		}
+		sb.WriteString(fmt.Sprintf("   %s\n\n", result.Snippet))
 	}
 
-	if sb.Len() == 0 {
-		return &Result{Content: "No results found"}, nil
+	source := "Source: " + provider
+	if cached {
+		source += " (cached)"
 	}
-
-	return &Result{Content: sb.String()}, nil
+	sb.WriteString(source)
+	return sb.String()
 }
 
 // ==================== Web Fetch ====================
 
-// WebFetchTool 网页抓取工具
+// 默认最多读取 5MB 响应
+const defaultFetchMaxBytes = 5 * 1024 * 1024
+
+// WebFetchTool 网页抓取工具：正文提取、PDF/纯文本、分页读取和磁盘缓存
 type WebFetchTool struct {
 	httpClient *http.Client
-	converter  *md.Converter
+	guard      *netGuard
+	cacheDir   string // 为空时不缓存
+	cacheTTL   time.Duration
+	maxBytes   int64
 }
 
 type WebFetchParams struct {
 	URL         string `json:"url"`
 	ExtractMode string `json:"extractMode,omitempty"` // "markdown" or "text"
 	MaxChars    int    `json:"maxChars,omitempty"`
+	Offset      int    `json:"offset,omitempty"`
+}
+
+// fetchedPage 抓取并提取后的页面 (缓存格式)
+type fetchedPage struct {
+	URL         string    `json:"url"`
+	Title       string    `json:"title,omitempty"`
+	ContentType string    `json:"contentType"`
+	Content     string    `json:"content"`
+	FetchedAt   time.Time `json:"fetchedAt"`
 }
 
 func NewWebFetchTool() *WebFetchTool {
-	converter := md.NewConverter("", true, nil)
-	return &WebFetchTool{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-		converter:  converter,
+	return NewWebFetchToolFromConfig(config.WebFetchConfig{}, "")
+}
+
+// NewWebFetchToolFromConfig 根据 tools.web.fetch 配置创建抓取工具，cacheDir 为空时禁用缓存
+func NewWebFetchToolFromConfig(cfg config.WebFetchConfig, cacheDir string) *WebFetchTool {
+	guard := newNetGuard(cfg.AllowPrivateNetwork, cfg.AllowHosts)
+
+	ttl := time.Hour
+	if cfg.CacheTTLSeconds > 0 {
+		ttl = time.Duration(cfg.CacheTTLSeconds) * time.Second
+	} else if cfg.CacheTTLSeconds < 0 {
+		cacheDir = ""
 	}
+	maxBytes := int64(defaultFetchMaxBytes)
+	if cfg.MaxBytes > 0 {
+		maxBytes = cfg.MaxBytes
+	}
+
+	t := &WebFetchTool{
+		httpClient: guard.httpClient(30 * time.Second),
+		guard:      guard,
+		cacheTTL:   ttl,
+		maxBytes:   maxBytes,
+	}
+	if cacheDir != "" {
+		t.cacheDir = filepath.Join(cacheDir, "web")
+	}
+	return t
 }
 
 func (t *WebFetchTool) Name() string {
@@ -172,7 +240,7 @@ func (t *WebFetchTool) Name() string {
 }
 
 func (t *WebFetchTool) Description() string {
-	return "Fetch and extract readable content from a URL (HTML → markdown/text)."
+	return "Fetch a URL and extract its readable content (article → markdown with headings, links and tables; PDF and plain text supported). Long pages are returned in parts: pass offset to continue reading."
 }
 
 func (t *WebFetchTool) Parameters() json.RawMessage {
@@ -181,7 +249,8 @@ func (t *WebFetchTool) Parameters() json.RawMessage {
 		"properties": {
 			"url": {"type": "string", "description": "HTTP or HTTPS URL to fetch"},
 			"extractMode": {"type": "string", "description": "Extraction mode: markdown or text", "enum": ["markdown", "text"]},
-			"maxChars": {"type": "number", "description": "Maximum characters to return"}
+			"maxChars": {"type": "number", "description": "Maximum characters to return (default 50000)"},
+			"offset": {"type": "number", "description": "Character offset to start from, for reading long pages in parts"}
 		},
 		"required": ["url"]
 	}`)
@@ -199,12 +268,15 @@ func (t *WebFetchTool) Execute(ctx context.Context, args json.RawMessage) (*Resu
 
 	// 验证 URL
 	parsedURL, err := url.Parse(params.URL)
-	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
 		return &Result{Content: "Invalid URL: must be http or https", IsError: true}, nil
 	}
+	if err := t.guard.checkHost(parsedURL.Hostname()); err != nil {
+		return &Result{Content: "Fetch blocked: " + err.Error(), IsError: true}, nil
+	}
 
 	mode := params.ExtractMode
-	if mode == "" {
+	if mode != "text" {
 		mode = "markdown"
 	}
 
@@ -213,61 +285,208 @@ func (t *WebFetchTool) Execute(ctx context.Context, args json.RawMessage) (*Resu
 		maxChars = 50000
 	}
 
-	// 发起请求
-	req, _ := http.NewRequestWithContext(ctx, "GET", params.URL, nil)
+	// 规范化后的 URL 同时用于请求和缓存 key
+	parsedURL = normalizeURL(parsedURL)
+	page, ok := t.readCache(parsedURL.String(), mode)
+	if !ok {
+		var errResult *Result
+		page, errResult = t.fetch(ctx, parsedURL, mode)
+		if errResult != nil {
+			return errResult, nil
+		}
+		t.writeCache(page, mode)
+	}
+
+	if page.Content == "" {
+		return &Result{Content: "No readable content found"}, nil
+	}
+
+	return &Result{Content: paginateContent(page.Content, params.Offset, maxChars)}, nil
+}
+
+// fetch 下载并提取页面内容
+func (t *WebFetchTool) fetch(ctx context.Context, u *url.URL, mode string) (*fetchedPage, *Result) {
+	req, _ := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
 	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; OpenClaw/1.0)")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,application/pdf,text/plain;q=0.8,*/*;q=0.5")
 
 	resp, err := t.httpClient.Do(req)
 	if err != nil {
-		return &Result{Content: "Fetch failed: " + err.Error(), IsError: true}, nil
+		return nil, &Result{Content: "Fetch failed: " + err.Error(), IsError: true}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return &Result{Content: fmt.Sprintf("HTTP error %d", resp.StatusCode), IsError: true}, nil
+		return nil, &Result{Content: fmt.Sprintf("HTTP error %d", resp.StatusCode), IsError: true}
 	}
 
-	// 解析 HTML
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.maxBytes))
 	if err != nil {
-		return &Result{Content: "Failed to parse HTML: " + err.Error(), IsError: true}, nil
+		return nil, &Result{Content: "Failed to read response: " + err.Error(), IsError: true}
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" {
+		mediaType = http.DetectContentType(body)
+		mediaType, _, _ = mime.ParseMediaType(mediaType)
+	}
+
+	page := &fetchedPage{URL: u.String(), ContentType: mediaType, FetchedAt: time.Now()}
+	finalURL := resp.Request.URL
+
+	switch {
+	case mediaType == "application/pdf" || bytes.HasPrefix(body, []byte("%PDF-")):
+		page.ContentType = "application/pdf"
+		page.Content = cleanText(extractPDFText(body))
+		if page.Content == "" {
+			return nil, &Result{Content: "No extractable text in PDF (it may be scanned or use embedded font encodings)", IsError: true}
+		}
+
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			reader = bytes.NewReader(body)
+		}
+		doc, err := goquery.NewDocumentFromReader(reader)
+		if err != nil {
+			return nil, &Result{Content: "Failed to parse HTML: " + err.Error(), IsError: true}
+		}
+		readable := extractReadable(doc)
+		page.Title = readable.Title
+		page.Content = renderReadable(readable, finalURL, mode)
+
+	case strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "xml"):
+		reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+		if err != nil {
+			reader = bytes.NewReader(body)
+		}
+		text, _ := io.ReadAll(reader)
+		page.Content = strings.TrimSpace(string(text))
+
+	default:
+		return nil, &Result{Content: fmt.Sprintf("Unsupported content type: %s", mediaType), IsError: true}
 	}
 
-	// 移除不需要的元素
-	doc.Find("script, style, nav, footer, header, aside, .ad, .ads, .advertisement").Remove()
+	return page, nil
+}
 
-	// 提取主要内容
+// renderReadable 把正文节点转为 markdown 或纯文本
+func renderReadable(doc readableDoc, base *url.URL, mode string) string {
 	var content string
-	
-	// 尝试找到主要内容区域
-	mainSelectors := []string{"main", "article", "#content", ".content", "#main", ".main", "body"}
-	for _, selector := range mainSelectors {
-		selection := doc.Find(selector).First()
-		if selection.Length() > 0 {
-			html, _ := selection.Html()
-			if mode == "markdown" {
-				content, _ = t.converter.ConvertString(html)
-			} else {
-				content = selection.Text()
-			}
-			break
+	if mode == "markdown" {
+		// 相对链接和图片地址按页面 URL 解析为绝对地址
+		converter := md.NewConverter(base.Host, true, &md.Options{
+			GetAbsoluteURL: func(_ *goquery.Selection, rawURL, _ string) string {
+				ref, err := url.Parse(strings.TrimSpace(rawURL))
+				if err != nil {
+					return rawURL
+				}
+				return base.ResolveReference(ref).String()
+			},
+		})
+		converter.Use(plugin.GitHubFlavored())
+		htmlContent, _ := doc.Content.Html()
+		content, _ = converter.ConvertString(htmlContent)
+		content = cleanText(content)
+		if doc.Title != "" && !strings.HasPrefix(content, "# ") {
+			content = "# " + doc.Title + "\n\n" + content
+		}
+	} else {
+		content = cleanText(doc.Content.Text())
+		if doc.Title != "" && !strings.HasPrefix(content, doc.Title) {
+			content = doc.Title + "\n\n" + content
 		}
 	}
+	return content
+}
+
+// paginateContent 按字符偏移截取内容，并提示如何继续读取
+func paginateContent(content string, offset, maxChars int) string {
+	runes := []rune(content)
+	total := len(runes)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= total {
+		return fmt.Sprintf("[Offset %d is past the end of the content (%d characters)]", offset, total)
+	}
 
-	// 清理内容
-	content = cleanText(content)
+	end := offset + maxChars
+	if end >= total {
+		if offset == 0 {
+			return content
+		}
+		return string(runes[offset:]) + fmt.Sprintf("\n\n[End of content: characters %d-%d of %d]", offset, total, total)
+	}
+	return string(runes[offset:end]) + fmt.Sprintf("\n\n[Truncated: characters %d-%d of %d. Continue with offset=%d]", offset, end, total, end)
+}
 
-	// 截断
-	if len(content) > maxChars {
-		content = content[:maxChars] + "\n\n[Truncated...]"
+// normalizeURL 规范化 URL：scheme 和主机名小写，去掉默认端口和片段，空路径为 "/"
+func normalizeURL(u *url.URL) *url.URL {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	host, port := strings.ToLower(n.Hostname()), n.Port()
+	if (n.Scheme == "http" && port == "80") || (n.Scheme == "https" && port == "443") {
+		port = ""
 	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	n.Host = host
+	n.Fragment, n.RawFragment = "", ""
+	if n.Path == "" && n.Opaque == "" {
+		n.Path = "/"
+	}
+	return &n
+}
 
-	if content == "" {
-		return &Result{Content: "No readable content found"}, nil
+// cachePath 缓存文件路径 (按规范化的 URL + 提取模式)
+func (t *WebFetchTool) cachePath(rawURL, mode string) string {
+	sum := sha256.Sum256([]byte(mode + "\n" + rawURL))
+	return filepath.Join(t.cacheDir, hex.EncodeToString(sum[:16])+".json")
+}
+
+// readCache 读取未过期的缓存
+func (t *WebFetchTool) readCache(rawURL, mode string) (*fetchedPage, bool) {
+	if t.cacheDir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(t.cachePath(rawURL, mode))
+	if err != nil {
+		return nil, false
+	}
+	var page fetchedPage
+	if err := json.Unmarshal(data, &page); err != nil || page.URL != rawURL {
+		return nil, false
 	}
+	if time.Since(page.FetchedAt) > t.cacheTTL {
+		return nil, false
+	}
+	return &page, true
+}
 
-	return &Result{Content: content}, nil
+// writeCache 写入缓存 (失败只记录日志)
+func (t *WebFetchTool) writeCache(page *fetchedPage, mode string) {
+	if t.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(t.cacheDir, 0755); err != nil {
+		log.Warn().Err(err).Msg("Failed to create web cache directory")
+		return
+	}
+	data, _ := json.Marshal(page)
+	path := t.cachePath(page.URL, mode)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		log.Warn().Err(err).Msg("Failed to write web cache")
+		return
+	}
+	os.Rename(tmp, path)
 }
 
 // cleanText 清理文本
@@ -1,14 +1,26 @@
 package tools
 
 import (
+	"bytes"
+	"compress/zlib"
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
 )
 
+// newLocalWebFetchTool 允许访问 httptest 服务器 (回环地址默认被 SSRF 防护拦截)
+func newLocalWebFetchTool() *WebFetchTool {
+	return NewWebFetchToolFromConfig(config.WebFetchConfig{AllowPrivateNetwork: true}, "")
+}
+
 func TestWebFetchTool_BasicFetch(t *testing.T) {
 	// Create a test server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -26,7 +38,7 @@ func TestWebFetchTool_BasicFetch(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool()
+	tool := newLocalWebFetchTool()
 	params := WebFetchParams{URL: server.URL}
 	args, _ := json.Marshal(params)
 
@@ -48,7 +60,7 @@ func TestWebFetchTool_TextMode(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool()
+	tool := newLocalWebFetchTool()
 	params := WebFetchParams{
 		URL:         server.URL,
 		ExtractMode: "text",
@@ -75,7 +87,7 @@ func TestWebFetchTool_MaxChars(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool()
+	tool := newLocalWebFetchTool()
 	params := WebFetchParams{
 		URL:      server.URL,
 		MaxChars: 100,
@@ -107,7 +119,7 @@ func TestWebFetchTool_RemovesScripts(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool()
+	tool := newLocalWebFetchTool()
 	params := WebFetchParams{URL: server.URL}
 	args, _ := json.Marshal(params)
 
@@ -129,7 +141,7 @@ func TestWebFetchTool_404Error(t *testing.T) {
 	}))
 	defer server.Close()
 
-	tool := NewWebFetchTool()
+	tool := newLocalWebFetchTool()
 	params := WebFetchParams{URL: server.URL}
 	args, _ := json.Marshal(params)
 
@@ -240,3 +252,181 @@ func TestCleanText(t *testing.T) {
 		}
 	}
 }
+
+func TestWebFetchTool_BlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><p>internal</p></body></html>`))
+	}))
+	defer server.Close()
+
+	for _, target := range []string{server.URL, "http://localhost:1/", "http://169.254.169.254/latest/meta-data/"} {
+		args, _ := json.Marshal(WebFetchParams{URL: target})
+		result, _ := NewWebFetchTool().Execute(context.Background(), args)
+		if !result.IsError || !strings.Contains(result.Content, "blocked") {
+			t.Errorf("expected %s to be blocked, got: %s", target, result.Content)
+		}
+	}
+
+	// 配置中显式允许的地址可以访问
+	tool := NewWebFetchToolFromConfig(config.WebFetchConfig{AllowHosts: []string{"127.0.0.0/8"}}, "")
+	args, _ := json.Marshal(WebFetchParams{URL: server.URL})
+	result, _ := tool.Execute(context.Background(), args)
+	if result.IsError || !strings.Contains(result.Content, "internal") {
+		t.Errorf("expected allowed host to be fetched, got: %s", result.Content)
+	}
+}
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := map[string]bool{
+		"10.1.2.3":        true,
+		"127.0.0.1":       true,
+		"169.254.169.254": true,
+		"100.64.0.1":      true,
+		"::1":             true,
+		"fd00::1":         true,
+		"::ffff:10.0.0.1": true,
+		"8.8.8.8":         false,
+		"2606:4700::1111": false,
+	}
+	for ip, want := range tests {
+		if got := isPrivateIP(net.ParseIP(ip)); got != want {
+			t.Errorf("isPrivateIP(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
+
+func TestWebFetchTool_ReadableExtraction(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Release Notes</title></head><body>
+			<div class="sidebar"><a href="/a">Popular</a> <a href="/b">Trending</a></div>
+			<div id="post-body" class="article-content">
+				<h2>What changed</h2>
+				<p>This release rewrites the scheduler, improves startup time, and fixes several bugs in the parser.</p>
+				<p>See the <a href="/docs/upgrade">upgrade guide</a> for details, including migration steps and caveats.</p>
+				<table><thead><tr><th>Version</th><th>Date</th></tr></thead><tbody><tr><td>2.0</td><td>2025-01-15</td></tr></tbody></table>
+			</div>
+			<div class="comments"><p>Great release, thanks a lot to everyone involved in it!</p></div>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(WebFetchParams{URL: server.URL})
+	result, _ := newLocalWebFetchTool().Execute(context.Background(), args)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content)
+	}
+
+	for _, want := range []string{"# Release Notes", "## What changed", "[upgrade guide](" + server.URL + "/docs/upgrade)", "| Version | Date |"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("expected %q in content:\n%s", want, result.Content)
+		}
+	}
+	if strings.Contains(result.Content, "Trending") || strings.Contains(result.Content, "Great release") {
+		t.Errorf("expected sidebar and comments to be dropped:\n%s", result.Content)
+	}
+}
+
+func TestWebFetchTool_Charset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/meta" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><meta charset=\"windows-1252\"></head><body><p>Caf\xe9 cr\xe8me</p></body></html>"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=iso-8859-1")
+		w.Write([]byte("na\xefve r\xe9sum\xe9"))
+	}))
+	defer server.Close()
+
+	tool := newLocalWebFetchTool()
+	args, _ := json.Marshal(WebFetchParams{URL: server.URL + "/plain"})
+	result, _ := tool.Execute(context.Background(), args)
+	if result.Content != "naïve résumé" {
+		t.Errorf("unexpected plain text decoding: %q", result.Content)
+	}
+
+	args, _ = json.Marshal(WebFetchParams{URL: server.URL + "/meta", ExtractMode: "text"})
+	result, _ = tool.Execute(context.Background(), args)
+	if !strings.Contains(result.Content, "Café crème") {
+		t.Errorf("unexpected meta charset decoding: %q", result.Content)
+	}
+}
+
+func TestWebFetchTool_PDF(t *testing.T) {
+	var stream bytes.Buffer
+	zw := zlib.NewWriter(&stream)
+	zw.Write([]byte("BT /F1 12 Tf 72 712 Td (Quarterly report) Tj 0 -14 Td [(Revenue ) -300 (grew)] TJ ET"))
+	zw.Close()
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n1 0 obj\n<< /Length ")
+	pdf.WriteString(strconv.Itoa(stream.Len()))
+	pdf.WriteString(" /Filter /FlateDecode >>\nstream\n")
+	pdf.Write(stream.Bytes())
+	pdf.WriteString("\nendstream\nendobj\n%%EOF\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write(pdf.Bytes())
+	}))
+	defer server.Close()
+
+	args, _ := json.Marshal(WebFetchParams{URL: server.URL + "/report.pdf"})
+	result, _ := newLocalWebFetchTool().Execute(context.Background(), args)
+	if result.IsError || result.Content != "Quarterly report\nRevenue  grew" {
+		t.Errorf("unexpected PDF text: %q", result.Content)
+	}
+}
+
+func TestWebFetchTool_PaginationAndCache(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strings.Repeat("a", 60) + strings.Repeat("b", 60)))
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchToolFromConfig(config.WebFetchConfig{AllowPrivateNetwork: true}, t.TempDir())
+
+	args, _ := json.Marshal(WebFetchParams{URL: server.URL, MaxChars: 60})
+	result, _ := tool.Execute(context.Background(), args)
+	if !strings.HasPrefix(result.Content, strings.Repeat("a", 60)+"\n") || !strings.Contains(result.Content, "offset=60") {
+		t.Errorf("unexpected first page: %s", result.Content)
+	}
+
+	args, _ = json.Marshal(WebFetchParams{URL: server.URL, MaxChars: 60, Offset: 60})
+	result, _ = tool.Execute(context.Background(), args)
+	if !strings.HasPrefix(result.Content, strings.Repeat("b", 60)+"\n") || !strings.Contains(result.Content, "End of content") {
+		t.Errorf("unexpected second page: %s", result.Content)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected second page to be served from cache, got %d requests", requests)
+	}
+
+	// 同一页面的不同写法共用缓存
+	for _, variant := range []string{server.URL + "/", strings.Replace(server.URL, "http://", "HTTP://", 1) + "#top"} {
+		args, _ = json.Marshal(WebFetchParams{URL: variant, MaxChars: 60})
+		tool.Execute(context.Background(), args)
+	}
+	if requests != 1 {
+		t.Errorf("expected URL variants to hit the cache, got %d requests", requests)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := map[string]string{
+		"HTTPS://Example.COM":           "https://example.com/",
+		"http://example.com:80/a?b=1#c": "http://example.com/a?b=1",
+		"https://example.com:8443/a":    "https://example.com:8443/a",
+		"http://[::1]:80/":              "http://[::1]/",
+	}
+	for raw, want := range tests {
+		u, _ := url.Parse(raw)
+		if got := normalizeURL(u).String(); got != want {
+			t.Errorf("normalizeURL(%s) = %s, want %s", raw, got, want)
+		}
+	}
+}
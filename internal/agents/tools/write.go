@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
 )
 
 // WriteTool 写入文件的工具
 type WriteTool struct {
-	workdir string
+	workdir     string
+	paths       *PathPolicy
+	checkpoints *checkpoints.Store
 }
 
 // WriteParams write 工具参数
@@ -23,9 +27,20 @@ type WriteParams struct {
 func NewWriteTool(workdir string) *WriteTool {
 	return &WriteTool{
 		workdir: workdir,
+		paths:   DefaultPathPolicy(workdir),
 	}
 }
 
+// SetCheckpoints 写入前保存文件快照
+func (t *WriteTool) SetCheckpoints(store *checkpoints.Store) {
+	t.checkpoints = store
+}
+
+// SetPathPolicy 设置路径访问策略
+func (t *WriteTool) SetPathPolicy(p *PathPolicy) {
+	t.paths = p
+}
+
 func (t *WriteTool) Name() string {
 	return ToolWrite
 }
@@ -63,9 +78,9 @@ func (t *WriteTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 	}
 
 	// 解析路径
-	path := params.Path
-	if !filepath.IsAbs(path) {
-		path = filepath.Join(t.workdir, path)
+	path, err := t.paths.Resolve(ctx, params.Path, true)
+	if err != nil {
+		return &Result{Content: err.Error(), IsError: true}, nil
 	}
 
 	// 创建父目录
@@ -78,6 +93,10 @@ func (t *WriteTool) Execute(ctx context.Context, args json.RawMessage) (*Result,
 	_, existsErr := os.Stat(path)
 	fileExists := existsErr == nil
 
+	if err := snapshotFiles(ctx, t.checkpoints, ToolWrite, path); err != nil {
+		return &Result{Content: fmt.Sprintf("Failed to checkpoint file: %v", err), IsError: true}, nil
+	}
+
 	// 写入文件
 	if err := os.WriteFile(path, []byte(params.Content), 0644); err != nil {
 		return &Result{Content: fmt.Sprintf("Failed to write file: %v", err), IsError: true}, nil
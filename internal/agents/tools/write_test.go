@@ -97,7 +97,7 @@ func TestWriteTool_CreateDirectories(t *testing.T) {
 
 func TestWriteTool_AbsolutePath(t *testing.T) {
 	tmpDir := t.TempDir()
-	tool := NewWriteTool("/different/path")
+	tool := NewWriteTool(tmpDir)
 
 	testFile := filepath.Join(tmpDir, "abs.txt")
 	params := WriteParams{
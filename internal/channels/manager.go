@@ -3,6 +3,9 @@ package channels
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -100,6 +103,36 @@ func (m *Manager) StopAll() error {
 	return nil
 }
 
+// Start 启动单个已注册的渠道 (如配置热应用后替换的渠道)
+func (m *Manager) Start(id string) error {
+	m.mu.RLock()
+	ch, ok := m.channels[id]
+	m.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("channel not found: %s", id)
+	}
+	if err := ch.Start(m.ctx); err != nil {
+		return err
+	}
+	log.Info().Str("channel", id).Msg("Channel started")
+	return nil
+}
+
+// Unregister 停止并移除渠道，渠道不存在时不做任何事
+func (m *Manager) Unregister(id string) error {
+	m.mu.Lock()
+	ch, ok := m.channels[id]
+	delete(m.channels, id)
+	m.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	log.Info().Str("channel", id).Msg("Channel unregistered")
+	return ch.Stop()
+}
+
 // SetMessageHandler 设置全局消息处理回调
 func (m *Manager) SetMessageHandler(handler func(msg *InboundMessage) error) {
 	m.mu.Lock()
@@ -251,8 +284,22 @@ type MessageRouter struct {
 	getSession  func(channelID, chatID string) (sessionKey string, isNew bool)
 	runAgent    func(ctx context.Context, sessionKey string, message *InboundMessage) (string, error)
 	sendMessage func(ctx context.Context, channelID, chatID, text string) error
+
+	// 语音回复
+	voiceMode  string
+	synthesize SynthesizeFunc
 }
 
+// SynthesizeFunc 将文本合成为适合 channel 的音频文件，返回文件路径
+type SynthesizeFunc func(ctx context.Context, text, channel string) (string, error)
+
+// 语音回复模式
+const (
+	VoiceReplyOff     = "off"
+	VoiceReplyInbound = "inbound" // 用户发送语音时用语音回复
+	VoiceReplyAlways  = "always"
+)
+
 // NewMessageRouter 创建消息路由器
 func NewMessageRouter(channels *Manager) *MessageRouter {
 	router := &MessageRouter{
@@ -277,6 +324,12 @@ func (r *MessageRouter) SetAgentRunner(runner func(ctx context.Context, sessionK
 	r.runAgent = runner
 }
 
+// SetVoiceReply 设置语音回复模式 (tts.autoReply) 和合成函数
+func (r *MessageRouter) SetVoiceReply(mode string, synthesize SynthesizeFunc) {
+	r.voiceMode = mode
+	r.synthesize = synthesize
+}
+
 // handleMessage 处理入站消息
 func (r *MessageRouter) handleMessage(msg *InboundMessage) error {
 	if r.getSession == nil || r.runAgent == nil {
@@ -304,8 +357,18 @@ func (r *MessageRouter) handleMessage(msg *InboundMessage) error {
 			response = "抱歉，处理消息时出错: " + err.Error()
 		}
 		
-		// 发送响应
+		// 发送响应；语音回复失败时回退到文本
 		if response != "" {
+			if r.synthesize != nil && WantsVoiceReply(r.voiceMode, msg) {
+				reply, err := NewVoiceReply(ctx, msg, response, r.synthesize)
+				if err == nil {
+					_, err = r.channels.Send(ctx, msg.Channel, reply)
+				}
+				if err == nil {
+					return
+				}
+				log.Warn().Err(err).Str("channel", msg.Channel).Msg("Voice reply failed, sending text")
+			}
 			if _, err := r.channels.Reply(ctx, msg, response); err != nil {
 				log.Error().Err(err).Msg("Failed to send response")
 			}
@@ -314,3 +377,57 @@ func (r *MessageRouter) handleMessage(msg *InboundMessage) error {
 	
 	return nil
 }
+
+// WantsVoiceReply 按语音回复模式判断是否应该用语音回复该消息
+func WantsVoiceReply(mode string, msg *InboundMessage) bool {
+	switch mode {
+	case VoiceReplyAlways:
+		return true
+	case VoiceReplyInbound:
+		for _, att := range msg.Attachments {
+			if att.Type == AttachmentTypeVoice {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewVoiceReply 合成 text 并构造回复 original 的语音消息
+func NewVoiceReply(ctx context.Context, original *InboundMessage, text string, synthesize SynthesizeFunc) (*OutboundMessage, error) {
+	path, err := synthesize(ctx, text, original.Channel)
+	if err != nil {
+		return nil, err
+	}
+	att, err := NewAudioAttachment(path)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboundMessage{
+		ChatID:      original.ChatID,
+		ReplyTo:     original.ID,
+		Attachments: []Attachment{*att},
+	}, nil
+}
+
+// NewAudioAttachment 读取音频文件作为附件；OGG/Opus 作为语音消息，其他格式作为音频
+func NewAudioAttachment(path string) (*Attachment, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read audio: %w", err)
+	}
+	att := &Attachment{
+		Type:     AttachmentTypeAudio,
+		Data:     data,
+		MimeType: "audio/mpeg",
+		Filename: filepath.Base(path),
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ogg", ".opus":
+		att.Type = AttachmentTypeVoice
+		att.MimeType = "audio/ogg; codecs=opus"
+	case ".wav":
+		att.MimeType = "audio/wav"
+	}
+	return att, nil
+}
@@ -2,6 +2,8 @@ package channels
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -141,6 +143,41 @@ func TestManager_StopAll(t *testing.T) {
 	}
 }
 
+func TestManager_RestartSingleChannel(t *testing.T) {
+	m := NewManager()
+	old := NewMockChannel("test", "Test")
+	other := NewMockChannel("other", "Other")
+	m.Register(old)
+	m.Register(other)
+	m.StartAll()
+
+	if err := m.Unregister("test"); err != nil {
+		t.Fatalf("Unregister failed: %v", err)
+	}
+	if old.Status().Connected {
+		t.Error("unregistered channel should be stopped")
+	}
+	if _, ok := m.Get("test"); ok {
+		t.Error("unregistered channel should be removed")
+	}
+
+	replacement := NewMockChannel("test", "Test")
+	m.Register(replacement)
+	if err := m.Start("test"); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !replacement.Status().Connected || !other.Status().Connected {
+		t.Error("replacement should be started and other channels left running")
+	}
+
+	if err := m.Start("missing"); err == nil {
+		t.Error("expected error for unknown channel")
+	}
+	if err := m.Unregister("missing"); err != nil {
+		t.Errorf("Unregister of unknown channel should be a no-op: %v", err)
+	}
+}
+
 func TestManager_Send(t *testing.T) {
 	m := NewManager()
 	ch := NewMockChannel("test", "Test")
@@ -335,3 +372,118 @@ func TestMessageRouter(t *testing.T) {
 		t.Errorf("Response mismatch: %s", sent[0].Text)
 	}
 }
+
+func TestMessageRouter_VoiceReply(t *testing.T) {
+	m := NewManager()
+	ch := NewMockChannel("test", "Test")
+	m.Register(ch)
+	
+	audio := filepath.Join(t.TempDir(), "reply.ogg")
+	os.WriteFile(audio, []byte("OggS"), 0644)
+	
+	router := NewMessageRouter(m)
+	router.SetSessionResolver(func(channelID, chatID string) (string, bool) {
+		return "session-" + chatID, false
+	})
+	router.SetAgentRunner(func(ctx context.Context, sessionKey string, msg *InboundMessage) (string, error) {
+		return "Agent response", nil
+	})
+	var mu sync.Mutex
+	var spoken []string
+	router.SetVoiceReply(VoiceReplyInbound, func(ctx context.Context, text, channel string) (string, error) {
+		mu.Lock()
+		spoken = append(spoken, channel+": "+text)
+		mu.Unlock()
+		return audio, nil
+	})
+	
+	// 文本消息用文本回复
+	ch.SimulateMessage(&InboundMessage{ID: "msg-1", Channel: "test", ChatID: "chat-1", Text: "hi"})
+	time.Sleep(100 * time.Millisecond)
+	// 语音消息用语音回复
+	ch.SimulateMessage(&InboundMessage{ID: "msg-2", Channel: "test", ChatID: "chat-1",
+		Attachments: []Attachment{{Type: AttachmentTypeVoice, URL: "file-id"}}})
+	time.Sleep(100 * time.Millisecond)
+	
+	sent := ch.GetSentMessages()
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 responses, got %d", len(sent))
+	}
+	if sent[0].Text != "Agent response" || len(sent[0].Attachments) != 0 {
+		t.Errorf("Expected text reply, got %+v", sent[0])
+	}
+	if len(sent[1].Attachments) != 1 || sent[1].ReplyTo != "msg-2" || sent[1].ChatID != "chat-1" {
+		t.Fatalf("Expected voice reply, got %+v", sent[1])
+	}
+	if att := sent[1].Attachments[0]; att.Type != AttachmentTypeVoice || string(att.Data) != "OggS" {
+		t.Errorf("Unexpected attachment: %+v", att)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(spoken) != 1 || spoken[0] != "test: Agent response" {
+		t.Errorf("Unexpected synthesized text: %v", spoken)
+	}
+}
+
+func TestMessageRouter_VoiceReplyFallback(t *testing.T) {
+	m := NewManager()
+	ch := NewMockChannel("test", "Test")
+	m.Register(ch)
+	
+	router := NewMessageRouter(m)
+	router.SetSessionResolver(func(channelID, chatID string) (string, bool) {
+		return "session-" + chatID, false
+	})
+	router.SetAgentRunner(func(ctx context.Context, sessionKey string, msg *InboundMessage) (string, error) {
+		return "Agent response", nil
+	})
+	router.SetVoiceReply(VoiceReplyAlways, func(ctx context.Context, text, channel string) (string, error) {
+		return "", os.ErrNotExist
+	})
+	
+	// 合成失败时回退到文本
+	ch.SimulateMessage(&InboundMessage{ID: "msg-1", Channel: "test", ChatID: "chat-1", Text: "hi"})
+	time.Sleep(100 * time.Millisecond)
+	
+	sent := ch.GetSentMessages()
+	if len(sent) != 1 || sent[0].Text != "Agent response" {
+		t.Fatalf("Expected text fallback, got %+v", sent)
+	}
+}
+
+func TestNewAudioAttachment(t *testing.T) {
+	dir := t.TempDir()
+	ogg := filepath.Join(dir, "reply.ogg")
+	mp3 := filepath.Join(dir, "reply.mp3")
+	os.WriteFile(ogg, []byte("OggS"), 0644)
+	os.WriteFile(mp3, []byte("ID3"), 0644)
+	
+	att, err := NewAudioAttachment(ogg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.Type != AttachmentTypeVoice || string(att.Data) != "OggS" || att.MimeType != "audio/ogg; codecs=opus" {
+		t.Errorf("Unexpected attachment: %+v", att)
+	}
+	if att, _ := NewAudioAttachment(mp3); att.Type != AttachmentTypeAudio || att.MimeType != "audio/mpeg" {
+		t.Errorf("Unexpected attachment: %+v", att)
+	}
+	if _, err := NewAudioAttachment(filepath.Join(dir, "missing.ogg")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+func TestWantsVoiceReply(t *testing.T) {
+	voice := &InboundMessage{Attachments: []Attachment{{Type: AttachmentTypeVoice}}}
+	text := &InboundMessage{Text: "hi"}
+	
+	if WantsVoiceReply("", voice) || WantsVoiceReply(VoiceReplyOff, voice) {
+		t.Error("Voice reply should be off by default")
+	}
+	if !WantsVoiceReply(VoiceReplyInbound, voice) || WantsVoiceReply(VoiceReplyInbound, text) {
+		t.Error("inbound mode should only reply to voice messages")
+	}
+	if !WantsVoiceReply(VoiceReplyAlways, text) {
+		t.Error("always mode should reply to text messages")
+	}
+}
@@ -3,6 +3,7 @@ package signal
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -195,6 +196,14 @@ func (c *Channel) Send(ctx context.Context, msg *channels.OutboundMessage) (*cha
 		Message:    msg.Text,
 	}
 	
+	// 附件 (如语音回复)
+	for _, att := range msg.Attachments {
+		if len(att.Data) > 0 {
+			reqBody.Base64Attachments = append(reqBody.Base64Attachments,
+				"data:"+att.MimeType+";base64,"+base64.StdEncoding.EncodeToString(att.Data))
+		}
+	}
+	
 	// 处理回复
 	if msg.ReplyTo != "" {
 		// msg.ReplyTo 应该是 timestamp
@@ -131,6 +131,13 @@ func (c *Channel) Send(ctx context.Context, msg *channels.OutboundMessage) (*cha
 		return nil, fmt.Errorf("invalid chat ID: %w", err)
 	}
 	
+	// 语音/音频附件
+	for _, att := range msg.Attachments {
+		if att.Type == channels.AttachmentTypeVoice || att.Type == channels.AttachmentTypeAudio {
+			return c.sendAudio(chatID, msg, att)
+		}
+	}
+	
 	tgMsg := tgbotapi.NewMessage(chatID, msg.Text)
 	
 	// 设置格式
@@ -181,6 +188,62 @@ func (c *Channel) Send(ctx context.Context, msg *channels.OutboundMessage) (*cha
 	}, nil
 }
 
+// maxCaptionLength Telegram 媒体说明的长度限制
+const maxCaptionLength = 1024
+
+// sendAudio 发送语音消息 (OGG/Opus) 或音频文件，文本作为说明；过长时先单独发送文本
+func (c *Channel) sendAudio(chatID int64, msg *channels.OutboundMessage, att channels.Attachment) (*channels.SendResult, error) {
+	var file tgbotapi.RequestFileData
+	switch {
+	case len(att.Data) > 0:
+		file = tgbotapi.FileBytes{Name: att.Filename, Bytes: att.Data}
+	case att.URL != "":
+		file = tgbotapi.FileURL(att.URL)
+	default:
+		return nil, fmt.Errorf("attachment has no data")
+	}
+	
+	caption := att.Caption
+	if caption == "" {
+		caption = msg.Text
+	}
+	if len([]rune(caption)) > maxCaptionLength {
+		text := *msg
+		text.Attachments = nil
+		if _, err := c.Send(c.ctx, &text); err != nil {
+			return nil, err
+		}
+		caption = ""
+	}
+	replyID, _ := strconv.Atoi(msg.ReplyTo)
+	
+	var cfg tgbotapi.Chattable
+	if att.Type == channels.AttachmentTypeVoice {
+		voice := tgbotapi.NewVoice(chatID, file)
+		voice.Caption = caption
+		voice.Duration = att.Duration
+		voice.ReplyToMessageID = replyID
+		voice.DisableNotification = msg.Silent
+		cfg = voice
+	} else {
+		audio := tgbotapi.NewAudio(chatID, file)
+		audio.Caption = caption
+		audio.Duration = att.Duration
+		audio.ReplyToMessageID = replyID
+		audio.DisableNotification = msg.Silent
+		cfg = audio
+	}
+	
+	sent, err := c.bot.Send(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send telegram audio: %w", err)
+	}
+	return &channels.SendResult{
+		MessageID: strconv.Itoa(sent.MessageID),
+		Timestamp: time.Now().UnixMilli(),
+	}, nil
+}
+
 func (c *Channel) SetMessageHandler(handler channels.MessageHandler) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
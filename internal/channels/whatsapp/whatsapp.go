@@ -170,6 +170,13 @@ func (c *Channel) Send(ctx context.Context, msg *channels.OutboundMessage) (*cha
 		return nil, fmt.Errorf("invalid chat ID: %w", err)
 	}
 
+	// 语音/音频附件
+	for _, att := range msg.Attachments {
+		if (att.Type == channels.AttachmentTypeVoice || att.Type == channels.AttachmentTypeAudio) && len(att.Data) > 0 {
+			return c.sendAudio(ctx, jid, msg.Text, att)
+		}
+	}
+
 	// 发送消息
 	resp, err := c.client.SendMessage(ctx, jid, &waE2E.Message{
 		Conversation: proto.String(msg.Text),
@@ -341,6 +348,48 @@ func (c *Channel) SendImage(ctx context.Context, chatID string, imageData []byte
 	}, nil
 }
 
+// sendAudio 发送音频；语音 (OGG/Opus) 作为按住说话 (PTT) 消息，文本单独发送在前
+func (c *Channel) sendAudio(ctx context.Context, jid types.JID, text string, att channels.Attachment) (*channels.SendResult, error) {
+	if text != "" {
+		if _, err := c.client.SendMessage(ctx, jid, &waE2E.Message{Conversation: proto.String(text)}); err != nil {
+			return nil, fmt.Errorf("send message: %w", err)
+		}
+	}
+
+	uploaded, err := c.client.Upload(ctx, att.Data, whatsmeow.MediaAudio)
+	if err != nil {
+		return nil, fmt.Errorf("upload audio: %w", err)
+	}
+
+	mimeType := att.MimeType
+	if mimeType == "" {
+		mimeType = "audio/mpeg"
+	}
+	audioMsg := &waE2E.AudioMessage{
+		Mimetype:      proto.String(mimeType),
+		URL:           proto.String(uploaded.URL),
+		DirectPath:    proto.String(uploaded.DirectPath),
+		MediaKey:      uploaded.MediaKey,
+		FileEncSHA256: uploaded.FileEncSHA256,
+		FileSHA256:    uploaded.FileSHA256,
+		FileLength:    proto.Uint64(uint64(len(att.Data))),
+		PTT:           proto.Bool(att.Type == channels.AttachmentTypeVoice),
+	}
+	if att.Duration > 0 {
+		audioMsg.Seconds = proto.Uint32(uint32(att.Duration))
+	}
+
+	resp, err := c.client.SendMessage(ctx, jid, &waE2E.Message{AudioMessage: audioMsg})
+	if err != nil {
+		return nil, fmt.Errorf("send audio: %w", err)
+	}
+
+	return &channels.SendResult{
+		MessageID: resp.ID,
+		Timestamp: resp.Timestamp.UnixMilli(),
+	}, nil
+}
+
 // Capabilities 返回渠道能力
 func (c *Channel) Capabilities() channels.ChannelCapabilities {
 	return channels.ChannelCapabilities{
@@ -0,0 +1,180 @@
+package checkpoints
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext 统一 diff 的上下文行数
+const diffContext = 3
+
+// UnifiedDiff 生成 from -> to 的统一 diff，内容相同时返回空字符串
+func UnifiedDiff(path, from, to string) string {
+	if from == to {
+		return ""
+	}
+	a := splitLines(from)
+	b := splitLines(to)
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", strings.TrimPrefix(path, "/"), strings.TrimPrefix(path, "/"))
+
+	// 按变化位置分组成 hunk
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// 连续的相同行超过两倍上下文时结束 hunk
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+
+		oldStart, newStart := ops[start].oldLine, ops[start].newLine
+		oldCount, newCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.text)
+			sb.WriteByte('\n')
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// 空范围的行号指向其前一行
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// diffOp 一行的编辑操作；oldLine/newLine 为该行在两侧的行号 (1 起)
+type diffOp struct {
+	kind    byte // ' ', '-', '+'
+	text    string
+	oldLine int
+	newLine int
+}
+
+// maxDiffEdits 编辑距离上限，超过时整体替换 (避免大文件重写时占用过多内存)
+const maxDiffEdits = 2000
+
+// diffLines Myers 算法计算最短编辑脚本
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := min(n+m, maxDiffEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] 保存第 d 步开始前 k ∈ [-d-1, d+1] 的 v
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b, d)
+			}
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack 从 trace 还原编辑操作
+func backtrack(trace [][]int, a, b []string, d int) []diffOp {
+	x, y := len(a), len(b)
+	var ops []diffOp
+	for ; d > 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{kind: ' ', text: a[x-1], oldLine: x, newLine: y})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{kind: '+', text: b[y-1], oldLine: x + 1, newLine: y})
+			y--
+		} else {
+			ops = append(ops, diffOp{kind: '-', text: a[x-1], oldLine: x, newLine: y + 1})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, diffOp{kind: ' ', text: a[x-1], oldLine: x, newLine: y})
+		x--
+		y--
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceAll 删除全部旧行再添加全部新行
+func replaceAll(a, b []string) []diffOp {
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i, line := range a {
+		ops = append(ops, diffOp{kind: '-', text: line, oldLine: i + 1, newLine: 1})
+	}
+	for i, line := range b {
+		ops = append(ops, diffOp{kind: '+', text: line, oldLine: len(a) + 1, newLine: i + 1})
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,523 @@
+// Package checkpoints 在工具修改文件前保存快照，支持按会话和轮次回滚。
+//
+// 文件内容按 SHA-256 内容寻址保存在 objects/ 下，相同内容只存一份；
+// 每个会话的检查点列表保存在 sessions/<session>.json。
+// 检查点记录的是某一轮中被修改的文件在修改前的状态，
+// 恢复到检查点 N 即撤销第 N 个及之后所有检查点的修改。
+package checkpoints
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultMaxPerSession 每个会话保留的检查点数量
+	DefaultMaxPerSession = 200
+	// MaxFileSize 超过此大小的文件不保存内容，无法恢复
+	MaxFileSize = 20 * 1024 * 1024
+)
+
+// Checkpoint 一轮对话中被修改文件的修改前快照
+type Checkpoint struct {
+	ID        int            `json:"id"`
+	Session   string         `json:"session"`
+	Turn      int            `json:"turn"`
+	Tools     []string       `json:"tools"`
+	CreatedAt time.Time      `json:"createdAt"`
+	Files     []FileSnapshot `json:"files"`
+}
+
+// FileSnapshot 文件在修改前的状态
+type FileSnapshot struct {
+	Path   string      `json:"path"`
+	Exists bool        `json:"exists"`
+	Hash   string      `json:"hash,omitempty"`
+	Mode   os.FileMode `json:"mode,omitempty"`
+	Size   int64       `json:"size,omitempty"`
+	TooBig bool        `json:"tooBig,omitempty"` // 内容未保存
+	Dir    string      `json:"dir,omitempty"`    // 快照时父目录的真实路径
+}
+
+// PathCheck 恢复前检查写入目标是否允许 (如文件工具的路径策略)，返回错误表示拒绝
+type PathCheck func(path string) error
+
+// Store 检查点存储
+type Store struct {
+	dir           string
+	maxPerSession int
+	open          map[string]openTurn // 每个会话当前轮次的检查点 (仅本进程内有效)
+	mu            sync.Mutex
+}
+
+type openTurn struct {
+	turn int
+	id   int
+}
+
+// NewStore 创建检查点存储，数据保存在 <stateDir>/checkpoints
+func NewStore(stateDir string) *Store {
+	return &Store{
+		dir:           filepath.Join(stateDir, "checkpoints"),
+		maxPerSession: DefaultMaxPerSession,
+		open:          make(map[string]openTurn),
+	}
+}
+
+// SetMaxPerSession 设置每个会话保留的检查点数量 (<= 0 表示不限制)
+func (s *Store) SetMaxPerSession(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxPerSession = n
+}
+
+// Snapshot 在修改 paths 前调用：记录它们在本轮中第一次被修改前的状态。
+// 同一会话同一轮的多次调用合并到一个检查点。
+func (s *Store) Snapshot(session string, turn int, tool string, paths ...string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+
+	var cp *Checkpoint
+	if o, ok := s.open[session]; ok && o.turn == turn && turn > 0 {
+		if n := len(list); n > 0 && list[n-1].ID == o.id {
+			cp = &list[n-1]
+		}
+	}
+	if cp == nil {
+		list = append(list, Checkpoint{
+			ID:        nextID(list),
+			Session:   session,
+			Turn:      turn,
+			CreatedAt: time.Now(),
+		})
+		cp = &list[len(list)-1]
+		s.open[session] = openTurn{turn: turn, id: cp.ID}
+	}
+	if !containsString(cp.Tools, tool) {
+		cp.Tools = append(cp.Tools, tool)
+	}
+
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if cp.file(p) != nil {
+			continue
+		}
+		snap, err := s.capture(p)
+		if err != nil {
+			return nil, err
+		}
+		cp.Files = append(cp.Files, snap)
+	}
+
+	pruned := false
+	if s.maxPerSession > 0 && len(list) > s.maxPerSession {
+		list = append([]Checkpoint(nil), list[len(list)-s.maxPerSession:]...)
+		pruned = true
+	}
+	if err := s.save(session, list); err != nil {
+		return nil, err
+	}
+	if pruned {
+		s.gc()
+	}
+	result := list[len(list)-1]
+	return &result, nil
+}
+
+// List 列出会话的检查点 (按 ID 升序)
+func (s *Store) List(session string) ([]Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load(session)
+}
+
+// Sessions 列出有检查点的会话
+func (s *Store) Sessions() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "sessions"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []string
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".json")
+		if name == e.Name() {
+			continue
+		}
+		if key, err := url.PathUnescape(name); err == nil {
+			sessions = append(sessions, key)
+		}
+	}
+	sort.Strings(sessions)
+	return sessions, nil
+}
+
+// FileChange 恢复到某个检查点时单个文件的变化
+type FileChange struct {
+	Path    string `json:"path"`
+	Action  string `json:"action"` // restore, delete, create
+	Diff    string `json:"diff,omitempty"`
+	Skipped string `json:"skipped,omitempty"` // 无法恢复的原因
+}
+
+// Diff 恢复到检查点 id 会产生的变化 (当前内容 -> 检查点前的内容)
+func (s *Store) Diff(session string, id int) ([]FileChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets, err := s.targets(session, id)
+	if err != nil {
+		return nil, err
+	}
+	var changes []FileChange
+	for _, snap := range targets {
+		change, _, err := s.plan(snap)
+		if err != nil {
+			return nil, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+	}
+	return changes, nil
+}
+
+// Restore 将检查点 id 及之后修改过的文件恢复到检查点前的状态。
+// 恢复前的当前状态会保存为一个新的检查点，因此恢复本身也可以撤销。
+// 父目录真实路径与快照时不同 (如被换成符号链接) 或未通过 check 的文件不会写入，
+// 在结果中标记为 Skipped；check 为空时只检查父目录。
+func (s *Store) Restore(session string, id int, check PathCheck) ([]FileChange, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets, err := s.targets(session, id)
+	if err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		snap FileSnapshot
+		data []byte
+	}
+	var changes []FileChange
+	var todo []pending
+	for _, snap := range targets {
+		change, data, err := s.plan(snap)
+		if err != nil {
+			return nil, err
+		}
+		if change == nil {
+			continue
+		}
+		if change.Skipped == "" {
+			change.Skipped = guard(snap, check)
+		}
+		changes = append(changes, *change)
+		if change.Skipped == "" {
+			todo = append(todo, pending{snap: snap, data: data})
+		}
+	}
+	if len(todo) == 0 {
+		return changes, nil
+	}
+
+	// 保存当前状态，之后可以撤销这次恢复
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	backup := Checkpoint{
+		ID:        nextID(list),
+		Session:   session,
+		Tools:     []string{fmt.Sprintf("restore:%d", id)},
+		CreatedAt: time.Now(),
+	}
+	for _, p := range todo {
+		snap, err := s.capture(p.snap.Path)
+		if err != nil {
+			return nil, err
+		}
+		backup.Files = append(backup.Files, snap)
+	}
+	if err := s.save(session, append(list, backup)); err != nil {
+		return nil, err
+	}
+
+	for _, p := range todo {
+		if !p.snap.Exists {
+			if err := os.Remove(p.snap.Path); err != nil && !os.IsNotExist(err) {
+				return changes, fmt.Errorf("remove %s: %w", p.snap.Path, err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p.snap.Path), 0755); err != nil {
+			return changes, err
+		}
+		if err := os.WriteFile(p.snap.Path, p.data, p.snap.Mode.Perm()); err != nil {
+			return changes, fmt.Errorf("restore %s: %w", p.snap.Path, err)
+		}
+		os.Chmod(p.snap.Path, p.snap.Mode.Perm())
+	}
+	return changes, nil
+}
+
+// guard 写入前重新检查目标路径，返回拒绝原因 (空表示允许)
+func guard(snap FileSnapshot, check PathCheck) string {
+	if snap.Dir != "" {
+		dir, err := realPath(filepath.Dir(snap.Path))
+		if err != nil {
+			return "cannot resolve parent directory: " + err.Error()
+		}
+		if dir != snap.Dir {
+			return fmt.Sprintf("parent directory now resolves to %s (was %s)", dir, snap.Dir)
+		}
+	}
+	if check != nil {
+		if err := check(snap.Path); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// realPath 解析符号链接后的真实路径；不存在时解析最长的已存在前缀
+func realPath(name string) (string, error) {
+	existing := name
+	var rest []string
+	for {
+		real, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(append([]string{real}, rest...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return name, nil
+		}
+		rest = append([]string{filepath.Base(existing)}, rest...)
+		existing = parent
+	}
+}
+
+// targets 检查点 id 及之后涉及的每个文件最早的快照
+func (s *Store) targets(session string, id int) ([]FileSnapshot, error) {
+	list, err := s.load(session)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	seen := make(map[string]bool)
+	var targets []FileSnapshot
+	for _, cp := range list {
+		if cp.ID < id {
+			continue
+		}
+		if cp.ID == id {
+			found = true
+		}
+		for _, f := range cp.Files {
+			if !seen[f.Path] {
+				seen[f.Path] = true
+				targets = append(targets, f)
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("checkpoint %d not found in session %s", id, session)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Path < targets[j].Path })
+	return targets, nil
+}
+
+// plan 比较快照和当前文件，返回需要的变化 (nil 表示无变化) 和要写入的内容
+func (s *Store) plan(snap FileSnapshot) (*FileChange, []byte, error) {
+	current, err := os.ReadFile(snap.Path)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+
+	if !snap.Exists {
+		if !exists {
+			return nil, nil, nil
+		}
+		return &FileChange{Path: snap.Path, Action: "delete", Diff: UnifiedDiff(snap.Path, string(current), "")}, nil, nil
+	}
+	if snap.TooBig {
+		return &FileChange{Path: snap.Path, Action: "restore", Skipped: "file was too large to snapshot"}, nil, nil
+	}
+
+	data, err := s.readObject(snap.Hash)
+	if err != nil {
+		return &FileChange{Path: snap.Path, Action: "restore", Skipped: "snapshot content missing"}, nil, nil
+	}
+	if exists && string(current) == string(data) {
+		return nil, nil, nil
+	}
+	change := &FileChange{Path: snap.Path, Action: "restore", Diff: UnifiedDiff(snap.Path, string(current), string(data))}
+	if !exists {
+		change.Action = "create"
+	}
+	return change, data, nil
+}
+
+// capture 读取文件当前状态并保存内容
+func (s *Store) capture(path string) (FileSnapshot, error) {
+	snap := FileSnapshot{Path: path}
+	if dir, err := realPath(filepath.Dir(path)); err == nil {
+		snap.Dir = dir
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return snap, nil
+	}
+	if err != nil {
+		return snap, err
+	}
+	if info.IsDir() {
+		return snap, fmt.Errorf("%s is a directory", path)
+	}
+	snap.Exists = true
+	snap.Mode = info.Mode().Perm()
+	snap.Size = info.Size()
+	if info.Size() > MaxFileSize {
+		snap.TooBig = true
+		return snap, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return snap, err
+	}
+	snap.Hash, err = s.writeObject(data)
+	return snap, err
+}
+
+func (s *Store) objectPath(hash string) string {
+	return filepath.Join(s.dir, "objects", hash[:2], hash)
+}
+
+// writeObject 按内容哈希保存，已存在则跳过
+func (s *Store) writeObject(data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := s.objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return "", err
+	}
+	return hash, os.Rename(tmp, path)
+}
+
+func (s *Store) readObject(hash string) ([]byte, error) {
+	if len(hash) < 2 {
+		return nil, fmt.Errorf("invalid object hash %q", hash)
+	}
+	return os.ReadFile(s.objectPath(hash))
+}
+
+// gc 删除不再被任何检查点引用的内容
+func (s *Store) gc() {
+	used := make(map[string]bool)
+	sessions, _ := s.Sessions()
+	for _, session := range sessions {
+		list, err := s.load(session)
+		if err != nil {
+			return // 索引损坏时不删除任何内容
+		}
+		for _, cp := range list {
+			for _, f := range cp.Files {
+				used[f.Hash] = true
+			}
+		}
+	}
+	filepath.WalkDir(filepath.Join(s.dir, "objects"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && !used[d.Name()] {
+			os.Remove(path)
+		}
+		return nil
+	})
+}
+
+func (s *Store) sessionPath(session string) string {
+	return filepath.Join(s.dir, "sessions", url.PathEscape(session)+".json")
+}
+
+func (s *Store) load(session string) ([]Checkpoint, error) {
+	data, err := os.ReadFile(s.sessionPath(session))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []Checkpoint
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("parse checkpoints for %s: %w", session, err)
+	}
+	return list, nil
+}
+
+func (s *Store) save(session string, list []Checkpoint) error {
+	path := s.sessionPath(session)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (cp *Checkpoint) file(path string) *FileSnapshot {
+	for i := range cp.Files {
+		if cp.Files[i].Path == path {
+			return &cp.Files[i]
+		}
+	}
+	return nil
+}
+
+func nextID(list []Checkpoint) int {
+	if len(list) == 0 {
+		return 1
+	}
+	return list[len(list)-1].ID + 1
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
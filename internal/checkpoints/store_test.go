@@ -0,0 +1,237 @@
+package checkpoints
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStore_SnapshotMergesTurn(t *testing.T) {
+	store := NewStore(t.TempDir())
+	ws := t.TempDir()
+	a := filepath.Join(ws, "a.txt")
+	writeFile(t, a, "v1\n")
+
+	store.Snapshot("main", 1, "write", a)
+	writeFile(t, a, "v2\n")
+	// 同一轮再次修改：保留第一次的快照
+	store.Snapshot("main", 1, "edit", a, filepath.Join(ws, "b.txt"))
+	writeFile(t, a, "v3\n")
+	store.Snapshot("main", 2, "edit", a)
+
+	list, err := store.List("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 checkpoints, got %d", len(list))
+	}
+	first := list[0]
+	if first.Turn != 1 || strings.Join(first.Tools, ",") != "write,edit" || len(first.Files) != 2 {
+		t.Errorf("Unexpected first checkpoint: %+v", first)
+	}
+	if first.Files[1].Exists {
+		t.Errorf("Expected b.txt recorded as missing")
+	}
+
+	// 重启后同一轮次号开启新的检查点
+	restarted := NewStore(filepath.Dir(store.dir))
+	restarted.Snapshot("main", 2, "edit", a)
+	list, _ = restarted.List("main")
+	if len(list) != 3 {
+		t.Errorf("Expected a new checkpoint after restart, got %d", len(list))
+	}
+}
+
+func TestStore_RestoreAndUndo(t *testing.T) {
+	store := NewStore(t.TempDir())
+	ws := t.TempDir()
+	a := filepath.Join(ws, "a.txt")
+	created := filepath.Join(ws, "sub", "new.txt")
+	writeFile(t, a, "one\ntwo\nthree\n")
+
+	// 第 1 轮：修改 a
+	store.Snapshot("s1", 1, "edit", a)
+	writeFile(t, a, "one\nTWO\nthree\n")
+	// 第 2 轮：再改 a，新建文件
+	store.Snapshot("s1", 2, "write", a, created)
+	writeFile(t, a, "one\nTWO\nthree\nfour\n")
+	os.MkdirAll(filepath.Dir(created), 0755)
+	writeFile(t, created, "hello\n")
+
+	// 恢复到第 2 个检查点：只撤销第 2 轮
+	changes, err := store.Diff("s1", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %+v", changes)
+	}
+	if !strings.Contains(changes[0].Diff, "-four") {
+		t.Errorf("Expected diff to remove 'four':\n%s", changes[0].Diff)
+	}
+	if changes[1].Action != "delete" {
+		t.Errorf("Expected new.txt to be deleted, got %s", changes[1].Action)
+	}
+
+	if _, err := store.Restore("s1", 2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, a); got != "one\nTWO\nthree\n" {
+		t.Errorf("a.txt after restore: %q", got)
+	}
+	if _, err := os.Stat(created); !os.IsNotExist(err) {
+		t.Errorf("Expected new.txt removed")
+	}
+
+	// 恢复到第 1 个检查点：撤销所有修改
+	if _, err := store.Restore("s1", 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, a); got != "one\ntwo\nthree\n" {
+		t.Errorf("a.txt after full restore: %q", got)
+	}
+
+	// 恢复本身也会生成检查点，可以撤销
+	list, _ := store.List("s1")
+	last := list[len(list)-1]
+	if last.Tools[0] != "restore:1" {
+		t.Fatalf("Expected restore checkpoint, got %+v", last)
+	}
+	if _, err := store.Restore("s1", last.ID, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, a); got != "one\nTWO\nthree\n" {
+		t.Errorf("a.txt after undoing restore: %q", got)
+	}
+
+	if _, err := store.Restore("s1", 99, nil); err == nil {
+		t.Errorf("Expected error for unknown checkpoint")
+	}
+}
+
+func TestStore_RestoreSkipsSwappedParent(t *testing.T) {
+	store := NewStore(t.TempDir())
+	ws := t.TempDir()
+	outside := t.TempDir()
+	dir := filepath.Join(ws, "sub")
+	os.Mkdir(dir, 0755)
+	a := filepath.Join(dir, "a.txt")
+	writeFile(t, a, "v1\n")
+	writeFile(t, filepath.Join(outside, "a.txt"), "outside\n")
+
+	store.Snapshot("s1", 1, "write", a)
+	writeFile(t, a, "v2\n")
+
+	// 快照之后把目录换成指向工作区外的符号链接
+	os.RemoveAll(dir)
+	if err := os.Symlink(outside, dir); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	changes, err := store.Restore("s1", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Skipped == "" {
+		t.Fatalf("Expected restore to be skipped, got %+v", changes)
+	}
+	if got := readFile(t, filepath.Join(outside, "a.txt")); got != "outside\n" {
+		t.Errorf("File outside the workspace was overwritten: %q", got)
+	}
+
+	// check 拒绝的目标同样跳过
+	os.Remove(dir)
+	os.Mkdir(dir, 0755)
+	writeFile(t, a, "v3\n")
+	changes, err = store.Restore("s1", 1, func(string) error { return fmt.Errorf("denied") })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Skipped != "denied" {
+		t.Fatalf("Expected denied target to be skipped, got %+v", changes)
+	}
+	if got := readFile(t, a); got != "v3\n" {
+		t.Errorf("Denied target was written: %q", got)
+	}
+}
+
+func TestStore_PruneAndGC(t *testing.T) {
+	store := NewStore(t.TempDir())
+	store.SetMaxPerSession(2)
+	ws := t.TempDir()
+	a := filepath.Join(ws, "a.txt")
+
+	for i, content := range []string{"first\n", "second\n", "third\n"} {
+		writeFile(t, a, content)
+		store.Snapshot("main", i+1, "write", a)
+	}
+
+	list, _ := store.List("main")
+	if len(list) != 2 || list[0].ID != 2 {
+		t.Fatalf("Expected checkpoints 2 and 3, got %+v", list)
+	}
+	var objects int
+	filepath.WalkDir(filepath.Join(store.dir, "objects"), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			objects++
+		}
+		return nil
+	})
+	if objects != 2 {
+		t.Errorf("Expected unreferenced object removed, found %d objects", objects)
+	}
+
+	sessions, _ := store.Sessions()
+	if len(sessions) != 1 || sessions[0] != "main" {
+		t.Errorf("Unexpected sessions: %v", sessions)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	got := UnifiedDiff("x.txt", from, to)
+	want := `--- a/x.txt
++++ b/x.txt
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,3 +10,4 @@
+ j
+ k
+ l
++m
+`
+	if got != want {
+		t.Errorf("Unexpected diff:\n%s\nwant:\n%s", got, want)
+	}
+	if UnifiedDiff("x", "same", "same") != "" {
+		t.Errorf("Expected empty diff for identical content")
+	}
+	if d := UnifiedDiff("x", "", "new\n"); !strings.Contains(d, "@@ -0,0 +1 @@\n+new") {
+		t.Errorf("Unexpected diff for new file:\n%s", d)
+	}
+}
@@ -1,18 +1,28 @@
 package cli
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/spf13/cobra"
+	"github.com/z8n24/openclaw-go/internal/agents/tools"
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
 	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/cron"
 	"github.com/z8n24/openclaw-go/internal/gateway"
+	"github.com/z8n24/openclaw-go/internal/skills"
 )
 
 // ============================================================================
@@ -173,8 +183,14 @@ var doctorCmd = &cobra.Command{
 			fmt.Println("  DEEPSEEK_API_KEY: ⚠️  Not set (optional)")
 		}
 
+		searchProviders := 0
+		if cfg, err := config.Load(); err == nil && cfg != nil {
+			searchProviders = len(cfg.Tools.Web.Search.Providers)
+		}
 		if os.Getenv("BRAVE_API_KEY") != "" {
 			fmt.Println("  BRAVE_API_KEY: ✅ Set")
+		} else if searchProviders > 0 {
+			fmt.Printf("  BRAVE_API_KEY: ⚠️  Not set (%d web search providers configured)\n", searchProviders)
 		} else {
 			fmt.Println("  BRAVE_API_KEY: ⚠️  Not set (web_search disabled)")
 		}
@@ -195,6 +211,20 @@ var doctorCmd = &cobra.Command{
 		checkCommand("git", "--version")
 		checkCommand("curl", "--version")
 
+		// 检查 exec 沙箱
+		fmt.Print("Exec sandbox: ")
+		if cfg, err := config.Load(); err == nil && cfg != nil && cfg.Tools.Exec.Sandbox.Mode != "" && cfg.Tools.Exec.Sandbox.Mode != tools.SandboxModeOff {
+			sandbox := tools.NewSandbox(cfg.Tools.Exec.Sandbox, workspace)
+			if err := sandbox.Validate(); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				allGood = false
+			} else {
+				fmt.Printf("✅ %s (%s)\n", sandbox.Mode(), sandbox.Backend())
+			}
+		} else {
+			fmt.Println("⚠️  Off (commands run directly on the host)")
+		}
+
 		// 检查 Gateway 状态
 		fmt.Println()
 		fmt.Print("Gateway: ")
@@ -383,56 +413,228 @@ var skillsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List installed skills",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		home, _ := os.UserHomeDir()
-		skillsDir := filepath.Join(home, ".openclaw", "skills")
+		loader := skillLoaderFromConfig()
+		if err := loader.LoadAll(); err != nil {
+			return err
+		}
 
-		entries, err := os.ReadDir(skillsDir)
-		if err != nil {
-			if os.IsNotExist(err) {
-				fmt.Println("No skills installed.")
-				fmt.Println()
-				fmt.Println("Install skills with:")
-				fmt.Println("  openclaw skills install <source>")
-				return nil
+		var list []*skills.Skill
+		for _, skill := range loader.List() {
+			if skill.Path != "" {
+				list = append(list, skill)
 			}
-			return err
 		}
+		if len(list) == 0 {
+			fmt.Println("No skills installed.")
+			fmt.Println()
+			fmt.Println("Install skills with:")
+			fmt.Println("  openclaw skills install <source>")
+			return nil
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
 
 		fmt.Println("Installed Skills:")
 		fmt.Println()
+		for _, skill := range list {
+			status := "disabled"
+			if skill.Enabled {
+				eligibility, _ := loader.Eligibility(skill.ID)
+				status = eligibility.String()
+			}
+			fmt.Printf("  - %-20s %-8s %s\n", skill.ID, skill.Version, status)
+		}
+		return nil
+	},
+}
+
+var skillsInstallCmd = &cobra.Command{
+	Use:   "install <source>",
+	Short: "Install a skill (user/repo[@ref], git+<url>[@ref], <url>.tar.gz#sha256=<hex> or a local path)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deps, _ := cmd.Flags().GetBool("deps")
+		loader := skillLoaderFromConfig()
+		if err := loader.LoadAll(); err != nil {
+			return err
+		}
 
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
+		fmt.Printf("Installing skill from: %s\n", args[0])
+		skill, err := loader.Install(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Installed %s (%s)\n", skill.Name, skill.ID)
+
+		if deps && len(skill.Install) > 0 {
+			if err := installSkillDeps(cmd.Context(), loader, skill.ID); err != nil {
+				return err
 			}
-			skillFile := filepath.Join(skillsDir, entry.Name(), "SKILL.md")
-			if _, err := os.Stat(skillFile); err != nil {
-				continue
+		}
+		eligibility, _ := loader.Eligibility(skill.ID)
+		fmt.Printf("Requirements: %s\n", eligibility)
+		return nil
+	},
+}
+
+var skillsDepsCmd = &cobra.Command{
+	Use:   "deps <id>",
+	Short: "Install the dependencies declared by a skill",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loader := skillLoaderFromConfig()
+		if err := loader.LoadAll(); err != nil {
+			return err
+		}
+		if err := installSkillDeps(cmd.Context(), loader, args[0]); err != nil {
+			return err
+		}
+		eligibility, _ := loader.Eligibility(args[0])
+		fmt.Printf("Requirements: %s\n", eligibility)
+		return nil
+	},
+}
+
+var skillsUpdateCmd = &cobra.Command{
+	Use:   "update <id>",
+	Short: "Preview and apply a skill update",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ref, _ := cmd.Flags().GetString("ref")
+		yes, _ := cmd.Flags().GetBool("yes")
+		loader := skillLoaderFromConfig()
+		if err := loader.LoadAll(); err != nil {
+			return err
+		}
+
+		plan, err := loader.PlanUpdate(args[0], skills.UpdateOptions{Ref: ref})
+		if err != nil {
+			return err
+		}
+		if plan.UpToDate {
+			fmt.Printf("%s is up to date (%s)\n", plan.ID, plan.ToVersion)
+			return nil
+		}
+
+		fmt.Printf("Update %s: %s -> %s\n", plan.ID, plan.FromVersion, plan.ToVersion)
+		if len(plan.Changelog) > 0 {
+			fmt.Println("\nChanges:")
+			for _, line := range plan.Changelog {
+				fmt.Println("  " + line)
 			}
-			fmt.Printf("  - %s\n", entry.Name())
 		}
+		if plan.Diff != "" {
+			fmt.Println("\nFiles:")
+			for _, line := range strings.Split(plan.Diff, "\n") {
+				fmt.Println("  " + strings.TrimSpace(line))
+			}
+		}
+		fmt.Println()
 
+		if !yes {
+			fmt.Print("Apply this update? [y/N] ")
+			answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				fmt.Println("Update cancelled.")
+				return nil
+			}
+		}
+
+		skill, err := loader.ApplyUpdate(plan.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Updated %s to %s\n", skill.ID, skill.Version)
 		return nil
 	},
 }
 
-var skillsInstallCmd = &cobra.Command{
-	Use:   "install <source>",
-	Short: "Install a skill",
+var skillsLintCmd = &cobra.Command{
+	Use:   "lint <dir>",
+	Short: "Check SKILL.md files in a skill directory (or a directory of skills)",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		source := args[0]
-		fmt.Printf("Installing skill from: %s\n", source)
+		files, err := skillFiles(args[0])
+		if err != nil {
+			return err
+		}
 
-		// TODO: 调用 skills loader
+		problems := 0
+		for _, file := range files {
+			skill, err := skills.ParseSKILLMD(file)
+			if err == nil {
+				err = skill.Validate()
+			}
+			var errs skills.ValidationErrors
+			switch {
+			case err == nil:
+				fmt.Printf("✅ %s (%s)\n", skill.ID, file)
+			case errors.As(err, &errs):
+				for _, e := range errs {
+					fmt.Println(e.Error())
+				}
+				problems += len(errs)
+			default:
+				fmt.Println(err)
+				problems++
+			}
+		}
 
+		if problems > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d problem(s) found", problems)
+		}
 		return nil
 	},
 }
 
+// skillFiles 返回 dir/SKILL.md，或 dir 下各子目录中的 SKILL.md
+func skillFiles(dir string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "SKILL.md")); err == nil {
+		return []string{filepath.Join(dir, "SKILL.md")}, nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*", "SKILL.md"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no SKILL.md found in %s", dir)
+	}
+	return files, nil
+}
+
+// skillLoaderFromConfig 按配置文件创建技能加载器
+func skillLoaderFromConfig() *skills.Loader {
+	var skillsCfg config.SkillsConfig
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		skillsCfg = cfg.Skills
+	}
+	return newSkillLoader(skillsCfg)
+}
+
+// installSkillDeps 执行技能的依赖安装并打印进度
+func installSkillDeps(ctx context.Context, loader *skills.Loader, id string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, err := loader.InstallDeps(ctx, id, func(p skills.InstallProgress) {
+		line := fmt.Sprintf("[%d/%d] %s: %s", p.Step, p.Total, p.Label, p.Status)
+		if p.Message != "" {
+			line += " (" + p.Message + ")"
+		}
+		fmt.Println(line)
+	})
+	return err
+}
+
 func init() {
+	skillsInstallCmd.Flags().Bool("deps", false, "Also install the skill's declared dependencies")
 	skillsCmd.AddCommand(skillsListCmd)
 	skillsCmd.AddCommand(skillsInstallCmd)
+	skillsUpdateCmd.Flags().String("ref", "", "Switch to a tag, branch or commit and pin it")
+	skillsUpdateCmd.Flags().BoolP("yes", "y", false, "Apply without confirmation")
+	skillsCmd.AddCommand(skillsDepsCmd)
+	skillsCmd.AddCommand(skillsUpdateCmd)
+	skillsCmd.AddCommand(skillsLintCmd)
 }
 
 // ============================================================================
@@ -510,8 +712,160 @@ var cronListCmd = &cobra.Command{
 	},
 }
 
+var cronRunsCmd = &cobra.Command{
+	Use:   "runs <id>",
+	Short: "Show the run history of a cron job",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+		home, _ := os.UserHomeDir()
+		runLog := cron.NewRunLog(filepath.Join(home, ".openclaw", "state"))
+
+		runs, err := runLog.List(args[0], limit)
+		if err != nil {
+			return err
+		}
+		if len(runs) == 0 {
+			fmt.Printf("No runs recorded for job %s.\n", args[0])
+			return nil
+		}
+		fmt.Println(tools.FormatCronRuns(runs))
+		return nil
+	},
+}
+
 func init() {
+	cronRunsCmd.Flags().IntP("limit", "n", 20, "Number of runs to show")
 	cronCmd.AddCommand(cronListCmd)
+	cronCmd.AddCommand(cronRunsCmd)
+}
+
+// ============================================================================
+// checkpoints 命令
+// ============================================================================
+
+var checkpointsCmd = &cobra.Command{
+	Use:   "checkpoints",
+	Short: "List, diff and restore file checkpoints taken before agent edits",
+}
+
+var (
+	checkpointStoresMu sync.Mutex
+	checkpointStores   = make(map[string]*checkpoints.Store) // 按状态目录
+)
+
+// checkpointStore 默认状态目录下的检查点存储，工具、gateway RPC 和 CLI 共用
+func checkpointStore() *checkpoints.Store {
+	home, _ := os.UserHomeDir()
+	return checkpointStoreFor(filepath.Join(home, ".openclaw", "state"))
+}
+
+// checkpointStoreFor 返回 stateDir 下共享的检查点存储；同一目录只创建一个 Store，避免多个实例并发修改索引
+func checkpointStoreFor(stateDir string) *checkpoints.Store {
+	checkpointStoresMu.Lock()
+	defer checkpointStoresMu.Unlock()
+	store, ok := checkpointStores[stateDir]
+	if !ok {
+		store = checkpoints.NewStore(stateDir)
+		checkpointStores[stateDir] = store
+	}
+	return store
+}
+
+var checkpointsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List checkpoints of a session (or sessions with checkpoints)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		session, _ := cmd.Flags().GetString("session")
+		store := checkpointStore()
+
+		if session == "" {
+			sessions, err := store.Sessions()
+			if err != nil {
+				return err
+			}
+			if len(sessions) == 0 {
+				fmt.Println("No checkpoints yet.")
+				return nil
+			}
+			fmt.Println("Sessions with checkpoints:")
+			for _, key := range sessions {
+				list, _ := store.List(key)
+				fmt.Printf("  %s (%d checkpoints)\n", key, len(list))
+			}
+			fmt.Println()
+			fmt.Println("Show one with: openclaw checkpoints list --session <key>")
+			return nil
+		}
+
+		list, err := store.List(session)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			fmt.Printf("No checkpoints for session %s.\n", session)
+			return nil
+		}
+		fmt.Println(tools.FormatCheckpoints(list))
+		return nil
+	},
+}
+
+var checkpointsDiffCmd = &cobra.Command{
+	Use:   "diff <id>",
+	Short: "Show what restoring a checkpoint would change",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCheckpointChanges(cmd, args[0], false)
+	},
+}
+
+var checkpointsRestoreCmd = &cobra.Command{
+	Use:   "restore <id>",
+	Short: "Revert files changed at a checkpoint and all later ones",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCheckpointChanges(cmd, args[0], true)
+	},
+}
+
+func runCheckpointChanges(cmd *cobra.Command, arg string, restore bool) error {
+	session, _ := cmd.Flags().GetString("session")
+	if session == "" {
+		session = "main"
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+	if err != nil || id <= 0 {
+		return fmt.Errorf("invalid checkpoint id: %s", arg)
+	}
+
+	store := checkpointStore()
+	var changes []checkpoints.FileChange
+	if restore {
+		workspace := ""
+		if cfg, err := config.Load(); err == nil && cfg != nil {
+			workspace = cfg.Agent.Workspace
+		}
+		if workspace == "" {
+			home, _ := os.UserHomeDir()
+			workspace = filepath.Join(home, ".openclaw", "workspace")
+		}
+		changes, err = store.Restore(session, id, filesPathPolicy(workspace).RestoreCheck(session))
+	} else {
+		changes, err = store.Diff(session, id)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(tools.FormatCheckpointChanges(changes, !restore))
+	return nil
+}
+
+func init() {
+	for _, c := range []*cobra.Command{checkpointsListCmd, checkpointsDiffCmd, checkpointsRestoreCmd} {
+		c.Flags().StringP("session", "s", "", "Session key (diff/restore default to main)")
+		checkpointsCmd.AddCommand(c)
+	}
 }
 
 // ============================================================================
@@ -729,6 +1083,7 @@ func init() {
 	rootCmd.AddCommand(skillsCmd)
 	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(cronCmd)
+	rootCmd.AddCommand(checkpointsCmd)
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(configCmd)
 }
@@ -0,0 +1,208 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/agents/anthropic"
+	"github.com/z8n24/openclaw-go/internal/agents/deepseek"
+	"github.com/z8n24/openclaw-go/internal/agents/gemini"
+	"github.com/z8n24/openclaw-go/internal/agents/openai"
+	"github.com/z8n24/openclaw-go/internal/agents/openrouter"
+	"github.com/z8n24/openclaw-go/internal/agents/tools"
+	"github.com/z8n24/openclaw-go/internal/channels"
+	"github.com/z8n24/openclaw-go/internal/channels/telegram"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/gateway"
+	"github.com/z8n24/openclaw-go/internal/sessions"
+)
+
+// gatewayChannels gateway 运行的渠道 (按配置中的名称)，未启用时返回 nil
+var gatewayChannels = map[string]func(cfg *config.Config) channels.Channel{
+	channels.ChannelTelegram: func(cfg *config.Config) channels.Channel {
+		if tg := cfg.Channels.Telegram; tg != nil && tg.Enabled {
+			return telegram.New(tg)
+		}
+		return nil
+	},
+}
+
+// restartChannel 按新配置重启单个渠道；渠道被禁用时只停止
+func restartChannel(mgr *channels.Manager, id string, cfg *config.Config) error {
+	if err := mgr.Unregister(id); err != nil {
+		log.Warn().Err(err).Str("channel", id).Msg("Failed to stop channel")
+	}
+	ch := gatewayChannels[id](cfg)
+	if ch == nil {
+		return nil
+	}
+	mgr.Register(ch)
+	return mgr.Start(id)
+}
+
+var (
+	// 可随配置热应用策略的工具 (tools.exec.allowlist、tools.browser)
+	policyMu     sync.Mutex
+	execTools    []*tools.ExecTool
+	browserTools []*tools.BrowserTool
+)
+
+// trackPolicyTools 记录工具，之后的配置变化通过 applyToolPolicy 更新
+func trackPolicyTools(execTool *tools.ExecTool, browserTool *tools.BrowserTool) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	execTools = append(execTools, execTool)
+	browserTools = append(browserTools, browserTool)
+}
+
+// applyToolPolicy 将 exec allowlist 和浏览器默认 profile/headless 应用到已创建的工具
+func applyToolPolicy(cfg config.ToolsConfig) {
+	policyMu.Lock()
+	defer policyMu.Unlock()
+	for _, t := range execTools {
+		t.SetAllowlist(cfg.Exec.Allowlist)
+	}
+	for _, t := range browserTools {
+		t.ApplyConfig(cfg.Browser)
+	}
+}
+
+// 各 provider 的 API key 查找函数 (参数为空时读取环境变量)
+var providerAPIKeys = map[string]func(explicit string) string{
+	"anthropic":  anthropic.GetAPIKey,
+	"deepseek":   deepseek.GetAPIKey,
+	"openai":     openai.GetAPIKey,
+	"google":     gemini.GetAPIKey,
+	"openrouter": openrouter.GetAPIKey,
+}
+
+// providerFromConfig 按 agent.defaultModel 创建 provider，返回 provider 和模型名
+//
+// 模型先按 agent.models 和内置别名解析，再按 "provider/model" 前缀选择客户端；
+// 没有前缀时按模型名推断 (默认 anthropic)。缺少 API key 时返回错误
+func providerFromConfig(agent config.AgentConfig) (agents.Provider, string, error) {
+	ref := agent.DefaultModel
+	if alias, ok := agent.Models[ref]; ok {
+		ref = alias
+	}
+	providerID, model := agents.ResolveModel(agents.ResolveAlias(ref))
+	if providerID == "" {
+		providerID = inferProvider(model)
+	}
+	if model == "" && providerID == "anthropic" {
+		model = "claude-sonnet-4-20250514"
+	}
+
+	apiKey, ok := providerAPIKeys[providerID]
+	if !ok {
+		return nil, model, fmt.Errorf("unsupported model provider %q", providerID)
+	}
+	if apiKey("") == "" {
+		return nil, model, fmt.Errorf("no API key configured for model %s/%s", providerID, model)
+	}
+
+	switch providerID {
+	case "deepseek":
+		return deepseek.NewClient(""), model, nil
+	case "openai":
+		return openai.NewClient(openai.Config{}), model, nil
+	case "google":
+		return gemini.NewClient(gemini.Config{}), model, nil
+	case "openrouter":
+		return openrouter.NewClient(openrouter.Config{}), model, nil
+	default:
+		return anthropic.NewClient(""), model, nil
+	}
+}
+
+// inferProvider 按模型名推断 provider
+func inferProvider(model string) string {
+	switch {
+	case strings.Contains(model, "deepseek"):
+		return "deepseek"
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "o1"), strings.HasPrefix(model, "o3"):
+		return "openai"
+	case strings.HasPrefix(model, "gemini"):
+		return "google"
+	default:
+		return "anthropic"
+	}
+}
+
+// agentProvider gateway 中 cron 和心跳使用的 provider 与模型，agent 配置变化时替换
+type agentProvider struct {
+	mu       sync.RWMutex
+	provider agents.Provider
+	model    string
+	err      error // provider 为空的原因
+}
+
+// newAgentProvider 按 agent 配置创建 provider，无法创建时 provider 为空
+func newAgentProvider(agent config.AgentConfig) *agentProvider {
+	p := &agentProvider{}
+	p.provider, p.model, p.err = providerFromConfig(agent)
+	return p
+}
+
+// setModel 按新的 agent 配置切换 provider；无法创建时保留当前 provider 并返回错误
+func (p *agentProvider) setModel(agent config.AgentConfig) error {
+	provider, model, err := providerFromConfig(agent)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.provider, p.model, p.err = provider, model, nil
+	p.mu.Unlock()
+	log.Info().Str("provider", provider.ID()).Str("model", model).Msg("Switched default model")
+	return nil
+}
+
+func (p *agentProvider) get() (agents.Provider, string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.provider, p.model
+}
+
+// runner 返回使用当前 provider 的 AgentRunner，每次运行时读取，替换后新的运行立即生效
+// 会话过长时先压缩历史，压缩前的事实写入 workspace 的每日记忆
+func (p *agentProvider) runner(registry *sessions.ToolRegistry, system func() string, workspace string) sessions.AgentRunner {
+	compaction := sessions.DefaultAutoCompactionConfig
+	compaction.Model = "" // 摘要使用当前默认模型
+	paths := config.NewPaths(workspace)
+	return func(ctx context.Context, session *sessions.EnhancedSession, message string) (string, error) {
+		provider, model := p.get()
+		if provider == nil {
+			p.mu.RLock()
+			defer p.mu.RUnlock()
+			return "", fmt.Errorf("no model provider configured: %w", p.err)
+		}
+		if _, err := sessions.AutoCompact(ctx, compaction, provider, model, paths, session); err != nil {
+			log.Warn().Err(err).Str("session", session.Key).Msg("Failed to compact session")
+		}
+		return sessions.NewLoopAgentRunnerFunc(provider, registry, system, model)(ctx, session, message)
+	}
+}
+
+// newConfigReconciler 创建 gateway 的配置协调器：渠道按需重启，默认模型和工具策略直接替换，其他配置需要重启
+func newConfigReconciler(cfg *config.Config, normalize func(*config.Config), channelMgr *channels.Manager, provider *agentProvider) *gateway.ConfigReconciler {
+	r := gateway.NewConfigReconciler(cfg)
+	r.SetNormalizer(normalize)
+	for id := range gatewayChannels {
+		id := id
+		r.Handle("channels."+id, func(_, next *config.Config) error {
+			return restartChannel(channelMgr, id, next)
+		})
+	}
+	r.HandleGroup("agent", []string{"agent.defaultModel", "agent.models"}, func(_, next *config.Config) error {
+		return provider.setModel(next.Agent)
+	})
+	r.HandleGroup("tools", []string{"tools.exec.allowlist", "tools.browser.profile", "tools.browser.headless"}, func(_, next *config.Config) error {
+		applyToolPolicy(next.Tools)
+		return nil
+	})
+	return r
+}
@@ -9,7 +9,9 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -23,7 +25,9 @@ import (
 	"github.com/z8n24/openclaw-go/internal/config"
 	"github.com/z8n24/openclaw-go/internal/cron"
 	"github.com/z8n24/openclaw-go/internal/gateway"
+	"github.com/z8n24/openclaw-go/internal/memory"
 	"github.com/z8n24/openclaw-go/internal/sessions"
+	"github.com/z8n24/openclaw-go/internal/skills"
 )
 
 var (
@@ -93,20 +97,113 @@ var gatewayCmd = &cobra.Command{
 			cfg = &config.Config{}
 		}
 		
-		if port != 0 {
-			cfg.Gateway.Port = port
+		// 命令行参数覆盖配置文件；配置热应用时同样处理
+		normalize := func(c *config.Config) {
+			if port != 0 {
+				c.Gateway.Port = port
+			}
+			if c.Gateway.Port == 0 {
+				c.Gateway.Port = 18789
+			}
+			if bind != "" {
+				c.Gateway.Bind = bind
+			}
+			if c.Gateway.Bind == "" {
+				c.Gateway.Bind = "127.0.0.1"
+			}
 		}
-		if cfg.Gateway.Port == 0 {
-			cfg.Gateway.Port = 18789
+		normalize(cfg)
+		
+		home, _ := os.UserHomeDir()
+		workspace := cfg.Agent.Workspace
+		if workspace == "" {
+			workspace = filepath.Join(home, ".openclaw", "workspace")
 		}
-		if bind != "" {
-			cfg.Gateway.Bind = bind
+		os.MkdirAll(workspace, 0755)
+		stateDir := filepath.Join(home, ".openclaw", "state")
+
+		// 渠道 (用于投递定时任务结果)
+		channelMgr := channels.NewManager()
+		for _, newChannel := range gatewayChannels {
+			if ch := newChannel(cfg); ch != nil {
+				channelMgr.Register(ch)
+			}
 		}
-		if cfg.Gateway.Bind == "" {
-			cfg.Gateway.Bind = "127.0.0.1"
+		if err := channelMgr.StartAll(); err != nil {
+			log.Warn().Err(err).Msg("Failed to start channels")
 		}
-		
+		defer channelMgr.StopAll()
+
+		// 定时任务：agentTurn 在隔离会话中运行，systemEvent 注入主会话
+		cronScheduler := cron.NewScheduler(stateDir, nil)
+
+		// provider 和模型随 agent.defaultModel 热替换
+		provider := newAgentProvider(cfg.Agent)
+		if p, _ := provider.get(); p == nil {
+			log.Warn().Err(provider.err).Msg("No model provider configured, cron agentTurn jobs will fail")
+		}
+
+		// 会话重置或删除时提取事实写入记忆；在后台运行，不阻塞 RPC
+		enhancedMgr := sessions.NewEnhancedManager(sessions.ManagerConfig{
+			DataDir: filepath.Join(stateDir, "sessions"),
+			OnSessionEnd: func(key string, messages []agents.Message) {
+				p, model := provider.get()
+				if p == nil {
+					return
+				}
+				go captureSessionFacts(p, model, workspace, key, messages)
+			},
+		})
+
+		indexCtx, stopIndex := context.WithCancel(context.Background())
+		defer stopIndex()
+		memoryIndex := newMemoryIndex(indexCtx, workspace, stateDir)
+		toolRegistry := createToolRegistry(workspace, stateDir, enhancedEvents(enhancedMgr), cronScheduler, memoryIndex)
+		runAgent := provider.runner(toolRegistry, systemPrompt(workspace).Get, workspace)
+		cronRunner := gateway.NewCronRunner(enhancedMgr, channelMgr, runAgent)
+		cronRunner.SetDefaultTarget(cfg.Cron.Delivery)
+		cronScheduler.SetRunHandler(cronRunner.Run)
+		cronScheduler.Start()
+		defer cronScheduler.Stop()
+
+		// 心跳：定期唤醒主会话执行 HEARTBEAT.md
+		var heartbeat *gateway.HeartbeatRunner
+		if cfg.Heartbeat.Enabled {
+			heartbeat, err = gateway.NewHeartbeatRunnerFromConfig(cfg.Heartbeat, cfg.Cron.Delivery, enhancedMgr, channelMgr, runAgent, config.NewPaths(workspace).HEARTBEATFile())
+			if err != nil {
+				return err
+			}
+			heartbeatCtx, stopHeartbeat := context.WithCancel(context.Background())
+			defer stopHeartbeat()
+			heartbeat.Start(heartbeatCtx)
+		}
+
 		server := gateway.NewServer(cfg)
+		server.SetDependencies(gateway.Dependencies{
+			CronScheduler:   cronScheduler,
+			Checkpoints:     checkpointStore(),
+			CheckpointPaths: filesPathPolicy(workspace).RestoreCheck,
+			Heartbeat:       heartbeat,
+			SkillLoader:     loadSkills().loader,
+			Config:          newConfigReconciler(cfg, normalize, channelMgr, provider),
+			Sessions:        enhancedMgr,
+		})
+		
+		// 配置文件变化时热应用，无法热应用的配置项在 config.apply 的结果中报告
+		if err := config.Watch(func(next *config.Config) { server.ApplyConfig(next) }); err != nil {
+			log.Warn().Err(err).Msg("Failed to watch config file")
+		}
+		
+		// 技能与工作区 prompt 文件热加载，通知控制台刷新
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		watchWorkspace(watchCtx, workspace, func(kind string, detail map[string]interface{}) {
+			payload := map[string]interface{}{"kind": kind}
+			for k, v := range detail {
+				payload[k] = v
+			}
+			server.BroadcastEvent("stateChange", payload)
+		})
 		
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -198,15 +295,22 @@ var chatCmd = &cobra.Command{
 		cronScheduler.Start()
 		defer cronScheduler.Stop()
 		
+		// 记忆索引
+		indexCtx, stopIndex := context.WithCancel(context.Background())
+		defer stopIndex()
+		memoryIndex := newMemoryIndex(indexCtx, workspace, stateDir)
+		
 		// 创建并注册工具
-		toolRegistry := createToolRegistry(workspace, cronScheduler)
+		toolRegistry := createToolRegistry(workspace, stateDir, managerEvents(sessionMgr), cronScheduler, memoryIndex)
 		
-		// 构建 system prompt
-		toolList := getToolList()
-		systemPrompt := agents.BuildSystemPrompt(workspace, toolList)
+		// system prompt 与技能随文件变化热加载
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		watchWorkspace(watchCtx, workspace, nil)
 		
 		// 创建 agent loop
-		loop := sessions.NewAgentLoop(provider, toolRegistry, session, systemPrompt, model)
+		loop := sessions.NewAgentLoop(provider, toolRegistry, session, "", model)
+		loop.SetSystemPrompt(systemPrompt(workspace).Get)
 		
 		fmt.Println("OpenClaw Chat (type 'exit' to quit, 'clear' to reset)")
 		fmt.Println("Provider:", providerName)
@@ -230,6 +334,7 @@ var chatCmd = &cobra.Command{
 				break
 			}
 			if input == "clear" {
+				captureSessionFacts(provider, model, workspace, session.Key, session.GetMessages())
 				session.ClearMessages()
 				fmt.Println("Session cleared.")
 				continue
@@ -249,10 +354,40 @@ var chatCmd = &cobra.Command{
 			fmt.Println()
 		}
 		
+		captureSessionFacts(provider, model, workspace, session.Key, session.GetMessages())
 		return nil
 	},
 }
 
+// captureSessionFacts 会话结束时提取事实写入记忆 (需在配置中启用 memory.capture)
+func captureSessionFacts(provider agents.Provider, model, workspace, sessionKey string, messages []agents.Message) {
+	cfg, err := config.Load()
+	if err != nil || cfg == nil || !cfg.Memory.Capture.Enabled {
+		return
+	}
+	if cfg.Memory.Capture.Model != "" {
+		model = cfg.Memory.Capture.Model
+	}
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	
+	capture := sessions.NewFactCapture(provider, model, config.NewPaths(workspace))
+	result, err := capture.Capture(ctx, sessionKey, messages)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to capture session facts")
+		return
+	}
+	
+	count := len(result.Daily)
+	for _, items := range result.LongTerm {
+		count += len(items)
+	}
+	if count > 0 {
+		fmt.Printf("Saved %d facts to memory.\n", count)
+	}
+}
+
 func init() {
 	chatCmd.Flags().StringP("model", "m", "", "Model to use (default depends on provider)")
 	chatCmd.Flags().StringP("provider", "p", "anthropic", "Provider: anthropic, deepseek")
@@ -260,12 +395,209 @@ func init() {
 	chatCmd.Flags().StringP("workspace", "w", "", "Workspace directory")
 }
 
-// createToolRegistry 创建并注册所有工具
-func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessions.ToolRegistry {
+// newMemoryIndex 根据配置创建记忆索引，并在后台监听记忆文件变化
+func newMemoryIndex(ctx context.Context, workspace, stateDir string) *memory.Index {
+	var searchCfg config.MemorySearchConfig
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		searchCfg = cfg.Memory.Search
+	}
+	
+	index := memory.NewIndex(memory.IndexConfig{
+		Workspace:   workspace,
+		StateDir:    stateDir,
+		SessionsDir: filepath.Join(stateDir, "sessions"),
+		Embedder: memory.NewEmbedder(memory.EmbedderConfig{
+			Provider: searchCfg.Provider,
+			Model:    searchCfg.Model,
+			BaseURL:  searchCfg.BaseURL,
+			APIKey:   searchCfg.APIKey,
+		}),
+		IncludeSessions: searchCfg.IncludeSessions,
+		VectorWeight:    searchCfg.VectorWeight,
+	})
+	if err := index.Watch(ctx); err != nil {
+		log.Warn().Err(err).Msg("Failed to watch memory files")
+	}
+	return index
+}
+
+// skillSet 已加载的技能及其配置
+type skillSet struct {
+	loader    *skills.Loader
+	permitted []*skills.Skill // 已启用、依赖满足且权限均已授予
+	cfg       config.SkillsConfig
+}
+
+var (
+	skillsMu      sync.Mutex
+	currentSkills *skillSet
+
+	// 需要随技能热加载更新的工具注册表，以及其中已注册的技能工具
+	skillRegistries = make(map[*sessions.ToolRegistry]skillRegistration)
+
+	promptMu     sync.Mutex
+	promptCaches = make(map[string]*agents.PromptCache) // 按 workspace
+)
+
+type skillRegistration struct {
+	workspace string
+	sandbox   *tools.Sandbox // exec 沙箱，技能工具按会话使用
+	names     []string
+}
+
+// loadSkills 从 skills.directories 加载技能 (首次调用时加载，之后由 reloadSkills 更新)
+func loadSkills() skillSet {
+	skillsMu.Lock()
+	defer skillsMu.Unlock()
+	if currentSkills == nil {
+		set := skillSet{}
+		if cfg, err := config.Load(); err == nil && cfg != nil {
+			set.cfg = cfg.Skills
+		}
+		set.loader = newSkillLoader(set.cfg)
+		if err := set.loader.LoadAll(); err != nil {
+			log.Warn().Err(err).Msg("Failed to load skills")
+		}
+		set.permitted = tools.PermittedSkills(set.loader.ListEligible(), set.cfg)
+		currentSkills = &set
+	}
+	return *currentSkills
+}
+
+// reloadSkills 技能变化后重新计算可用技能，更新各注册表中的技能工具并使 system prompt 失效
+func reloadSkills() {
+	skillsMu.Lock()
+	if currentSkills == nil {
+		skillsMu.Unlock()
+		return
+	}
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		currentSkills.cfg = cfg.Skills
+	}
+	currentSkills.permitted = tools.PermittedSkills(currentSkills.loader.ListEligible(), currentSkills.cfg)
+	for registry, reg := range skillRegistries {
+		for _, name := range reg.names {
+			registry.Unregister(name)
+		}
+		reg.names = registerSkillTools(registry, *currentSkills, reg.workspace, reg.sandbox)
+		skillRegistries[registry] = reg
+	}
+	skillsMu.Unlock()
+
+	invalidatePrompts()
+}
+
+// watchWorkspace 监听技能目录和工作区的 prompt 文件，变化时热加载；notify 用于通知客户端，可为空
+func watchWorkspace(ctx context.Context, workspace string, notify func(kind string, detail map[string]interface{})) {
+	if notify == nil {
+		notify = func(string, map[string]interface{}) {}
+	}
+	err := loadSkills().loader.Watch(ctx, func(changed []string) {
+		reloadSkills()
+		notify("skills", map[string]interface{}{"skills": changed})
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to watch skills")
+	}
+	err = agents.WatchPromptFiles(ctx, workspace, func(file string) {
+		invalidatePrompts()
+		notify("prompt", map[string]interface{}{"file": file})
+	})
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to watch workspace prompt files")
+	}
+}
+
+// newSkillLoader 按配置创建技能加载器
+func newSkillLoader(cfg config.SkillsConfig) *skills.Loader {
+	home, _ := os.UserHomeDir()
+	var dirs []string
+	for _, dir := range cfg.Directories {
+		if strings.HasPrefix(dir, "~/") {
+			dir = filepath.Join(home, dir[2:])
+		}
+		dirs = append(dirs, dir)
+	}
+	return skills.NewLoader(skills.LoaderConfig{
+		SkillDirs:   dirs,
+		Version:     cliVersion,
+		SkillConfig: cfg.EntryConfigs(),
+	})
+}
+
+// systemPrompt 返回 workspace 的 system prompt 缓存
+func systemPrompt(workspace string) *agents.PromptCache {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	cache, ok := promptCaches[workspace]
+	if !ok {
+		cache = agents.NewPromptCache(func() string { return buildSystemPrompt(workspace) })
+		promptCaches[workspace] = cache
+	}
+	return cache
+}
+
+func invalidatePrompts() {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+	for _, cache := range promptCaches {
+		cache.Invalidate()
+	}
+}
+
+// buildSystemPrompt 构建 system prompt，包含技能的工具与 SKILL.md 说明
+func buildSystemPrompt(workspace string) string {
+	set := loadSkills()
+	toolList := getToolList()
+	for _, skillTool := range tools.NewSkillTools(set.permitted, set.cfg, workspace) {
+		toolList = append(toolList, toolSchema(skillTool))
+	}
+	return agents.BuildSystemPromptWithSkills(workspace, toolList, set.permitted)
+}
+
+// systemEventSink 向会话加入系统事件，会话不存在时返回 false
+type systemEventSink func(sessionKey, text string) bool
+
+// managerEvents 将系统事件投递到 sessions.Manager 中的会话
+func managerEvents(mgr *sessions.Manager) systemEventSink {
+	return func(sessionKey, text string) bool {
+		session, ok := mgr.Get(sessionKey)
+		if ok {
+			session.EnqueueSystemEvent(text)
+		}
+		return ok
+	}
+}
+
+// enhancedEvents 将系统事件投递到 gateway 的 EnhancedManager 中的会话
+func enhancedEvents(mgr *sessions.EnhancedManager) systemEventSink {
+	return func(sessionKey, text string) bool {
+		session, ok := mgr.Get(sessionKey)
+		if ok {
+			session.EnqueueSystemEvent(text)
+		}
+		return ok
+	}
+}
+
+// filesPathPolicy 按配置 tools.files 创建文件工具的路径策略
+func filesPathPolicy(workspace string) *tools.PathPolicy {
+	var filesCfg config.PathPolicyConfig
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		filesCfg = cfg.Tools.Files
+	}
+	return tools.NewPathPolicy(filesCfg, workspace)
+}
+
+// createToolRegistry 创建并注册所有工具；后台进程结束事件通过 events 投递给启动它的会话
+func createToolRegistry(workspace, stateDir string, events systemEventSink, cronScheduler *cron.Scheduler, memoryIndex *memory.Index) *sessions.ToolRegistry {
 	registry := sessions.NewToolRegistry()
 	
-	// 文件操作工具
+	// 文件操作工具，访问范围由 tools.files 路径策略限制 (默认只允许工作区)
+	pathPolicy := filesPathPolicy(workspace)
+	
 	readTool := tools.NewReadTool(workspace)
+	readTool.SetPathPolicy(pathPolicy)
 	registry.Register(readTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := readTool.Execute(ctx, args)
 		if err != nil {
@@ -274,7 +606,12 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
+	// 文件修改前保存快照，可用 checkpoint 工具或 openclaw checkpoints 回滚
+	checkpointStore := checkpointStoreFor(stateDir)
+	
 	writeTool := tools.NewWriteTool(workspace)
+	writeTool.SetCheckpoints(checkpointStore)
+	writeTool.SetPathPolicy(pathPolicy)
 	registry.Register(writeTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := writeTool.Execute(ctx, args)
 		if err != nil {
@@ -284,6 +621,8 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 	})
 	
 	editTool := tools.NewEditTool(workspace)
+	editTool.SetCheckpoints(checkpointStore)
+	editTool.SetPathPolicy(pathPolicy)
 	registry.Register(editTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := editTool.Execute(ctx, args)
 		if err != nil {
@@ -292,8 +631,41 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
+	applyPatchTool := tools.NewApplyPatchTool(workspace)
+	applyPatchTool.SetCheckpoints(checkpointStore)
+	applyPatchTool.SetPathPolicy(pathPolicy)
+	registry.RegisterWithSchema(toolSchema(applyPatchTool), func(ctx context.Context, args json.RawMessage) (string, error) {
+		result, err := applyPatchTool.Execute(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		return result.Content, nil
+	})
+	
+	checkpointTool := tools.NewCheckpointTool(checkpointStore)
+	checkpointTool.SetPathPolicy(pathPolicy)
+	registry.RegisterWithSchema(toolSchema(checkpointTool), func(ctx context.Context, args json.RawMessage) (string, error) {
+		result, err := checkpointTool.Execute(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		return result.Content, nil
+	})
+	
 	// 命令执行工具
 	execTool := tools.NewExecTool(workspace)
+	var sandbox *tools.Sandbox
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		execTool.SetAllowlist(cfg.Tools.Exec.Allowlist)
+		if sandboxCfg := cfg.Tools.Exec.Sandbox; sandboxCfg.Mode != "" && sandboxCfg.Mode != tools.SandboxModeOff {
+			sandbox = tools.NewSandbox(sandboxCfg, workspace)
+			if err := sandbox.Validate(); err != nil {
+				// 不回退到主机执行：需要沙箱的会话中 exec 会直接报错
+				log.Warn().Err(err).Msg("Exec sandbox unavailable")
+			}
+			execTool.SetSandbox(sandbox)
+		}
+	}
 	registry.Register(execTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := execTool.Execute(ctx, args)
 		if err != nil {
@@ -302,8 +674,34 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
+	// 后台进程：输出持久化到状态目录，结束时通知启动它的会话
+	processTool := tools.NewProcessTool(workspace)
+	if err := processTool.SetStateDir(filepath.Join(stateDir, "processes")); err != nil {
+		log.Warn().Err(err).Msg("Failed to load background process sessions")
+	}
+	processTool.SetExitHandler(func(event tools.ProcessEvent) {
+		if !events(event.Owner, event.Message()) {
+			log.Debug().Str("session", event.Owner).Msg("Process exit event for unknown session")
+		}
+	})
+	processTool.SetSandbox(sandbox)
+	execTool.SetProcessTool(processTool)
+	registry.RegisterWithSchema(toolSchema(processTool), func(ctx context.Context, args json.RawMessage) (string, error) {
+		result, err := processTool.Execute(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		return result.Content, nil
+	})
+	
 	// Web 工具
-	webSearchTool := tools.NewWebSearchTool()
+	var webSearchCfg config.WebSearchConfig
+	var webFetchCfg config.WebFetchConfig
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		webSearchCfg = cfg.Tools.Web.Search
+		webFetchCfg = cfg.Tools.Web.Fetch
+	}
+	webSearchTool := tools.NewWebSearchToolFromConfig(webSearchCfg)
 	registry.Register(webSearchTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := webSearchTool.Execute(ctx, args)
 		if err != nil {
@@ -312,7 +710,7 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
-	webFetchTool := tools.NewWebFetchTool()
+	webFetchTool := tools.NewWebFetchToolFromConfig(webFetchCfg, config.GetPaths().CacheDir())
 	registry.Register(webFetchTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := webFetchTool.Execute(ctx, args)
 		if err != nil {
@@ -321,9 +719,15 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
-	// 浏览器工具
-	browserTool := tools.NewBrowserTool()
-	registry.Register(browserTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
+	// 浏览器工具：profile 数据保存在 state/browser，下载保存到工作区
+	var browserCfg config.BrowserConfig
+	if cfg, err := config.Load(); err == nil && cfg != nil {
+		browserCfg = cfg.Tools.Browser
+	}
+	browserTool := tools.NewBrowserToolFromConfig(browserCfg, stateDir, workspace)
+	browserTool.SetPathPolicy(pathPolicy)
+	trackPolicyTools(execTool, browserTool)
+	registry.RegisterWithSchema(toolSchema(browserTool), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := browserTool.Execute(ctx, args)
 		if err != nil {
 			return "", err
@@ -333,6 +737,9 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 	
 	// 记忆工具
 	memorySearchTool := tools.NewMemorySearchTool(workspace)
+	if memoryIndex != nil {
+		memorySearchTool.SetIndex(memoryIndex)
+	}
 	registry.Register(memorySearchTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := memorySearchTool.Execute(ctx, args)
 		if err != nil {
@@ -342,6 +749,9 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 	})
 	
 	memoryGetTool := tools.NewMemoryGetTool(workspace)
+	if memoryIndex != nil {
+		memoryGetTool.SetSessionsDir(memoryIndex.SessionsDir())
+	}
 	registry.Register(memoryGetTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
 		result, err := memoryGetTool.Execute(ctx, args)
 		if err != nil {
@@ -350,6 +760,36 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
+	memoryAppendTool := tools.NewMemoryAppendTool(workspace)
+	registry.Register(memoryAppendTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
+		result, err := memoryAppendTool.Execute(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		return result.Content, nil
+	})
+	
+	memoryUpdateTool := tools.NewMemoryUpdateTool(workspace)
+	registry.Register(memoryUpdateTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
+		result, err := memoryUpdateTool.Execute(ctx, args)
+		if err != nil {
+			return "", err
+		}
+		return result.Content, nil
+	})
+	
+	// TTS 工具 (tts.enabled 时注册)
+	if cfg, err := config.Load(); err == nil && cfg != nil && cfg.TTS.Enabled {
+		ttsTool := tools.NewTTSToolFromConfig(cfg.TTS, stateDir, workspace)
+		registry.RegisterWithSchema(toolSchema(ttsTool), func(ctx context.Context, args json.RawMessage) (string, error) {
+			result, err := ttsTool.Execute(ctx, args)
+			if err != nil {
+				return "", err
+			}
+			return result.Content, nil
+		})
+	}
+	
 	// Cron 工具
 	cronTool := tools.NewCronTool(cronScheduler)
 	registry.Register(cronTool.Name(), func(ctx context.Context, args json.RawMessage) (string, error) {
@@ -360,21 +800,65 @@ func createToolRegistry(workspace string, cronScheduler *cron.Scheduler) *sessio
 		return result.Content, nil
 	})
 	
+	// 技能声明的工具，名称为 <skillID>__<tool>；技能热加载时更新
+	set := loadSkills()
+	skillsMu.Lock()
+	skillRegistries[registry] = skillRegistration{
+		workspace: workspace,
+		sandbox:   sandbox,
+		names:     registerSkillTools(registry, set, workspace, sandbox),
+	}
+	skillsMu.Unlock()
+	
 	return registry
 }
 
+// registerSkillTools 注册技能工具，返回注册的名称；技能在 sandbox (可为空) 适用的会话中沙箱运行
+func registerSkillTools(registry *sessions.ToolRegistry, set skillSet, workspace string, sandbox *tools.Sandbox) []string {
+	var names []string
+	for _, skillTool := range tools.NewSkillTools(set.permitted, set.cfg, workspace) {
+		skillTool := skillTool
+		skillTool.SetSandbox(sandbox)
+		registry.RegisterWithSchema(toolSchema(skillTool), func(ctx context.Context, args json.RawMessage) (string, error) {
+			result, err := skillTool.Execute(ctx, args)
+			if err != nil {
+				return "", err
+			}
+			return result.Content, nil
+		})
+		names = append(names, skillTool.Name())
+	}
+	return names
+}
+
+// toolSchema 将 tools.Tool 的 JSON schema 转为 agents.Tool
+func toolSchema(tool tools.Tool) agents.Tool {
+	var params map[string]interface{}
+	json.Unmarshal(tool.Parameters(), &params)
+	return agents.Tool{
+		Name:        tool.Name(),
+		Description: tool.Description(),
+		Parameters:  params,
+	}
+}
+
 // getToolList 获取工具列表用于 system prompt
 func getToolList() []agents.Tool {
 	return []agents.Tool{
 		{Name: "read", Description: "Read file contents. Supports text and images."},
 		{Name: "write", Description: "Write content to file. Creates directories automatically."},
 		{Name: "edit", Description: "Edit file by replacing exact text."},
+		{Name: "apply_patch", Description: "Apply a multi-file patch (unified diff or *** Begin Patch)."},
+		{Name: "checkpoint", Description: "List, diff and restore file checkpoints."},
 		{Name: "exec", Description: "Execute shell commands."},
-		{Name: "web_search", Description: "Search the web using Brave Search API."},
+		{Name: "process", Description: "Manage background exec sessions: list, poll, log, write, kill."},
+		{Name: "web_search", Description: "Search the web (Brave, SearXNG, Tavily or custom backends)."},
 		{Name: "web_fetch", Description: "Fetch and extract content from URL (HTML → markdown)."},
-		{Name: "browser", Description: "Control web browser: navigate, screenshot, interact."},
-		{Name: "memory_search", Description: "Search MEMORY.md and memory/*.md files."},
+		{Name: "browser", Description: "Control web browser: navigate, accessibility snapshot with element refs, screenshot, act by ref."},
+		{Name: "memory_search", Description: "Semantic search over MEMORY.md and memory/*.md files, with citations."},
 		{Name: "memory_get", Description: "Read snippet from memory files."},
+		{Name: "memory_append", Description: "Append notes to today's memory journal (memory/YYYY-MM-DD.md)."},
+		{Name: "memory_update", Description: "Append, replace or remove entries in a MEMORY.md section."},
 		{Name: "cron", Description: "Manage cron jobs: add, list, update, remove, run."},
 	}
 }
@@ -474,12 +958,27 @@ var telegramCmd = &cobra.Command{
 		cronScheduler.Start()
 		defer cronScheduler.Stop()
 		
+		// 记忆索引
+		indexCtx, stopIndex := context.WithCancel(context.Background())
+		defer stopIndex()
+		memoryIndex := newMemoryIndex(indexCtx, workspace, stateDir)
+		
 		// 创建工具注册表
-		toolRegistry := createToolRegistry(workspace, cronScheduler)
+		toolRegistry := createToolRegistry(workspace, stateDir, managerEvents(sessionMgr), cronScheduler, memoryIndex)
 		
-		// 构建 system prompt
-		toolList := getToolList()
-		systemPrompt := agents.BuildSystemPrompt(workspace, toolList)
+		// system prompt 与技能随文件变化热加载
+		watchCtx, stopWatch := context.WithCancel(context.Background())
+		defer stopWatch()
+		watchWorkspace(watchCtx, workspace, nil)
+		prompt := systemPrompt(workspace)
+		
+		// 语音回复 (tts.autoReply)
+		voiceMode := channels.VoiceReplyOff
+		var ttsTool *tools.TTSTool
+		if cfg, err := config.Load(); err == nil && cfg != nil && cfg.TTS.Enabled && cfg.TTS.AutoReply != "" {
+			voiceMode = cfg.TTS.AutoReply
+			ttsTool = tools.NewTTSToolFromConfig(cfg.TTS, stateDir, workspace)
+		}
 		
 		// 设置消息处理器
 		tgChannel.SetMessageHandler(func(msg *channels.InboundMessage) {
@@ -491,10 +990,14 @@ var telegramCmd = &cobra.Command{
 			
 			// 获取或创建会话
 			sessionKey := fmt.Sprintf("telegram:%s", msg.ChatID)
-			session := sessionMgr.GetOrCreate(sessionKey, "telegram", msg.SenderName)
+			sessionKind := "telegram"
+			if msg.ChatType == channels.ChatTypeGroup {
+				sessionKind = "group"
+			}
+			session := sessionMgr.GetOrCreate(sessionKey, sessionKind, msg.SenderName)
 			
 			// 创建 agent loop
-			loop := sessions.NewAgentLoop(provider, toolRegistry, session, systemPrompt, model)
+			loop := sessions.NewAgentLoop(provider, toolRegistry, session, prompt.Get(), model)
 			
 			// 运行 agent
 			ctx := context.Background()
@@ -522,6 +1025,18 @@ var telegramCmd = &cobra.Command{
 				replyText = "No response"
 			}
 			
+			// 语音回复，失败时回退到文本
+			if ttsTool != nil && channels.WantsVoiceReply(voiceMode, msg) {
+				reply, err := channels.NewVoiceReply(ctx, msg, replyText, ttsTool.Speak)
+				if err == nil {
+					_, err = tgChannel.Send(ctx, reply)
+				}
+				if err == nil {
+					return
+				}
+				log.Warn().Err(err).Msg("Voice reply failed, sending text")
+			}
+			
 			// Telegram 限制 4096 字符
 			if len(replyText) > 4000 {
 				replyText = replyText[:4000] + "\n...[truncated]"
@@ -40,8 +40,17 @@ type Config struct {
 	// Cron 配置
 	Cron CronConfig `json:"cron,omitempty"`
 
+	// 心跳配置
+	Heartbeat HeartbeatConfig `json:"heartbeat,omitempty"`
+
 	// TTS 配置
 	TTS TTSConfig `json:"tts,omitempty"`
+
+	// 记忆配置
+	Memory MemoryConfig `json:"memory,omitempty"`
+
+	// 技能配置
+	Skills SkillsConfig `json:"skills,omitempty"`
 }
 
 type GatewayConfig struct {
@@ -129,18 +138,154 @@ type PluginEntry struct {
 
 type ToolsConfig struct {
 	Exec struct {
-		Enabled   bool     `json:"enabled,omitempty"`
-		Allowlist []string `json:"allowlist,omitempty"`
+		Enabled   bool          `json:"enabled,omitempty"`
+		Allowlist []string      `json:"allowlist,omitempty"`
+		Sandbox   SandboxConfig `json:"sandbox,omitempty"`
 	} `json:"exec,omitempty"`
-	Browser struct {
-		Enabled bool   `json:"enabled,omitempty"`
-		Profile string `json:"profile,omitempty"`
-	} `json:"browser,omitempty"`
+	Browser BrowserConfig `json:"browser,omitempty"`
+	Web struct {
+		Search WebSearchConfig `json:"search,omitempty"`
+		Fetch  WebFetchConfig  `json:"fetch,omitempty"`
+	} `json:"web,omitempty"`
+	Files PathPolicyConfig `json:"files,omitempty"`
+}
+
+// BrowserConfig 浏览器工具配置
+type BrowserConfig struct {
+	Enabled     bool   `json:"enabled,omitempty"`
+	Profile     string `json:"profile,omitempty"`     // 默认 profile (默认 "default")，数据保存在 state/browser/profiles/<name>
+	Headless    *bool  `json:"headless,omitempty"`    // 默认 true
+	DownloadDir string `json:"downloadDir,omitempty"` // 下载保存目录，相对路径基于工作区 (默认 downloads)
+}
+
+// PathPolicyConfig 文件工具 (read/write/edit/apply_patch/image) 可访问的路径
+type PathPolicyConfig struct {
+	Mode          string                  `json:"mode,omitempty"`          // "workspace" (默认，仅工作区和下面的根目录) | "off" (不限制根目录)
+	ReadOnlyPaths []string                `json:"readOnlyPaths,omitempty"` // 额外的只读根目录
+	WritablePaths []string                `json:"writablePaths,omitempty"` // 额外的读写根目录
+	Deny          []string                `json:"deny,omitempty"`          // 追加的拒绝 glob，如 "**/secrets/**"
+	Sessions      []PathPolicySessionRule `json:"sessions,omitempty"`      // 按会话覆盖，第一条匹配的生效
+}
+
+// PathPolicySessionRule 按会话覆盖路径策略 (根目录和拒绝规则在全局配置基础上追加)
+type PathPolicySessionRule struct {
+	Match         string   `json:"match"`                   // 会话 key 的 glob，如 "telegram:-100*"
+	Mode          string   `json:"mode,omitempty"`          // 为空时沿用全局 mode
+	ReadOnlyPaths []string `json:"readOnlyPaths,omitempty"`
+	WritablePaths []string `json:"writablePaths,omitempty"`
+	Deny          []string `json:"deny,omitempty"`
+}
+
+// SandboxConfig exec/process 沙箱配置
+type SandboxConfig struct {
+	Mode          string               `json:"mode,omitempty"`          // "off" (默认) | "all" | "non-main" | "groups"
+	Backend       string               `json:"backend,omitempty"`       // "namespaces" (默认, 仅 Linux) | "command"
+	Command       []string             `json:"command,omitempty"`       // backend=command 时的外部沙箱命令前缀，支持 {workspace} {workdir}
+	Network       bool                 `json:"network,omitempty"`       // 允许网络访问
+	ReadOnlyPaths []string             `json:"readOnlyPaths,omitempty"` // 额外只读可见的主机路径
+	WritablePaths []string             `json:"writablePaths,omitempty"` // 额外可写的主机路径 (工作区始终可写)
+	MemoryMB      int                  `json:"memoryMb,omitempty"`      // cgroups v2 memory.max
+	CPUs          float64              `json:"cpus,omitempty"`          // cgroups v2 cpu.max
+	Pids          int                  `json:"pids,omitempty"`          // cgroups v2 pids.max
+	Env           []string             `json:"env,omitempty"`           // 透传的主机环境变量名
+	Sessions      []SandboxSessionRule `json:"sessions,omitempty"`      // 按会话覆盖 Mode，第一个匹配的规则生效
+}
+
+// SandboxSessionRule 按会话 key 覆盖沙箱选择
+type SandboxSessionRule struct {
+	Match   string `json:"match"`   // 会话 key 的 glob，如 "telegram:-100*"
+	Sandbox bool   `json:"sandbox"` // true 强制沙箱，false 直接在主机执行
+}
+
+// WebFetchConfig 网页抓取配置
+type WebFetchConfig struct {
+	CacheTTLSeconds     int      `json:"cacheTtlSeconds,omitempty"`     // 磁盘缓存时间，默认 3600，负数禁用
+	MaxBytes            int64    `json:"maxBytes,omitempty"`            // 最大下载字节数，默认 5MB
+	AllowPrivateNetwork bool     `json:"allowPrivateNetwork,omitempty"` // 允许访问内网/回环地址
+	AllowHosts          []string `json:"allowHosts,omitempty"`          // 允许访问的内网主机名、IP 或 CIDR
+}
+
+// WebSearchConfig 网页搜索后端配置
+type WebSearchConfig struct {
+	Providers       []SearchProviderConfig `json:"providers,omitempty"`       // 按顺序尝试，失败时回退到下一个
+	CacheTTLSeconds int                    `json:"cacheTtlSeconds,omitempty"` // 结果缓存时间，默认 900，负数禁用
+}
+
+// SearchProviderConfig 单个搜索后端
+type SearchProviderConfig struct {
+	Type      string `json:"type"` // "brave" | "searxng" | "tavily" | "json"
+	Name      string `json:"name,omitempty"`
+	BaseURL   string `json:"baseUrl,omitempty"`
+	APIKey    string `json:"apiKey,omitempty"`
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"`
+
+	// 通用 JSON 接口 (type=json)
+	URL          string            `json:"url,omitempty"` // 支持 {query} {count} {country} {language} {freshness}
+	Method       string            `json:"method,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"` // 值中的 {apiKey} 会被替换
+	Body         string            `json:"body,omitempty"`
+	ResultsPath  string            `json:"resultsPath,omitempty"` // 如 "data.items"
+	TitleField   string            `json:"titleField,omitempty"`
+	URLField     string            `json:"urlField,omitempty"`
+	SnippetField string            `json:"snippetField,omitempty"`
 }
 
 type CronConfig struct {
-	Enabled bool      `json:"enabled,omitempty"`
-	Jobs    []CronJob `json:"jobs,omitempty"`
+	Enabled  bool               `json:"enabled,omitempty"`
+	Jobs     []CronJob          `json:"jobs,omitempty"`
+	Delivery CronDeliveryConfig `json:"delivery,omitempty"` // agentTurn 结果的默认投递目标
+}
+
+// CronDeliveryConfig 定时任务结果投递目标
+type CronDeliveryConfig struct {
+	Channel string `json:"channel,omitempty"` // 如 "telegram"
+	ChatID  string `json:"chatId,omitempty"`
+}
+
+// HeartbeatConfig 心跳：定期唤醒主会话，按 HEARTBEAT.md 主动检查并汇报
+type HeartbeatConfig struct {
+	Enabled    bool              `json:"enabled,omitempty"`
+	Every      string            `json:"every,omitempty"` // 间隔，如 "30m" (默认)
+	QuietHours *QuietHoursConfig `json:"quietHours,omitempty"`
+	Channel    string            `json:"channel,omitempty"` // 投递目标，为空时使用 cron.delivery
+	ChatID     string            `json:"chatId,omitempty"`
+}
+
+// QuietHoursConfig 免打扰时段 ("HH:MM"，可跨午夜)
+type QuietHoursConfig struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Tz    string `json:"tz,omitempty"` // IANA 时区，默认本地时间
+}
+
+// SkillsConfig 技能：目录与授予的权限
+type SkillsConfig struct {
+	Directories []string                    `json:"directories,omitempty"` // 技能目录，默认 ~/.openclaw/skills
+	Permissions []string                    `json:"permissions,omitempty"` // 授予所有技能的权限
+	Entries     map[string]SkillEntryConfig `json:"entries,omitempty"`     // 按技能 ID 覆盖
+}
+
+// SkillEntryConfig 单个技能的配置
+type SkillEntryConfig struct {
+	Permissions []string          `json:"permissions,omitempty"` // 额外授予此技能的权限
+	Config      map[string]string `json:"config,omitempty"`      // 技能配置 (requires.config)，以 SKILL_CONFIG_<KEY> 传给工具
+}
+
+// EntryConfigs 返回按技能 ID 的用户配置
+func (c SkillsConfig) EntryConfigs() map[string]map[string]string {
+	configs := make(map[string]map[string]string, len(c.Entries))
+	for id, entry := range c.Entries {
+		if len(entry.Config) > 0 {
+			configs[id] = entry.Config
+		}
+	}
+	return configs
+}
+
+// GrantedPermissions 返回授予指定技能的全部权限
+func (c SkillsConfig) GrantedPermissions(id string) []string {
+	granted := append([]string(nil), c.Permissions...)
+	return append(granted, c.Entries[id].Permissions...)
 }
 
 type CronJob struct {
@@ -153,9 +298,43 @@ type CronJob struct {
 }
 
 type TTSConfig struct {
-	Enabled  bool   `json:"enabled,omitempty"`
-	Provider string `json:"provider,omitempty"`
-	Voice    string `json:"voice,omitempty"`
+	Enabled   bool   `json:"enabled,omitempty"`
+	Provider  string `json:"provider,omitempty"` // "openai" (默认) | "elevenlabs" | "command"
+	Voice     string `json:"voice,omitempty"`
+	Model     string `json:"model,omitempty"`
+	BaseURL   string `json:"baseUrl,omitempty"` // OpenAI 兼容接口如 http://localhost:8880/v1
+	APIKey    string `json:"apiKey,omitempty"`
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"`
+
+	// 本地命令 (provider=command)，文本从 stdin 传入；参数支持 {output} {voice} {text}
+	Command []string `json:"command,omitempty"`
+	Format  string   `json:"format,omitempty"` // 命令输出格式，默认 wav
+
+	FFmpeg    string `json:"ffmpeg,omitempty"`    // 转码使用的 ffmpeg，默认从 PATH 查找
+	OutputDir string `json:"outputDir,omitempty"` // 音频输出目录，默认 <stateDir>/tts
+
+	// 语音回复: "off" (默认) | "inbound" (用户发语音时用语音回复) | "always"
+	AutoReply string `json:"autoReply,omitempty"`
+}
+
+type MemoryConfig struct {
+	Search  MemorySearchConfig  `json:"search,omitempty"`
+	Capture MemoryCaptureConfig `json:"capture,omitempty"`
+}
+
+// MemoryCaptureConfig 会话结束时自动提取事实写入记忆
+type MemoryCaptureConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	Model   string `json:"model,omitempty"` // 默认使用会话模型
+}
+
+type MemorySearchConfig struct {
+	Provider        string  `json:"provider,omitempty"` // "openai" | "local"
+	Model           string  `json:"model,omitempty"`
+	BaseURL         string  `json:"baseUrl,omitempty"`
+	APIKey          string  `json:"apiKey,omitempty"`
+	IncludeSessions bool    `json:"includeSessions,omitempty"`
+	VectorWeight    float64 `json:"vectorWeight,omitempty"` // 0-1，默认 0.6
 }
 
 // SetConfigFile 设置配置文件路径
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Diff 比较两份配置，返回发生变化的配置项路径 (如 "channels.telegram.botToken"、"tools.exec.allowlist")，按字母排序
+//
+// 路径使用 JSON 字段名；对象逐层比较，数组和其他值整体比较。一侧缺失的对象只报告对象本身 (如新增 "channels.discord")
+func Diff(old, new *Config) []string {
+	var changed []string
+	diffValue("", toJSONValue(old), toJSONValue(new), &changed)
+	sort.Strings(changed)
+	return changed
+}
+
+// toJSONValue 将配置转换为 JSON 值，保证与配置文件中的字段名和省略规则一致
+func toJSONValue(c *Config) interface{} {
+	if c == nil {
+		return map[string]interface{}{}
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil
+	}
+	var v interface{}
+	json.Unmarshal(data, &v)
+	return v
+}
+
+func diffValue(path string, old, new interface{}, changed *[]string) {
+	oldMap, oldOK := old.(map[string]interface{})
+	newMap, newOK := new.(map[string]interface{})
+	if !oldOK || !newOK {
+		if !reflect.DeepEqual(old, new) {
+			*changed = append(*changed, path)
+		}
+		return
+	}
+
+	for key, value := range oldMap {
+		diffValue(joinPath(path, key), value, newMap[key], changed)
+	}
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			*changed = append(*changed, joinPath(path, key))
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
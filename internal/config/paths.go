@@ -42,6 +42,7 @@ type ProjectLayout struct {
 	PluginsDir  string // plugins/
 	CacheDir    string // cache/
 	LogsDir     string // logs/
+	StateDir    string // state/
 
 	// WhatsApp 数据
 	WhatsAppDir string // whatsapp/
@@ -63,6 +64,7 @@ var DefaultLayout = ProjectLayout{
 	PluginsDir:    "plugins",
 	CacheDir:      "cache",
 	LogsDir:       "logs",
+	StateDir:      "state",
 	WhatsAppDir:   "whatsapp",
 }
 
@@ -200,6 +202,11 @@ func (p *Paths) LogsDir() string {
 	return filepath.Join(p.Root, DefaultLayout.LogsDir)
 }
 
+// StateDir 运行状态目录 (索引、任务状态等)
+func (p *Paths) StateDir() string {
+	return filepath.Join(p.Root, DefaultLayout.StateDir)
+}
+
 // WhatsAppDir whatsapp 数据目录
 func (p *Paths) WhatsAppDir() string {
 	return filepath.Join(p.Root, DefaultLayout.WhatsAppDir)
@@ -228,6 +235,7 @@ func (p *Paths) EnsureDirs() error {
 		p.PluginsDir(),
 		p.CacheDir(),
 		p.LogsDir(),
+		p.StateDir(),
 	}
 
 	for _, dir := range dirs {
@@ -0,0 +1,173 @@
+package cron
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 运行状态
+const (
+	RunStatusOK      = "ok"
+	RunStatusError   = "error"
+	RunStatusTimeout = "timeout"
+	RunStatusSkipped = "skipped" // 上一次运行尚未结束 (overlap=skip)
+)
+
+// 触发方式
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+	TriggerCatchUp  = "catchup" // 启动时补跑错过的任务
+	TriggerQueued   = "queued"  // 上一次运行结束后补跑 (overlap=queue)
+)
+
+const (
+	maxRunOutput  = 500 // 输出摘要的最大字符数
+	maxRunRecords = 200 // 每个任务保留的运行记录数
+)
+
+// RunRecord 一次任务运行记录
+type RunRecord struct {
+	JobID      string    `json:"jobId"`
+	Trigger    string    `json:"trigger"`
+	Status     string    `json:"status"`
+	StartedAt  time.Time `json:"startedAt"`
+	EndedAt    time.Time `json:"endedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Output     string    `json:"output,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	SessionKey string    `json:"sessionKey,omitempty"`
+}
+
+// RunLog 按任务保存运行记录 (<stateDir>/cron-runs/<jobId>.jsonl)
+type RunLog struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewRunLog 创建运行记录存储
+func NewRunLog(stateDir string) *RunLog {
+	return &RunLog{dir: filepath.Join(stateDir, "cron-runs")}
+}
+
+// Append 追加一条记录，超过上限时只保留最近的记录
+func (l *RunLog) Append(record RunRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Output = excerpt(record.Output, maxRunOutput)
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.dir, 0755); err != nil {
+		return err
+	}
+
+	path := l.path(record.JobID)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	records, err := l.read(record.JobID)
+	if err != nil || len(records) <= maxRunRecords+maxRunRecords/4 {
+		return err
+	}
+	return l.rewrite(record.JobID, records[len(records)-maxRunRecords:])
+}
+
+// List 返回最近的运行记录 (新的在前)，limit <= 0 时返回全部
+func (l *RunLog) List(jobID string, limit int) ([]RunRecord, error) {
+	l.mu.Lock()
+	records, err := l.read(jobID)
+	l.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	return records, nil
+}
+
+// Remove 删除任务的运行记录
+func (l *RunLog) Remove(jobID string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.Remove(l.path(jobID)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (l *RunLog) path(jobID string) string {
+	return filepath.Join(l.dir, filepath.Base(jobID)+".jsonl")
+}
+
+func (l *RunLog) read(jobID string) ([]RunRecord, error) {
+	f, err := os.Open(l.path(jobID))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []RunRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record RunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // 跳过损坏的行
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+func (l *RunLog) rewrite(jobID string, records []RunRecord) error {
+	var sb strings.Builder
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		sb.Write(line)
+		sb.WriteByte('\n')
+	}
+
+	path := l.path(jobID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0644); err != nil {
+		return fmt.Errorf("write run log: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// excerpt 截断输出，保留开头部分
+func excerpt(s string, max int) string {
+	s = strings.TrimSpace(s)
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "..."
+}
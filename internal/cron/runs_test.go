@@ -0,0 +1,258 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunLog_AppendAndList(t *testing.T) {
+	l := NewRunLog(t.TempDir())
+	for i := 0; i < maxRunRecords+maxRunRecords/4+1; i++ {
+		if err := l.Append(RunRecord{JobID: "job-1", Status: RunStatusOK, DurationMs: int64(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Append(RunRecord{JobID: "job-1", Status: RunStatusError, Output: strings.Repeat("x", maxRunOutput+10)})
+
+	all, _ := l.List("job-1", 0)
+	if len(all) != maxRunRecords+1 {
+		t.Errorf("Expected log trimmed to %d records, got %d", maxRunRecords+1, len(all))
+	}
+	recent, err := l.List("job-1", 2)
+	if err != nil || len(recent) != 2 {
+		t.Fatalf("List = %v, %v", recent, err)
+	}
+	if recent[0].Status != RunStatusError || len([]rune(recent[0].Output)) != maxRunOutput+3 {
+		t.Errorf("Expected newest record first with excerpted output, got %+v", recent[0])
+	}
+
+	l.Remove("job-1")
+	if records, _ := l.List("job-1", 0); len(records) != 0 {
+		t.Errorf("Expected empty log after remove, got %d", len(records))
+	}
+}
+
+func TestScheduler_RunHistory(t *testing.T) {
+	s := NewScheduler(t.TempDir(), nil)
+	s.SetRunHandler(func(ctx context.Context, job *Job) (RunOutput, error) {
+		if job.Payload.Text == "fail" {
+			return RunOutput{}, errors.New("boom")
+		}
+		return RunOutput{Output: "done", SessionKey: "isolated:abc"}, nil
+	})
+
+	job := &Job{Schedule: Schedule{Kind: "every", EveryMs: 3600000}, Payload: Payload{Kind: "systemEvent", Text: "ok"}}
+	s.AddJob(job)
+	s.RunJob(job.ID)
+	job.Payload.Text = "fail"
+	s.RunJob(job.ID)
+
+	runs, err := s.Runs(job.ID, 10)
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("Runs = %v, %v", runs, err)
+	}
+	if runs[0].Status != RunStatusError || runs[0].Error != "boom" || runs[0].Trigger != TriggerManual {
+		t.Errorf("Unexpected failed run: %+v", runs[0])
+	}
+	if runs[1].Status != RunStatusOK || runs[1].Output != "done" || runs[1].SessionKey != "isolated:abc" {
+		t.Errorf("Unexpected successful run: %+v", runs[1])
+	}
+	if _, err := s.Runs("missing", 10); err == nil {
+		t.Error("Expected error for unknown job")
+	}
+}
+
+func TestScheduler_Timeout(t *testing.T) {
+	s := NewScheduler(t.TempDir(), nil)
+	s.SetRunHandler(func(ctx context.Context, job *Job) (RunOutput, error) {
+		<-ctx.Done()
+		return RunOutput{}, ctx.Err()
+	})
+
+	job := &Job{Schedule: Schedule{Kind: "every", EveryMs: 3600000}, TimeoutMs: 20}
+	s.AddJob(job)
+	if err := s.RunJob(job.ID); !errors.Is(err, ErrJobTimeout) {
+		t.Fatalf("Expected timeout, got %v", err)
+	}
+	runs, _ := s.Runs(job.ID, 1)
+	if len(runs) != 1 || runs[0].Status != RunStatusTimeout {
+		t.Errorf("Expected timeout record, got %+v", runs)
+	}
+}
+
+func TestScheduler_TimeoutKeepsJobRunning(t *testing.T) {
+	s := NewScheduler(t.TempDir(), nil)
+	release := make(chan struct{})
+	var mu sync.Mutex
+	count := 0
+	s.SetRunHandler(func(ctx context.Context, job *Job) (RunOutput, error) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		<-release // 忽略 ctx 取消的处理器
+		return RunOutput{}, nil
+	})
+
+	job := &Job{Schedule: Schedule{Kind: "every", EveryMs: 3600000}, TimeoutMs: 20, Overlap: OverlapSkip}
+	s.AddJob(job)
+	if err := s.RunJob(job.ID); !errors.Is(err, ErrJobTimeout) {
+		t.Fatalf("Expected timeout, got %v", err)
+	}
+
+	// 处理器仍在运行，skip 策略跳过新的运行
+	if err := s.RunJob(job.ID); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Expected ErrJobRunning while the timed-out handler runs, got %v", err)
+	}
+
+	close(release)
+	waitFor(t, func() bool {
+		s.jobsMu.RLock()
+		defer s.jobsMu.RUnlock()
+		return s.running[job.ID] == 0
+	})
+	s.SetRunHandler(func(ctx context.Context, job *Job) (RunOutput, error) {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		return RunOutput{}, nil
+	})
+	if err := s.RunJob(job.ID); err != nil {
+		t.Errorf("Expected run after the handler returned, got %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 handler calls, got %d", count)
+	}
+}
+
+func TestScheduler_OverlapPolicy(t *testing.T) {
+	tests := []struct {
+		overlap string
+		want    int // 运行次数
+	}{
+		{OverlapSkip, 1},
+		{OverlapQueue, 2},
+		{OverlapAllow, 3},
+	}
+	for _, tt := range tests {
+		s := NewScheduler(t.TempDir(), nil)
+		release := make(chan struct{})
+		var mu sync.Mutex
+		count := 0
+		s.SetRunHandler(func(ctx context.Context, job *Job) (RunOutput, error) {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			<-release
+			return RunOutput{}, nil
+		})
+
+		job := &Job{Schedule: Schedule{Kind: "every", EveryMs: 3600000}, Overlap: tt.overlap}
+		s.AddJob(job)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.RunJob(job.ID)
+		}()
+		waitFor(t, func() bool { mu.Lock(); defer mu.Unlock(); return count == 1 })
+
+		for i := 0; i < 2; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.RunJob(job.ID)
+			}()
+		}
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if count != tt.want {
+			t.Errorf("overlap=%s: expected %d runs, got %d", tt.overlap, tt.want, count)
+		}
+		if tt.overlap == OverlapSkip {
+			runs, _ := s.Runs(job.ID, 0)
+			skippedRuns := 0
+			for _, run := range runs {
+				if run.Status == RunStatusSkipped {
+					skippedRuns++
+				}
+			}
+			if len(runs) != 3 || skippedRuns != 2 {
+				t.Errorf("Expected skipped runs to be recorded, got %+v", runs)
+			}
+		}
+	}
+
+	if err := NewScheduler(t.TempDir(), nil).AddJob(&Job{Overlap: "parallel"}); err == nil {
+		t.Error("Expected error for invalid overlap policy")
+	}
+}
+
+func TestScheduler_CatchUp(t *testing.T) {
+	dir := t.TempDir()
+	s := NewScheduler(dir, nil)
+	last := time.Now().Add(-2 * time.Hour)
+	missed := &Job{ID: "missed", Schedule: Schedule{Kind: "every", EveryMs: 3600000}, CatchUp: true, Enabled: true}
+	skipped := &Job{ID: "no-catchup", Schedule: Schedule{Kind: "every", EveryMs: 3600000}, Enabled: true}
+	fresh := &Job{ID: "fresh", Schedule: Schedule{Kind: "every", EveryMs: 3600000}, CatchUp: true, Enabled: true}
+	s.AddJob(missed)
+	s.AddJob(skipped)
+	s.AddJob(fresh)
+	missed.LastRunAt, skipped.LastRunAt = &last, &last
+	s.saveJobs()
+
+	// 重新加载，模拟网关重启
+	s = NewScheduler(dir, nil)
+	var mu sync.Mutex
+	ran := map[string]string{}
+	s.SetRunHandler(func(ctx context.Context, job *Job) (RunOutput, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		ran[job.ID] = "ran"
+		return RunOutput{}, nil
+	})
+	s.Start()
+	defer s.Stop()
+
+	waitFor(t, func() bool { mu.Lock(); defer mu.Unlock(); return len(ran) > 0 })
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(ran) != 1 || ran["missed"] == "" {
+		t.Errorf("Expected only the missed job to catch up, got %v", ran)
+	}
+	if runs, _ := s.Runs("missed", 1); len(runs) != 1 || runs[0].Trigger != TriggerCatchUp {
+		t.Errorf("Expected catch-up run record, got %+v", runs)
+	}
+}
+
+func TestMissedRun(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	if !missedRun(&Job{Schedule: Schedule{Kind: "at", AtMs: past.UnixMilli()}}, now) {
+		t.Error("Expected missed one-shot job")
+	}
+	if missedRun(&Job{Schedule: Schedule{Kind: "at", AtMs: past.UnixMilli()}, LastRunAt: &past}, now) {
+		t.Error("One-shot job that already ran should not catch up")
+	}
+	if missedRun(&Job{Schedule: Schedule{Kind: "cron", Expr: "0 0 0 1 1 *"}, CreatedAt: past}, now) && now.YearDay() != 1 {
+		t.Error("Yearly job should not have missed a run within the last minute")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,92 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// specParser 解析 cron 表达式，秒字段可选 ("0 9 * * *" 与 "0 0 9 * * *" 等价)
+var specParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// onceSchedule 一次性调度，触发后 Next 返回零值，不会再次运行
+type onceSchedule struct {
+	at time.Time
+}
+
+func (s onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(s.at) {
+		return s.at
+	}
+	return time.Time{}
+}
+
+// everySchedule 以 anchor 为起点、精确到毫秒的固定间隔调度
+type everySchedule struct {
+	every  time.Duration
+	anchor time.Time
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	if t.Before(s.anchor) {
+		return s.anchor
+	}
+	n := t.Sub(s.anchor)/s.every + 1
+	return s.anchor.Add(n * s.every)
+}
+
+// jobSchedule 根据任务的调度配置创建 cron.Schedule
+func jobSchedule(job *Job) (cron.Schedule, error) {
+	switch job.Schedule.Kind {
+	case "cron":
+		expr := strings.TrimSpace(job.Schedule.Expr)
+		if expr == "" {
+			return nil, fmt.Errorf("cron expression is required")
+		}
+		if job.Schedule.Tz != "" {
+			if _, err := time.LoadLocation(job.Schedule.Tz); err != nil {
+				return nil, fmt.Errorf("invalid time zone %q: %w", job.Schedule.Tz, err)
+			}
+			expr = "CRON_TZ=" + job.Schedule.Tz + " " + expr
+		}
+		schedule, err := specParser.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", job.Schedule.Expr, err)
+		}
+		return schedule, nil
+	case "every":
+		if job.Schedule.EveryMs <= 0 {
+			return nil, fmt.Errorf("everyMs must be positive")
+		}
+		anchor := job.CreatedAt
+		if job.Schedule.AnchorMs > 0 {
+			anchor = time.UnixMilli(job.Schedule.AnchorMs)
+		}
+		return everySchedule{every: time.Duration(job.Schedule.EveryMs) * time.Millisecond, anchor: anchor}, nil
+	case "at":
+		if job.Schedule.AtMs <= 0 {
+			return nil, fmt.Errorf("atMs is required")
+		}
+		return onceSchedule{at: time.UnixMilli(job.Schedule.AtMs)}, nil
+	default:
+		return nil, fmt.Errorf("unknown schedule kind: %s", job.Schedule.Kind)
+	}
+}
+
+// nextRun 计算任务在 now 之后的下一次运行时间，禁用或不会再运行时返回 nil
+func nextRun(job *Job, now time.Time) *time.Time {
+	if !job.Enabled {
+		return nil
+	}
+	schedule, err := jobSchedule(job)
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(now)
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
@@ -0,0 +1,139 @@
+package cron
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnceSchedule(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := onceSchedule{at: at}
+	if next := s.Next(at.Add(-time.Hour)); !next.Equal(at) {
+		t.Errorf("Next before = %v, want %v", next, at)
+	}
+	// 触发后不会在下一年重复
+	if next := s.Next(at); !next.IsZero() {
+		t.Errorf("Expected no further runs, got %v", next)
+	}
+}
+
+func TestEverySchedule(t *testing.T) {
+	anchor := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	s := everySchedule{every: 90 * time.Minute, anchor: anchor}
+
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{anchor.Add(-time.Hour), anchor},
+		{anchor, anchor.Add(90 * time.Minute)},
+		{anchor.Add(time.Hour), anchor.Add(90 * time.Minute)},
+		{anchor.Add(4 * time.Hour), anchor.Add(270 * time.Minute)},
+	}
+	for _, tt := range tests {
+		if got := s.Next(tt.now); !got.Equal(tt.want) {
+			t.Errorf("Next(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+
+	ms := everySchedule{every: 1500 * time.Millisecond, anchor: anchor}
+	if got := ms.Next(anchor.Add(time.Second)); !got.Equal(anchor.Add(1500 * time.Millisecond)) {
+		t.Errorf("Expected millisecond precision, got %v", got)
+	}
+}
+
+func TestJobSchedule_Cron(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC)
+
+	// 5 字段表达式，Asia/Shanghai (UTC+8) 的 9 点即 UTC 1 点
+	s, err := jobSchedule(&Job{Schedule: Schedule{Kind: "cron", Expr: "0 9 * * *", Tz: "Asia/Shanghai"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Next(now).UTC(); !got.Equal(time.Date(2026, 3, 1, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("Next = %v", got)
+	}
+
+	// 6 字段 (带秒) 仍然支持
+	if _, err := jobSchedule(&Job{Schedule: Schedule{Kind: "cron", Expr: "*/5 * * * * *"}}); err != nil {
+		t.Errorf("Expected seconds field to parse: %v", err)
+	}
+
+	for _, schedule := range []Schedule{
+		{Kind: "cron", Expr: "0 9 * * *", Tz: "Mars/Olympus"},
+		{Kind: "cron", Expr: "not a cron"},
+		{Kind: "every"},
+		{Kind: "at"},
+		{Kind: "weekly"},
+	} {
+		if _, err := jobSchedule(&Job{Schedule: schedule}); err == nil {
+			t.Errorf("Expected error for %+v", schedule)
+		}
+	}
+}
+
+func TestScheduler_OneShot(t *testing.T) {
+	for _, deleteAfterRun := range []bool{false, true} {
+		var mu sync.Mutex
+		count := 0
+		s := NewScheduler(t.TempDir(), func(job *Job) error {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			return nil
+		})
+		s.Start()
+
+		job := &Job{
+			Schedule:       Schedule{Kind: "at", AtMs: time.Now().Add(300 * time.Millisecond).UnixMilli()},
+			Enabled:        true,
+			DeleteAfterRun: deleteAfterRun,
+		}
+		if err := s.AddJob(job); err != nil {
+			t.Fatal(err)
+		}
+		if jobs := s.ListJobs(false); len(jobs) != 1 || jobs[0].NextRunAt == nil || jobs[0].NextRunAt.UnixMilli() != job.Schedule.AtMs {
+			t.Fatalf("Expected NextRunAt at the scheduled time, got %+v", jobs)
+		}
+
+		waitFor(t, func() bool { return len(s.ListJobs(false)) == 0 })
+		s.Stop()
+
+		mu.Lock()
+		if count != 1 {
+			t.Errorf("Expected exactly one run, got %d", count)
+		}
+		mu.Unlock()
+		_, exists := s.GetJob(job.ID)
+		if exists == deleteAfterRun {
+			t.Errorf("deleteAfterRun=%v: job exists = %v", deleteAfterRun, exists)
+		}
+		if runs, err := s.Runs(job.ID, 0); err != nil || len(runs) != 1 {
+			t.Errorf("Expected run history to be kept, got %v, %v", runs, err)
+		}
+	}
+
+	past := &Job{Schedule: Schedule{Kind: "at", AtMs: time.Now().Add(-time.Minute).UnixMilli()}, Enabled: true}
+	if err := NewScheduler(t.TempDir(), nil).AddJob(past); err == nil {
+		t.Error("Expected error for one-shot job in the past")
+	}
+}
+
+func TestScheduler_ListJobsNextRunAt(t *testing.T) {
+	s := NewScheduler(t.TempDir(), nil)
+	anchor := time.Now().Add(-10 * time.Minute).Truncate(time.Millisecond)
+	s.AddJob(&Job{Name: "interval", Schedule: Schedule{Kind: "every", EveryMs: 90 * 60000, AnchorMs: anchor.UnixMilli()}, Enabled: true})
+	s.AddJob(&Job{Name: "off", Schedule: Schedule{Kind: "every", EveryMs: 60000}})
+
+	jobs := s.ListJobs(true)
+	if len(jobs) != 2 || jobs[0].Name != "interval" {
+		t.Fatalf("Unexpected jobs: %+v", jobs)
+	}
+	if jobs[0].NextRunAt == nil || !jobs[0].NextRunAt.Equal(anchor.Add(90*time.Minute)) {
+		t.Errorf("NextRunAt = %v, want %v", jobs[0].NextRunAt, anchor.Add(90*time.Minute))
+	}
+	if jobs[1].NextRunAt != nil {
+		t.Errorf("Disabled job should have no NextRunAt, got %v", jobs[1].NextRunAt)
+	}
+}
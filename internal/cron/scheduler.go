@@ -1,10 +1,13 @@
 package cron
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -19,9 +22,27 @@ type Scheduler struct {
 	jobs      map[string]*Job
 	jobsMu    sync.RWMutex
 	stateFile string
-	handler   JobHandler
+	run       RunHandler
+	runs      *RunLog
+
+	running map[string]int  // 正在运行的次数
+	queued  map[string]bool // overlap=queue 时等待补跑的任务
 }
 
+// 并发策略：上一次运行尚未结束时如何处理新的触发
+const (
+	OverlapSkip  = "skip"  // 跳过并记录 (默认)
+	OverlapQueue = "queue" // 结束后再运行一次
+	OverlapAllow = "allow" // 允许并发运行
+)
+
+var (
+	// ErrJobRunning 任务正在运行 (overlap=skip)
+	ErrJobRunning = errors.New("job is already running")
+	// ErrJobTimeout 任务超过 timeoutMs
+	ErrJobTimeout = errors.New("job timed out")
+)
+
 // Job 定时任务
 type Job struct {
 	ID            string      `json:"id"`
@@ -34,6 +55,12 @@ type Job struct {
 	NextRunAt     *time.Time  `json:"nextRunAt,omitempty"`
 	LastRunAt     *time.Time  `json:"lastRunAt,omitempty"`
 	LastResult    string      `json:"lastResult,omitempty"`
+
+	Overlap   string `json:"overlap,omitempty"`   // "skip" | "queue" | "allow"
+	TimeoutMs int64  `json:"timeoutMs,omitempty"` // 运行时限，0 表示不限
+	CatchUp   bool   `json:"catchUp,omitempty"`   // 启动时补跑停机期间错过的运行 (一次)
+
+	DeleteAfterRun bool `json:"deleteAfterRun,omitempty"` // "at" 任务触发后删除 (默认禁用)
 	
 	entryID cron.EntryID `json:"-"`
 }
@@ -44,8 +71,8 @@ type Schedule struct {
 	AtMs     int64  `json:"atMs,omitempty"`     // for "at": unix timestamp ms
 	EveryMs  int64  `json:"everyMs,omitempty"`  // for "every": interval ms
 	Expr     string `json:"expr,omitempty"`     // for "cron": cron expression
-	Tz       string `json:"tz,omitempty"`       // timezone
-	AnchorMs int64  `json:"anchorMs,omitempty"` // for "every": anchor time
+	Tz       string `json:"tz,omitempty"`       // for "cron": IANA 时区，如 "Asia/Shanghai"
+	AnchorMs int64  `json:"anchorMs,omitempty"` // for "every": 间隔的起点，默认为创建时间
 }
 
 // Payload 任务内容
@@ -54,19 +81,36 @@ type Payload struct {
 	Text    string `json:"text,omitempty"`
 	Message string `json:"message,omitempty"`
 	Model   string `json:"model,omitempty"`
+
+	// 结果投递目标 (如 Telegram 私聊)，为空时使用 cron.delivery 配置
+	Channel string `json:"channel,omitempty"`
+	ChatID  string `json:"chatId,omitempty"`
+	Deliver *bool  `json:"deliver,omitempty"` // false 时不投递
 }
 
 // JobHandler 任务执行处理器
 type JobHandler func(job *Job) error
 
+// RunOutput 任务运行结果，写入运行记录
+type RunOutput struct {
+	Output     string
+	SessionKey string
+}
+
+// RunHandler 带上下文和结果的任务执行处理器，任务超时时 ctx 被取消
+type RunHandler func(ctx context.Context, job *Job) (RunOutput, error)
+
 // NewScheduler 创建调度器
 func NewScheduler(stateDir string, handler JobHandler) *Scheduler {
 	s := &Scheduler{
 		cron:      cron.New(cron.WithSeconds()),
 		jobs:      make(map[string]*Job),
 		stateFile: filepath.Join(stateDir, "cron-jobs.json"),
-		handler:   handler,
+		runs:      NewRunLog(stateDir),
+		running:   make(map[string]int),
+		queued:    make(map[string]bool),
 	}
+	s.SetHandler(handler)
 	
 	// 加载持久化的任务
 	s.loadJobs()
@@ -74,9 +118,26 @@ func NewScheduler(stateDir string, handler JobHandler) *Scheduler {
 	return s
 }
 
-// Start 启动调度器
+// SetHandler 设置任务执行处理器 (需在 Start 之前调用)
+func (s *Scheduler) SetHandler(handler JobHandler) {
+	if handler == nil {
+		s.run = nil
+		return
+	}
+	s.run = func(ctx context.Context, job *Job) (RunOutput, error) {
+		return RunOutput{}, handler(job)
+	}
+}
+
+// SetRunHandler 设置带上下文和结果的处理器 (需在 Start 之前调用)
+func (s *Scheduler) SetRunHandler(handler RunHandler) {
+	s.run = handler
+}
+
+// Start 启动调度器，并补跑停机期间错过的任务 (catchUp)
 func (s *Scheduler) Start() {
 	s.cron.Start()
+	s.catchUp(time.Now())
 	log.Info().Msg("Cron scheduler started")
 }
 
@@ -95,6 +156,9 @@ func (s *Scheduler) AddJob(job *Job) error {
 	if job.ID == "" {
 		job.ID = uuid.New().String()
 	}
+	if err := validateOverlap(job.Overlap); err != nil {
+		return err
+	}
 	job.CreatedAt = time.Now()
 
 	// 添加到 cron
@@ -120,27 +184,63 @@ func (s *Scheduler) UpdateJob(id string, patch map[string]interface{}) error {
 		return fmt.Errorf("job not found: %s", id)
 	}
 
-	// 先移除旧的调度
-	if job.entryID != 0 {
-		s.cron.Remove(job.entryID)
-	}
-
-	// 应用更新
+	// 在副本上应用并校验更新，失败时任务和调度保持不变
+	updated := *job
 	if name, ok := patch["name"].(string); ok {
-		job.Name = name
+		updated.Name = name
 	}
 	if enabled, ok := patch["enabled"].(bool); ok {
-		job.Enabled = enabled
+		updated.Enabled = enabled
 	}
-	// TODO: 更多字段...
-
-	// 重新调度
-	if job.Enabled {
-		if err := s.scheduleJob(job); err != nil {
+	if overlap, ok := patch["overlap"].(string); ok {
+		if err := validateOverlap(overlap); err != nil {
+			return err
+		}
+		updated.Overlap = overlap
+	}
+	if timeoutMs, ok := patch["timeoutMs"].(float64); ok {
+		updated.TimeoutMs = int64(timeoutMs)
+	}
+	if catchUp, ok := patch["catchUp"].(bool); ok {
+		updated.CatchUp = catchUp
+	}
+	if raw, ok := patch["schedule"]; ok {
+		data, _ := json.Marshal(raw)
+		var schedule Schedule
+		if err := json.Unmarshal(data, &schedule); err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		updated.Schedule = schedule
+	}
+	if raw, ok := patch["payload"]; ok {
+		data, _ := json.Marshal(raw)
+		var payload Payload
+		if err := json.Unmarshal(data, &payload); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+		updated.Payload = payload
+	}
+	if deleteAfterRun, ok := patch["deleteAfterRun"].(bool); ok {
+		updated.DeleteAfterRun = deleteAfterRun
+	}
+	var schedule cron.Schedule
+	if updated.Enabled {
+		var err error
+		if schedule, err = checkSchedule(&updated); err != nil {
 			return err
 		}
 	}
 
+	// 替换调度；已调度的闭包持有 job 指针，因此原地更新
+	if job.entryID != 0 {
+		s.cron.Remove(job.entryID)
+	}
+	updated.entryID = 0
+	*job = updated
+	if job.Enabled {
+		s.addEntry(job, schedule)
+	}
+
 	s.saveJobs()
 	return nil
 }
@@ -161,6 +261,9 @@ func (s *Scheduler) RemoveJob(id string) error {
 
 	delete(s.jobs, id)
 	s.saveJobs()
+	if err := s.runs.Remove(id); err != nil {
+		log.Warn().Err(err).Str("jobId", id).Msg("Failed to remove cron run log")
+	}
 	
 	return nil
 }
@@ -175,28 +278,38 @@ func (s *Scheduler) RunJob(id string) error {
 		return fmt.Errorf("job not found: %s", id)
 	}
 
-	return s.executeJob(job)
+	return s.executeJob(job, TriggerManual)
+}
+
+// Runs 返回任务最近的运行记录 (新的在前)；运行后已删除的一次性任务仍保留记录
+func (s *Scheduler) Runs(id string, limit int) ([]RunRecord, error) {
+	runs, err := s.runs.List(id, limit)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.GetJob(id); !ok && len(runs) == 0 {
+		return nil, fmt.Errorf("job not found: %s", id)
+	}
+	return runs, nil
 }
 
 // ListJobs 列出所有任务
 func (s *Scheduler) ListJobs(includeDisabled bool) []*Job {
-	s.jobsMu.RLock()
-	defer s.jobsMu.RUnlock()
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
 
+	now := time.Now()
 	var jobs []*Job
 	for _, job := range s.jobs {
 		if includeDisabled || job.Enabled {
 			// 更新 NextRunAt
-			if job.entryID != 0 {
-				entry := s.cron.Entry(job.entryID)
-				if !entry.Next.IsZero() {
-					next := entry.Next
-					job.NextRunAt = &next
-				}
-			}
+			job.NextRunAt = nextRun(job, now)
 			jobs = append(jobs, job)
 		}
 	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
 	return jobs
 }
 
@@ -210,61 +323,237 @@ func (s *Scheduler) GetJob(id string) (*Job, bool) {
 
 // scheduleJob 调度任务
 func (s *Scheduler) scheduleJob(job *Job) error {
-	var spec string
-	
-	switch job.Schedule.Kind {
-	case "cron":
-		spec = job.Schedule.Expr
-	case "every":
-		// 转换为 cron 表达式
-		intervalSec := job.Schedule.EveryMs / 1000
-		if intervalSec < 60 {
-			spec = fmt.Sprintf("@every %ds", intervalSec)
-		} else if intervalSec < 3600 {
-			spec = fmt.Sprintf("@every %dm", intervalSec/60)
-		} else {
-			spec = fmt.Sprintf("@every %dh", intervalSec/3600)
-		}
-	case "at":
-		// 一次性任务
-		targetTime := time.UnixMilli(job.Schedule.AtMs)
-		if targetTime.Before(time.Now()) {
-			return fmt.Errorf("scheduled time is in the past")
+	schedule, err := checkSchedule(job)
+	if err != nil {
+		return err
+	}
+	s.addEntry(job, schedule)
+	return nil
+}
+
+// checkSchedule 校验任务的调度配置并返回调度
+func checkSchedule(job *Job) (cron.Schedule, error) {
+	schedule, err := jobSchedule(job)
+	if err != nil {
+		return nil, err
+	}
+	if job.Schedule.Kind == "at" && time.UnixMilli(job.Schedule.AtMs).Before(time.Now()) {
+		return nil, fmt.Errorf("scheduled time is in the past")
+	}
+	return schedule, nil
+}
+
+// addEntry 将任务加入 cron
+func (s *Scheduler) addEntry(job *Job, schedule cron.Schedule) {
+	job.entryID = s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.executeJob(job, TriggerSchedule)
+	}))
+}
+
+// executeJob 按任务的并发策略执行
+func (s *Scheduler) executeJob(job *Job, trigger string) error {
+	s.jobsMu.Lock()
+	if s.running[job.ID] > 0 {
+		switch job.Overlap {
+		case OverlapAllow:
+		case OverlapQueue:
+			s.queued[job.ID] = true
+			s.jobsMu.Unlock()
+			log.Info().Str("jobId", job.ID).Msg("Cron job still running, queued next run")
+			return nil
+		default:
+			s.jobsMu.Unlock()
+			now := time.Now()
+			s.recordRun(RunRecord{
+				JobID:     job.ID,
+				Trigger:   trigger,
+				Status:    RunStatusSkipped,
+				StartedAt: now,
+				EndedAt:   now,
+				Error:     ErrJobRunning.Error(),
+			})
+			return ErrJobRunning
 		}
-		// 使用 cron 的 once 语义
-		spec = targetTime.Format("05 04 15 02 01 *") // second minute hour day month dow
+	}
+	s.running[job.ID]++
+	s.jobsMu.Unlock()
+
+	finished, err := s.runOnce(job, trigger)
+	select {
+	case <-finished:
+		s.release(job, finished)
 	default:
-		return fmt.Errorf("unknown schedule kind: %s", job.Schedule.Kind)
+		// 超时后处理器仍在运行：返回超时错误，处理器返回后再释放
+		go s.release(job, finished)
 	}
+	return err
+}
 
-	entryID, err := s.cron.AddFunc(spec, func() {
-		s.executeJob(job)
-	})
+// release 等待处理器返回后结束运行；期间排队的运行依次执行，完成后运行计数才减少
+func (s *Scheduler) release(job *Job, finished <-chan struct{}) {
+	for {
+		<-finished
+		s.jobsMu.Lock()
+		if !s.queued[job.ID] {
+			s.running[job.ID]--
+			s.jobsMu.Unlock()
+			return
+		}
+		delete(s.queued, job.ID)
+		s.jobsMu.Unlock()
+		finished, _ = s.runOnce(job, TriggerQueued)
+	}
+}
+
+// runOnce 运行一次任务并写入运行记录；finished 在处理器返回后关闭 (超时时可能晚于 runOnce 返回)
+func (s *Scheduler) runOnce(job *Job, trigger string) (finished <-chan struct{}, err error) {
+	log.Info().Str("jobId", job.ID).Str("name", job.Name).Str("trigger", trigger).Msg("Executing cron job")
+
+	record := RunRecord{JobID: job.ID, Trigger: trigger, StartedAt: time.Now()}
+	finished = closedChan
+	if s.run != nil {
+		var out RunOutput
+		out, finished, err = s.invoke(job)
+		record.Output, record.SessionKey = out.Output, out.SessionKey
+	}
+	record.EndedAt = time.Now()
+	record.DurationMs = record.EndedAt.Sub(record.StartedAt).Milliseconds()
+	switch {
+	case errors.Is(err, ErrJobTimeout):
+		record.Status = RunStatusTimeout
+	case err != nil:
+		record.Status = RunStatusError
+	default:
+		record.Status = RunStatusOK
+	}
 	if err != nil {
-		return err
+		record.Error = err.Error()
 	}
 
-	job.entryID = entryID
-	return nil
+	s.jobsMu.Lock()
+	startedAt := record.StartedAt
+	job.LastRunAt = &startedAt
+	if s.run != nil {
+		if err != nil {
+			job.LastResult = "error: " + err.Error()
+		} else {
+			job.LastResult = "success"
+		}
+	}
+	if job.Schedule.Kind == "at" && trigger != TriggerManual {
+		s.finishOneShot(job)
+	}
+	s.saveJobs()
+	s.jobsMu.Unlock()
+
+	s.recordRun(record)
+	return finished, err
 }
 
-// executeJob 执行任务
-func (s *Scheduler) executeJob(job *Job) error {
-	log.Info().Str("jobId", job.ID).Str("name", job.Name).Msg("Executing cron job")
-	
-	now := time.Now()
-	job.LastRunAt = &now
+// finishOneShot "at" 任务触发后禁用，或按 deleteAfterRun 删除 (需持有 jobsMu)
+func (s *Scheduler) finishOneShot(job *Job) {
+	if job.entryID != 0 {
+		s.cron.Remove(job.entryID)
+		job.entryID = 0
+	}
+	job.Enabled = false
+	job.NextRunAt = nil
+	if job.DeleteAfterRun {
+		delete(s.jobs, job.ID)
+		log.Info().Str("jobId", job.ID).Msg("One-shot cron job deleted after run")
+	}
+}
 
-	if s.handler != nil {
-		if err := s.handler(job); err != nil {
-			job.LastResult = "error: " + err.Error()
-			return err
+// closedChan 已关闭的 channel，表示处理器已经返回
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// invoke 调用处理器；超过 timeoutMs 时取消 ctx 并立即返回 ErrJobTimeout，finished 在处理器真正返回后关闭
+func (s *Scheduler) invoke(job *Job) (RunOutput, <-chan struct{}, error) {
+	if job.TimeoutMs <= 0 {
+		out, err := s.run(context.Background(), job)
+		return out, closedChan, err
+	}
+
+	timeout := time.Duration(job.TimeoutMs) * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+	type result struct {
+		out RunOutput
+		err error
+	}
+	done := make(chan result, 1)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		defer cancel()
+		out, err := s.run(ctx, job)
+		done <- result{out, err}
+	}()
+
+	select {
+	case r := <-done:
+		<-finished
+		return r.out, finished, r.err
+	case <-ctx.Done():
+		return RunOutput{}, finished, fmt.Errorf("%w after %s", ErrJobTimeout, timeout)
+	}
+}
+
+func (s *Scheduler) recordRun(record RunRecord) {
+	if err := s.runs.Append(record); err != nil {
+		log.Warn().Err(err).Str("jobId", record.JobID).Msg("Failed to record cron run")
+	}
+}
+
+// catchUp 对启用了 catchUp 且错过运行的任务补跑一次
+func (s *Scheduler) catchUp(now time.Time) {
+	s.jobsMu.RLock()
+	var missed []*Job
+	for _, job := range s.jobs {
+		if job.Enabled && job.CatchUp && missedRun(job, now) {
+			missed = append(missed, job)
 		}
-		job.LastResult = "success"
 	}
+	s.jobsMu.RUnlock()
 
-	s.saveJobs()
-	return nil
+	for _, job := range missed {
+		log.Info().Str("jobId", job.ID).Str("name", job.Name).Msg("Catching up missed cron job")
+		go s.executeJob(job, TriggerCatchUp)
+	}
+}
+
+// missedRun 上次运行 (或创建) 之后是否有应运行而未运行的时间点
+func missedRun(job *Job, now time.Time) bool {
+	if job.Schedule.Kind == "at" {
+		return job.LastRunAt == nil && job.Schedule.AtMs > 0 && time.UnixMilli(job.Schedule.AtMs).Before(now)
+	}
+
+	last := job.CreatedAt
+	if job.LastRunAt != nil {
+		last = *job.LastRunAt
+	}
+	if last.IsZero() {
+		return false
+	}
+	schedule, err := jobSchedule(job)
+	if err != nil {
+		return false
+	}
+	next := schedule.Next(last)
+	return !next.IsZero() && next.Before(now)
+}
+
+func validateOverlap(overlap string) error {
+	switch overlap {
+	case "", OverlapSkip, OverlapQueue, OverlapAllow:
+		return nil
+	default:
+		return fmt.Errorf("invalid overlap policy %q (want skip, queue or allow)", overlap)
+	}
 }
 
 // loadJobs 加载持久化的任务
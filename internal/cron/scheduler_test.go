@@ -163,6 +163,49 @@ func TestScheduler_UpdateJob(t *testing.T) {
 	}
 }
 
+func TestScheduler_UpdateJobInvalidPatch(t *testing.T) {
+	stateDir := t.TempDir()
+	s := NewScheduler(stateDir, nil)
+	job := &Job{
+		Name:     "Original Name",
+		Schedule: Schedule{Kind: "every", EveryMs: 60000},
+		Payload:  Payload{Kind: "systemEvent", Text: "Test"},
+		Enabled:  true,
+	}
+	s.AddJob(job)
+	entryID := job.entryID
+
+	for _, patch := range []map[string]interface{}{
+		{"name": "Broken", "schedule": map[string]interface{}{"kind": "cron", "expr": "not a cron"}},
+		{"name": "Broken", "overlap": "parallel"},
+		{"name": "Broken", "schedule": map[string]interface{}{"kind": "at", "atMs": 1000}},
+	} {
+		if err := s.UpdateJob(job.ID, patch); err == nil {
+			t.Errorf("Expected error for %v", patch)
+		}
+	}
+
+	// 任务和调度保持不变
+	if job.Name != "Original Name" || job.Schedule.Kind != "every" || job.Overlap != "" {
+		t.Errorf("Job modified by failed update: %+v", job)
+	}
+	if job.entryID != entryID || s.cron.Entry(entryID).ID == 0 {
+		t.Error("Job should still be scheduled")
+	}
+	reloaded := NewScheduler(stateDir, nil)
+	if saved, ok := reloaded.GetJob(job.ID); !ok || saved.Name != "Original Name" {
+		t.Errorf("Saved job modified: %+v", saved)
+	}
+
+	// 成功的更新替换调度
+	if err := s.UpdateJob(job.ID, map[string]interface{}{"schedule": map[string]interface{}{"kind": "every", "everyMs": 120000}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.cron.Entries()) != 1 || s.cron.Entry(entryID).ID != 0 {
+		t.Errorf("Expected the old entry to be replaced, got %d entries", len(s.cron.Entries()))
+	}
+}
+
 func TestScheduler_UpdateJobNotFound(t *testing.T) {
 	tmpDir := t.TempDir()
 	s := NewScheduler(tmpDir, nil)
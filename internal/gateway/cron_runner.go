@@ -0,0 +1,154 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/z8n24/openclaw-go/internal/channels"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/cron"
+	"github.com/z8n24/openclaw-go/internal/sessions"
+)
+
+// defaultCronTimeout agentTurn 任务的默认运行时限
+const defaultCronTimeout = 10 * time.Minute
+
+// CronRunner 执行定时任务：agentTurn 在新的隔离会话中运行并投递结果 (运行结束后删除该会话)，systemEvent 注入主会话
+type CronRunner struct {
+	sessions *sessions.EnhancedManager
+	channels *channels.Manager
+	runAgent sessions.AgentRunner
+	target   config.CronDeliveryConfig
+	timeout  time.Duration
+}
+
+// NewCronRunner 创建定时任务执行器；channels 为空时不投递结果
+func NewCronRunner(sessionMgr *sessions.EnhancedManager, channelMgr *channels.Manager, runAgent sessions.AgentRunner) *CronRunner {
+	return &CronRunner{
+		sessions: sessionMgr,
+		channels: channelMgr,
+		runAgent: runAgent,
+		timeout:  defaultCronTimeout,
+	}
+}
+
+// SetDefaultTarget 设置任务未指定 channel/chatId 时的投递目标
+func (r *CronRunner) SetDefaultTarget(target config.CronDeliveryConfig) {
+	r.target = target
+}
+
+// SetTimeout 设置 agentTurn 任务的默认运行时限 (任务未设置 timeoutMs 时使用)
+func (r *CronRunner) SetTimeout(d time.Duration) {
+	r.timeout = d
+}
+
+// Handle 执行任务 (cron.JobHandler)
+func (r *CronRunner) Handle(job *cron.Job) error {
+	_, err := r.Run(context.Background(), job)
+	return err
+}
+
+// Run 执行任务并返回输出和会话 (cron.RunHandler)
+func (r *CronRunner) Run(ctx context.Context, job *cron.Job) (cron.RunOutput, error) {
+	switch job.Payload.Kind {
+	case "systemEvent":
+		return r.systemEvent(job)
+	case "agentTurn":
+		return r.agentTurn(ctx, job)
+	default:
+		return cron.RunOutput{}, fmt.Errorf("unknown payload kind: %q", job.Payload.Kind)
+	}
+}
+
+// systemEvent 将文本加入主会话的系统事件，随下一轮对话注入
+func (r *CronRunner) systemEvent(job *cron.Job) (cron.RunOutput, error) {
+	text := job.Payload.Text
+	if text == "" {
+		text = job.Payload.Message
+	}
+	if text == "" {
+		return cron.RunOutput{}, fmt.Errorf("systemEvent text is empty")
+	}
+	main, ok := r.sessions.Get("main")
+	if !ok {
+		return cron.RunOutput{}, fmt.Errorf("main session not found")
+	}
+	main.EnqueueSystemEvent(text)
+	log.Info().Str("jobId", job.ID).Msg("Cron system event queued for main session")
+	return cron.RunOutput{Output: text, SessionKey: main.Key}, nil
+}
+
+// agentTurn 在新的隔离会话中运行一轮对话，并把结果 (或错误) 投递到目标聊天
+func (r *CronRunner) agentTurn(ctx context.Context, job *cron.Job) (cron.RunOutput, error) {
+	message := job.Payload.Message
+	if message == "" {
+		message = job.Payload.Text
+	}
+	if message == "" {
+		return cron.RunOutput{}, fmt.Errorf("agentTurn message is empty")
+	}
+	if r.runAgent == nil {
+		return cron.RunOutput{}, fmt.Errorf("no agent configured for cron jobs")
+	}
+
+	session := r.sessions.CreateIsolatedSession("main", "Cron: "+cronJobName(job), job.Payload.Model)
+	out := cron.RunOutput{SessionKey: session.Key}
+	// 输出已记录在运行历史中，结束后删除隔离会话，避免会话随运行次数无限增长
+	defer func() {
+		if err := r.sessions.Delete(session.Key); err != nil {
+			log.Warn().Err(err).Str("jobId", job.ID).Str("session", session.Key).Msg("Failed to delete cron session")
+		}
+	}()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	response, err := r.runAgent(ctx, session, message)
+	if err != nil {
+		if derr := r.deliver(job, fmt.Sprintf("Cron job %q failed: %v", cronJobName(job), err)); derr != nil {
+			log.Warn().Err(derr).Str("jobId", job.ID).Msg("Failed to deliver cron error")
+		}
+		return out, err
+	}
+	out.Output = response
+	log.Info().Str("jobId", job.ID).Str("session", session.Key).Msg("Cron agent turn finished")
+
+	if err := r.deliver(job, response); err != nil {
+		return out, fmt.Errorf("deliver: %w", err)
+	}
+	return out, nil
+}
+
+// deliver 发送到任务的投递目标；没有目标或 deliver=false 时跳过
+func (r *CronRunner) deliver(job *cron.Job, text string) error {
+	if text == "" || (job.Payload.Deliver != nil && !*job.Payload.Deliver) {
+		return nil
+	}
+	channel, chatID := job.Payload.Channel, job.Payload.ChatID
+	if channel == "" && chatID == "" {
+		channel, chatID = r.target.Channel, r.target.ChatID
+	}
+	if channel == "" || chatID == "" {
+		return nil
+	}
+	if r.channels == nil {
+		return fmt.Errorf("channels are not available")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, err := r.channels.SendToChat(ctx, channel, chatID, text)
+	return err
+}
+
+func cronJobName(job *cron.Job) string {
+	if job.Name != "" {
+		return job.Name
+	}
+	return job.ID
+}
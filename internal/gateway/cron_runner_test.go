@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/channels"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/cron"
+	"github.com/z8n24/openclaw-go/internal/sessions"
+)
+
+// recordingChannel 记录发送的消息
+type recordingChannel struct {
+	id   string
+	mu   sync.Mutex
+	sent []*channels.OutboundMessage
+}
+
+func (c *recordingChannel) ID() string                      { return c.id }
+func (c *recordingChannel) Label() string                   { return c.id }
+func (c *recordingChannel) Start(ctx context.Context) error { return nil }
+func (c *recordingChannel) Stop() error                     { return nil }
+func (c *recordingChannel) Status() channels.ChannelStatus {
+	return channels.ChannelStatus{Connected: true}
+}
+func (c *recordingChannel) SetMessageHandler(h channels.MessageHandler) {}
+
+func (c *recordingChannel) Send(ctx context.Context, msg *channels.OutboundMessage) (*channels.SendResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, msg)
+	return &channels.SendResult{MessageID: "1"}, nil
+}
+
+func newTestCronRunner(t *testing.T, runAgent sessions.AgentRunner) (*CronRunner, *sessions.EnhancedManager, *recordingChannel) {
+	t.Helper()
+	sessionMgr := sessions.NewEnhancedManager(sessions.ManagerConfig{DataDir: t.TempDir()})
+	channelMgr := channels.NewManager()
+	ch := &recordingChannel{id: "telegram"}
+	channelMgr.Register(ch)
+	return NewCronRunner(sessionMgr, channelMgr, runAgent), sessionMgr, ch
+}
+
+func TestCronRunner_AgentTurn(t *testing.T) {
+	var session *sessions.EnhancedSession
+	runner, _, ch := newTestCronRunner(t, func(ctx context.Context, s *sessions.EnhancedSession, message string) (string, error) {
+		session = s
+		return "report: " + message, nil
+	})
+	runner.SetDefaultTarget(config.CronDeliveryConfig{Channel: "telegram", ChatID: "42"})
+
+	job := &cron.Job{ID: "j1", Name: "daily", Payload: cron.Payload{Kind: "agentTurn", Message: "summarize", Model: "gpt-4o"}}
+	if err := runner.Handle(job); err != nil {
+		t.Fatal(err)
+	}
+	if session == nil || session.Kind != sessions.SessionKindIsolated || session.Model != "gpt-4o" || session.ParentKey != "main" {
+		t.Errorf("Unexpected session: %+v", session)
+	}
+	if len(ch.sent) != 1 || ch.sent[0].ChatID != "42" || ch.sent[0].Text != "report: summarize" {
+		t.Fatalf("Unexpected deliveries: %+v", ch.sent)
+	}
+
+	out, _ := runner.Run(context.Background(), &cron.Job{ID: "j1", Payload: cron.Payload{Kind: "agentTurn", Message: "x", Deliver: new(bool)}})
+	if out.Output != "report: x" || out.SessionKey != session.Key {
+		t.Errorf("Unexpected run output: %+v", out)
+	}
+
+	// 每次运行都使用新的隔离会话
+	first := session
+	runner.Handle(job)
+	if session == first {
+		t.Error("Expected a fresh isolated session per run")
+	}
+
+	// deliver=false 时不投递
+	off := false
+	job.Payload.Deliver = &off
+	runner.Handle(job)
+	if len(ch.sent) != 2 {
+		t.Errorf("Expected no delivery, got %d messages", len(ch.sent))
+	}
+}
+
+func TestCronRunner_AgentTurnFailure(t *testing.T) {
+	runner, _, ch := newTestCronRunner(t, func(ctx context.Context, s *sessions.EnhancedSession, message string) (string, error) {
+		return "", errors.New("rate limited")
+	})
+
+	job := &cron.Job{ID: "j1", Name: "daily", Payload: cron.Payload{Kind: "agentTurn", Message: "hi", Channel: "telegram", ChatID: "7"}}
+	if err := runner.Handle(job); err == nil || !strings.Contains(err.Error(), "rate limited") {
+		t.Errorf("Expected agent error, got %v", err)
+	}
+	if len(ch.sent) != 1 || !strings.Contains(ch.sent[0].Text, "failed") || ch.sent[0].ChatID != "7" {
+		t.Errorf("Expected failure notice, got %+v", ch.sent)
+	}
+
+	job.Payload.Channel = "discord"
+	if err := runner.Handle(job); err == nil {
+		t.Error("Expected error for unknown channel")
+	}
+}
+
+func TestCronRunner_AgentTurnDeletesSession(t *testing.T) {
+	var keys []string
+	runner, sessionMgr, _ := newTestCronRunner(t, func(ctx context.Context, s *sessions.EnhancedSession, message string) (string, error) {
+		keys = append(keys, s.Key)
+		if len(keys) == 2 {
+			return "", errors.New("boom")
+		}
+		return "ok", nil
+	})
+
+	job := &cron.Job{ID: "j1", Payload: cron.Payload{Kind: "agentTurn", Message: "ping"}}
+	for i := 0; i < 5; i++ {
+		runner.Run(context.Background(), job)
+	}
+	if len(keys) != 5 {
+		t.Fatalf("Expected 5 runs, got %d", len(keys))
+	}
+	// 成功和失败的运行都不留下隔离会话
+	if isolated := sessionMgr.List(sessions.SessionFilter{Kinds: []sessions.SessionKind{sessions.SessionKindIsolated}}); len(isolated) != 0 {
+		t.Errorf("Expected isolated sessions to be deleted, %d left", len(isolated))
+	}
+	for _, key := range keys {
+		if _, ok := sessionMgr.Get(key); ok {
+			t.Errorf("Session %s still exists", key)
+		}
+	}
+}
+
+func TestCronRunner_SystemEvent(t *testing.T) {
+	runner, sessionMgr, ch := newTestCronRunner(t, nil)
+
+	job := &cron.Job{ID: "j2", Payload: cron.Payload{Kind: "systemEvent", Text: "Check the inbox"}}
+	if err := runner.Handle(job); err != nil {
+		t.Fatal(err)
+	}
+	main, _ := sessionMgr.Get("main")
+	if events := main.DrainSystemEvents(); len(events) != 1 || events[0] != "Check the inbox" {
+		t.Errorf("Unexpected system events: %v", events)
+	}
+	if len(ch.sent) != 0 {
+		t.Errorf("systemEvent should not deliver, got %+v", ch.sent)
+	}
+
+	if err := runner.Handle(&cron.Job{Payload: cron.Payload{Kind: "agentTurn", Message: "hi"}}); err == nil {
+		t.Error("Expected error without agent")
+	}
+	if err := runner.Handle(&cron.Job{Payload: cron.Payload{Kind: "webhook"}}); err == nil {
+		t.Error("Expected error for unknown kind")
+	}
+}
@@ -1,19 +1,28 @@
 package gateway
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/z8n24/openclaw-go/internal/checkpoints"
+	"github.com/z8n24/openclaw-go/internal/config"
 	"github.com/z8n24/openclaw-go/internal/cron"
 	"github.com/z8n24/openclaw-go/internal/gateway/protocol"
+	"github.com/z8n24/openclaw-go/internal/sessions"
 	"github.com/z8n24/openclaw-go/internal/skills"
 )
 
 // Dependencies 用于注入依赖
 type Dependencies struct {
-	CronScheduler *cron.Scheduler
-	SkillLoader   *skills.Loader
+	CronScheduler   *cron.Scheduler
+	SkillLoader     *skills.Loader
+	Checkpoints     *checkpoints.Store
+	CheckpointPaths func(session string) checkpoints.PathCheck // 恢复检查点时检查写入目标，为空时只检查父目录
+	Heartbeat       *HeartbeatRunner
+	Config          *ConfigReconciler // 为空时 config.apply 只通知控制台
+	Sessions        *sessions.EnhancedManager
 	// SessionManager 等其他依赖可以后续添加
 }
 
@@ -56,6 +65,7 @@ func (s *Server) registerDefaultHandlers() {
 	s.RegisterHandler("cron.update", s.handleCronUpdate)
 	s.RegisterHandler("cron.remove", s.handleCronRemove)
 	s.RegisterHandler("cron.run", s.handleCronRun)
+	s.RegisterHandler("cron.runs", s.handleCronRuns)
 
 	// Chat 相关
 	s.RegisterHandler("chat.send", s.handleChatSend)
@@ -69,6 +79,11 @@ func (s *Server) registerDefaultHandlers() {
 	s.RegisterHandler("skills.install", s.handleSkillsInstall)
 	s.RegisterHandler("skills.update", s.handleSkillsUpdate)
 
+	// Checkpoints 相关
+	s.RegisterHandler("checkpoints.list", s.handleCheckpointsList)
+	s.RegisterHandler("checkpoints.diff", s.handleCheckpointsDiff)
+	s.RegisterHandler("checkpoints.restore", s.handleCheckpointsRestore)
+
 	// Logs 相关
 	s.RegisterHandler("logs.tail", s.handleLogsTail)
 
@@ -86,7 +101,7 @@ func (s *Server) registerDefaultHandlers() {
 // ============================================================================
 
 func (s *Server) handleConfigGet(ctx *MethodContext) error {
-	ctx.Respond(true, s.cfg)
+	ctx.Respond(true, s.config())
 	return nil
 }
 
@@ -135,12 +150,60 @@ func (s *Server) handleConfigSchema(ctx *MethodContext) error {
 }
 
 func (s *Server) handleConfigApply(ctx *MethodContext) error {
-	// 重新加载配置
-	ctx.Respond(true, map[string]interface{}{"applied": true})
-	s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "config"})
+	if s.deps.Config == nil {
+		ctx.Respond(true, map[string]interface{}{"applied": true})
+		s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "config"})
+		return nil
+	}
+
+	// 重新加载配置文件，热应用变化的部分
+	result, err := s.ReloadConfig()
+	if err != nil {
+		ctx.RespondError(protocol.ErrorCodes.InvalidRequest, "Failed to load config: "+err.Error())
+		return nil
+	}
+	ctx.Respond(true, map[string]interface{}{
+		"applied":         true,
+		"changed":         result.Changed,
+		"hotApplied":      result.HotApplied,
+		"restartRequired": result.RestartRequired,
+		"errors":          result.Errors,
+	})
 	return nil
 }
 
+// ReloadConfig 重新读取配置文件并热应用，需要设置 Dependencies.Config
+func (s *Server) ReloadConfig() (*ReloadResult, error) {
+	next, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return s.ApplyConfig(next), nil
+}
+
+// ApplyConfig 热应用新配置并通知控制台，需要设置 Dependencies.Config
+func (s *Server) ApplyConfig(next *config.Config) *ReloadResult {
+	result := s.deps.Config.Apply(next)
+
+	s.cfgMu.Lock()
+	s.cfg = s.deps.Config.Current()
+	s.cfgMu.Unlock()
+
+	s.BroadcastEvent("stateChange", map[string]interface{}{
+		"kind":            "config",
+		"hotApplied":      result.HotApplied,
+		"restartRequired": result.RestartRequired,
+	})
+	return result
+}
+
+// config 返回当前配置
+func (s *Server) config() *config.Config {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg
+}
+
 // ============================================================================
 // Sessions handlers
 // ============================================================================
@@ -206,7 +269,15 @@ func (s *Server) handleSessionsReset(ctx *MethodContext) error {
 		return nil
 	}
 
-	// TODO: 实际重置会话
+	if s.deps.Sessions == nil {
+		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Sessions not available")
+		return nil
+	}
+	if err := s.deps.Sessions.Reset(params.Key); err != nil {
+		ctx.RespondError(protocol.ErrorCodes.NotFound, err.Error())
+		return nil
+	}
+
 	ctx.Respond(true, map[string]interface{}{"reset": true})
 	s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "sessions"})
 	return nil
@@ -224,7 +295,15 @@ func (s *Server) handleSessionsDelete(ctx *MethodContext) error {
 		return nil
 	}
 
-	// TODO: 实际删除会话
+	if s.deps.Sessions == nil {
+		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Sessions not available")
+		return nil
+	}
+	if err := s.deps.Sessions.Delete(params.Key); err != nil {
+		ctx.RespondError(protocol.ErrorCodes.NotFound, err.Error())
+		return nil
+	}
+
 	ctx.Respond(true, map[string]interface{}{"deleted": true})
 	s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "sessions"})
 	return nil
@@ -260,8 +339,9 @@ type ChannelStatus struct {
 
 func (s *Server) handleChannelsStatus(ctx *MethodContext) error {
 	channels := []ChannelStatus{}
+	cfg := s.config()
 
-	if s.cfg.Channels.Telegram != nil && s.cfg.Channels.Telegram.Enabled {
+	if cfg.Channels.Telegram != nil && cfg.Channels.Telegram.Enabled {
 		channels = append(channels, ChannelStatus{
 			ID:     "telegram",
 			Label:  "Telegram",
@@ -269,7 +349,7 @@ func (s *Server) handleChannelsStatus(ctx *MethodContext) error {
 		})
 	}
 
-	if s.cfg.Channels.WhatsApp != nil && s.cfg.Channels.WhatsApp.Enabled {
+	if cfg.Channels.WhatsApp != nil && cfg.Channels.WhatsApp.Enabled {
 		channels = append(channels, ChannelStatus{
 			ID:     "whatsapp",
 			Label:  "WhatsApp",
@@ -277,7 +357,7 @@ func (s *Server) handleChannelsStatus(ctx *MethodContext) error {
 		})
 	}
 
-	if s.cfg.Channels.Discord != nil && s.cfg.Channels.Discord.Enabled {
+	if cfg.Channels.Discord != nil && cfg.Channels.Discord.Enabled {
 		channels = append(channels, ChannelStatus{
 			ID:     "discord",
 			Label:  "Discord",
@@ -285,7 +365,7 @@ func (s *Server) handleChannelsStatus(ctx *MethodContext) error {
 		})
 	}
 
-	if s.cfg.Channels.Signal != nil && s.cfg.Channels.Signal.Enabled {
+	if cfg.Channels.Signal != nil && cfg.Channels.Signal.Enabled {
 		channels = append(channels, ChannelStatus{
 			ID:     "signal",
 			Label:  "Signal",
@@ -327,12 +407,13 @@ type AgentSummary struct {
 }
 
 func (s *Server) handleAgentsList(ctx *MethodContext) error {
+	cfg := s.config()
 	agents := []AgentSummary{
 		{
 			ID:        "main",
 			Label:     "Main Agent",
-			Model:     s.cfg.Agent.DefaultModel,
-			Workspace: s.cfg.Agent.Workspace,
+			Model:     cfg.Agent.DefaultModel,
+			Workspace: cfg.Agent.Workspace,
 			Status:    "idle",
 		},
 	}
@@ -341,17 +422,36 @@ func (s *Server) handleAgentsList(ctx *MethodContext) error {
 }
 
 func (s *Server) handleAgentIdentity(ctx *MethodContext) error {
+	cfg := s.config()
 	ctx.Respond(true, map[string]interface{}{
 		"id":        "main",
 		"name":      "OpenClaw Agent",
-		"model":     s.cfg.Agent.DefaultModel,
-		"workspace": s.cfg.Agent.Workspace,
+		"model":     cfg.Agent.DefaultModel,
+		"workspace": cfg.Agent.Workspace,
 	})
 	return nil
 }
 
+type WakeParams struct {
+	Text string `json:"text,omitempty"` // 注入主会话的系统事件
+}
+
 func (s *Server) handleWake(ctx *MethodContext) error {
-	// 唤醒 agent (用于 heartbeat)
+	// 唤醒 agent：立即运行一次心跳
+	var params WakeParams
+	if len(ctx.Request.Params) > 0 {
+		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+			ctx.RespondError(protocol.ErrorCodes.InvalidParams, "Invalid params")
+			return nil
+		}
+	}
+
+	if s.deps.Heartbeat == nil {
+		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Heartbeat not enabled")
+		return nil
+	}
+
+	s.deps.Heartbeat.Wake(params.Text)
 	ctx.Respond(true, map[string]interface{}{"woken": true})
 	return nil
 }
@@ -450,6 +550,11 @@ type CronAddParams struct {
 	Payload       cron.Payload `json:"payload"`
 	SessionTarget string      `json:"sessionTarget,omitempty"`
 	Enabled       *bool       `json:"enabled,omitempty"`
+
+	Overlap        string `json:"overlap,omitempty"`
+	TimeoutMs      int64  `json:"timeoutMs,omitempty"`
+	CatchUp        bool   `json:"catchUp,omitempty"`
+	DeleteAfterRun bool   `json:"deleteAfterRun,omitempty"`
 }
 
 func (s *Server) handleCronAdd(ctx *MethodContext) error {
@@ -475,6 +580,11 @@ func (s *Server) handleCronAdd(ctx *MethodContext) error {
 		Payload:       params.Payload,
 		SessionTarget: params.SessionTarget,
 		Enabled:       enabled,
+
+		Overlap:        params.Overlap,
+		TimeoutMs:      params.TimeoutMs,
+		CatchUp:        params.CatchUp,
+		DeleteAfterRun: params.DeleteAfterRun,
 	}
 
 	if err := s.deps.CronScheduler.AddJob(job); err != nil {
@@ -561,6 +671,39 @@ func (s *Server) handleCronRun(ctx *MethodContext) error {
 	return nil
 }
 
+type CronRunsParams struct {
+	ID    string `json:"id"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+func (s *Server) handleCronRuns(ctx *MethodContext) error {
+	var params CronRunsParams
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+		ctx.RespondError(protocol.ErrorCodes.InvalidParams, "Invalid params")
+		return nil
+	}
+
+	if s.deps.CronScheduler == nil {
+		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Cron scheduler not available")
+		return nil
+	}
+
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	runs, err := s.deps.CronScheduler.Runs(params.ID, params.Limit)
+	if err != nil {
+		ctx.RespondError(protocol.ErrorCodes.NotFound, err.Error())
+		return nil
+	}
+	if runs == nil {
+		runs = []cron.RunRecord{}
+	}
+
+	ctx.Respond(true, map[string]interface{}{"runs": runs})
+	return nil
+}
+
 // ============================================================================
 // Chat handlers
 // ============================================================================
@@ -674,6 +817,7 @@ func (s *Server) handleSkillsStatus(ctx *MethodContext) error {
 
 	status := s.deps.SkillLoader.Status()
 	skillList := s.deps.SkillLoader.List()
+	lock := s.deps.SkillLoader.Lock()
 
 	skills := make([]map[string]interface{}, len(skillList))
 	for i, sk := range skillList {
@@ -685,30 +829,50 @@ func (s *Server) handleSkillsStatus(ctx *MethodContext) error {
 			"enabled":     sk.Enabled,
 			"source":      sk.Source,
 			"toolCount":   len(sk.Tools),
+			"requires":    sk.Requires,
+			"install":     sk.Install,
+		}
+		if entry, ok := lock.Skills[sk.ID]; ok {
+			skills[i]["lock"] = entry
+		}
+		if eligibility, ok := s.deps.SkillLoader.Eligibility(sk.ID); ok {
+			skills[i]["eligible"] = eligibility.Eligible
+			skills[i]["eligibility"] = eligibility
+			skills[i]["missing"] = eligibility.Missing()
 		}
 	}
 
 	ctx.Respond(true, map[string]interface{}{
-		"totalSkills":   status.TotalSkills,
-		"enabledSkills": status.EnabledSkills,
-		"totalTools":    status.TotalTools,
-		"skills":        skills,
+		"totalSkills":    status.TotalSkills,
+		"enabledSkills":  status.EnabledSkills,
+		"eligibleSkills": status.EligibleSkills,
+		"totalTools":     status.TotalTools,
+		"skills":         skills,
 	})
 	return nil
 }
 
 func (s *Server) handleSkillsBins(ctx *MethodContext) error {
 	bins := make(map[string]string)
+	missing := make(map[string][]string)
 	if s.deps.SkillLoader != nil {
 		bins = s.deps.SkillLoader.GetBinaries()
+		for _, sk := range s.deps.SkillLoader.List() {
+			if eligibility, ok := s.deps.SkillLoader.Eligibility(sk.ID); ok && len(eligibility.MissingBins) > 0 {
+				missing[sk.ID] = eligibility.MissingBins
+			}
+		}
 	}
 
-	ctx.Respond(true, map[string]interface{}{"bins": bins})
+	ctx.Respond(true, map[string]interface{}{"bins": bins, "missing": missing})
 	return nil
 }
 
+// SkillsInstallParams source 安装技能；deps=true 时再执行其依赖安装 (只传 id 时只安装依赖)
 type SkillsInstallParams struct {
-	Source string `json:"source"`
+	Source string `json:"source,omitempty"`
+	ID     string `json:"id,omitempty"`
+	Deps   bool   `json:"deps,omitempty"`
 }
 
 func (s *Server) handleSkillsInstall(ctx *MethodContext) error {
@@ -717,27 +881,58 @@ func (s *Server) handleSkillsInstall(ctx *MethodContext) error {
 		ctx.RespondError(protocol.ErrorCodes.InvalidParams, "Invalid params")
 		return nil
 	}
+	if params.Source == "" && params.ID == "" {
+		ctx.RespondError(protocol.ErrorCodes.InvalidParams, "source or id is required")
+		return nil
+	}
 
 	if s.deps.SkillLoader == nil {
 		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Skill loader not available")
 		return nil
 	}
 
-	skill, err := s.deps.SkillLoader.Install(params.Source)
-	if err != nil {
-		ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
-		return nil
+	id := params.ID
+	result := map[string]interface{}{}
+	if params.Source != "" {
+		skill, err := s.deps.SkillLoader.Install(params.Source)
+		if err != nil {
+			ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
+			return nil
+		}
+		id = skill.ID
+		result["id"] = skill.ID
+		result["name"] = skill.Name
+	} else {
+		result["id"] = id
 	}
 
-	ctx.Respond(true, map[string]interface{}{
-		"id":   skill.ID,
-		"name": skill.Name,
-	})
+	if params.Deps || params.Source == "" {
+		installCtx, cancel := context.WithTimeout(s.ctx, 10*time.Minute)
+		defer cancel()
+		steps, err := s.deps.SkillLoader.InstallDeps(installCtx, id, func(p skills.InstallProgress) {
+			s.BroadcastEvent("skills.install.progress", p)
+		})
+		result["steps"] = steps
+		if err != nil {
+			ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
+			return nil
+		}
+	}
+
+	if eligibility, ok := s.deps.SkillLoader.Eligibility(id); ok {
+		result["eligibility"] = eligibility
+	}
+	s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "skills"})
+	ctx.Respond(true, result)
 	return nil
 }
 
+// SkillsUpdateParams 不带 apply 时只下载并返回变更预览；apply=true 时应用 (有预览则使用预览的版本)
 type SkillsUpdateParams struct {
-	ID string `json:"id"`
+	ID     string `json:"id"`
+	Ref    string `json:"ref,omitempty"`    // 切换到指定 ref 并固定
+	Source string `json:"source,omitempty"` // 替换来源
+	Apply  bool   `json:"apply,omitempty"`
 }
 
 func (s *Server) handleSkillsUpdate(ctx *MethodContext) error {
@@ -746,18 +941,138 @@ func (s *Server) handleSkillsUpdate(ctx *MethodContext) error {
 		ctx.RespondError(protocol.ErrorCodes.InvalidParams, "Invalid params")
 		return nil
 	}
+	if params.ID == "" {
+		ctx.RespondError(protocol.ErrorCodes.InvalidParams, "id is required")
+		return nil
+	}
 
 	if s.deps.SkillLoader == nil {
 		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Skill loader not available")
 		return nil
 	}
 
-	if err := s.deps.SkillLoader.Update(params.ID); err != nil {
+	loader := s.deps.SkillLoader
+	plan, ok := loader.PendingUpdate(params.ID)
+	if !params.Apply || !ok || params.Ref != "" || params.Source != "" {
+		var err error
+		plan, err = loader.PlanUpdate(params.ID, skills.UpdateOptions{Ref: params.Ref, Source: params.Source})
+		if err != nil {
+			ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
+			return nil
+		}
+	}
+	if !params.Apply || plan.UpToDate {
+		ctx.Respond(true, map[string]interface{}{"plan": plan, "updated": false})
+		return nil
+	}
+
+	skill, err := loader.ApplyUpdate(params.ID)
+	if err != nil {
 		ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
 		return nil
 	}
+	s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "skills"})
+	ctx.Respond(true, map[string]interface{}{"plan": plan, "updated": true, "version": skill.Version})
+	return nil
+}
 
-	ctx.Respond(true, map[string]interface{}{"updated": true})
+// ============================================================================
+// Checkpoints handlers
+// ============================================================================
+
+type CheckpointsParams struct {
+	Session string `json:"session,omitempty"`
+	ID      int    `json:"id,omitempty"`
+}
+
+func (s *Server) handleCheckpointsList(ctx *MethodContext) error {
+	var params CheckpointsParams
+	if len(ctx.Request.Params) > 0 {
+		if err := json.Unmarshal(ctx.Request.Params, &params); err != nil {
+			ctx.RespondError(protocol.ErrorCodes.InvalidParams, "Invalid params")
+			return nil
+		}
+	}
+
+	if s.deps.Checkpoints == nil {
+		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Checkpoints not available")
+		return nil
+	}
+
+	// 未指定会话时返回所有有检查点的会话
+	if params.Session == "" {
+		sessions, err := s.deps.Checkpoints.Sessions()
+		if err != nil {
+			ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
+			return nil
+		}
+		ctx.Respond(true, map[string]interface{}{"sessions": sessions})
+		return nil
+	}
+
+	list, err := s.deps.Checkpoints.List(params.Session)
+	if err != nil {
+		ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
+		return nil
+	}
+	if list == nil {
+		list = []checkpoints.Checkpoint{}
+	}
+	ctx.Respond(true, map[string]interface{}{
+		"session":     params.Session,
+		"checkpoints": list,
+	})
+	return nil
+}
+
+func (s *Server) handleCheckpointsDiff(ctx *MethodContext) error {
+	return s.handleCheckpointChanges(ctx, false)
+}
+
+func (s *Server) handleCheckpointsRestore(ctx *MethodContext) error {
+	return s.handleCheckpointChanges(ctx, true)
+}
+
+// handleCheckpointChanges checkpoints.diff / checkpoints.restore
+func (s *Server) handleCheckpointChanges(ctx *MethodContext, restore bool) error {
+	var params CheckpointsParams
+	if err := json.Unmarshal(ctx.Request.Params, &params); err != nil || params.Session == "" || params.ID <= 0 {
+		ctx.RespondError(protocol.ErrorCodes.InvalidParams, "session and id are required")
+		return nil
+	}
+
+	if s.deps.Checkpoints == nil {
+		ctx.RespondError(protocol.ErrorCodes.ServiceUnavailable, "Checkpoints not available")
+		return nil
+	}
+
+	var changes []checkpoints.FileChange
+	var err error
+	if restore {
+		var check checkpoints.PathCheck
+		if s.deps.CheckpointPaths != nil {
+			check = s.deps.CheckpointPaths(params.Session)
+		}
+		changes, err = s.deps.Checkpoints.Restore(params.Session, params.ID, check)
+	} else {
+		changes, err = s.deps.Checkpoints.Diff(params.Session, params.ID)
+	}
+	if err != nil {
+		ctx.RespondError(protocol.ErrorCodes.InternalError, err.Error())
+		return nil
+	}
+	if changes == nil {
+		changes = []checkpoints.FileChange{}
+	}
+
+	ctx.Respond(true, map[string]interface{}{
+		"session": params.Session,
+		"id":      params.ID,
+		"changes": changes,
+	})
+	if restore {
+		s.BroadcastEvent("stateChange", map[string]interface{}{"kind": "checkpoints", "session": params.Session})
+	}
 	return nil
 }
 
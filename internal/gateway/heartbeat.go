@@ -0,0 +1,270 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/z8n24/openclaw-go/internal/channels"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/sessions"
+)
+
+// HeartbeatOK 心跳时无事可报的回复标记，此类回复不会投递
+const HeartbeatOK = "HEARTBEAT_OK"
+
+const (
+	defaultHeartbeatInterval = 30 * time.Minute
+	heartbeatTimeout         = 10 * time.Minute
+	heartbeatAckMaxChars     = 300 // 标记之外不超过此长度的附言同样视为无事可报
+)
+
+const heartbeatPrompt = "Heartbeat check. Follow the HEARTBEAT.md instructions below strictly; do not infer or repeat old tasks from earlier chats. " +
+	"If nothing needs attention, reply with exactly " + HeartbeatOK + ".\n\n"
+
+// ErrHeartbeatRunning 上一次心跳尚未结束
+var ErrHeartbeatRunning = errors.New("heartbeat already running")
+
+// HeartbeatRunner 定期唤醒主会话，按 HEARTBEAT.md 主动检查，有内容时投递给用户
+type HeartbeatRunner struct {
+	sessions *sessions.EnhancedManager
+	channels *channels.Manager
+	runAgent sessions.AgentRunner
+	file     string
+	interval time.Duration
+	quiet    *quietHours
+	target   config.CronDeliveryConfig
+	now      func() time.Time
+
+	wake    chan struct{}
+	running sync.Mutex
+}
+
+// NewHeartbeatRunner 创建心跳执行器，file 为 HEARTBEAT.md 路径
+func NewHeartbeatRunner(sessionMgr *sessions.EnhancedManager, channelMgr *channels.Manager, runAgent sessions.AgentRunner, file string) *HeartbeatRunner {
+	return &HeartbeatRunner{
+		sessions: sessionMgr,
+		channels: channelMgr,
+		runAgent: runAgent,
+		file:     file,
+		interval: defaultHeartbeatInterval,
+		now:      time.Now,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// NewHeartbeatRunnerFromConfig 按配置创建心跳执行器；未配置投递目标时使用 fallback (cron.delivery)
+func NewHeartbeatRunnerFromConfig(cfg config.HeartbeatConfig, fallback config.CronDeliveryConfig, sessionMgr *sessions.EnhancedManager, channelMgr *channels.Manager, runAgent sessions.AgentRunner, file string) (*HeartbeatRunner, error) {
+	h := NewHeartbeatRunner(sessionMgr, channelMgr, runAgent, file)
+	if cfg.Every != "" {
+		interval, err := time.ParseDuration(cfg.Every)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid heartbeat interval %q", cfg.Every)
+		}
+		h.SetInterval(interval)
+	}
+	if q := cfg.QuietHours; q != nil {
+		if err := h.SetQuietHours(q.Start, q.End, q.Tz); err != nil {
+			return nil, err
+		}
+	}
+
+	target := config.CronDeliveryConfig{Channel: cfg.Channel, ChatID: cfg.ChatID}
+	if target.Channel == "" && target.ChatID == "" {
+		target = fallback
+	}
+	h.SetTarget(target)
+	return h, nil
+}
+
+// SetInterval 设置心跳间隔
+func (h *HeartbeatRunner) SetInterval(d time.Duration) {
+	h.interval = d
+}
+
+// SetQuietHours 设置免打扰时段 ("HH:MM")，tz 为空时使用本地时间
+func (h *HeartbeatRunner) SetQuietHours(start, end, tz string) error {
+	q, err := parseQuietHours(start, end, tz)
+	if err != nil {
+		return err
+	}
+	h.quiet = q
+	return nil
+}
+
+// SetTarget 设置心跳消息的投递目标
+func (h *HeartbeatRunner) SetTarget(target config.CronDeliveryConfig) {
+	h.target = target
+}
+
+// Start 按间隔运行心跳，直到 ctx 取消
+func (h *HeartbeatRunner) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if h.quiet != nil && h.quiet.contains(h.now()) {
+					log.Debug().Msg("Heartbeat skipped during quiet hours")
+					continue
+				}
+			case <-h.wake:
+			}
+			if _, err := h.Beat(ctx); err != nil {
+				log.Warn().Err(err).Msg("Heartbeat failed")
+			}
+		}
+	}()
+	log.Info().Dur("every", h.interval).Msg("Heartbeat started")
+}
+
+// Wake 立即触发一次心跳 (不受免打扰时段限制)；text 非空时先作为系统事件注入主会话
+func (h *HeartbeatRunner) Wake(text string) {
+	if text != "" {
+		if main, ok := h.sessions.Get("main"); ok {
+			main.EnqueueSystemEvent(text)
+		}
+	}
+	select {
+	case h.wake <- struct{}{}:
+	default: // 已有待处理的唤醒
+	}
+}
+
+// Beat 运行一次心跳，返回投递的消息；HEARTBEAT.md 为空或无事可报时返回空字符串
+func (h *HeartbeatRunner) Beat(ctx context.Context) (string, error) {
+	if !h.running.TryLock() {
+		return "", ErrHeartbeatRunning
+	}
+	defer h.running.Unlock()
+
+	instructions, ok := h.instructions()
+	if !ok {
+		log.Debug().Str("file", h.file).Msg("HEARTBEAT.md is empty, skipping heartbeat")
+		return "", nil
+	}
+	if h.runAgent == nil {
+		return "", fmt.Errorf("no agent configured for heartbeat")
+	}
+	main, ok := h.sessions.Get("main")
+	if !ok {
+		return "", fmt.Errorf("main session not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+	defer cancel()
+
+	before := len(main.GetMessages())
+	reply, err := h.runAgent(ctx, main, heartbeatPrompt+instructions)
+	if err != nil {
+		return "", err
+	}
+
+	text, deliver := stripHeartbeatOK(reply)
+	if !deliver {
+		// 无事可报的轮次不保留在主会话中
+		main.TruncateMessages(before)
+		log.Debug().Msg("Heartbeat: nothing to report")
+		return "", nil
+	}
+
+	if err := h.deliver(ctx, text); err != nil {
+		return text, fmt.Errorf("deliver: %w", err)
+	}
+	return text, nil
+}
+
+// instructions 读取 HEARTBEAT.md；只有标题、注释或空行时视为空
+func (h *HeartbeatRunner) instructions() (string, bool) {
+	data, err := os.ReadFile(h.file)
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return strings.TrimSpace(string(data)), true
+		}
+	}
+	return "", false
+}
+
+func (h *HeartbeatRunner) deliver(ctx context.Context, text string) error {
+	if h.target.Channel == "" || h.target.ChatID == "" {
+		log.Warn().Msg("Heartbeat has a message but no delivery target is configured")
+		return nil
+	}
+	if h.channels == nil {
+		return fmt.Errorf("channels are not available")
+	}
+	_, err := h.channels.SendToChat(ctx, h.target.Channel, h.target.ChatID, text)
+	return err
+}
+
+// stripHeartbeatOK 去掉回复首尾的 HEARTBEAT_OK；只剩简短附言时不投递
+func stripHeartbeatOK(reply string) (string, bool) {
+	text := strings.TrimSpace(reply)
+	found := false
+	for _, trim := range []func(string, string) (string, bool){strings.CutPrefix, strings.CutSuffix} {
+		// 兼容 **HEARTBEAT_OK** 之类的 Markdown 包裹
+		if rest, ok := trim(strings.Trim(text, "*_`"), HeartbeatOK); ok {
+			text, found = strings.TrimSpace(strings.Trim(rest, "*_`")), true
+		}
+	}
+	if !found {
+		return text, text != ""
+	}
+	if len([]rune(text)) <= heartbeatAckMaxChars {
+		return "", false
+	}
+	return text, true
+}
+
+// quietHours 免打扰时段，start > end 时跨越午夜
+type quietHours struct {
+	start, end int // 一天中的分钟数
+	loc        *time.Location
+}
+
+func parseQuietHours(start, end, tz string) (*quietHours, error) {
+	q := &quietHours{loc: time.Local}
+	var err error
+	if q.start, err = parseClock(start); err != nil {
+		return nil, err
+	}
+	if q.end, err = parseClock(end); err != nil {
+		return nil, err
+	}
+	if tz != "" {
+		if q.loc, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("invalid quiet hours time zone %q: %w", tz, err)
+		}
+	}
+	return q, nil
+}
+
+func (q *quietHours) contains(t time.Time) bool {
+	t = t.In(q.loc)
+	m := t.Hour()*60 + t.Minute()
+	if q.start <= q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
+
+// parseClock 解析 "HH:MM"，返回一天中的分钟数
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (want HH:MM)", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
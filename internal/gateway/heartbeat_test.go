@@ -0,0 +1,163 @@
+package gateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/channels"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/sessions"
+)
+
+func newTestHeartbeat(t *testing.T, instructions, reply string) (*HeartbeatRunner, *sessions.EnhancedManager, *recordingChannel, *string) {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "HEARTBEAT.md")
+	os.WriteFile(file, []byte(instructions), 0644)
+
+	sessionMgr := sessions.NewEnhancedManager(sessions.ManagerConfig{DataDir: t.TempDir()})
+	channelMgr := channels.NewManager()
+	ch := &recordingChannel{id: "telegram"}
+	channelMgr.Register(ch)
+
+	var prompt string
+	runAgent := func(ctx context.Context, s *sessions.EnhancedSession, message string) (string, error) {
+		prompt = message
+		s.AddMessage(agents.Message{Role: "user", Content: message})
+		s.AddMessage(agents.Message{Role: "assistant", Content: reply})
+		return reply, nil
+	}
+	h := NewHeartbeatRunner(sessionMgr, channelMgr, runAgent, file)
+	h.SetTarget(config.CronDeliveryConfig{Channel: "telegram", ChatID: "42"})
+	return h, sessionMgr, ch, &prompt
+}
+
+func TestHeartbeat_NothingToReport(t *testing.T) {
+	h, sessionMgr, ch, prompt := newTestHeartbeat(t, "# HEARTBEAT.md\n\n- Check the inbox\n", "HEARTBEAT_OK")
+
+	text, err := h.Beat(context.Background())
+	if err != nil || text != "" {
+		t.Fatalf("Beat = %q, %v", text, err)
+	}
+	if !strings.Contains(*prompt, "Check the inbox") || !strings.Contains(*prompt, HeartbeatOK) {
+		t.Errorf("Unexpected prompt: %q", *prompt)
+	}
+	if len(ch.sent) != 0 {
+		t.Errorf("HEARTBEAT_OK should be swallowed, got %+v", ch.sent)
+	}
+	main, _ := sessionMgr.Get("main")
+	if msgs := main.GetMessages(); len(msgs) != 0 {
+		t.Errorf("Expected swallowed turn to be pruned, got %d messages", len(msgs))
+	}
+}
+
+func TestHeartbeat_Delivers(t *testing.T) {
+	h, sessionMgr, ch, _ := newTestHeartbeat(t, "- Check the inbox\n", "You have 2 unread emails from your manager.")
+
+	text, err := h.Beat(context.Background())
+	if err != nil || text != "You have 2 unread emails from your manager." {
+		t.Fatalf("Beat = %q, %v", text, err)
+	}
+	if len(ch.sent) != 1 || ch.sent[0].ChatID != "42" || ch.sent[0].Text != text {
+		t.Errorf("Unexpected deliveries: %+v", ch.sent)
+	}
+	main, _ := sessionMgr.Get("main")
+	if msgs := main.GetMessages(); len(msgs) != 2 {
+		t.Errorf("Expected heartbeat turn kept in main session, got %d messages", len(msgs))
+	}
+}
+
+func TestHeartbeat_EmptyFileSkips(t *testing.T) {
+	h, _, ch, prompt := newTestHeartbeat(t, "# HEARTBEAT.md\n\n# Keep this file empty to skip heartbeat tasks.\n", "hello")
+	if text, err := h.Beat(context.Background()); err != nil || text != "" {
+		t.Fatalf("Beat = %q, %v", text, err)
+	}
+	if *prompt != "" || len(ch.sent) != 0 {
+		t.Error("Empty HEARTBEAT.md should not run the agent")
+	}
+}
+
+func TestHeartbeat_Wake(t *testing.T) {
+	h, sessionMgr, ch, _ := newTestHeartbeat(t, "- Remind me to stretch\n", "Time to stretch!")
+	h.SetInterval(time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.Start(ctx)
+	h.Wake("User asked for a check-in")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		ch.mu.Lock()
+		n := len(ch.sent)
+		ch.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Wake did not trigger a heartbeat")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	main, _ := sessionMgr.Get("main")
+	if events := main.DrainSystemEvents(); len(events) != 1 {
+		t.Errorf("Expected wake text queued as system event, got %v", events)
+	}
+}
+
+func TestStripHeartbeatOK(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    string
+		deliver bool
+	}{
+		{"HEARTBEAT_OK", "", false},
+		{"  **HEARTBEAT_OK**  ", "", false},
+		{"All quiet. HEARTBEAT_OK", "", false},
+		{"Your flight is delayed by 2 hours.", "Your flight is delayed by 2 hours.", true},
+		{"HEARTBEAT_OK " + strings.Repeat("x", heartbeatAckMaxChars+1), strings.Repeat("x", heartbeatAckMaxChars+1), true},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, deliver := stripHeartbeatOK(tt.reply)
+		if got != tt.want || deliver != tt.deliver {
+			t.Errorf("stripHeartbeatOK(%q) = %q, %v; want %q, %v", tt.reply, got, deliver, tt.want, tt.deliver)
+		}
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+	q, err := parseQuietHours("23:00", "08:00", "UTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 1, h, m, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(23, 30), true},
+		{at(3, 0), true},
+		{at(8, 0), false},
+		{at(12, 0), false},
+	} {
+		if got := q.contains(tt.t); got != tt.want {
+			t.Errorf("contains(%s) = %v, want %v", tt.t.Format("15:04"), got, tt.want)
+		}
+	}
+
+	if _, err := parseQuietHours("25:00", "08:00", ""); err == nil {
+		t.Error("Expected error for invalid time")
+	}
+	if _, err := NewHeartbeatRunnerFromConfig(config.HeartbeatConfig{Every: "soon"}, config.CronDeliveryConfig{}, nil, nil, nil, ""); err == nil {
+		t.Error("Expected error for invalid interval")
+	}
+	h, err := NewHeartbeatRunnerFromConfig(config.HeartbeatConfig{Every: "1h"}, config.CronDeliveryConfig{Channel: "telegram", ChatID: "1"}, nil, nil, nil, "")
+	if err != nil || h.interval != time.Hour || h.target.ChatID != "1" {
+		t.Errorf("Unexpected runner: %+v, %v", h, err)
+	}
+}
@@ -73,6 +73,11 @@ var SupportedMethods = []string{
 	"exec.approvals.node.get",
 	"exec.approvals.node.set",
 
+	// Checkpoints
+	"checkpoints.list",
+	"checkpoints.diff",
+	"checkpoints.restore",
+
 	// Logs
 	"logs.tail",
 
@@ -114,6 +119,7 @@ var SupportedEvents = []string{
 	"device.pair.requested",
 	"device.pair.resolved",
 	"exec.approval.request",
+	"skills.install.progress",
 }
 
 // ErrorCodes 标准错误码
@@ -0,0 +1,146 @@
+package gateway
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// ConfigReconciler 将配置变化应用到运行中的组件
+//
+// 每个配置项 (config.Diff 返回的路径) 由注册了对应前缀的 handler 热应用；
+// 没有 handler 或 handler 失败的配置项需要重启 gateway 才能生效
+type ConfigReconciler struct {
+	mu        sync.Mutex
+	current   *config.Config
+	normalize func(*config.Config)
+	handlers  []reloadHandler
+	startup   *config.Config // 启动时的配置；与之不同且无法热应用的配置项需要重启
+	failed    map[int]bool   // 上次热应用失败的 handler
+}
+
+type reloadHandler struct {
+	name     string // 热应用失败时 Errors 中的 key
+	prefixes []string
+	apply    func(old, new *config.Config) error
+}
+
+// ReloadResult 一次配置应用的结果
+type ReloadResult struct {
+	Changed         []string          `json:"changed"`          // 本次变化的配置项
+	HotApplied      []string          `json:"hotApplied"`       // 已热应用的配置项
+	RestartRequired []string          `json:"restartRequired"`  // 所有需要重启 gateway 才能生效的配置项 (包括之前的变化)
+	Errors          map[string]string `json:"errors,omitempty"` // handler 名称 (默认为前缀) -> 热应用失败原因
+}
+
+// NewConfigReconciler 创建配置协调器，current 为当前运行使用的配置
+func NewConfigReconciler(current *config.Config) *ConfigReconciler {
+	return &ConfigReconciler{current: current, startup: current, failed: make(map[int]bool)}
+}
+
+// SetNormalizer 设置应用前对新配置的调整 (如命令行参数覆盖和默认值)，接收的是配置的浅拷贝
+func (r *ConfigReconciler) SetNormalizer(fn func(*config.Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.normalize = fn
+}
+
+// Handle 注册配置项的热应用函数；prefix 匹配路径本身及其下级 (如 "channels.telegram" 匹配 "channels.telegram.botToken")
+// 同一次变化中多个配置项匹配同一 handler 时只调用一次；多个 handler 匹配时使用最先注册的
+func (r *ConfigReconciler) Handle(prefix string, apply func(old, new *config.Config) error) {
+	r.HandleGroup(prefix, []string{prefix}, apply)
+}
+
+// HandleGroup 注册由同一函数热应用的多个配置项，任一项变化时只调用一次；失败时 Errors 的 key 为 name
+func (r *ConfigReconciler) HandleGroup(name string, prefixes []string, apply func(old, new *config.Config) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers = append(r.handlers, reloadHandler{name: name, prefixes: prefixes, apply: apply})
+}
+
+// Current 返回当前生效的配置
+func (r *ConfigReconciler) Current() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Apply 比较新旧配置，调用受影响的 handler，并返回热应用和需要重启的配置项
+func (r *ConfigReconciler) Apply(next *config.Config) *ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := *next
+	if r.normalize != nil {
+		r.normalize(&c)
+	}
+	old := r.current
+	changed := config.Diff(old, &c)
+	result := &ReloadResult{Changed: changed, HotApplied: []string{}}
+
+	// 按 handler 分组，每个 handler 只调用一次
+	groups := make(map[int][]string)
+	var order []int
+	for _, path := range changed {
+		i := r.handlerFor(path)
+		if i < 0 {
+			continue
+		}
+		if _, ok := groups[i]; !ok {
+			order = append(order, i)
+		}
+		groups[i] = append(groups[i], path)
+	}
+
+	for _, i := range order {
+		h := r.handlers[i]
+		if err := h.apply(old, &c); err != nil {
+			log.Error().Err(err).Str("config", h.name).Msg("Failed to hot-apply config")
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[h.name] = err.Error()
+			r.failed[i] = true
+			continue
+		}
+		delete(r.failed, i)
+		result.HotApplied = append(result.HotApplied, groups[i]...)
+	}
+
+	r.current = &c
+	sort.Strings(result.HotApplied)
+	result.RestartRequired = r.restartRequiredLocked()
+	if len(changed) > 0 {
+		log.Info().
+			Strs("hotApplied", result.HotApplied).
+			Strs("restartRequired", result.RestartRequired).
+			Msg("Config applied")
+	}
+	return result
+}
+
+// handlerFor 返回匹配配置项的 handler 下标，没有时返回 -1 (调用者持有 mu)
+func (r *ConfigReconciler) handlerFor(path string) int {
+	for i, h := range r.handlers {
+		for _, prefix := range h.prefixes {
+			if path == prefix || strings.HasPrefix(path, prefix+".") {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// restartRequiredLocked 返回相对启动时配置变化、且没有生效的配置项；改回启动时的值后不再需要重启 (调用者持有 mu)
+func (r *ConfigReconciler) restartRequiredLocked() []string {
+	paths := []string{}
+	for _, path := range config.Diff(r.startup, r.current) {
+		if i := r.handlerFor(path); i < 0 || r.failed[i] {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
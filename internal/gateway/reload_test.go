@@ -0,0 +1,138 @@
+package gateway
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Gateway: config.GatewayConfig{Port: 18789, Bind: "127.0.0.1"},
+		Channels: config.ChannelsConfig{
+			Telegram: &config.TelegramConfig{BotToken: "old", Enabled: true},
+		},
+		Agent: config.AgentConfig{DefaultModel: "anthropic/claude-sonnet-4-20250514"},
+	}
+}
+
+func TestConfigDiff(t *testing.T) {
+	old := testConfig()
+	next := testConfig()
+	next.Channels.Telegram = &config.TelegramConfig{BotToken: "new", Enabled: true}
+	next.Channels.Discord = &config.DiscordConfig{BotToken: "d", Enabled: true}
+	next.Tools.Exec.Allowlist = []string{"git"}
+	headless := false
+	next.Tools.Browser.Headless = &headless
+
+	got := strings.Join(config.Diff(old, next), ",")
+	want := "channels.discord,channels.telegram.botToken,tools.browser.headless,tools.exec.allowlist"
+	if got != want {
+		t.Errorf("Diff = %s, want %s", got, want)
+	}
+	if diff := config.Diff(old, testConfig()); len(diff) != 0 {
+		t.Errorf("equal configs reported %v", diff)
+	}
+}
+
+func TestConfigReconciler_Apply(t *testing.T) {
+	r := NewConfigReconciler(testConfig())
+	r.SetNormalizer(func(c *config.Config) {
+		if c.Gateway.Port == 0 {
+			c.Gateway.Port = 18789
+		}
+	})
+
+	var telegramCalls, modelCalls int
+	modelErr := errors.New("no API key")
+	r.Handle("channels.telegram", func(old, next *config.Config) error {
+		telegramCalls++
+		if old.Channels.Telegram.BotToken != "old" {
+			t.Errorf("handler should receive the running config, got %+v", old.Channels.Telegram)
+		}
+		return nil
+	})
+	r.Handle("agent.defaultModel", func(old, next *config.Config) error {
+		modelCalls++
+		return modelErr
+	})
+
+	next := testConfig()
+	next.Gateway.Port = 0 // 由 normalizer 补上默认值，不算变化
+	next.Gateway.Bind = "0.0.0.0"
+	next.Channels.Telegram.BotToken = "new"
+	next.Channels.Telegram.AllowFrom = []string{"alice"}
+	next.Agent.DefaultModel = "deepseek/deepseek-chat"
+
+	result := r.Apply(next)
+	if telegramCalls != 1 || modelCalls != 1 {
+		t.Errorf("handlers called %d/%d times, want once each", telegramCalls, modelCalls)
+	}
+	if got := strings.Join(result.HotApplied, ","); got != "channels.telegram.allowFrom,channels.telegram.botToken" {
+		t.Errorf("HotApplied = %s", got)
+	}
+	if got := strings.Join(result.RestartRequired, ","); got != "agent.defaultModel,gateway.bind" {
+		t.Errorf("RestartRequired = %s", got)
+	}
+	if result.Errors["agent.defaultModel"] != "no API key" {
+		t.Errorf("Errors = %v", result.Errors)
+	}
+	if r.Current().Channels.Telegram.BotToken != "new" || r.Current().Gateway.Port != 18789 {
+		t.Errorf("Current = %+v", r.Current())
+	}
+
+	// 之后的变化继续报告尚未生效的配置项；改回启动时的值后不再需要重启
+	again := testConfig()
+	again.Channels.Telegram.BotToken = "new"
+	again.Channels.Telegram.AllowFrom = []string{"alice"}
+	again.Agent.DefaultModel = "deepseek/deepseek-chat"
+	again.Gateway.Bind = "0.0.0.0"
+	again.Heartbeat.Enabled = true
+	result = r.Apply(again)
+	if len(result.Changed) != 1 || result.Changed[0] != "heartbeat.enabled" || telegramCalls != 1 {
+		t.Errorf("Changed = %v (telegram handler calls %d)", result.Changed, telegramCalls)
+	}
+	if got := strings.Join(result.RestartRequired, ","); got != "agent.defaultModel,gateway.bind,heartbeat.enabled" {
+		t.Errorf("RestartRequired = %s", got)
+	}
+
+	again = testConfig()
+	again.Channels.Telegram.BotToken = "new"
+	again.Channels.Telegram.AllowFrom = []string{"alice"}
+	again.Agent.DefaultModel = "deepseek/deepseek-chat"
+	result = r.Apply(again)
+	if got := strings.Join(result.HotApplied, ","); got != "" {
+		t.Errorf("HotApplied = %s", got)
+	}
+	if got := strings.Join(result.RestartRequired, ","); got != "agent.defaultModel" {
+		t.Errorf("RestartRequired = %s", got)
+	}
+}
+
+func TestConfigReconciler_HandleGroup(t *testing.T) {
+	r := NewConfigReconciler(testConfig())
+	calls := 0
+	r.HandleGroup("tools", []string{"tools.exec.allowlist", "tools.browser.headless"}, func(old, next *config.Config) error {
+		calls++
+		return nil
+	})
+
+	next := testConfig()
+	next.Tools.Exec.Allowlist = []string{"git"}
+	headless := false
+	next.Tools.Browser.Headless = &headless
+	next.Tools.Exec.Sandbox.Mode = "all"
+
+	result := r.Apply(next)
+	if calls != 1 {
+		t.Errorf("group handler called %d times, want once", calls)
+	}
+	if got := strings.Join(result.HotApplied, ","); got != "tools.browser.headless,tools.exec.allowlist" {
+		t.Errorf("HotApplied = %s", got)
+	}
+	if got := strings.Join(result.RestartRequired, ","); got != "tools.exec.sandbox.mode" {
+		t.Errorf("RestartRequired = %s", got)
+	}
+}
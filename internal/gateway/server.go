@@ -24,6 +24,7 @@ const (
 // Server 是 Gateway 服务器
 type Server struct {
 	cfg      *config.Config
+	cfgMu    sync.RWMutex
 	addr     string
 	token    string
 	
@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Chunk 索引中的一个文本片段
+type Chunk struct {
+	Path      string    `json:"path"` // 相对 workspace 的路径
+	StartLine int       `json:"startLine"`
+	EndLine   int       `json:"endLine"`
+	Heading   string    `json:"heading,omitempty"`
+	Text      string    `json:"text"`
+	Vector    []float32 `json:"vector,omitempty"`
+}
+
+// Citation 返回引用位置，例如 memory/2025-01-15.md#L3-L7
+func (c *Chunk) Citation() string {
+	if c.StartLine == c.EndLine {
+		return fmt.Sprintf("%s#L%d", c.Path, c.StartLine)
+	}
+	return fmt.Sprintf("%s#L%d-L%d", c.Path, c.StartLine, c.EndLine)
+}
+
+// 单个片段的目标字符数
+const chunkTargetChars = 800
+
+// ChunkMarkdown 按标题和段落切分 markdown，每个片段不超过 chunkTargetChars
+func ChunkMarkdown(path, content string) []Chunk {
+	lines := strings.Split(content, "\n")
+
+	var chunks []Chunk
+	var buf []string
+	start := 0
+	heading := ""
+	size := 0
+
+	flush := func(end int) {
+		text := strings.TrimSpace(strings.Join(buf, "\n"))
+		if text != "" {
+			// 去掉首尾空行，让行号指向真正的内容
+			first, last := start, end
+			for first < last && strings.TrimSpace(lines[first]) == "" {
+				first++
+			}
+			for last > first && strings.TrimSpace(lines[last-1]) == "" {
+				last--
+			}
+			chunks = append(chunks, Chunk{
+				Path:      path,
+				StartLine: first + 1,
+				EndLine:   last,
+				Heading:   heading,
+				Text:      text,
+			})
+		}
+		buf = nil
+		size = 0
+		start = end
+	}
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		isHeading := strings.HasPrefix(trimmed, "#")
+		paragraphBreak := trimmed == "" && size >= chunkTargetChars/2
+
+		if isHeading || paragraphBreak || size+len(line) > chunkTargetChars {
+			flush(i)
+		}
+		if isHeading {
+			heading = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+		}
+		buf = append(buf, line)
+		size += len(line) + 1
+	}
+	flush(len(lines))
+
+	return chunks
+}
+
+// transcript 会话记录文件 (sessions/*.json)
+type transcript struct {
+	Session struct {
+		Key   string `json:"key"`
+		Label string `json:"label"`
+	} `json:"session"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+// ChunkTranscript 把会话记录 (sessions/*.json) 切分为片段，行号为消息序号
+func ChunkTranscript(path string, data []byte) []Chunk {
+	var t transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil
+	}
+
+	var chunks []Chunk
+	for i, msg := range t.Messages {
+		text := transcriptText(msg.Content)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if len(text) > chunkTargetChars*2 {
+			text = text[:chunkTargetChars*2] + "..."
+		}
+		chunks = append(chunks, Chunk{
+			Path:      path,
+			StartLine: i + 1,
+			EndLine:   i + 1,
+			Heading:   t.Session.Key,
+			Text:      msg.Role + ": " + text,
+		})
+	}
+	return chunks
+}
+
+// TranscriptLines 返回会话记录中每条消息的 "role: text"，下标 i 对应引用中的行号 i+1；没有文本的消息为空行
+func TranscriptLines(data []byte) ([]string, error) {
+	var t transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	lines := make([]string, len(t.Messages))
+	for i, msg := range t.Messages {
+		if text := transcriptText(msg.Content); strings.TrimSpace(text) != "" {
+			lines[i] = msg.Role + ": " + text
+		}
+	}
+	return lines, nil
+}
+
+// transcriptText 提取消息文本 (字符串或 text 块)
+func transcriptText(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, b := range blocks {
+		if b.Type == "text" && b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Tokenize 分词：小写的字母数字序列，CJK 字符逐字切分
+func Tokenize(text string) []string {
+	var tokens []string
+	var cur []rune
+
+	flush := func() {
+		if len(cur) > 0 {
+			tokens = append(tokens, string(cur))
+			cur = cur[:0]
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			cur = append(cur, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return tokens
+}
@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Embedder 是向量化提供者的抽象接口
+type Embedder interface {
+	// ID 返回提供者标识 (模型变化时索引需要重建)
+	ID() string
+
+	// Embed 批量计算文本向量
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderConfig 向量化配置
+type EmbedderConfig struct {
+	Provider string // "openai" | "local"
+	Model    string
+	BaseURL  string
+	APIKey   string
+}
+
+// NewEmbedder 根据配置创建向量化提供者，未配置或缺少 API key 时使用本地哈希向量
+func NewEmbedder(cfg EmbedderConfig) Embedder {
+	if cfg.Provider == "openai" {
+		if e := NewOpenAIEmbedder(cfg.BaseURL, cfg.Model, cfg.APIKey); e.apiKey != "" || cfg.BaseURL != "" {
+			return e
+		}
+	}
+	return NewHashEmbedder(0)
+}
+
+// ==================== OpenAI 兼容 HTTP ====================
+
+// OpenAIEmbedder 使用 OpenAI 兼容的 /embeddings 接口
+type OpenAIEmbedder struct {
+	baseURL    string
+	model      string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpenAIEmbedder 创建 OpenAI 兼容的向量化提供者
+func NewOpenAIEmbedder(baseURL, model, apiKey string) *OpenAIEmbedder {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return &OpenAIEmbedder{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      model,
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (e *OpenAIEmbedder) ID() string {
+	return "openai:" + e.model
+}
+
+// 单次请求的最大文本数
+const openAIEmbedBatch = 64
+
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += openAIEmbedBatch {
+		end := start + openAIEmbedBatch
+		if end > len(texts) {
+			end = len(texts)
+		}
+		batch, err := e.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, _ := json.Marshal(map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("embedding API error %d: %s", resp.StatusCode, string(data))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("parse embedding response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding API returned %d vectors for %d inputs", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding API returned invalid index %d", d.Index)
+		}
+		vectors[d.Index] = normalize(d.Embedding)
+	}
+	return vectors, nil
+}
+
+// ==================== 本地哈希向量 ====================
+
+// HashEmbedder 确定性的本地特征哈希向量 (离线回退，不依赖网络)
+//
+// 词和词内字符三元组被哈希到固定维度，能匹配词形变化和部分拼写差异，
+// 但无法理解真正的同义改写。
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder 创建本地哈希向量提供者 (dims <= 0 时默认 512)
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = 512
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+func (e *HashEmbedder) ID() string {
+	return fmt.Sprintf("local-hash:%d", e.dims)
+}
+
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = e.embed(text)
+	}
+	return vectors, nil
+}
+
+func (e *HashEmbedder) embed(text string) []float32 {
+	vec := make([]float32, e.dims)
+	add := func(feature string, weight float32) {
+		h := fnv.New64a()
+		h.Write([]byte(feature))
+		sum := h.Sum64()
+		idx := int(sum % uint64(e.dims))
+		if sum&(1<<63) != 0 {
+			weight = -weight
+		}
+		vec[idx] += weight
+	}
+
+	for _, token := range Tokenize(text) {
+		add("w:"+token, 1)
+		runes := []rune(token)
+		if len(runes) < 4 {
+			continue
+		}
+		for i := 0; i+3 <= len(runes); i++ {
+			add("g:"+string(runes[i:i+3]), 0.5)
+		}
+	}
+	return normalize(vec)
+}
+
+// ==================== 向量工具 ====================
+
+// normalize L2 归一化
+func normalize(vec []float32) []float32 {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return vec
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = v / norm
+	}
+	return out
+}
+
+// cosine 计算已归一化向量的余弦相似度
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return dot
+}
@@ -0,0 +1,459 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// 索引文件格式版本，格式变化时强制重建
+const indexVersion = 1
+
+// IndexConfig 索引配置
+type IndexConfig struct {
+	Workspace       string   // 记忆文件所在的 workspace
+	StateDir        string   // 索引文件存放目录
+	Embedder        Embedder // 为空时使用本地哈希向量
+	IncludeSessions bool     // 是否索引 sessions/*.json 会话记录
+	SessionsDir     string   // 会话目录 (默认 <stateDir>/sessions，没有 StateDir 时为 <workspace>/sessions)
+	VectorWeight    float64  // 混合检索中向量分数的权重 (0-1，默认 0.6)
+}
+
+// Index 记忆文件的向量 + BM25 索引
+type Index struct {
+	cfg      IndexConfig
+	embedder Embedder
+	path     string
+
+	files map[string]*indexedFile
+	mu    sync.RWMutex
+
+	// 串行化 Sync，避免重复计算向量
+	syncMu sync.Mutex
+}
+
+// indexedFile 已索引文件
+type indexedFile struct {
+	ModTime int64   `json:"modTime"`
+	Size    int64   `json:"size"`
+	Chunks  []Chunk `json:"chunks"`
+}
+
+// indexState 持久化格式
+type indexState struct {
+	Version   int                     `json:"version"`
+	Embedder  string                  `json:"embedder"`
+	Workspace string                  `json:"workspace"`
+	Files     map[string]*indexedFile `json:"files"`
+}
+
+// SearchResult 检索结果
+type SearchResult struct {
+	Path        string  `json:"path"`
+	StartLine   int     `json:"startLine"`
+	EndLine     int     `json:"endLine"`
+	Heading     string  `json:"heading,omitempty"`
+	Content     string  `json:"content"`
+	Citation    string  `json:"citation"`
+	Score       float64 `json:"score"`
+	VectorScore float64 `json:"vectorScore"`
+	TextScore   float64 `json:"textScore"`
+}
+
+// NewIndex 创建索引并加载已持久化的数据
+func NewIndex(cfg IndexConfig) *Index {
+	if cfg.Embedder == nil {
+		cfg.Embedder = NewHashEmbedder(0)
+	}
+	if cfg.SessionsDir == "" {
+		cfg.SessionsDir = filepath.Join(cfg.Workspace, "sessions")
+		if cfg.StateDir != "" {
+			cfg.SessionsDir = filepath.Join(cfg.StateDir, "sessions")
+		}
+	}
+	if cfg.VectorWeight <= 0 || cfg.VectorWeight > 1 {
+		cfg.VectorWeight = 0.6
+	}
+
+	idx := &Index{
+		cfg:      cfg,
+		embedder: cfg.Embedder,
+		files:    make(map[string]*indexedFile),
+	}
+	if cfg.StateDir != "" {
+		idx.path = filepath.Join(cfg.StateDir, "memory-index.json")
+	}
+	idx.load()
+	return idx
+}
+
+// Workspace 返回索引对应的 workspace
+func (idx *Index) Workspace() string {
+	return idx.cfg.Workspace
+}
+
+// SessionsDir 返回被索引的会话记录目录，未启用 IncludeSessions 时为空
+func (idx *Index) SessionsDir() string {
+	if !idx.cfg.IncludeSessions {
+		return ""
+	}
+	return idx.cfg.SessionsDir
+}
+
+// sources 列出需要索引的文件 (相对路径 -> 绝对路径)
+func (idx *Index) sources() map[string]string {
+	sources := make(map[string]string)
+
+	memoryFile := filepath.Join(idx.cfg.Workspace, "MEMORY.md")
+	if _, err := os.Stat(memoryFile); err == nil {
+		sources["MEMORY.md"] = memoryFile
+	}
+
+	memoryDir := filepath.Join(idx.cfg.Workspace, "memory")
+	if entries, err := os.ReadDir(memoryDir); err == nil {
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+				sources["memory/"+entry.Name()] = filepath.Join(memoryDir, entry.Name())
+			}
+		}
+	}
+
+	if idx.cfg.IncludeSessions {
+		if entries, err := os.ReadDir(idx.cfg.SessionsDir); err == nil {
+			for _, entry := range entries {
+				if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+					sources["sessions/"+entry.Name()] = filepath.Join(idx.cfg.SessionsDir, entry.Name())
+				}
+			}
+		}
+	}
+
+	return sources
+}
+
+// Sync 增量更新索引：只重新切分和向量化发生变化的文件
+func (idx *Index) Sync(ctx context.Context) error {
+	idx.syncMu.Lock()
+	defer idx.syncMu.Unlock()
+
+	sources := idx.sources()
+
+	idx.mu.RLock()
+	current := make(map[string]*indexedFile, len(idx.files))
+	for k, v := range idx.files {
+		current[k] = v
+	}
+	idx.mu.RUnlock()
+
+	updated := make(map[string]*indexedFile)
+	var removed []string
+	for rel := range current {
+		if _, ok := sources[rel]; !ok {
+			removed = append(removed, rel)
+		}
+	}
+
+	for rel, abs := range sources {
+		info, err := os.Stat(abs)
+		if err != nil {
+			continue
+		}
+		if f, ok := current[rel]; ok && f.ModTime == info.ModTime().UnixNano() && f.Size == info.Size() {
+			continue
+		}
+
+		data, err := os.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+
+		var chunks []Chunk
+		if strings.HasPrefix(rel, "sessions/") {
+			chunks = ChunkTranscript(rel, data)
+		} else {
+			chunks = ChunkMarkdown(rel, string(data))
+		}
+
+		if len(chunks) > 0 {
+			texts := make([]string, len(chunks))
+			for i, c := range chunks {
+				texts[i] = c.Text
+			}
+			vectors, err := idx.embedder.Embed(ctx, texts)
+			if err != nil {
+				return fmt.Errorf("embed %s: %w", rel, err)
+			}
+			for i := range chunks {
+				chunks[i].Vector = vectors[i]
+			}
+		}
+
+		updated[rel] = &indexedFile{
+			ModTime: info.ModTime().UnixNano(),
+			Size:    info.Size(),
+			Chunks:  chunks,
+		}
+	}
+
+	if len(updated) == 0 && len(removed) == 0 {
+		return nil
+	}
+
+	idx.mu.Lock()
+	for _, rel := range removed {
+		delete(idx.files, rel)
+	}
+	for rel, f := range updated {
+		idx.files[rel] = f
+	}
+	idx.mu.Unlock()
+
+	log.Debug().Int("updated", len(updated)).Int("removed", len(removed)).Msg("Memory index synced")
+
+	return idx.save()
+}
+
+// SearchOptions 检索选项
+type SearchOptions struct {
+	MaxResults int
+	MinScore   float64
+	Filter     func(path string) bool // 只检索 Filter 返回 true 的文件，为空时检索全部
+}
+
+// Search 混合检索 (BM25 + 向量相似度)
+func (idx *Index) Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	// 检索前增量同步，保证结果反映最新的文件内容
+	if err := idx.Sync(ctx); err != nil {
+		log.Warn().Err(err).Msg("Memory index sync failed, searching stale index")
+	}
+
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = 10
+	}
+
+	queryVecs, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("embed query: %w", err)
+	}
+	queryVec := queryVecs[0]
+	queryTokens := Tokenize(query)
+
+	idx.mu.RLock()
+	var chunks []*Chunk
+	for path, f := range idx.files {
+		if opts.Filter != nil && !opts.Filter(path) {
+			continue
+		}
+		for i := range f.Chunks {
+			chunks = append(chunks, &f.Chunks[i])
+		}
+	}
+	textScores := bm25(chunks, queryTokens)
+
+	maxText := 0.0
+	for _, s := range textScores {
+		maxText = math.Max(maxText, s)
+	}
+
+	w := idx.cfg.VectorWeight
+	results := make([]SearchResult, 0, len(chunks))
+	for i, c := range chunks {
+		vectorScore := math.Max(0, cosine(queryVec, c.Vector))
+		textScore := 0.0
+		if maxText > 0 {
+			textScore = textScores[i] / maxText
+		}
+		score := w*vectorScore + (1-w)*textScore
+		if score < opts.MinScore {
+			continue
+		}
+		results = append(results, SearchResult{
+			Path:        c.Path,
+			StartLine:   c.StartLine,
+			EndLine:     c.EndLine,
+			Heading:     c.Heading,
+			Content:     c.Text,
+			Citation:    c.Citation(),
+			Score:       score,
+			VectorScore: vectorScore,
+			TextScore:   textScore,
+		})
+	}
+	idx.mu.RUnlock()
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Citation < results[j].Citation
+	})
+	if len(results) > opts.MaxResults {
+		results = results[:opts.MaxResults]
+	}
+	return results, nil
+}
+
+// bm25 计算每个片段对查询的 BM25 分数
+func bm25(chunks []*Chunk, queryTokens []string) []float64 {
+	const k1, b = 1.2, 0.75
+
+	scores := make([]float64, len(chunks))
+	if len(chunks) == 0 || len(queryTokens) == 0 {
+		return scores
+	}
+
+	tokens := make([][]string, len(chunks))
+	df := make(map[string]int)
+	totalLen := 0
+	for i, c := range chunks {
+		tokens[i] = Tokenize(c.Text)
+		totalLen += len(tokens[i])
+		seen := make(map[string]bool)
+		for _, t := range tokens[i] {
+			if !seen[t] {
+				seen[t] = true
+				df[t]++
+			}
+		}
+	}
+	avgLen := float64(totalLen) / float64(len(chunks))
+	n := float64(len(chunks))
+
+	for i := range chunks {
+		tf := make(map[string]int)
+		for _, t := range tokens[i] {
+			tf[t]++
+		}
+		docLen := float64(len(tokens[i]))
+		for _, q := range queryTokens {
+			f := float64(tf[q])
+			if f == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-float64(df[q])+0.5)/(float64(df[q])+0.5))
+			scores[i] += idf * f * (k1 + 1) / (f + k1*(1-b+b*docLen/avgLen))
+		}
+	}
+	return scores
+}
+
+// Watch 监听记忆文件变化并在后台增量更新索引，直到 ctx 结束
+func (idx *Index) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dirs := []string{idx.cfg.Workspace, filepath.Join(idx.cfg.Workspace, "memory")}
+	if idx.cfg.IncludeSessions {
+		dirs = append(dirs, idx.cfg.SessionsDir)
+	}
+	for _, dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			log.Debug().Err(err).Str("dir", dir).Msg("Memory index: cannot watch directory")
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		// 合并短时间内的多次写入
+		var timer *time.Timer
+		trigger := make(chan struct{}, 1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Base(event.Name)
+				if !strings.HasSuffix(name, ".md") && !strings.HasSuffix(name, ".json") {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(500*time.Millisecond, func() {
+					select {
+					case trigger <- struct{}{}:
+					default:
+					}
+				})
+			case <-trigger:
+				if err := idx.Sync(ctx); err != nil {
+					log.Warn().Err(err).Msg("Memory index sync failed")
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("Memory index watcher error")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// load 加载持久化的索引
+func (idx *Index) load() {
+	if idx.path == "" {
+		return
+	}
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return
+	}
+
+	var state indexState
+	if err := json.Unmarshal(data, &state); err != nil {
+		log.Warn().Err(err).Msg("Memory index corrupted, rebuilding")
+		return
+	}
+	// 格式、向量模型或 workspace 变化时丢弃旧索引
+	if state.Version != indexVersion || state.Embedder != idx.embedder.ID() || state.Workspace != idx.cfg.Workspace || state.Files == nil {
+		return
+	}
+	idx.files = state.Files
+}
+
+// save 持久化索引
+func (idx *Index) save() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	idx.mu.RLock()
+	data, err := json.Marshal(indexState{
+		Version:   indexVersion,
+		Embedder:  idx.embedder.ID(),
+		Workspace: idx.cfg.Workspace,
+		Files:     idx.files,
+	})
+	idx.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, idx.path)
+}
@@ -0,0 +1,171 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChunkMarkdown_SplitsOnHeadings(t *testing.T) {
+	content := "# Memory\n\n## Decisions\nUse Go for the rewrite.\nDeadline is January.\n\n## Preferences\nDark mode.\n"
+	chunks := ChunkMarkdown("MEMORY.md", content)
+
+	if len(chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %d: %+v", len(chunks), chunks)
+	}
+	decisions := chunks[1]
+	if decisions.Heading != "Decisions" || decisions.StartLine != 3 || decisions.EndLine != 5 {
+		t.Errorf("unexpected decisions chunk: %+v", decisions)
+	}
+	if decisions.Citation() != "MEMORY.md#L3-L5" {
+		t.Errorf("unexpected citation: %s", decisions.Citation())
+	}
+}
+
+func TestTokenize_CJK(t *testing.T) {
+	tokens := Tokenize("Deploy 部署 v2!")
+	want := []string{"deploy", "部", "署", "v2"}
+	if strings.Join(tokens, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", tokens, want)
+	}
+}
+
+func TestIndex_HybridSearchWithCitations(t *testing.T) {
+	workspace := t.TempDir()
+	writeFile(t, filepath.Join(workspace, "MEMORY.md"), "# Memory\n\n## Preferences\nThe user prefers dark mode in every editor.\n")
+	writeFile(t, filepath.Join(workspace, "memory", "2025-01-15.md"), "## Deploys\nProduction deployment happens on Fridays via the release script.\n")
+
+	idx := NewIndex(IndexConfig{Workspace: workspace, StateDir: t.TempDir()})
+	results, err := idx.Search(context.Background(), "when do we deploy to production", SearchOptions{MaxResults: 3})
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatal("expected results")
+	}
+	top := results[0]
+	if top.Path != "memory/2025-01-15.md" || top.Citation != "memory/2025-01-15.md#L1-L2" {
+		t.Errorf("unexpected top result: %+v", top)
+	}
+	if top.VectorScore <= 0 || top.TextScore <= 0 {
+		t.Errorf("expected both vector and text scores, got %+v", top)
+	}
+}
+
+func TestIndex_IncrementalSyncAndPersistence(t *testing.T) {
+	workspace := t.TempDir()
+	stateDir := t.TempDir()
+	memoryFile := filepath.Join(workspace, "MEMORY.md")
+	writeFile(t, memoryFile, "Favourite colour is green.\n")
+
+	idx := NewIndex(IndexConfig{Workspace: workspace, StateDir: stateDir})
+	if err := idx.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改文件后检索应反映新内容
+	time.Sleep(10 * time.Millisecond)
+	writeFile(t, memoryFile, "Favourite colour is purple now.\n")
+	results, _ := idx.Search(context.Background(), "favourite colour", SearchOptions{})
+	if len(results) != 1 || !strings.Contains(results[0].Content, "purple") {
+		t.Fatalf("expected updated content, got %+v", results)
+	}
+
+	// 新实例从磁盘加载，无需重新计算
+	reloaded := NewIndex(IndexConfig{Workspace: workspace, StateDir: stateDir})
+	if len(reloaded.files) != 1 {
+		t.Errorf("expected persisted index to load 1 file, got %d", len(reloaded.files))
+	}
+
+	// 删除文件后索引同步移除
+	os.Remove(memoryFile)
+	results, _ = reloaded.Search(context.Background(), "favourite colour", SearchOptions{})
+	if len(results) != 0 {
+		t.Errorf("expected no results after deletion, got %+v", results)
+	}
+}
+
+func TestIndex_SessionTranscripts(t *testing.T) {
+	workspace := t.TempDir()
+	transcript := `{"session":{"key":"main"},"messages":[
+		{"role":"user","content":"remind me that the wifi password is hunter2"},
+		{"role":"assistant","content":[{"type":"text","text":"Noted the wifi password."}]}
+	]}`
+	writeFile(t, filepath.Join(workspace, "sessions", "main.json"), transcript)
+
+	idx := NewIndex(IndexConfig{Workspace: workspace, IncludeSessions: true})
+	results, err := idx.Search(context.Background(), "wifi password", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Path != "sessions/main.json" {
+		t.Fatalf("expected transcript results, got %+v", results)
+	}
+}
+
+func TestIndex_SessionsDirDefaultsToStateDir(t *testing.T) {
+	workspace, stateDir := t.TempDir(), t.TempDir()
+	writeFile(t, filepath.Join(stateDir, "sessions", "main.json"),
+		`{"session":{"key":"main"},"messages":[{"role":"user","content":"the deploy key lives in vault"}]}`)
+
+	idx := NewIndex(IndexConfig{Workspace: workspace, StateDir: stateDir, IncludeSessions: true})
+	if idx.SessionsDir() != filepath.Join(stateDir, "sessions") {
+		t.Errorf("SessionsDir = %s", idx.SessionsDir())
+	}
+	results, err := idx.Search(context.Background(), "deploy key", SearchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Path != "sessions/main.json" {
+		t.Fatalf("expected transcript from state dir, got %+v", results)
+	}
+}
+
+func TestOpenAIEmbedder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" || r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i := range req.Input {
+			data = append(data, item{Index: i, Embedding: []float32{3, 4}})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer server.Close()
+
+	e := NewOpenAIEmbedder(server.URL, "test-model", "test-key")
+	vectors, err := e.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 0.6 || vectors[0][1] != 0.8 {
+		t.Errorf("expected normalized vectors, got %v", vectors)
+	}
+	if e.ID() != "openai:test-model" {
+		t.Errorf("unexpected ID: %s", e.ID())
+	}
+}
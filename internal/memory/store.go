@@ -0,0 +1,430 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// SectionMode MEMORY.md 分节更新方式
+type SectionMode string
+
+const (
+	SectionAppend  SectionMode = "append"  // 追加条目 (去重)
+	SectionReplace SectionMode = "replace" // 替换整个分节内容
+	SectionRemove  SectionMode = "remove"  // 删除匹配条目，无条目时删除整个分节
+)
+
+// 锁文件超过该时间视为残留 (持有进程已崩溃)
+const staleLockAge = 30 * time.Second
+
+// WriteResult 记忆写入结果
+type WriteResult struct {
+	Path    string   `json:"path"`
+	Section string   `json:"section,omitempty"`
+	Added   []string `json:"added,omitempty"`
+	Skipped []string `json:"skipped,omitempty"` // 已存在而跳过的重复条目
+	Removed []string `json:"removed,omitempty"`
+	Created bool     `json:"created,omitempty"` // 新建了文件或分节
+}
+
+// Store 记忆文件写入器：每日日志 memory/YYYY-MM-DD.md 和长期记忆 MEMORY.md 的分节。
+// 所有写入都在文件锁内完成 (进程内互斥 + 锁文件)，避免并发写入互相覆盖。
+type Store struct {
+	paths *config.Paths
+}
+
+// NewStore 创建记忆写入器
+func NewStore(paths *config.Paths) *Store {
+	return &Store{paths: paths}
+}
+
+// Paths 返回记忆文件所在的项目路径
+func (s *Store) Paths() *config.Paths {
+	return s.paths
+}
+
+// Today 今天的日期 (每日日志文件名)
+func Today() string {
+	return time.Now().Format("2006-01-02")
+}
+
+// AppendDaily 向每日日志追加条目，section 非空时写入对应 "## section" 分节。
+// 文件中已存在的条目会被跳过。
+func (s *Store) AppendDaily(date, section string, entries []string) (*WriteResult, error) {
+	if date == "" {
+		date = Today()
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return nil, fmt.Errorf("invalid date %q (expected YYYY-MM-DD)", date)
+	}
+
+	path := s.paths.MemoryFile(date)
+	result := &WriteResult{Path: path, Section: section}
+
+	err := s.modify(path, func(content string) string {
+		if content == "" {
+			result.Created = true
+			content = "# " + date + "\n"
+		}
+		lines := splitLines(content)
+
+		var fresh []string
+		existing := entrySet(lines)
+		for _, entry := range normalizeEntries(entries) {
+			key := entryKey(entry)
+			if existing[key] {
+				result.Skipped = append(result.Skipped, entry)
+				continue
+			}
+			existing[key] = true
+			fresh = append(fresh, entry)
+		}
+		result.Added = fresh
+		if len(fresh) == 0 {
+			return content
+		}
+
+		if section == "" {
+			// 紧接已有列表继续追加，否则另起一段
+			lines = trimBlankRun(lines)
+			if n := len(lines); n > 0 && isListItem(lines[n-1]) {
+				return joinLines(append(lines, fresh...))
+			}
+			return joinLines(appendBlock(lines, fresh))
+		}
+		start, end := findSection(lines, section)
+		if start < 0 {
+			return joinLines(appendBlock(lines, append([]string{"## " + section}, fresh...)))
+		}
+		return joinLines(insertLines(lines, sectionInsertPoint(lines, start, end), fresh))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AppendDailyText 向每日日志原样追加一段文本 (不去重)
+func (s *Store) AppendDailyText(date, text string) error {
+	if date == "" {
+		date = Today()
+	}
+	path := s.paths.MemoryFile(date)
+	return s.modify(path, func(content string) string {
+		return content + text
+	})
+}
+
+// UpdateSection 更新 MEMORY.md 中的 "## section" 分节，分节不存在时创建
+func (s *Store) UpdateSection(section string, entries []string, mode SectionMode) (*WriteResult, error) {
+	section = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(section), "#"))
+	if section == "" {
+		return nil, fmt.Errorf("section is required")
+	}
+	if mode == "" {
+		mode = SectionAppend
+	}
+	switch mode {
+	case SectionAppend, SectionReplace, SectionRemove:
+	default:
+		return nil, fmt.Errorf("unknown mode %q (use append, replace or remove)", mode)
+	}
+
+	path := s.paths.MEMORYFile()
+	result := &WriteResult{Path: path, Section: section}
+	entries = normalizeEntries(entries)
+
+	err := s.modify(path, func(content string) string {
+		if content == "" {
+			if mode == SectionRemove {
+				return content
+			}
+			content = "# MEMORY.md\n"
+		}
+		lines := splitLines(content)
+		start, end := findSection(lines, section)
+
+		switch mode {
+		case SectionRemove:
+			if start < 0 {
+				return content
+			}
+			if len(entries) == 0 {
+				result.Removed = nonEmpty(lines[start+1 : end])
+				return joinLines(trimBlankRun(append(append([]string{}, lines[:start]...), lines[end:]...)))
+			}
+			remove := make(map[string]bool)
+			for _, e := range entries {
+				remove[entryKey(e)] = true
+			}
+			kept := append([]string{}, lines[:start+1]...)
+			for _, line := range lines[start+1 : end] {
+				if strings.TrimSpace(line) != "" && remove[entryKey(line)] {
+					result.Removed = append(result.Removed, strings.TrimSpace(line))
+					continue
+				}
+				kept = append(kept, line)
+			}
+			return joinLines(append(kept, lines[end:]...))
+
+		case SectionReplace:
+			result.Added = entries
+			body := append([]string{"## " + section}, entries...)
+			if start < 0 {
+				result.Created = true
+				return joinLines(appendBlock(lines, body))
+			}
+			result.Removed = nonEmpty(lines[start+1 : end])
+			if end < len(lines) {
+				body = append(body, "")
+			}
+			out := append(append([]string{}, lines[:start]...), body...)
+			return joinLines(append(out, lines[end:]...))
+
+		default: // append
+			var existing map[string]bool
+			if start >= 0 {
+				existing = entrySet(lines[start+1 : end])
+			} else {
+				existing = make(map[string]bool)
+			}
+			var fresh []string
+			for _, entry := range entries {
+				key := entryKey(entry)
+				if existing[key] {
+					result.Skipped = append(result.Skipped, entry)
+					continue
+				}
+				existing[key] = true
+				fresh = append(fresh, entry)
+			}
+			result.Added = fresh
+			if len(fresh) == 0 {
+				return content
+			}
+			if start < 0 {
+				result.Created = true
+				return joinLines(appendBlock(lines, append([]string{"## " + section}, fresh...)))
+			}
+			return joinLines(insertLines(lines, sectionInsertPoint(lines, start, end), fresh))
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// modify 在文件锁内读取、修改并原子写回文件
+func (s *Store) modify(path string, fn func(content string) string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	updated := fn(string(data))
+	if updated == string(data) {
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(updated), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ============================================================================
+// 文件锁
+// ============================================================================
+
+var fileLocks sync.Map // path -> *sync.Mutex
+
+// lockFile 获取文件写锁：进程内互斥 + <path>.lock 锁文件 (跨进程)
+func lockFile(path string) (func(), error) {
+	muAny, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+	mu := muAny.(*sync.Mutex)
+	mu.Lock()
+
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() {
+				os.Remove(lockPath)
+				mu.Unlock()
+			}, nil
+		}
+		if !os.IsExist(err) {
+			mu.Unlock()
+			return nil, err
+		}
+		// 清理崩溃进程遗留的锁文件
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			mu.Unlock()
+			return nil, fmt.Errorf("memory file is locked: %s", path)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// ============================================================================
+// Markdown 辅助
+// ============================================================================
+
+// normalizeEntries 把输入拆成列表条目，非列表行补上 "- " 前缀
+func normalizeEntries(entries []string) []string {
+	var out []string
+	for _, entry := range entries {
+		for _, line := range strings.Split(entry, "\n") {
+			line = strings.TrimRight(line, " \t\r")
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" {
+				continue
+			}
+			if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") ||
+				strings.HasPrefix(trimmed, "#") || strings.HasPrefix(line, "  ") {
+				out = append(out, line)
+				continue
+			}
+			out = append(out, "- "+trimmed)
+		}
+	}
+	return out
+}
+
+func isListItem(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ")
+}
+
+// entryKey 去重用的归一化键：忽略列表符号、勾选框、大小写和多余空白
+func entryKey(line string) string {
+	s := strings.TrimSpace(line)
+	for _, prefix := range []string{"- ", "* "} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	for _, prefix := range []string{"[ ] ", "[x] ", "[X] "} {
+		s = strings.TrimPrefix(s, prefix)
+	}
+	s = strings.TrimRight(s, ".。 ")
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// entrySet 已有条目的归一化集合 (不含标题)
+func entrySet(lines []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		set[entryKey(trimmed)] = true
+	}
+	return set
+}
+
+// findSection 查找 "## name" 分节 (不区分大小写)，返回标题行和分节结束位置 (下一个同级或更高级标题)
+func findSection(lines []string, name string) (int, int) {
+	want := strings.ToLower(strings.TrimSpace(name))
+	for i, line := range lines {
+		level, title := headingOf(line)
+		if level != 2 || strings.ToLower(title) != want {
+			continue
+		}
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if l, _ := headingOf(lines[j]); l > 0 && l <= 2 {
+				end = j
+				break
+			}
+		}
+		return i, end
+	}
+	return -1, -1
+}
+
+// headingOf 解析 Markdown 标题行，非标题返回 0
+func headingOf(line string) (int, string) {
+	trimmed := strings.TrimSpace(line)
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level >= len(trimmed) || trimmed[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(trimmed[level:])
+}
+
+// sectionInsertPoint 分节内最后一个非空行之后的位置
+func sectionInsertPoint(lines []string, start, end int) int {
+	at := end
+	for at > start+1 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+	return at
+}
+
+func insertLines(lines []string, at int, items []string) []string {
+	out := append([]string{}, lines[:at]...)
+	out = append(out, items...)
+	return append(out, lines[at:]...)
+}
+
+// appendBlock 在文件末尾追加一个块，与前文之间空一行
+func appendBlock(lines []string, block []string) []string {
+	lines = trimBlankRun(lines)
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	return append(lines, block...)
+}
+
+// trimBlankRun 去掉末尾空行
+func trimBlankRun(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func nonEmpty(lines []string) []string {
+	var out []string
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			out = append(out, strings.TrimSpace(line))
+		}
+	}
+	return out
+}
+
+func splitLines(content string) []string {
+	return strings.Split(strings.TrimRight(content, "\n"), "\n")
+}
+
+func joinLines(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
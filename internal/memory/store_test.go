@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestStore_AppendDailyDeduplicates(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	store := NewStore(paths)
+
+	if _, err := store.AppendDaily("2025-01-15", "", []string{"Met Alice for lunch\nBooked flights"}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := store.AppendDaily("2025-01-15", "", []string{"- booked flights.", "Paid rent"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Added) != 1 || len(result.Skipped) != 1 {
+		t.Errorf("expected 1 added and 1 skipped, got %+v", result)
+	}
+
+	data, _ := os.ReadFile(paths.MemoryFile("2025-01-15"))
+	want := "# 2025-01-15\n\n- Met Alice for lunch\n- Booked flights\n- Paid rent\n"
+	if string(data) != want {
+		t.Errorf("unexpected journal:\n%s", data)
+	}
+
+	if _, err := store.AppendDaily("15/01/2025", "", []string{"x"}); err == nil {
+		t.Error("expected error for invalid date")
+	}
+}
+
+func TestStore_AppendDailySection(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	store := NewStore(paths)
+
+	store.AppendDaily("2025-01-15", "Work", []string{"Shipped v1"})
+	store.AppendDaily("2025-01-15", "Home", []string{"Fixed the sink"})
+	store.AppendDaily("2025-01-15", "work", []string{"Planned v2"})
+
+	data, _ := os.ReadFile(paths.MemoryFile("2025-01-15"))
+	want := "# 2025-01-15\n\n## Work\n- Shipped v1\n- Planned v2\n\n## Home\n- Fixed the sink\n"
+	if string(data) != want {
+		t.Errorf("unexpected journal:\n%s", data)
+	}
+}
+
+func TestStore_UpdateSection(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	store := NewStore(paths)
+	os.WriteFile(paths.MEMORYFile(), []byte("# Memory\n\n## Preferences\n- Dark mode\n\n## People\n- Alice is the PM\n"), 0644)
+
+	result, err := store.UpdateSection("Preferences", []string{"dark mode", "Prefers metric units"}, SectionAppend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Added) != 1 || len(result.Skipped) != 1 {
+		t.Errorf("expected 1 added and 1 skipped, got %+v", result)
+	}
+
+	if _, err := store.UpdateSection("People", []string{"Bob is the tech lead"}, SectionReplace); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.UpdateSection("Projects", []string{"openclaw-go"}, SectionAppend); err != nil {
+		t.Fatal(err)
+	}
+	result, err = store.UpdateSection("Preferences", []string{"Dark mode"}, SectionRemove)
+	if err != nil || len(result.Removed) != 1 {
+		t.Fatalf("expected 1 removed entry, got %+v (%v)", result, err)
+	}
+
+	data, _ := os.ReadFile(paths.MEMORYFile())
+	want := "# Memory\n\n## Preferences\n- Prefers metric units\n\n## People\n- Bob is the tech lead\n\n## Projects\n- openclaw-go\n"
+	if string(data) != want {
+		t.Errorf("unexpected MEMORY.md:\n%s", data)
+	}
+
+	if _, err := store.UpdateSection("People", nil, SectionRemove); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = os.ReadFile(paths.MEMORYFile())
+	if strings.Contains(string(data), "People") || !strings.Contains(string(data), "## Projects") {
+		t.Errorf("expected People section removed:\n%s", data)
+	}
+}
+
+func TestStore_ConcurrentAppends(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	store := NewStore(paths)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := store.AppendDaily("2025-01-15", "", []string{fmt.Sprintf("entry %d", i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, _ := os.ReadFile(paths.MemoryFile("2025-01-15"))
+	if n := strings.Count(string(data), "- entry "); n != 20 {
+		t.Errorf("expected 20 entries, got %d:\n%s", n, data)
+	}
+}
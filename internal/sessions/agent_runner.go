@@ -0,0 +1,58 @@
+package sessions
+
+import (
+	"context"
+
+	"github.com/z8n24/openclaw-go/internal/agents"
+)
+
+// NewLoopAgentRunner 用 AgentLoop 运行 EnhancedSession 的一轮对话
+//
+// 会话已以该用户消息结尾时 (ToolsSessionManager 的调用方式) 不会重复添加。
+// 待注入的系统事件随这轮对话发送，新产生的消息追加回会话；已压缩的会话以压缩摘要开头。
+func NewLoopAgentRunner(provider agents.Provider, tools *ToolRegistry, system, defaultModel string) AgentRunner {
+	return NewLoopAgentRunnerFunc(provider, tools, func() string { return system }, defaultModel)
+}
+
+// NewLoopAgentRunnerFunc 同 NewLoopAgentRunner，每轮通过 system 获取 system prompt
+func NewLoopAgentRunnerFunc(provider agents.Provider, tools *ToolRegistry, system func() string, defaultModel string) AgentRunner {
+	return func(ctx context.Context, session *EnhancedSession, message string) (string, error) {
+		history := session.GetMessagesWithCompaction()
+		queued := false
+		if n := len(history); n > 0 && history[n-1].Role == "user" {
+			if text, ok := history[n-1].Content.(string); ok && text == message {
+				history = history[:n-1]
+				queued = true
+			}
+		}
+
+		tmp := &Session{
+			Key:      session.Key,
+			Kind:     string(session.Kind),
+			Label:    session.Label,
+			Channel:  session.Channel,
+			ChatID:   session.ChatID,
+			messages: history,
+			turns:    session.NextTurn() - 1,
+		}
+		for _, event := range session.DrainSystemEvents() {
+			tmp.EnqueueSystemEvent(event)
+		}
+
+		loop := NewAgentLoop(provider, tools, tmp, "", session.GetEffectiveModel(defaultModel))
+		loop.SetSystemPrompt(system)
+		resp, err := loop.Run(ctx, message, nil)
+
+		added := tmp.GetMessages()[len(history):]
+		if queued && len(added) > 0 {
+			added = added[1:]
+		}
+		for _, msg := range added {
+			session.AddMessage(msg)
+		}
+		if err != nil {
+			return "", err
+		}
+		return resp.Content, nil
+	}
+}
@@ -0,0 +1,95 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/z8n24/openclaw-go/internal/agents"
+)
+
+// streamingProvider 以流式事件返回固定内容
+type streamingProvider struct {
+	fakeProvider
+}
+
+func (p *streamingProvider) ChatStream(ctx context.Context, req *agents.ChatRequest) (<-chan agents.StreamEvent, error) {
+	ch := make(chan agents.StreamEvent, 2)
+	ch <- agents.StreamEvent{Type: agents.StreamEventDelta, Content: p.content}
+	ch <- agents.StreamEvent{Type: agents.StreamEventDone, Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestLoopAgentRunner(t *testing.T) {
+	mgr := NewEnhancedManager(ManagerConfig{DataDir: t.TempDir()})
+	session := mgr.CreateIsolatedSession("main", "Cron: daily", "")
+	session.EnqueueSystemEvent("Reminder fired.")
+
+	run := NewLoopAgentRunner(&streamingProvider{fakeProvider{content: "all done"}}, NewToolRegistry(), "system", "model")
+	resp, err := run(context.Background(), session, "daily report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != "all done" {
+		t.Errorf("Unexpected response %q", resp)
+	}
+
+	msgs := session.GetMessages()
+	if len(msgs) != 2 || msgs[1].Role != "assistant" {
+		t.Fatalf("Expected user+assistant messages, got %+v", msgs)
+	}
+	if text, _ := msgs[0].Content.(string); text != "System: Reminder fired.\n\ndaily report" {
+		t.Errorf("Expected system event in user message, got %q", text)
+	}
+	if events := session.DrainSystemEvents(); len(events) != 0 {
+		t.Errorf("Expected events to be drained, got %v", events)
+	}
+}
+
+// recordingProvider 记录每次请求的 system prompt 和工具
+type recordingProvider struct {
+	streamingProvider
+	systems []string
+	tools   [][]string
+}
+
+func (p *recordingProvider) ChatStream(ctx context.Context, req *agents.ChatRequest) (<-chan agents.StreamEvent, error) {
+	p.systems = append(p.systems, req.System)
+	var names []string
+	for _, tool := range req.Tools {
+		names = append(names, tool.Name)
+	}
+	p.tools = append(p.tools, names)
+	return p.streamingProvider.ChatStream(ctx, req)
+}
+
+func TestLoopAgentRunnerFunc_ReloadBetweenTurns(t *testing.T) {
+	mgr := NewEnhancedManager(ManagerConfig{DataDir: t.TempDir()})
+	session := mgr.CreateIsolatedSession("main", "test", "")
+
+	prompt := "v1"
+	registry := NewToolRegistry()
+	registry.RegisterWithSchema(agents.Tool{Name: "demo__old"}, func(ctx context.Context, args json.RawMessage) (string, error) { return "", nil })
+
+	provider := &recordingProvider{streamingProvider: streamingProvider{fakeProvider{content: "ok"}}}
+	run := NewLoopAgentRunnerFunc(provider, registry, func() string { return prompt }, "model")
+	if _, err := run(context.Background(), session, "first"); err != nil {
+		t.Fatal(err)
+	}
+
+	prompt = "v2"
+	registry.Unregister("demo__old")
+	registry.RegisterWithSchema(agents.Tool{Name: "demo__new"}, func(ctx context.Context, args json.RawMessage) (string, error) { return "", nil })
+	if _, err := run(context.Background(), session, "second"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(provider.systems) != 2 || provider.systems[0] != "v1" || provider.systems[1] != "v2" {
+		t.Errorf("system prompts = %v", provider.systems)
+	}
+	if !slices.Contains(provider.tools[1], "demo__new") || slices.Contains(provider.tools[1], "demo__old") {
+		t.Errorf("second turn tools = %v", provider.tools[1])
+	}
+}
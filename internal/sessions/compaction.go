@@ -2,16 +2,77 @@ package sessions
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/memory"
 )
 
+// 每类事实最多保留的条目数 (超出时丢弃最旧的)
+const maxCompactionFacts = 40
+
+// CompactionState 结构化压缩状态 (滚动摘要 + 提取出的事实)
+type CompactionState struct {
+	Summary      string    `json:"summary"`
+	Decisions    []string  `json:"decisions,omitempty"`
+	Files        []string  `json:"files,omitempty"`
+	Todos        []string  `json:"todos,omitempty"`
+	ToolOutcomes []string  `json:"toolOutcomes,omitempty"`
+	Generation   int       `json:"generation"`
+	CompactedAt  time.Time `json:"compactedAt"`
+
+	// 历代压缩记录
+	History []CompactionGeneration `json:"history,omitempty"`
+}
+
+// CompactionGeneration 单次压缩记录
+type CompactionGeneration struct {
+	Generation     int       `json:"generation"`
+	CompactedAt    time.Time `json:"compactedAt"`
+	CompactedCount int       `json:"compactedCount"`
+	SummaryLength  int       `json:"summaryLength"`
+}
+
+// Render 渲染为注入到上下文中的文本
+func (c *CompactionState) Render() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[Previous conversation summary - compaction generation %d]\n", c.Generation))
+	sb.WriteString(strings.TrimSpace(c.Summary))
+	sb.WriteString("\n")
+
+	writeList := func(title string, items []string, prefix string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n## %s\n", title))
+		for _, item := range items {
+			sb.WriteString(prefix + item + "\n")
+		}
+	}
+	writeList("Decisions", c.Decisions, "- ")
+	writeList("Open tasks", c.Todos, "- [ ] ")
+	writeList("Files touched", c.Files, "- ")
+	writeList("Tool outcomes", c.ToolOutcomes, "- ")
+
+	sb.WriteString("[End of summary - continue from here]")
+	return sb.String()
+}
+
+// Summarizer 根据上一代压缩状态和待压缩消息生成新的压缩状态
+type Summarizer func(prev *CompactionState, messages []agents.Message) (*CompactionState, error)
+
 // Compactor 会话压缩器
 type Compactor struct {
 	provider agents.Provider
 	model    string
+
+	// 非空时，压缩前把重要事实写入 memory/YYYY-MM-DD.md
+	paths *config.Paths
 }
 
 // NewCompactor 创建压缩器
@@ -22,41 +83,84 @@ func NewCompactor(provider agents.Provider, model string) *Compactor {
 	}
 }
 
+// SetMemoryFlush 启用压缩前写入每日记忆文件
+func (c *Compactor) SetMemoryFlush(paths *config.Paths) {
+	c.paths = paths
+}
+
 // CompactSession 压缩会话
-func (c *Compactor) CompactSession(ctx context.Context, session *EnhancedSession, keepCount int) error {
+func (c *Compactor) CompactSession(ctx context.Context, session *EnhancedSession, keepCount int) (*CompactionResult, error) {
 	if !session.NeedsCompaction(keepCount * 2) {
-		return nil // 不需要压缩
+		return nil, nil // 不需要压缩
 	}
 	
-	return session.Compact(func(messages []agents.Message) (string, error) {
-		return c.GenerateSummary(ctx, messages)
+	return session.Compact(func(prev *CompactionState, messages []agents.Message) (*CompactionState, error) {
+		state, err := c.GenerateState(ctx, prev, messages)
+		if err != nil {
+			return nil, err
+		}
+		if c.paths != nil {
+			if err := c.flushToMemory(session.Key, prev, state); err != nil {
+				// 写入失败不应阻止压缩
+				log.Warn().Err(err).Str("session", session.Key).Msg("Failed to flush compaction facts to memory")
+			}
+		}
+		return state, nil
 	}, keepCount)
 }
 
 // GenerateSummary 使用 LLM 生成对话摘要
 func (c *Compactor) GenerateSummary(ctx context.Context, messages []agents.Message) (string, error) {
+	state, err := c.GenerateState(ctx, nil, messages)
+	if err != nil {
+		return "", err
+	}
+	return state.Render(), nil
+}
+
+// GenerateState 在上一代状态的基础上生成新的结构化压缩状态
+func (c *Compactor) GenerateState(ctx context.Context, prev *CompactionState, messages []agents.Message) (*CompactionState, error) {
+	// 启发式提取的事实始终合并，LLM 漏掉的文件路径和工具结果也能保留下来
+	extracted := extractFacts(messages)
+
 	if c.provider == nil {
 		// 如果没有 provider，使用简单摘要
-		return c.simpleSummary(messages), nil
+		return mergeCompactionState(prev, c.simpleSummary(messages), extracted), nil
 	}
 	
 	// 构建摘要请求
 	conversationText := formatConversation(messages)
 	
+	var prevText string
+	if prev != nil {
+		prevJSON, _ := json.MarshalIndent(compactionFacts{
+			Summary:      prev.Summary,
+			Decisions:    prev.Decisions,
+			Files:        prev.Files,
+			Todos:        prev.Todos,
+			ToolOutcomes: prev.ToolOutcomes,
+		}, "", "  ")
+		prevText = fmt.Sprintf("Existing summary state (update it, drop completed tasks):\n%s\n\n", prevJSON)
+	}
+
 	req := &agents.ChatRequest{
 		Model: c.model,
-		System: `You are a conversation summarizer. Your task is to create a concise but comprehensive summary of the conversation that preserves:
-1. Key decisions and conclusions
-2. Important facts and information shared
-3. Action items or tasks mentioned
-4. User preferences or requirements expressed
-5. Technical details that might be referenced later
-
-Write the summary in a neutral, factual tone. Be concise but don't omit important details.`,
+		System: `You are a conversation compactor. Older turns of an agent conversation are about to be discarded; you produce the state the agent needs to keep working.
+
+Respond with a single JSON object and nothing else:
+{
+  "summary": "rolling narrative summary: goals, context, what was done and where things stand",
+  "decisions": ["decisions and conclusions that were agreed on"],
+  "files": ["file paths that were read, created or modified"],
+  "todos": ["tasks that are still open"],
+  "toolOutcomes": ["important tool results, e.g. 'go test ./... failed: TestFoo'"]
+}
+
+Merge with the existing state if one is given: keep facts that still matter, remove todos that were completed, and keep the summary concise but complete.`,
 		Messages: []agents.Message{
 			{
 				Role:    "user",
-				Content: fmt.Sprintf("Please summarize the following conversation:\n\n%s", conversationText),
+				Content: fmt.Sprintf("%sConversation to compact:\n\n%s", prevText, conversationText),
 			},
 		},
 		MaxTokens: 2000,
@@ -65,10 +169,92 @@ Write the summary in a neutral, factual tone. Be concise but don't omit importan
 	resp, err := c.provider.Chat(ctx, req)
 	if err != nil {
 		// 如果 LLM 失败，回退到简单摘要
-		return c.simpleSummary(messages), nil
+		return mergeCompactionState(prev, c.simpleSummary(messages), extracted), nil
 	}
 	
-	return resp.Content, nil
+	facts, ok := parseCompactionFacts(resp.Content)
+	if !ok {
+		// 模型没有按格式返回，把原文当作摘要
+		return mergeCompactionState(prev, resp.Content, extracted), nil
+	}
+
+	// LLM 返回的是合并后的完整状态，摘要直接替换
+	state := &CompactionState{
+		Summary:      facts.Summary,
+		Decisions:    facts.Decisions,
+		Files:        facts.Files,
+		Todos:        facts.Todos,
+		ToolOutcomes: facts.ToolOutcomes,
+	}
+	state.Files = appendUnique(state.Files, extracted.Files...)
+	state.ToolOutcomes = appendUnique(state.ToolOutcomes, extracted.ToolOutcomes...)
+	if prev != nil {
+		state.Generation = prev.Generation
+		state.History = prev.History
+	}
+	state.trim()
+	return state, nil
+}
+
+// compactionFacts LLM 输出格式
+type compactionFacts struct {
+	Summary      string   `json:"summary"`
+	Decisions    []string `json:"decisions,omitempty"`
+	Files        []string `json:"files,omitempty"`
+	Todos        []string `json:"todos,omitempty"`
+	ToolOutcomes []string `json:"toolOutcomes,omitempty"`
+}
+
+// parseCompactionFacts 从模型输出中解析 JSON (容忍代码块包裹)
+func parseCompactionFacts(content string) (*compactionFacts, bool) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+
+	var facts compactionFacts
+	if err := json.Unmarshal([]byte(content[start:end+1]), &facts); err != nil {
+		return nil, false
+	}
+	if strings.TrimSpace(facts.Summary) == "" {
+		return nil, false
+	}
+	return &facts, true
+}
+
+// mergeCompactionState 把新摘要和事实追加到上一代状态上
+func mergeCompactionState(prev *CompactionState, summary string, facts compactionFacts) *CompactionState {
+	state := &CompactionState{}
+	if prev != nil {
+		state.Summary = prev.Summary
+		state.Decisions = append([]string(nil), prev.Decisions...)
+		state.Files = append([]string(nil), prev.Files...)
+		state.Todos = append([]string(nil), prev.Todos...)
+		state.ToolOutcomes = append([]string(nil), prev.ToolOutcomes...)
+		state.Generation = prev.Generation
+		state.History = prev.History
+	}
+
+	if state.Summary != "" {
+		state.Summary += "\n\n" + summary
+	} else {
+		state.Summary = summary
+	}
+	state.Decisions = appendUnique(state.Decisions, facts.Decisions...)
+	state.Files = appendUnique(state.Files, facts.Files...)
+	state.Todos = appendUnique(state.Todos, facts.Todos...)
+	state.ToolOutcomes = appendUnique(state.ToolOutcomes, facts.ToolOutcomes...)
+	state.trim()
+	return state
+}
+
+// trim 限制每类事实的数量
+func (c *CompactionState) trim() {
+	c.Decisions = keepLast(c.Decisions, maxCompactionFacts)
+	c.Files = keepLast(c.Files, maxCompactionFacts)
+	c.Todos = keepLast(c.Todos, maxCompactionFacts)
+	c.ToolOutcomes = keepLast(c.ToolOutcomes, maxCompactionFacts)
 }
 
 // simpleSummary 简单摘要 (不使用 LLM)
@@ -125,6 +311,177 @@ func (c *Compactor) simpleSummary(messages []agents.Message) string {
 	return strings.Join(parts, "\n")
 }
 
+// extractFacts 从消息中启发式提取事实 (文件路径、工具结果、决定、待办)
+func extractFacts(messages []agents.Message) compactionFacts {
+	var facts compactionFacts
+	calls := make(map[string]agents.ToolCall)
+
+	for _, msg := range messages {
+		if text, ok := msg.Content.(string); ok {
+			collectTextFacts(&facts, text)
+			continue
+		}
+		blocks, ok := msg.Content.([]agents.ContentBlock)
+		if !ok {
+			continue
+		}
+		for _, b := range blocks {
+			switch b.Type {
+			case "text":
+				if msg.Role == "assistant" {
+					collectTextFacts(&facts, b.Text)
+				}
+			case "tool_use":
+				if b.ToolUse == nil {
+					continue
+				}
+				calls[b.ToolUse.ID] = *b.ToolUse
+				args := toolCallArgs(*b.ToolUse)
+				if path, ok := args["path"].(string); ok && path != "" {
+					facts.Files = appendUnique(facts.Files, path)
+				}
+			case "tool_result":
+				if b.ToolResult == nil {
+					continue
+				}
+				tc, ok := calls[b.ToolResult.ToolCallID]
+				if !ok {
+					continue
+				}
+				facts.ToolOutcomes = append(facts.ToolOutcomes, describeToolOutcome(tc, b.ToolResult))
+			}
+		}
+	}
+
+	return facts
+}
+
+// collectTextFacts 从文本行中提取决定和待办
+func collectTextFacts(facts *compactionFacts, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "-*"))
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "[ ]"):
+			facts.Todos = appendUnique(facts.Todos, strings.TrimSpace(line[3:]))
+		case strings.HasPrefix(lower, "todo:"):
+			facts.Todos = appendUnique(facts.Todos, strings.TrimSpace(line[5:]))
+		case strings.HasPrefix(lower, "decision:"):
+			facts.Decisions = appendUnique(facts.Decisions, strings.TrimSpace(line[9:]))
+		case strings.HasPrefix(lower, "decided "), strings.HasPrefix(lower, "we decided "):
+			facts.Decisions = appendUnique(facts.Decisions, line)
+		}
+	}
+}
+
+// describeToolOutcome 生成一行工具结果描述
+func describeToolOutcome(tc agents.ToolCall, result *agents.ToolResult) string {
+	target := ""
+	args := toolCallArgs(tc)
+	for _, key := range []string{"command", "path", "url", "query", "action"} {
+		if v, ok := args[key].(string); ok && v != "" {
+			target = truncate(v, 80)
+			break
+		}
+	}
+
+	status := "ok"
+	if result.IsError {
+		status = "error"
+	}
+
+	firstLine := strings.TrimSpace(result.Content)
+	if i := strings.Index(firstLine, "\n"); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	desc := tc.Name
+	if target != "" {
+		desc += " " + target
+	}
+	desc += " → " + status
+	if firstLine != "" {
+		desc += ": " + truncate(firstLine, 120)
+	}
+	return desc
+}
+
+// toolCallArgs 把工具调用参数统一转为 map
+func toolCallArgs(tc agents.ToolCall) map[string]interface{} {
+	switch a := tc.Arguments.(type) {
+	case map[string]interface{}:
+		return a
+	case string:
+		var m map[string]interface{}
+		json.Unmarshal([]byte(a), &m)
+		return m
+	case json.RawMessage:
+		var m map[string]interface{}
+		json.Unmarshal(a, &m)
+		return m
+	}
+	return nil
+}
+
+// flushToMemory 把本次新增的重要事实追加到当天的记忆文件
+func (c *Compactor) flushToMemory(sessionKey string, prev, state *CompactionState) error {
+	var old compactionFacts
+	if prev != nil {
+		old = compactionFacts{Decisions: prev.Decisions, Files: prev.Files, Todos: prev.Todos}
+	}
+
+	decisions := newItems(old.Decisions, state.Decisions)
+	todos := newItems(old.Todos, state.Todos)
+	files := newItems(old.Files, state.Files)
+	if len(decisions) == 0 && len(todos) == 0 && len(files) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\n## Session %s (compacted %s)\n", sessionKey, now.Format("15:04")))
+	writeList := func(title string, items []string, prefix string) {
+		if len(items) == 0 {
+			return
+		}
+		sb.WriteString(fmt.Sprintf("\n### %s\n", title))
+		for _, item := range items {
+			sb.WriteString(prefix + item + "\n")
+		}
+	}
+	writeList("Decisions", decisions, "- ")
+	writeList("Open tasks", todos, "- [ ] ")
+	writeList("Files", files, "- ")
+
+	return memory.NewStore(c.paths).AppendDailyText(now.Format("2006-01-02"), sb.String())
+}
+
+// compactionBoundary 计算压缩分界点，保证 tool_use 和它的 tool_result 不被拆开
+func compactionBoundary(messages []agents.Message, keepCount int) int {
+	split := len(messages) - keepCount
+	if split <= 0 {
+		return 0
+	}
+
+	// 保留部分不能以 tool_result 开头，否则对应的 tool_use 会被压缩掉
+	for split > 0 && hasToolResult(messages[split]) {
+		split--
+	}
+	return split
+}
+
+// hasToolResult 消息是否包含 tool_result
+func hasToolResult(msg agents.Message) bool {
+	if blocks, ok := msg.Content.([]agents.ContentBlock); ok {
+		for _, b := range blocks {
+			if b.Type == "tool_result" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // formatConversation 格式化对话用于摘要
 func formatConversation(messages []agents.Message) string {
 	var parts []string
@@ -143,7 +500,8 @@ func formatConversation(messages []agents.Message) string {
 					textParts = append(textParts, b.Text)
 				case "tool_use":
 					if b.ToolUse != nil {
-						textParts = append(textParts, fmt.Sprintf("[Tool: %s]", b.ToolUse.Name))
+						argsJSON, _ := json.Marshal(b.ToolUse.Arguments)
+						textParts = append(textParts, fmt.Sprintf("[Tool: %s %s]", b.ToolUse.Name, truncate(string(argsJSON), 200)))
 					}
 				case "tool_result":
 					if b.ToolResult != nil {
@@ -151,7 +509,11 @@ func formatConversation(messages []agents.Message) string {
 						if len(result) > 200 {
 							result = result[:200] + "..."
 						}
-						textParts = append(textParts, fmt.Sprintf("[Result: %s]", result))
+						if b.ToolResult.IsError {
+							textParts = append(textParts, fmt.Sprintf("[Error result: %s]", result))
+						} else {
+							textParts = append(textParts, fmt.Sprintf("[Result: %s]", result))
+						}
 					}
 				}
 			}
@@ -180,13 +542,54 @@ func formatConversation(messages []agents.Message) string {
 	return strings.Join(parts, "\n\n")
 }
 
+// appendUnique 追加不重复的非空条目
+func appendUnique(list []string, items ...string) []string {
+	seen := make(map[string]bool, len(list))
+	for _, s := range list {
+		seen[s] = true
+	}
+	for _, s := range items {
+		s = strings.TrimSpace(s)
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		list = append(list, s)
+	}
+	return list
+}
+
+// newItems 返回 next 中不在 prev 里的条目
+func newItems(prev, next []string) []string {
+	seen := make(map[string]bool, len(prev))
+	for _, s := range prev {
+		seen[s] = true
+	}
+	var result []string
+	for _, s := range next {
+		if !seen[s] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// keepLast 只保留最后 n 个元素
+func keepLast(list []string, n int) []string {
+	if len(list) <= n {
+		return list
+	}
+	return list[len(list)-n:]
+}
+
 // AutoCompactionConfig 自动压缩配置
 type AutoCompactionConfig struct {
-	Enabled         bool   // 是否启用自动压缩
-	Threshold       int    // 触发压缩的消息数
-	KeepCount       int    // 保留的最近消息数
-	Model           string // 用于生成摘要的模型
-	UseSimpleSummary bool  // 使用简单摘要而非 LLM
+	Enabled          bool   // 是否启用自动压缩
+	Threshold        int    // 触发压缩的消息数
+	KeepCount        int    // 保留的最近消息数
+	Model            string // 用于生成摘要的模型
+	UseSimpleSummary bool   // 使用简单摘要而非 LLM
+	FlushToMemory    bool   // 压缩前将重要事实写入 memory/YYYY-MM-DD.md
 }
 
 // DefaultAutoCompactionConfig 默认自动压缩配置
@@ -196,15 +599,37 @@ var DefaultAutoCompactionConfig = AutoCompactionConfig{
 	KeepCount:        10,
 	Model:            "claude-3-haiku", // 使用便宜的模型
 	UseSimpleSummary: false,
+	FlushToMemory:    true,
+}
+
+// AutoCompact 会话消息数达到 cfg.Threshold 时压缩历史；paths 非空且启用 FlushToMemory 时压缩前写入每日记忆
+// cfg.Model 为空时使用 model 生成摘要
+func AutoCompact(ctx context.Context, cfg AutoCompactionConfig, provider agents.Provider, model string, paths *config.Paths, session *EnhancedSession) (*CompactionResult, error) {
+	if !cfg.Enabled || !session.NeedsCompaction(cfg.Threshold) {
+		return nil, nil
+	}
+	if cfg.Model != "" {
+		model = cfg.Model
+	}
+	if cfg.UseSimpleSummary {
+		provider = nil
+	}
+
+	compactor := NewCompactor(provider, model)
+	if cfg.FlushToMemory && paths != nil {
+		compactor.SetMemoryFlush(paths)
+	}
+	return compactor.CompactSession(ctx, session, cfg.KeepCount)
 }
 
 // CompactionResult 压缩结果
 type CompactionResult struct {
-	SessionKey      string `json:"sessionKey"`
-	CompactedCount  int    `json:"compactedCount"`
-	KeptCount       int    `json:"keptCount"`
-	SummaryLength   int    `json:"summaryLength"`
-	TokensSaved     int    `json:"tokensSaved,omitempty"`
+	SessionKey     string `json:"sessionKey"`
+	CompactedCount int    `json:"compactedCount"`
+	KeptCount      int    `json:"keptCount"`
+	SummaryLength  int    `json:"summaryLength"`
+	Generation     int    `json:"generation"`
+	TokensSaved    int    `json:"tokensSaved,omitempty"`
 }
 
 // EstimateTokens 估算消息的 token 数量
@@ -0,0 +1,234 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+// fakeProvider 返回固定内容的 provider
+type fakeProvider struct {
+	content string
+	err     error
+}
+
+func (p *fakeProvider) ID() string                     { return "fake" }
+func (p *fakeProvider) Name() string                   { return "Fake" }
+func (p *fakeProvider) ListModels() []agents.ModelInfo { return nil }
+func (p *fakeProvider) Chat(ctx context.Context, req *agents.ChatRequest) (*agents.ChatResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &agents.ChatResponse{Content: p.content}, nil
+}
+func (p *fakeProvider) ChatStream(ctx context.Context, req *agents.ChatRequest) (<-chan agents.StreamEvent, error) {
+	return nil, errors.New("not implemented")
+}
+
+func toolTurn(id, name string, args map[string]interface{}, result string, isError bool) []agents.Message {
+	return []agents.Message{
+		{Role: "assistant", Content: []agents.ContentBlock{
+			{Type: "tool_use", ToolUse: &agents.ToolCall{ID: id, Name: name, Arguments: args}},
+		}},
+		{Role: "user", Content: []agents.ContentBlock{
+			{Type: "tool_result", ToolResult: &agents.ToolResult{ToolCallID: id, Content: result, IsError: isError}},
+		}},
+	}
+}
+
+func TestCompactionBoundary_KeepsToolPairsTogether(t *testing.T) {
+	var msgs []agents.Message
+	msgs = append(msgs, agents.Message{Role: "user", Content: "edit the config"})
+	msgs = append(msgs, toolTurn("t1", "read", map[string]interface{}{"path": "a.go"}, "package a", false)...)
+	msgs = append(msgs, toolTurn("t2", "edit", map[string]interface{}{"path": "a.go"}, "ok", false)...)
+	msgs = append(msgs, agents.Message{Role: "assistant", Content: "done"})
+
+	// keepCount=2 would start the kept half at t2's tool_result
+	split := compactionBoundary(msgs, 2)
+	if split != 3 {
+		t.Fatalf("expected split at 3 (tool_use of t2), got %d", split)
+	}
+	if hasToolResult(msgs[split]) {
+		t.Error("kept messages must not start with a tool_result")
+	}
+
+	if got := compactionBoundary(msgs, 10); got != 0 {
+		t.Errorf("expected no compaction when keepCount exceeds length, got %d", got)
+	}
+}
+
+func TestCompactSession_SimpleSummaryExtractsFacts(t *testing.T) {
+	session := &EnhancedSession{Key: "test"}
+	session.AddMessage(agents.Message{Role: "user", Content: "please fix the build"})
+	for _, m := range toolTurn("t1", "exec", map[string]interface{}{"command": "go test ./..."}, "FAIL TestFoo\nexit 1", true) {
+		session.AddMessage(m)
+	}
+	for _, m := range toolTurn("t2", "write", map[string]interface{}{"path": "internal/foo.go"}, "Wrote 10 bytes", false) {
+		session.AddMessage(m)
+	}
+	session.AddMessage(agents.Message{Role: "assistant", Content: []agents.ContentBlock{
+		{Type: "text", Text: "Decision: keep the old API\nTODO: update the docs"},
+	}})
+	session.AddMessage(agents.Message{Role: "user", Content: "thanks"})
+	session.AddMessage(agents.Message{Role: "assistant", Content: "you're welcome"})
+
+	compactor := NewCompactor(nil, "")
+	result, err := compactor.CompactSession(context.Background(), session, 2)
+	if err != nil {
+		t.Fatalf("CompactSession: %v", err)
+	}
+	if result == nil || result.Generation != 1 || result.KeptCount != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	state := session.CompactionState()
+	if state == nil {
+		t.Fatal("expected compaction state")
+	}
+	if len(state.Files) != 1 || state.Files[0] != "internal/foo.go" {
+		t.Errorf("expected file to be extracted, got %v", state.Files)
+	}
+	if len(state.Decisions) != 1 || state.Decisions[0] != "keep the old API" {
+		t.Errorf("expected decision, got %v", state.Decisions)
+	}
+	if len(state.Todos) != 1 || state.Todos[0] != "update the docs" {
+		t.Errorf("expected todo, got %v", state.Todos)
+	}
+	if len(state.ToolOutcomes) != 2 || !strings.Contains(state.ToolOutcomes[0], "go test ./... → error: FAIL TestFoo") {
+		t.Errorf("unexpected tool outcomes: %v", state.ToolOutcomes)
+	}
+
+	msgs := session.GetMessagesWithCompaction()
+	if len(msgs) != 3 {
+		t.Fatalf("expected summary + 2 kept messages, got %d", len(msgs))
+	}
+	rendered, _ := msgs[0].Content.(string)
+	if !strings.Contains(rendered, "## Decisions") || !strings.Contains(rendered, "- [ ] update the docs") {
+		t.Errorf("rendered summary missing sections: %s", rendered)
+	}
+}
+
+func TestCompactSession_MultipleGenerations(t *testing.T) {
+	provider := &fakeProvider{content: "```json\n{\"summary\": \"Working on the parser.\", \"decisions\": [\"use recursive descent\"], \"todos\": [\"add tests\"]}\n```"}
+	compactor := NewCompactor(provider, "cheap")
+
+	session := &EnhancedSession{Key: "gen"}
+	for i := 0; i < 6; i++ {
+		session.AddMessage(agents.Message{Role: "user", Content: "message"})
+	}
+	if _, err := compactor.CompactSession(context.Background(), session, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		session.AddMessage(agents.Message{Role: "user", Content: "more"})
+	}
+	result, err := compactor.CompactSession(context.Background(), session, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Generation != 2 {
+		t.Errorf("expected generation 2, got %d", result.Generation)
+	}
+
+	state := session.CompactionState()
+	if state.Summary != "Working on the parser." {
+		t.Errorf("LLM summary should replace the rolling summary, got %q", state.Summary)
+	}
+	if len(state.History) != 2 {
+		t.Errorf("expected 2 history entries, got %d", len(state.History))
+	}
+}
+
+func TestCompactSession_LLMFailureFallsBack(t *testing.T) {
+	compactor := NewCompactor(&fakeProvider{err: errors.New("boom")}, "cheap")
+	session := &EnhancedSession{Key: "fallback"}
+	for i := 0; i < 4; i++ {
+		session.AddMessage(agents.Message{Role: "user", Content: "hello"})
+	}
+	if _, err := compactor.CompactSession(context.Background(), session, 1); err != nil {
+		t.Fatal(err)
+	}
+	if state := session.CompactionState(); state == nil || !strings.Contains(state.Summary, "Conversation overview") {
+		t.Errorf("expected simple summary fallback, got %+v", state)
+	}
+}
+
+func TestCompactSession_FlushToMemory(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	compactor := NewCompactor(nil, "")
+	compactor.SetMemoryFlush(paths)
+
+	session := &EnhancedSession{Key: "main"}
+	session.AddMessage(agents.Message{Role: "assistant", Content: "Decision: ship on Friday"})
+	session.AddMessage(agents.Message{Role: "user", Content: "ok"})
+	session.AddMessage(agents.Message{Role: "assistant", Content: "noted"})
+
+	if _, err := compactor.CompactSession(context.Background(), session, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(paths.MemoryFile(time.Now().Format("2006-01-02")))
+	if err != nil {
+		t.Fatalf("memory file not written: %v", err)
+	}
+	if !strings.Contains(string(data), "Session main") || !strings.Contains(string(data), "- ship on Friday") {
+		t.Errorf("unexpected memory content: %s", data)
+	}
+}
+
+// messagesProvider 记录最近一次请求的消息
+type messagesProvider struct {
+	streamingProvider
+	messages []agents.Message
+}
+
+func (p *messagesProvider) ChatStream(ctx context.Context, req *agents.ChatRequest) (<-chan agents.StreamEvent, error) {
+	p.messages = req.Messages
+	return p.streamingProvider.ChatStream(ctx, req)
+}
+
+func TestAutoCompact(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	cfg := AutoCompactionConfig{Enabled: true, Threshold: 6, KeepCount: 1, UseSimpleSummary: true, FlushToMemory: true}
+	provider := &messagesProvider{streamingProvider: streamingProvider{fakeProvider{content: "ok"}}}
+
+	session := &EnhancedSession{Key: "main"}
+	session.AddMessage(agents.Message{Role: "assistant", Content: "Decision: ship on Friday"})
+	for i := 0; i < 4; i++ {
+		session.AddMessage(agents.Message{Role: "user", Content: "hello"})
+	}
+	if result, err := AutoCompact(context.Background(), cfg, provider, "model", paths, session); err != nil || result != nil {
+		t.Fatalf("session below threshold should not be compacted: %+v, %v", result, err)
+	}
+
+	session.AddMessage(agents.Message{Role: "assistant", Content: "hi"})
+	result, err := AutoCompact(context.Background(), cfg, provider, "model", paths, session)
+	if err != nil || result == nil || result.Generation != 1 {
+		t.Fatalf("expected first compaction, got %+v, %v", result, err)
+	}
+	if _, err := os.Stat(paths.MemoryFile(time.Now().Format("2006-01-02"))); err != nil {
+		t.Errorf("facts not flushed to memory: %v", err)
+	}
+
+	// 运行时压缩摘要作为历史发送给模型
+	run := NewLoopAgentRunner(provider, NewToolRegistry(), "system", "model")
+	if _, err := run(context.Background(), session, "what did we decide?"); err != nil {
+		t.Fatal(err)
+	}
+	if len(provider.messages) == 0 {
+		t.Fatal("no messages sent")
+	}
+	if text, _ := provider.messages[0].Content.(string); !strings.Contains(text, "ship on Friday") {
+		t.Errorf("expected compaction summary first, got %q", text)
+	}
+	if msgs := session.GetMessages(); len(msgs) != 3 {
+		t.Errorf("expected kept message plus the new turn, got %d messages", len(msgs))
+	}
+}
@@ -45,8 +45,10 @@ type EnhancedSession struct {
 	messages []agents.Message
 	
 	// Compaction 状态
-	compactedSummary string
-	compactedAt      time.Time
+	compaction *CompactionState
+	
+	systemEvents []string // 待注入的系统事件 (如定时任务)，随下一轮对话发送
+	turns        int
 	
 	mu sync.RWMutex
 }
@@ -75,6 +77,9 @@ type EnhancedManager struct {
 	compactionThreshold int // 触发压缩的消息数量
 	compactionKeep      int // 压缩后保留的最近消息数
 	
+	// 会话结束 (重置/删除) 时的回调，例如提取事实写入记忆
+	onSessionEnd SessionEndHook
+	
 	ctx    context.Context
 	cancel context.CancelFunc
 }
@@ -86,8 +91,12 @@ type ManagerConfig struct {
 	SaveInterval        time.Duration
 	CompactionThreshold int
 	CompactionKeep      int
+	OnSessionEnd        SessionEndHook
 }
 
+// SessionEndHook 会话结束回调，收到的是结束前的消息副本
+type SessionEndHook func(key string, messages []agents.Message)
+
 // NewEnhancedManager 创建增强的会话管理器
 func NewEnhancedManager(cfg ManagerConfig) *EnhancedManager {
 	if cfg.DataDir == "" {
@@ -113,6 +122,7 @@ func NewEnhancedManager(cfg ManagerConfig) *EnhancedManager {
 		saveInterval:        cfg.SaveInterval,
 		compactionThreshold: cfg.CompactionThreshold,
 		compactionKeep:      cfg.CompactionKeep,
+		onSessionEnd:        cfg.OnSessionEnd,
 		ctx:                 ctx,
 		cancel:              cancel,
 	}
@@ -265,13 +275,15 @@ type SessionFilter struct {
 // Delete 删除会话
 func (m *EnhancedManager) Delete(key string) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	
 	if key == "main" {
+		m.mu.Unlock()
 		return fmt.Errorf("cannot delete main session")
 	}
 	
-	if _, ok := m.sessions[key]; !ok {
+	session, ok := m.sessions[key]
+	if !ok {
+		m.mu.Unlock()
 		return fmt.Errorf("session not found: %s", key)
 	}
 	
@@ -282,10 +294,32 @@ func (m *EnhancedManager) Delete(key string) error {
 		transcriptPath := filepath.Join(m.dataDir, key+".json")
 		os.Remove(transcriptPath)
 	}
+	m.mu.Unlock()
+	
+	m.endSession(key, session.GetMessages())
+	return nil
+}
+
+// Reset 结束当前对话并清空会话消息 (保留会话本身)
+func (m *EnhancedManager) Reset(key string) error {
+	session, ok := m.Get(key)
+	if !ok {
+		return fmt.Errorf("session not found: %s", key)
+	}
 	
+	messages := session.GetMessages()
+	session.ClearMessages()
+	m.endSession(key, messages)
 	return nil
 }
 
+// endSession 触发会话结束回调 (在锁外同步调用)
+func (m *EnhancedManager) endSession(key string, messages []agents.Message) {
+	if m.onSessionEnd != nil && len(messages) > 0 {
+		m.onSessionEnd(key, messages)
+	}
+}
+
 // Close 关闭管理器
 func (m *EnhancedManager) Close() error {
 	m.cancel()
@@ -312,6 +346,30 @@ func (s *EnhancedSession) AddMessage(msg agents.Message) {
 	s.Usage.MessageCount++
 }
 
+// EnqueueSystemEvent 加入系统事件，在下一轮对话开始时注入
+func (s *EnhancedSession) EnqueueSystemEvent(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.systemEvents = append(s.systemEvents, text)
+}
+
+// DrainSystemEvents 取出并清空待注入的系统事件
+func (s *EnhancedSession) DrainSystemEvents() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.systemEvents
+	s.systemEvents = nil
+	return events
+}
+
+// NextTurn 开始新的一轮对话，返回轮次 (从 1 开始)
+func (s *EnhancedSession) NextTurn() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turns++
+	return s.turns
+}
+
 // GetMessages 获取消息
 func (s *EnhancedSession) GetMessages() []agents.Message {
 	s.mu.RLock()
@@ -327,28 +385,45 @@ func (s *EnhancedSession) GetMessagesWithCompaction() []agents.Message {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
-	if s.compactedSummary == "" {
-		return s.GetMessages()
-	}
-	
-	// 在消息前添加压缩摘要
-	summaryMsg := agents.Message{
-		Role:    "user",
-		Content: fmt.Sprintf("[Previous conversation summary]\n%s\n[End of summary - continue from here]", s.compactedSummary),
-	}
-	
 	msgs := make([]agents.Message, 0, len(s.messages)+1)
-	msgs = append(msgs, summaryMsg)
+	if s.compaction != nil {
+		// 在消息前添加压缩摘要
+		msgs = append(msgs, agents.Message{
+			Role:    "user",
+			Content: s.compaction.Render(),
+		})
+	}
 	msgs = append(msgs, s.messages...)
 	return msgs
 }
 
+// CompactionState 获取当前压缩状态 (副本)
+func (s *EnhancedSession) CompactionState() *CompactionState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	
+	if s.compaction == nil {
+		return nil
+	}
+	state := *s.compaction
+	return &state
+}
+
 // ClearMessages 清空消息
 func (s *EnhancedSession) ClearMessages() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.messages = nil
-	s.compactedSummary = ""
+	s.compaction = nil
+}
+
+// TruncateMessages 只保留前 n 条消息 (如丢弃无内容的心跳轮次)
+func (s *EnhancedSession) TruncateMessages(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n >= 0 && n < len(s.messages) {
+		s.messages = s.messages[:n]
+	}
 }
 
 // UpdateUsage 更新使用量
@@ -406,40 +481,59 @@ func (s *EnhancedSession) NeedsCompaction(threshold int) bool {
 }
 
 // Compact 压缩会话历史
-func (s *EnhancedSession) Compact(summaryFunc func([]agents.Message) (string, error), keepCount int) error {
+//
+// 分界点会前移以保证 tool_use 和对应的 tool_result 留在同一侧；
+// summarize 接收上一代压缩状态，返回合并后的新状态。
+func (s *EnhancedSession) Compact(summarize Summarizer, keepCount int) (*CompactionResult, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	if len(s.messages) <= keepCount {
-		return nil // 不需要压缩
+	split := compactionBoundary(s.messages, keepCount)
+	if split == 0 {
+		return nil, nil // 不需要压缩
 	}
 	
 	// 分离要压缩的消息和要保留的消息
-	toCompact := s.messages[:len(s.messages)-keepCount]
-	toKeep := s.messages[len(s.messages)-keepCount:]
+	toCompact := s.messages[:split]
+	toKeep := s.messages[split:]
 	
-	// 生成摘要
-	summary, err := summaryFunc(toCompact)
+	// 生成新一代摘要
+	state, err := summarize(s.compaction, toCompact)
 	if err != nil {
-		return fmt.Errorf("generate summary: %w", err)
+		return nil, fmt.Errorf("generate summary: %w", err)
 	}
 	
-	// 如果已有摘要，合并
-	if s.compactedSummary != "" {
-		summary = s.compactedSummary + "\n\n" + summary
+	generation := 1
+	if s.compaction != nil {
+		generation = s.compaction.Generation + 1
 	}
+	state.Generation = generation
+	state.CompactedAt = time.Now()
+	state.History = append(append([]CompactionGeneration(nil), state.History...), CompactionGeneration{
+		Generation:     generation,
+		CompactedAt:    state.CompactedAt,
+		CompactedCount: len(toCompact),
+		SummaryLength:  len(state.Summary),
+	})
 	
-	s.compactedSummary = summary
-	s.compactedAt = time.Now()
-	s.messages = toKeep
+	s.compaction = state
+	s.messages = append([]agents.Message(nil), toKeep...)
 	
 	log.Info().
 		Str("session", s.Key).
 		Int("compacted", len(toCompact)).
 		Int("kept", len(toKeep)).
+		Int("generation", generation).
 		Msg("Session compacted")
 	
-	return nil
+	return &CompactionResult{
+		SessionKey:     s.Key,
+		CompactedCount: len(toCompact),
+		KeptCount:      len(toKeep),
+		SummaryLength:  len(state.Summary),
+		Generation:     generation,
+		TokensSaved:    EstimateTokens(toCompact),
+	}, nil
 }
 
 // ============================================================================
@@ -450,7 +544,9 @@ func (s *EnhancedSession) Compact(summaryFunc func([]agents.Message) (string, er
 type Transcript struct {
 	Session  *EnhancedSession `json:"session"`
 	Messages []agents.Message `json:"messages"`
-	Summary  string           `json:"summary,omitempty"`
+	Summary  string           `json:"summary,omitempty"` // 旧版纯文本摘要
+	
+	Compaction *CompactionState `json:"compaction,omitempty"`
 }
 
 // loadSessions 加载持久化的会话
@@ -486,7 +582,11 @@ func (m *EnhancedManager) loadSessions() {
 		
 		s := transcript.Session
 		s.messages = transcript.Messages
-		s.compactedSummary = transcript.Summary
+		s.compaction = transcript.Compaction
+		if s.compaction == nil && transcript.Summary != "" {
+			// 兼容旧版纯文本摘要
+			s.compaction = &CompactionState{Summary: transcript.Summary, Generation: 1}
+		}
 		
 		m.sessions[s.Key] = s
 		log.Debug().Str("session", s.Key).Msg("Loaded session")
@@ -516,8 +616,8 @@ func (m *EnhancedManager) saveSession(s *EnhancedSession) {
 	
 	transcript := Transcript{
 		Session:  s,
-		Messages: s.messages,
-		Summary:  s.compactedSummary,
+		Messages:   s.messages,
+		Compaction: s.compaction,
 	}
 	
 	data, err := json.MarshalIndent(transcript, "", "  ")
@@ -0,0 +1,142 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/config"
+	"github.com/z8n24/openclaw-go/internal/memory"
+)
+
+// FactCapture 会话结束时让模型提取值得长期记住的事实，写入每日日志和 MEMORY.md
+type FactCapture struct {
+	provider agents.Provider
+	model    string
+	store    *memory.Store
+}
+
+// CaptureResult 事实提取结果
+type CaptureResult struct {
+	SessionKey string              `json:"sessionKey"`
+	Daily      []string            `json:"daily,omitempty"`    // 写入每日日志的新条目
+	LongTerm   map[string][]string `json:"longTerm,omitempty"` // 写入 MEMORY.md 各分节的新条目
+	Skipped    int                 `json:"skipped,omitempty"`  // 已存在而跳过的条目数
+}
+
+// capturedFacts LLM 输出格式
+type capturedFacts struct {
+	Daily    []string            `json:"daily"`
+	LongTerm map[string][]string `json:"longTerm"`
+}
+
+// NewFactCapture 创建事实提取器
+func NewFactCapture(provider agents.Provider, model string, paths *config.Paths) *FactCapture {
+	return &FactCapture{
+		provider: provider,
+		model:    model,
+		store:    memory.NewStore(paths),
+	}
+}
+
+// Capture 从会话消息中提取事实并写入记忆，会话太短时不做任何事
+func (c *FactCapture) Capture(ctx context.Context, sessionKey string, messages []agents.Message) (*CaptureResult, error) {
+	result := &CaptureResult{SessionKey: sessionKey}
+	if c.provider == nil || !worthCapturing(messages) {
+		return result, nil
+	}
+
+	resp, err := c.provider.Chat(ctx, &agents.ChatRequest{
+		Model: c.model,
+		System: `You maintain an assistant's long-term memory about its user. Read the conversation and extract only durable facts worth remembering in future sessions: preferences, personal details the user shared, people, ongoing projects, decisions and commitments. Skip small talk, transient details and anything already obvious from the task itself.
+
+Respond with a single JSON object and nothing else:
+{
+  "daily": ["short notes about what happened in this session"],
+  "longTerm": {"Preferences": ["..."], "People": ["..."], "Projects": ["..."]}
+}
+
+Use short, self-contained sentences. Use empty lists when there is nothing worth keeping.`,
+		Messages: []agents.Message{
+			{Role: "user", Content: "Conversation:\n\n" + formatConversation(messages)},
+		},
+		MaxTokens: 1000,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fact capture failed: %w", err)
+	}
+
+	facts, ok := parseCapturedFacts(resp.Content)
+	if !ok {
+		return nil, fmt.Errorf("fact capture returned invalid JSON")
+	}
+
+	if len(facts.Daily) > 0 {
+		written, err := c.store.AppendDaily("", "Session "+sessionKey, facts.Daily)
+		if err != nil {
+			return nil, err
+		}
+		result.Daily = written.Added
+		result.Skipped += len(written.Skipped)
+	}
+
+	// 按分节名排序，保证写入顺序稳定
+	sections := make([]string, 0, len(facts.LongTerm))
+	for section, items := range facts.LongTerm {
+		if len(items) > 0 && strings.TrimSpace(section) != "" {
+			sections = append(sections, section)
+		}
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		written, err := c.store.UpdateSection(section, facts.LongTerm[section], memory.SectionAppend)
+		if err != nil {
+			return nil, err
+		}
+		if len(written.Added) > 0 {
+			if result.LongTerm == nil {
+				result.LongTerm = make(map[string][]string)
+			}
+			result.LongTerm[written.Section] = written.Added
+		}
+		result.Skipped += len(written.Skipped)
+	}
+
+	return result, nil
+}
+
+// worthCapturing 至少有一轮用户和助手的对话
+func worthCapturing(messages []agents.Message) bool {
+	var user, assistant bool
+	for _, msg := range messages {
+		if extractTextContent(msg) == "" {
+			continue
+		}
+		switch msg.Role {
+		case "user":
+			user = true
+		case "assistant":
+			assistant = true
+		}
+	}
+	return user && assistant
+}
+
+// parseCapturedFacts 从模型输出中解析 JSON (容忍代码块包裹)
+func parseCapturedFacts(content string) (*capturedFacts, bool) {
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, false
+	}
+
+	var facts capturedFacts
+	if err := json.Unmarshal([]byte(content[start:end+1]), &facts); err != nil {
+		return nil, false
+	}
+	return &facts, true
+}
@@ -0,0 +1,80 @@
+package sessions
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/config"
+)
+
+func TestFactCapture_WritesDailyAndLongTerm(t *testing.T) {
+	paths := config.NewPaths(t.TempDir())
+	provider := &fakeProvider{content: "```json\n" + `{"daily": ["Planned the Lisbon trip"], "longTerm": {"Preferences": ["Prefers window seats"], "People": []}}` + "\n```"}
+	capture := NewFactCapture(provider, "test", paths)
+
+	messages := []agents.Message{
+		{Role: "user", Content: "Book me a window seat to Lisbon"},
+		{Role: "assistant", Content: "Done, window seat booked."},
+	}
+	result, err := capture.Capture(context.Background(), "main", messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Daily) != 1 || len(result.LongTerm["Preferences"]) != 1 || len(result.LongTerm) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	daily, _ := os.ReadFile(paths.MemoryFile(time.Now().Format("2006-01-02")))
+	if !strings.Contains(string(daily), "## Session main\n- Planned the Lisbon trip") {
+		t.Errorf("unexpected journal: %s", daily)
+	}
+	longTerm, _ := os.ReadFile(paths.MEMORYFile())
+	if !strings.Contains(string(longTerm), "## Preferences\n- Prefers window seats") {
+		t.Errorf("unexpected MEMORY.md: %s", longTerm)
+	}
+
+	// 再次提取相同事实不会重复写入
+	result, err = capture.Capture(context.Background(), "main", messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Daily) != 0 || len(result.LongTerm) != 0 || result.Skipped != 2 {
+		t.Errorf("expected duplicates to be skipped: %+v", result)
+	}
+}
+
+func TestEnhancedManager_SessionEndHook(t *testing.T) {
+	var ended []string
+	m := NewEnhancedManager(ManagerConfig{
+		DataDir: t.TempDir(),
+		OnSessionEnd: func(key string, messages []agents.Message) {
+			ended = append(ended, key)
+		},
+	})
+	defer m.Close()
+
+	main, _ := m.Get("main")
+	main.AddMessage(agents.Message{Role: "user", Content: "hi"})
+	if err := m.Reset("main"); err != nil {
+		t.Fatal(err)
+	}
+	if len(main.GetMessages()) != 0 {
+		t.Error("expected messages cleared after reset")
+	}
+
+	// 空会话不触发回调
+	m.CreateIsolatedSession("main", "task", "")
+	for _, s := range m.List(SessionFilter{}) {
+		if s.Kind == SessionKindIsolated {
+			m.Delete(s.Key)
+		}
+	}
+
+	if len(ended) != 1 || ended[0] != "main" {
+		t.Errorf("unexpected hook calls: %v", ended)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/z8n24/openclaw-go/internal/agents"
+	"github.com/z8n24/openclaw-go/internal/agents/tools"
 )
 
 // Session 表示一个对话会话
@@ -22,8 +25,10 @@ type Session struct {
 	CreatedAt     time.Time `json:"createdAt"`
 	LastMessageAt time.Time `json:"lastMessageAt"`
 	
-	messages []agents.Message
-	mu       sync.RWMutex
+	messages     []agents.Message
+	systemEvents []string // 待注入的系统事件，随下一条用户消息发送
+	turns        int      // 已开始的对话轮次
+	mu           sync.RWMutex
 }
 
 // Manager 管理所有会话
@@ -97,8 +102,49 @@ func (s *Session) ClearMessages() {
 	s.messages = nil
 }
 
+// NextTurn 开始新的一轮对话，返回轮次 (从 1 开始)
+func (s *Session) NextTurn() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turns++
+	return s.turns
+}
+
+// EnqueueSystemEvent 加入系统事件 (如后台进程结束)，在下一轮对话开始时注入
+func (s *Session) EnqueueSystemEvent(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.systemEvents = append(s.systemEvents, text)
+}
+
+// DrainSystemEvents 取出并清空待注入的系统事件
+func (s *Session) DrainSystemEvents() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := s.systemEvents
+	s.systemEvents = nil
+	return events
+}
+
+// withSystemEvents 将系统事件以 "System:" 前缀放在用户消息之前
+func withSystemEvents(events []string, userMessage string) string {
+	if len(events) == 0 {
+		return userMessage
+	}
+	var sb strings.Builder
+	for _, event := range events {
+		sb.WriteString("System: ")
+		sb.WriteString(event)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(userMessage)
+	return sb.String()
+}
+
 // ToolRegistry 工具注册表
 type ToolRegistry struct {
+	mu      sync.RWMutex // 技能热加载时会在运行中增删工具
 	tools   map[string]ToolExecutor
 	schemas map[string]agents.Tool
 }
@@ -116,18 +162,32 @@ func NewToolRegistry() *ToolRegistry {
 
 // Register 注册工具
 func (r *ToolRegistry) Register(name string, executor ToolExecutor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.tools[name] = executor
 }
 
 // RegisterWithSchema 注册工具带 schema
 func (r *ToolRegistry) RegisterWithSchema(tool agents.Tool, executor ToolExecutor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.tools[tool.Name] = executor
 	r.schemas[tool.Name] = tool
 }
 
+// Unregister 移除工具
+func (r *ToolRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tools, name)
+	delete(r.schemas, name)
+}
+
 // Execute 执行工具
 func (r *ToolRegistry) Execute(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	r.mu.RLock()
 	executor, ok := r.tools[name]
+	r.mu.RUnlock()
 	if !ok {
 		return fmt.Sprintf("Tool not found: %s", name), nil
 	}
@@ -136,10 +196,13 @@ func (r *ToolRegistry) Execute(ctx context.Context, name string, args json.RawMe
 
 // GetSchemas 获取所有工具的 schema
 func (r *ToolRegistry) GetSchemas() []agents.Tool {
+	r.mu.RLock()
 	schemas := make([]agents.Tool, 0, len(r.schemas))
 	for _, s := range r.schemas {
 		schemas = append(schemas, s)
 	}
+	r.mu.RUnlock()
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
 	return schemas
 }
 
@@ -149,6 +212,7 @@ type AgentLoop struct {
 	tools    *ToolRegistry
 	session  *Session
 	system   string
+	systemFn func() string
 	model    string
 }
 
@@ -163,12 +227,25 @@ func NewAgentLoop(provider agents.Provider, tools *ToolRegistry, session *Sessio
 	}
 }
 
+// SetSystemPrompt 每轮开始时通过 fn 获取 system prompt (热加载后新的轮次使用新的 prompt)
+func (l *AgentLoop) SetSystemPrompt(fn func() string) {
+	l.systemFn = fn
+}
+
 // Run 运行 agent 循环
 func (l *AgentLoop) Run(ctx context.Context, userMessage string, onDelta func(string)) (*agents.ChatResponse, error) {
-	// 添加用户消息
+	// 工具按会话决定行为 (如 exec 沙箱、文件检查点)
+	ctx = tools.WithSession(ctx, tools.SessionContext{
+		Key:     l.session.Key,
+		Kind:    l.session.Kind,
+		Channel: l.session.Channel,
+		Turn:    l.session.NextTurn(),
+	})
+	
+	// 添加用户消息 (附带期间发生的系统事件)
 	l.session.AddMessage(agents.Message{
 		Role:    "user",
-		Content: userMessage,
+		Content: withSystemEvents(l.session.DrainSystemEvents(), userMessage),
 	})
 	
 	// 获取工具定义
@@ -226,12 +303,24 @@ func (l *AgentLoop) Run(ctx context.Context, userMessage string, onDelta func(st
 		},
 	}
 	
+	system := l.system
+	if l.systemFn != nil {
+		system = l.systemFn()
+	}
+	
+	// 追加带 schema 注册的其他工具
+	for _, schema := range l.tools.GetSchemas() {
+		if !hasTool(toolDefs, schema.Name) {
+			toolDefs = append(toolDefs, schema)
+		}
+	}
+	
 	maxIterations := 20
 	for i := 0; i < maxIterations; i++ {
 		// 构建请求
 		req := &agents.ChatRequest{
 			Model:     l.model,
-			System:    l.system,
+			System:    system,
 			Messages:  l.session.GetMessages(),
 			Tools:     toolDefs,
 			MaxTokens: 16384,
@@ -332,3 +421,13 @@ func (l *AgentLoop) Run(ctx context.Context, userMessage string, onDelta func(st
 	
 	return nil, fmt.Errorf("max iterations reached")
 }
+
+// hasTool 工具列表中是否已有同名工具
+func hasTool(defs []agents.Tool, name string) bool {
+	for _, def := range defs {
+		if def.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package sessions
+
+import (
+	"testing"
+)
+
+func TestSession_SystemEvents(t *testing.T) {
+	s := NewManager().GetOrCreate("telegram:1", "telegram", "")
+	s.EnqueueSystemEvent("Background process session-1 (make) exited with code 0.")
+	s.EnqueueSystemEvent("Background process session-2 (npm test) exited with code 1.")
+
+	events := s.DrainSystemEvents()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+	if again := s.DrainSystemEvents(); len(again) != 0 {
+		t.Errorf("Expected events to be drained, got %v", again)
+	}
+
+	got := withSystemEvents(events, "what happened?")
+	want := "System: Background process session-1 (make) exited with code 0.\n" +
+		"System: Background process session-2 (npm test) exited with code 1.\n" +
+		"\nwhat happened?"
+	if got != want {
+		t.Errorf("Unexpected message:\n%q\nwant:\n%q", got, want)
+	}
+	if withSystemEvents(nil, "hi") != "hi" {
+		t.Errorf("Expected message unchanged without events")
+	}
+}
@@ -209,7 +209,8 @@ func (m *ToolsSessionManager) GetSessionStatus(sessionKey string) (map[string]in
 			"toolCallCount": session.Usage.ToolCallCount,
 		},
 		"messageCount":    len(session.messages),
-		"hasCompaction":   session.compactedSummary != "",
+		"hasCompaction":   session.compaction != nil,
+		"compactionGeneration": compactionGeneration(session.compaction),
 	}, nil
 }
 
@@ -259,6 +260,13 @@ func extractTextContent(msg agents.Message) string {
 	return ""
 }
 
+func compactionGeneration(state *CompactionState) int {
+	if state == nil {
+		return 0
+	}
+	return state.Generation
+}
+
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
@@ -0,0 +1,270 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// 安装方式
+const (
+	InstallGo       = "go"       // go install <package>，安装到 <skill>/bin
+	InstallDownload = "download" // 下载 url (校验 sha256) 到 <skill>/bin，支持 .tar.gz
+	InstallBrew     = "brew"     // 以下只给出提示，需要用户手动执行
+	InstallApt      = "apt"
+	InstallNpm      = "npm"
+	InstallPip      = "pip"
+)
+
+// 安装步骤状态
+const (
+	InstallRunning = "running"
+	InstallOK      = "ok"
+	InstallSkipped = "skipped" // 提供的可执行文件已存在
+	InstallManual  = "manual"  // 需要用户手动执行 Message 中的命令
+	InstallFailed  = "error"
+)
+
+// maxDownloadSize 下载文件的大小上限
+const maxDownloadSize = 200 << 20
+
+// InstallSpec 依赖的安装方式 (SKILL.md front-matter 中的 install)
+type InstallSpec struct {
+	Kind    string   `yaml:"kind" json:"kind"`
+	Package string   `yaml:"package" json:"package,omitempty"` // go/brew/apt/npm/pip 的包名
+	URL     string   `yaml:"url" json:"url,omitempty"`
+	SHA256  string   `yaml:"sha256" json:"sha256,omitempty"`
+	Bins    []string `yaml:"bins" json:"bins,omitempty"` // 安装后提供的可执行文件
+	OS      string   `yaml:"os" json:"os,omitempty"`     // 只在指定系统上使用
+	Arch    string   `yaml:"arch" json:"arch,omitempty"`
+	Label   string   `yaml:"label" json:"label,omitempty"`
+}
+
+// InstallProgress 依赖安装进度
+type InstallProgress struct {
+	Skill   string `json:"skill"`
+	Step    int    `json:"step"`
+	Total   int    `json:"total"`
+	Kind    string `json:"kind"`
+	Label   string `json:"label"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// InstallDeps 依次执行适用于当前系统的安装方式，遇到错误时停止；progress 可为空
+func InstallDeps(ctx context.Context, skill *Skill, progress func(InstallProgress)) ([]InstallProgress, error) {
+	var specs []InstallSpec
+	for _, spec := range skill.Install {
+		if (spec.OS == "" || spec.OS == runtime.GOOS) && (spec.Arch == "" || spec.Arch == runtime.GOARCH) {
+			specs = append(specs, spec)
+		}
+	}
+
+	results := make([]InstallProgress, 0, len(specs))
+	for i, spec := range specs {
+		p := InstallProgress{Skill: skill.ID, Step: i + 1, Total: len(specs), Kind: spec.Kind, Label: spec.label()}
+		report := func(status, message string) {
+			p.Status, p.Message = status, message
+			if progress != nil {
+				progress(p)
+			}
+		}
+
+		if len(spec.Bins) > 0 && skill.hasBins(spec.Bins) {
+			report(InstallSkipped, "already installed")
+			results = append(results, p)
+			continue
+		}
+		report(InstallRunning, "")
+		status, message, err := skill.runInstall(ctx, spec)
+		if err != nil {
+			report(InstallFailed, err.Error())
+			return append(results, p), fmt.Errorf("install %s: %w", p.Label, err)
+		}
+		report(status, message)
+		results = append(results, p)
+	}
+	return results, nil
+}
+
+func (spec InstallSpec) label() string {
+	switch {
+	case spec.Label != "":
+		return spec.Label
+	case spec.Package != "":
+		return spec.Kind + " " + spec.Package
+	default:
+		return spec.Kind + " " + spec.URL
+	}
+}
+
+func (s *Skill) hasBins(bins []string) bool {
+	for _, bin := range bins {
+		if _, ok := s.FindBinary(bin); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Skill) runInstall(ctx context.Context, spec InstallSpec) (string, string, error) {
+	switch spec.Kind {
+	case InstallGo:
+		if spec.Package == "" {
+			return "", "", fmt.Errorf("package is required")
+		}
+		pkg := spec.Package
+		if !strings.Contains(pkg, "@") {
+			pkg += "@latest"
+		}
+		cmd := exec.CommandContext(ctx, "go", "install", pkg)
+		cmd.Env = append(os.Environ(), "GOBIN="+filepath.Join(s.Path, "bin"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return "", "", fmt.Errorf("go install: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		return InstallOK, "installed to " + filepath.Join(s.Path, "bin"), nil
+	case InstallDownload:
+		if err := s.download(ctx, spec); err != nil {
+			return "", "", err
+		}
+		return InstallOK, "installed to " + filepath.Join(s.Path, "bin"), nil
+	case InstallBrew, InstallApt, InstallNpm, InstallPip:
+		if spec.Package == "" {
+			return "", "", fmt.Errorf("package is required")
+		}
+		return InstallManual, "run: " + packageManagerHint(spec), nil
+	default:
+		return "", "", fmt.Errorf("unknown install kind %q", spec.Kind)
+	}
+}
+
+// packageManagerHint 返回手动安装的命令
+func packageManagerHint(spec InstallSpec) string {
+	switch spec.Kind {
+	case InstallBrew:
+		return "brew install " + spec.Package
+	case InstallApt:
+		return "sudo apt-get install -y " + spec.Package
+	case InstallNpm:
+		return "npm install -g " + spec.Package
+	default:
+		return "pip install " + spec.Package
+	}
+}
+
+// download 下载并校验 sha256，写入 <skill>/bin；.tar.gz 只解出 bins 中列出的文件
+func (s *Skill) download(ctx context.Context, spec InstallSpec) error {
+	if spec.URL == "" || spec.SHA256 == "" {
+		return fmt.Errorf("url and sha256 are required")
+	}
+	if len(spec.Bins) == 0 {
+		return fmt.Errorf("bins is required")
+	}
+
+	data, err := fetchVerified(ctx, spec.URL, spec.SHA256)
+	if err != nil {
+		return err
+	}
+
+	binDir := filepath.Join(s.Path, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return err
+	}
+	if strings.HasSuffix(spec.URL, ".tar.gz") || strings.HasSuffix(spec.URL, ".tgz") {
+		return extractBins(data, spec.Bins, binDir)
+	}
+	return writeExecutable(filepath.Join(binDir, filepath.Base(spec.Bins[0])), data)
+}
+
+// fetchVerified 下载 url 并校验 sha256
+func fetchVerified(ctx context.Context, url, sha string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: HTTP %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("download %s: file too large", url)
+	}
+
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, strings.TrimSpace(sha)) {
+		return nil, fmt.Errorf("sha256 mismatch: got %s, want %s", got, sha)
+	}
+	return data, nil
+}
+
+// extractBins 从 tar.gz 中按文件名解出 bins
+func extractBins(data []byte, bins []string, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer gz.Close()
+
+	want := make(map[string]bool, len(bins))
+	for _, bin := range bins {
+		want[filepath.Base(bin)] = true
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		name := filepath.Base(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !want[name] {
+			continue
+		}
+		content, err := io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+		if err != nil {
+			return err
+		}
+		if err := writeExecutable(filepath.Join(dir, name), content); err != nil {
+			return err
+		}
+		delete(want, name)
+	}
+	if len(want) > 0 {
+		var missing []string
+		for name := range want {
+			missing = append(missing, name)
+		}
+		return fmt.Errorf("archive does not contain %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// writeExecutable 先写临时文件再重命名，避免留下不完整的可执行文件
+func writeExecutable(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
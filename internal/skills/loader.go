@@ -1,10 +1,10 @@
 package skills
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -15,16 +15,24 @@ import (
 
 // Loader 技能加载器
 type Loader struct {
-	skills     map[string]*Skill
-	skillDirs  []string
-	stateFile  string
-	mu         sync.RWMutex
+	skills      map[string]*Skill
+	skillDirs   []string
+	stateFile   string
+	lockFile    string
+	version     string
+	skillConfig map[string]map[string]string
+	pending     map[string]*UpdatePlan // PlanUpdate 下载、等待 ApplyUpdate 的版本
+	mu          sync.RWMutex
+	installMu   sync.Mutex // 串行化安装与更新 (下载期间不持有 mu)
 }
 
 // LoaderConfig 加载器配置
 type LoaderConfig struct {
-	SkillDirs []string // 技能目录列表
-	StateFile string   // 状态文件路径
+	SkillDirs   []string                     // 技能目录列表
+	StateFile   string                       // 状态文件路径
+	LockFile    string                       // 锁文件路径，默认 <第一个技能目录>/skills.lock.json
+	Version     string                       // 当前 openclaw 版本，用于检查 requires.minVersion
+	SkillConfig map[string]map[string]string // 按技能 ID 的用户配置 (skills.entries.<id>.config)
 }
 
 // NewLoader 创建技能加载器
@@ -41,9 +49,13 @@ func NewLoader(cfg LoaderConfig) *Loader {
 	}
 
 	l := &Loader{
-		skills:    make(map[string]*Skill),
-		skillDirs: cfg.SkillDirs,
-		stateFile: cfg.StateFile,
+		skills:      make(map[string]*Skill),
+		skillDirs:   cfg.SkillDirs,
+		stateFile:   cfg.StateFile,
+		lockFile:    cfg.LockFile,
+		version:     cfg.Version,
+		pending:     make(map[string]*UpdatePlan),
+		skillConfig: cfg.SkillConfig,
 	}
 
 	// 确保目录存在
@@ -83,11 +95,15 @@ func (l *Loader) scanDirectory(dir string) error {
 	}
 
 	for _, entry := range entries {
-		if !entry.IsDir() {
+		// .versions 等隐藏目录不是技能；符号链接 (版本化安装、本地安装) 指向目录时同样加载
+		if strings.HasPrefix(entry.Name(), ".") {
 			continue
 		}
-
 		skillPath := filepath.Join(dir, entry.Name())
+		if info, err := os.Stat(skillPath); err != nil || !info.IsDir() {
+			continue
+		}
+
 		skillFile := filepath.Join(skillPath, "SKILL.md")
 
 		// 检查 SKILL.md 是否存在
@@ -102,9 +118,14 @@ func (l *Loader) scanDirectory(dir string) error {
 			continue
 		}
 
-		// 恢复启用状态（从 state）
+		// 恢复启用状态与来源（从 state）
 		if existing, ok := l.skills[skill.ID]; ok {
 			skill.Enabled = existing.Enabled
+			skill.Source = existing.Source
+		}
+		l.applyConfig(skill)
+		if err := skill.Validate(); err != nil {
+			log.Warn().Err(err).Str("id", skill.ID).Msg("Skill definition has problems, run 'openclaw skills lint'")
 		}
 
 		l.skills[skill.ID] = skill
@@ -178,95 +199,36 @@ func (l *Loader) Disable(id string) error {
 	return nil
 }
 
-// Install 安装技能
+// Install 安装技能：GitHub (user/repo[@ref])、git+<url|路径>[@ref]、带 #sha256= 的 tarball URL 或本地目录。
+// 远程来源安装到版本目录并记录在锁文件中
 func (l *Loader) Install(source string) (*Skill, error) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	spec, err := parseSource(source)
+	if err != nil {
+		return nil, err
+	}
 
+	l.installMu.Lock()
 	var skill *Skill
-	var err error
-
-	// 判断来源类型
-	if strings.HasPrefix(source, "github.com/") || strings.HasPrefix(source, "https://github.com/") {
-		skill, err = l.installFromGitHub(source)
-	} else if strings.HasPrefix(source, "/") || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "~") {
+	if spec.kind == SourceLocal {
 		skill, err = l.installFromLocal(source)
 	} else {
-		// 假设是 GitHub 短格式 (user/repo)
-		skill, err = l.installFromGitHub("github.com/" + source)
+		skill, err = l.installVersioned(source, spec)
 	}
-
+	l.installMu.Unlock()
 	if err != nil {
 		return nil, err
 	}
 
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if old, ok := l.skills[skill.ID]; ok {
+		skill.Enabled = old.Enabled
+	}
+	l.applyConfig(skill)
 	l.skills[skill.ID] = skill
 	l.saveState()
 
-	log.Info().Str("id", skill.ID).Str("name", skill.Name).Msg("Installed skill")
-	return skill, nil
-}
-
-// installFromGitHub 从 GitHub 安装
-func (l *Loader) installFromGitHub(source string) (*Skill, error) {
-	// 解析 URL
-	source = strings.TrimPrefix(source, "https://")
-	parts := strings.Split(source, "/")
-	if len(parts) < 3 {
-		return nil, fmt.Errorf("invalid github url: %s", source)
-	}
-
-	user := parts[1]
-	repo := parts[2]
-	ref := "main"
-
-	// 检查是否指定了 ref (@branch 或 #branch)
-	if idx := strings.Index(repo, "@"); idx != -1 {
-		ref = repo[idx+1:]
-		repo = repo[:idx]
-	} else if idx := strings.Index(repo, "#"); idx != -1 {
-		ref = repo[idx+1:]
-		repo = repo[:idx]
-	}
-
-	// 确定安装目录
-	destDir := filepath.Join(l.skillDirs[0], repo)
-
-	// 如果目录已存在，先删除
-	if _, err := os.Stat(destDir); err == nil {
-		os.RemoveAll(destDir)
-	}
-
-	// git clone
-	cloneURL := fmt.Sprintf("https://github.com/%s/%s.git", user, repo)
-	cmd := exec.Command("git", "clone", "--depth", "1", "-b", ref, cloneURL, destDir)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git clone failed: %w", err)
-	}
-
-	// 解析 SKILL.md
-	skillFile := filepath.Join(destDir, "SKILL.md")
-	skill, err := ParseSKILLMD(skillFile)
-	if err != nil {
-		os.RemoveAll(destDir)
-		return nil, fmt.Errorf("parse skill: %w", err)
-	}
-
-	skill.Source = SkillSource{
-		Kind: "github",
-		URL:  fmt.Sprintf("https://github.com/%s/%s", user, repo),
-		Ref:  ref,
-	}
-
-	// 获取 commit hash
-	cmd = exec.Command("git", "-C", destDir, "rev-parse", "HEAD")
-	if out, err := cmd.Output(); err == nil {
-		skill.Source.Commit = strings.TrimSpace(string(out))
-	}
-
+	log.Info().Str("id", skill.ID).Str("name", skill.Name).Str("commit", skill.Source.Commit).Msg("Installed skill")
 	return skill, nil
 }
 
@@ -307,15 +269,22 @@ func (l *Loader) installFromLocal(source string) (*Skill, error) {
 	}
 
 	skill.Source = SkillSource{
-		Kind: "local",
+		Kind: SourceLocal,
 		URL:  absPath,
 	}
 
+	lock := l.readLock()
+	lock.Skills[skill.ID] = LockEntry{ID: skill.ID, Source: source, Kind: SourceLocal, URL: absPath, Version: skill.Version, Path: absPath, InstalledAt: time.Now()}
+	if err := l.writeLock(lock); err != nil {
+		return nil, err
+	}
 	return skill, nil
 }
 
-// Uninstall 卸载技能
+// Uninstall 卸载技能；本地安装只删除符号链接，不删除源目录
 func (l *Loader) Uninstall(id string) error {
+	l.installMu.Lock()
+	defer l.installMu.Unlock()
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
@@ -324,62 +293,45 @@ func (l *Loader) Uninstall(id string) error {
 		return fmt.Errorf("skill not found: %s", id)
 	}
 
-	// 删除目录
-	if err := os.RemoveAll(skill.Path); err != nil {
-		return fmt.Errorf("remove skill directory: %w", err)
+	link := filepath.Join(l.skillDirs[0], filepath.Base(id))
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(link); err != nil {
+			return fmt.Errorf("remove skill link: %w", err)
+		}
+	} else if skill.Path != "" && skill.Source.Kind != SourceLocal {
+		if err := os.RemoveAll(skill.Path); err != nil {
+			return fmt.Errorf("remove skill directory: %w", err)
+		}
+	}
+	if err := l.removeLocked(id); err != nil {
+		return err
 	}
 
 	delete(l.skills, id)
+	delete(l.pending, id)
 	l.saveState()
 
 	log.Info().Str("id", id).Msg("Uninstalled skill")
 	return nil
 }
 
-// Update 更新技能
+// Update 下载并直接应用新版本 (不预览)；固定了 ref 的技能保持在该 ref
 func (l *Loader) Update(id string) error {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-
-	skill, ok := l.skills[id]
-	if !ok {
-		return fmt.Errorf("skill not found: %s", id)
-	}
-
-	if skill.Source.Kind != "github" {
-		return fmt.Errorf("can only update github skills")
-	}
-
-	// git pull
-	cmd := exec.Command("git", "-C", skill.Path, "pull", "--ff-only")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git pull failed: %w", err)
-	}
-
-	// 重新解析
-	skillFile := filepath.Join(skill.Path, "SKILL.md")
-	newSkill, err := ParseSKILLMD(skillFile)
+	plan, err := l.PlanUpdate(id, UpdateOptions{})
 	if err != nil {
-		return fmt.Errorf("parse updated skill: %w", err)
+		return err
 	}
-
-	// 保留原有状态
-	newSkill.Source = skill.Source
-	newSkill.Enabled = skill.Enabled
-
-	// 更新 commit
-	cmd = exec.Command("git", "-C", skill.Path, "rev-parse", "HEAD")
-	if out, err := cmd.Output(); err == nil {
-		newSkill.Source.Commit = strings.TrimSpace(string(out))
+	if plan.UpToDate {
+		l.mu.Lock()
+		delete(l.pending, id)
+		l.mu.Unlock()
+		log.Info().Str("id", id).Msg("Skill is up to date")
+		return nil
 	}
-
-	l.skills[id] = newSkill
-	l.saveState()
-
-	log.Info().Str("id", id).Msg("Updated skill")
+	if _, err := l.ApplyUpdate(id); err != nil {
+		return err
+	}
+	log.Info().Str("id", id).Str("version", plan.ToVersion).Msg("Updated skill")
 	return nil
 }
 
@@ -405,10 +357,72 @@ func (l *Loader) GetBinaries() map[string]string {
 	return bins
 }
 
+// Eligibility 检查技能的依赖是否满足
+func (l *Loader) Eligibility(id string) (Eligibility, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	skill, ok := l.skills[id]
+	if !ok || skill.Path == "" {
+		return Eligibility{}, false
+	}
+	return skill.Check(l.checkOptions(id)), true
+}
+
+// ListEligible 列出已启用且依赖满足的技能
+func (l *Loader) ListEligible() []*Skill {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var result []*Skill
+	for _, skill := range l.skills {
+		if !skill.Enabled || skill.Path == "" {
+			continue
+		}
+		if e := skill.Check(l.checkOptions(skill.ID)); !e.Eligible {
+			log.Info().Str("id", skill.ID).Str("reason", e.String()).Msg("Skill not eligible")
+			continue
+		}
+		result = append(result, skill)
+	}
+	return result
+}
+
+// InstallDeps 执行技能声明的依赖安装方式
+func (l *Loader) InstallDeps(ctx context.Context, id string, progress func(InstallProgress)) ([]InstallProgress, error) {
+	skill, ok := l.Get(id)
+	if !ok || skill.Path == "" {
+		return nil, fmt.Errorf("skill not found: %s", id)
+	}
+	if len(skill.Install) == 0 {
+		return nil, fmt.Errorf("skill %s declares no install steps", id)
+	}
+	results, err := InstallDeps(ctx, skill, progress)
+	if err != nil {
+		return results, err
+	}
+	log.Info().Str("id", id).Int("steps", len(results)).Msg("Installed skill dependencies")
+	return results, nil
+}
+
+func (l *Loader) checkOptions(id string) CheckOptions {
+	return CheckOptions{Version: l.version, Config: l.skillConfig[id]}
+}
+
+// applyConfig 用户配置覆盖 SKILL.md 中的配置项
+func (l *Loader) applyConfig(skill *Skill) {
+	if len(l.skillConfig[skill.ID]) > 0 && skill.Config == nil {
+		skill.Config = make(map[string]string)
+	}
+	for key, value := range l.skillConfig[skill.ID] {
+		skill.Config[key] = value
+	}
+}
+
 // Status 获取加载器状态
 type Status struct {
 	TotalSkills   int    `json:"totalSkills"`
 	EnabledSkills int    `json:"enabledSkills"`
+	EligibleSkills int   `json:"eligibleSkills"`
 	TotalTools    int    `json:"totalTools"`
 	SkillDirs     []string `json:"skillDirs"`
 }
@@ -425,6 +439,9 @@ func (l *Loader) Status() Status {
 	for _, s := range l.skills {
 		if s.Enabled {
 			status.EnabledSkills++
+			if s.Path != "" && s.Check(l.checkOptions(s.ID)).Eligible {
+				status.EligibleSkills++
+			}
 		}
 		status.TotalTools += len(s.Tools)
 	}
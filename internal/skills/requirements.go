@@ -0,0 +1,166 @@
+package skills
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Requirements 技能运行所需的环境 (SKILL.md front-matter 中的 requires)
+type Requirements struct {
+	Bins       []string `yaml:"bins" json:"bins,omitempty"`     // 需要的可执行文件 (技能目录、bin/ 或 PATH 中)
+	Env        []string `yaml:"env" json:"env,omitempty"`       // 需要的环境变量
+	Config     []string `yaml:"config" json:"config,omitempty"` // 需要在 skills.entries.<id>.config 中提供的配置项
+	OS         []string `yaml:"os" json:"os,omitempty"`         // 支持的系统 (GOOS)，为空时不限
+	MinVersion string   `yaml:"minVersion" json:"minVersion,omitempty"`
+}
+
+// CheckOptions 资格检查的上下文
+type CheckOptions struct {
+	Version string            // 当前 openclaw 版本，为空或 "dev" 时不检查 minVersion
+	Config  map[string]string // 用户为技能提供的配置
+}
+
+// Eligibility 技能是否满足运行条件
+type Eligibility struct {
+	Eligible      bool     `json:"eligible"`
+	MissingBins   []string `json:"missingBins,omitempty"`
+	MissingEnv    []string `json:"missingEnv,omitempty"`
+	MissingConfig []string `json:"missingConfig,omitempty"`
+	Unsupported   string   `json:"unsupported,omitempty"` // 系统或版本不满足的原因
+}
+
+// Missing 以可读形式列出缺失项
+func (e Eligibility) Missing() []string {
+	var missing []string
+	missing = append(missing, e.MissingBins...)
+	for _, env := range e.MissingEnv {
+		missing = append(missing, "$"+env)
+	}
+	for _, key := range e.MissingConfig {
+		missing = append(missing, "config."+key)
+	}
+	return missing
+}
+
+// String 返回 "ok" 或 "missing: ffmpeg, $API_KEY" 之类的说明
+func (e Eligibility) String() string {
+	if e.Eligible {
+		return "ok"
+	}
+	var parts []string
+	if e.Unsupported != "" {
+		parts = append(parts, e.Unsupported)
+	}
+	if missing := e.Missing(); len(missing) > 0 {
+		parts = append(parts, "missing: "+strings.Join(missing, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Check 检查技能的依赖：requires 中的条件以及工具声明的 binary
+func (s *Skill) Check(opts CheckOptions) Eligibility {
+	var e Eligibility
+	req := s.Requires
+	if req == nil {
+		req = &Requirements{}
+	}
+
+	if len(req.OS) > 0 && !containsFold(req.OS, runtime.GOOS) {
+		e.Unsupported = fmt.Sprintf("requires os %s", strings.Join(req.OS, "/"))
+	}
+	if req.MinVersion != "" && opts.Version != "" && opts.Version != "dev" {
+		if compareVersions(opts.Version, req.MinVersion) < 0 {
+			reason := fmt.Sprintf("requires openclaw >= %s", req.MinVersion)
+			if e.Unsupported != "" {
+				reason = e.Unsupported + ", " + reason
+			}
+			e.Unsupported = reason
+		}
+	}
+
+	seen := make(map[string]bool)
+	bins := append([]string(nil), req.Bins...)
+	for _, tool := range s.Tools {
+		if tool.Binary != "" {
+			bins = append(bins, tool.Binary)
+		}
+	}
+	for _, bin := range bins {
+		if seen[bin] {
+			continue
+		}
+		seen[bin] = true
+		if _, ok := s.FindBinary(bin); !ok {
+			e.MissingBins = append(e.MissingBins, bin)
+		}
+	}
+	for _, env := range req.Env {
+		if os.Getenv(env) == "" {
+			e.MissingEnv = append(e.MissingEnv, env)
+		}
+	}
+	for _, key := range req.Config {
+		if opts.Config[key] == "" {
+			e.MissingConfig = append(e.MissingConfig, key)
+		}
+	}
+
+	e.Eligible = e.Unsupported == "" && len(e.Missing()) == 0
+	return e
+}
+
+// FindBinary 在技能目录、bin/ 子目录和 PATH 中查找可执行文件
+func (s *Skill) FindBinary(name string) (string, bool) {
+	if s.Path != "" {
+		for _, path := range []string{filepath.Join(s.Path, name), filepath.Join(s.Path, "bin", name)} {
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, true
+			}
+		}
+	}
+	if strings.ContainsRune(name, '/') {
+		return "", false
+	}
+	path, err := exec.LookPath(name)
+	return path, err == nil
+}
+
+// compareVersions 比较 "v1.2.3" 形式的版本号，忽略预发布后缀
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < 3; i++ {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) [3]int {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	for i, p := range strings.SplitN(v, ".", 3) {
+		parts[i], _ = strconv.Atoi(p)
+	}
+	return parts
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
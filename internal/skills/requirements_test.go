@@ -0,0 +1,194 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseSKILLMD_FrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	content := `---
+requires:
+  bins: [ffmpeg]
+  env: [VIDEO_API_KEY]
+  config: [api_key]
+  minVersion: 1.2.0
+install:
+  - kind: brew
+    package: ffmpeg
+    bins: [ffmpeg]
+---
+# Video Skill
+
+- **Version**: 0.1.0
+`
+	os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644)
+
+	skill, err := ParseSKILLMD(filepath.Join(dir, "SKILL.md"))
+	if err != nil {
+		t.Fatalf("ParseSKILLMD failed: %v", err)
+	}
+	if skill.Name != "Video Skill" || skill.Version != "0.1.0" {
+		t.Errorf("markdown after front-matter not parsed: %+v", skill)
+	}
+	if skill.Requires == nil || skill.Requires.Bins[0] != "ffmpeg" || skill.Requires.MinVersion != "1.2.0" {
+		t.Errorf("Requires = %+v", skill.Requires)
+	}
+	if len(skill.Install) != 1 || skill.Install[0].Kind != InstallBrew {
+		t.Errorf("Install = %+v", skill.Install)
+	}
+	if strings.Contains(skill.Guidance(), "requires:") {
+		t.Error("Guidance should not include front-matter")
+	}
+}
+
+func TestSkill_Check(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	os.WriteFile(filepath.Join(dir, "bin", "local-tool"), []byte("#!/bin/sh\n"), 0755)
+	t.Setenv("SKILL_TEST_PRESENT", "1")
+
+	skill := &Skill{
+		ID:   "demo",
+		Path: dir,
+		Requires: &Requirements{
+			Bins:       []string{"local-tool", "sh", "definitely-not-installed-xyz"},
+			Env:        []string{"SKILL_TEST_PRESENT", "SKILL_TEST_ABSENT"},
+			Config:     []string{"api_key"},
+			MinVersion: "1.2.0",
+		},
+		Tools: []ToolSpec{{Name: "run", Binary: "missing-tool-bin"}},
+	}
+
+	e := skill.Check(CheckOptions{Version: "v1.1.9"})
+	if e.Eligible {
+		t.Fatal("skill should not be eligible")
+	}
+	if strings.Join(e.MissingBins, ",") != "definitely-not-installed-xyz,missing-tool-bin" {
+		t.Errorf("MissingBins = %v", e.MissingBins)
+	}
+	if len(e.MissingEnv) != 1 || e.MissingEnv[0] != "SKILL_TEST_ABSENT" {
+		t.Errorf("MissingEnv = %v", e.MissingEnv)
+	}
+	if len(e.MissingConfig) != 1 || !strings.Contains(e.Unsupported, ">= 1.2.0") {
+		t.Errorf("unexpected eligibility: %+v", e)
+	}
+	if got := e.String(); !strings.Contains(got, "missing: definitely-not-installed-xyz") || !strings.Contains(got, "$SKILL_TEST_ABSENT") {
+		t.Errorf("String() = %q", got)
+	}
+
+	skill.Requires = &Requirements{Bins: []string{"local-tool"}, OS: []string{runtime.GOOS}, MinVersion: "1.2.0"}
+	skill.Tools = nil
+	if e := skill.Check(CheckOptions{Version: "dev"}); !e.Eligible {
+		t.Errorf("expected eligible, got %s", e)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"v1.10.0", "1.9.9", 1},
+		{"0.9.1-rc1", "0.9.2", -1},
+		{"2", "1.9", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestInstallDeps_Download(t *testing.T) {
+	binary := []byte("#!/bin/sh\necho hi\n")
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "pkg/archived-tool", Mode: 0755, Size: int64(len(binary)), Typeflag: tar.TypeReg})
+	tw.Write(binary)
+	tw.Close()
+	gz.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".tar.gz") {
+			w.Write(archive.Bytes())
+			return
+		}
+		w.Write(binary)
+	}))
+	defer srv.Close()
+
+	sum := func(b []byte) string {
+		h := sha256.Sum256(b)
+		return hex.EncodeToString(h[:])
+	}
+	skill := &Skill{ID: "demo", Path: t.TempDir(), Install: []InstallSpec{
+		{Kind: InstallDownload, URL: srv.URL + "/raw-tool", SHA256: sum(binary), Bins: []string{"raw-tool"}},
+		{Kind: InstallDownload, URL: srv.URL + "/tool.tar.gz", SHA256: sum(archive.Bytes()), Bins: []string{"archived-tool"}},
+		{Kind: InstallBrew, Package: "jq", OS: runtime.GOOS},
+		{Kind: InstallApt, Package: "never", OS: "plan9"},
+	}}
+
+	var events []InstallProgress
+	results, err := InstallDeps(context.Background(), skill, func(p InstallProgress) { events = append(events, p) })
+	if err != nil {
+		t.Fatalf("InstallDeps failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 applicable steps, got %d", len(results))
+	}
+	if results[0].Status != InstallOK || results[1].Status != InstallOK {
+		t.Errorf("downloads should succeed: %+v", results)
+	}
+	if results[2].Status != InstallManual || !strings.Contains(results[2].Message, "brew install jq") {
+		t.Errorf("brew step = %+v", results[2])
+	}
+	if len(events) != 6 || events[0].Status != InstallRunning {
+		t.Errorf("expected running+final events per step, got %+v", events)
+	}
+	for _, bin := range []string{"raw-tool", "archived-tool"} {
+		if _, ok := skill.FindBinary(bin); !ok {
+			t.Errorf("%s not installed", bin)
+		}
+	}
+
+	// 再次运行时已存在的可执行文件跳过
+	results, _ = InstallDeps(context.Background(), skill, nil)
+	if results[0].Status != InstallSkipped {
+		t.Errorf("expected skipped, got %+v", results[0])
+	}
+}
+
+func TestInstallDeps_ChecksumMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("tampered"))
+	}))
+	defer srv.Close()
+
+	skill := &Skill{ID: "demo", Path: t.TempDir(), Install: []InstallSpec{
+		{Kind: InstallDownload, URL: srv.URL + "/tool", SHA256: strings.Repeat("0", 64), Bins: []string{"tool"}},
+	}}
+	results, err := InstallDeps(context.Background(), skill, nil)
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	if results[0].Status != InstallFailed {
+		t.Errorf("status = %q", results[0].Status)
+	}
+	if _, ok := skill.FindBinary("tool"); ok {
+		t.Error("binary should not be written on checksum mismatch")
+	}
+}
@@ -2,12 +2,15 @@ package skills
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Skill 表示一个技能
@@ -20,21 +23,47 @@ type Skill struct {
 	Tools       []ToolSpec        `json:"tools,omitempty"`
 	Permissions []string          `json:"permissions,omitempty"`
 	Config      map[string]string `json:"config,omitempty"`
+	Requires    *Requirements     `json:"requires,omitempty"`
+	Install     []InstallSpec     `json:"install,omitempty"`
 	Path        string            `json:"path"`
 	Enabled     bool              `json:"enabled"`
 	LoadedAt    time.Time         `json:"loadedAt"`
 	Source      SkillSource       `json:"source"`
+
+	lines  map[string]int    // 字段在 SKILL.md 中的行号，见 Validate
+	issues []ValidationError // 解析时发现的问题 (无法识别的工具定义等)
 }
 
 // ToolSpec 工具定义
 type ToolSpec struct {
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Binary      string                 `json:"binary,omitempty"`
-	Command     string                 `json:"command,omitempty"`
-	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Name        string                 `yaml:"name" json:"name"`
+	Description string                 `yaml:"description" json:"description"`
+	Binary      string                 `yaml:"binary" json:"binary,omitempty"`
+	Command     string                 `yaml:"command" json:"command,omitempty"` // shell 命令，{param} 替换为参数值
+	Input       string                 `yaml:"input" json:"input,omitempty"`     // 参数传递方式："stdin" (JSON，默认) | "args" (--key=value)
+	Parameters  map[string]interface{} `yaml:"parameters" json:"parameters,omitempty"` // JSON schema
 }
 
+// 参数传递方式
+const (
+	InputStdin = "stdin"
+	InputArgs  = "args"
+)
+
+// 技能权限
+const (
+	PermissionNetwork    = "network"
+	PermissionFilesystem = "filesystem" // 在工作区内运行 (否则在技能目录内运行)
+	PermissionExec       = "exec"
+	PermissionEnv        = "env" // 继承完整环境变量 (否则只传递 PATH/HOME 等基本变量)
+	PermissionBrowser    = "browser"
+	PermissionCamera     = "camera"
+	PermissionLocation   = "location"
+)
+
+// maxGuidanceChars SKILL.md 注入 system prompt 的最大字符数
+const maxGuidanceChars = 8000
+
 // SkillSource 技能来源
 type SkillSource struct {
 	Kind   string `json:"kind"` // "local" | "github" | "builtin"
@@ -43,13 +72,35 @@ type SkillSource struct {
 	Commit string `json:"commit,omitempty"`
 }
 
-// ParseSKILLMD 解析 SKILL.md 文件
+// frontMatter SKILL.md 开头 "---" 之间的 YAML，其中的字段优先于 markdown 中的同名信息
+type frontMatter struct {
+	ID          string            `yaml:"id"`
+	Name        string            `yaml:"name"`
+	Description string            `yaml:"description"`
+	Version     string            `yaml:"version"`
+	Author      string            `yaml:"author"`
+	Tools       []ToolSpec        `yaml:"tools"`
+	Permissions []string          `yaml:"permissions"`
+	Config      map[string]string `yaml:"config"`
+	Requires    *Requirements     `yaml:"requires"`
+	Install     []InstallSpec     `yaml:"install"`
+}
+
+// frontMatterFields front-matter 支持的字段
+var frontMatterFields = map[string]bool{
+	"id": true, "name": true, "description": true, "version": true, "author": true,
+	"tools": true, "permissions": true, "config": true, "requires": true, "install": true,
+}
+
+// toolFields 工具定义支持的字段
+var toolFields = map[string]bool{"name": true, "description": true, "binary": true, "command": true, "input": true, "parameters": true}
+
+// ParseSKILLMD 解析 SKILL.md 文件：可选的 YAML front-matter 加上 markdown 格式的说明
 func ParseSKILLMD(path string) (*Skill, error) {
-	file, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("open skill file: %w", err)
 	}
-	defer file.Close()
 
 	skill := &Skill{
 		Path:     filepath.Dir(path),
@@ -57,22 +108,56 @@ func ParseSKILLMD(path string) (*Skill, error) {
 		LoadedAt: time.Now(),
 		Source:   SkillSource{Kind: "local"},
 		Config:   make(map[string]string),
+		lines:    make(map[string]int),
 	}
 
-	scanner := bufio.NewScanner(file)
+	front, body := splitFrontMatter(data)
+	bodyOffset := 0 // body 第一行之前的行数
+	if front != nil {
+		bodyOffset = bytes.Count(data[:len(data)-len(body)], []byte("\n"))
+	}
+
+	explicitID, err := skill.parseMarkdown(body, bodyOffset)
+	if err != nil {
+		return nil, err
+	}
+	if front != nil {
+		if err := skill.applyFrontMatter(path, front, explicitID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 验证必要字段
+	if skill.Name == "" {
+		return nil, fmt.Errorf("skill name not found in %s", path)
+	}
+	if skill.ID == "" {
+		skill.ID = sanitizeID(skill.Name)
+	}
+
+	return skill, nil
+}
+
+// parseMarkdown 解析旧格式：标题、**key**: value 列表和 ## Tools 下的 ### 工具定义。
+// offset 为 body 之前的行数，用于记录行号；返回 markdown 中是否显式指定了 id
+func (s *Skill) parseMarkdown(body []byte, offset int) (bool, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
 	var currentSection string
 	var currentTool *ToolSpec
 	var toolParamSection bool
+	explicitID := false
 	
 	// 正则表达式
 	headerRe := regexp.MustCompile(`^#+\s+(.+)`)
 	kvRe := regexp.MustCompile(`^\s*[-*]\s*\*\*([^*]+)\*\*:\s*(.+)`)
 	kvRe2 := regexp.MustCompile(`^\s*[-*]\s*([^:]+):\s*(.+)`)
-	toolHeaderRe := regexp.MustCompile(`^###\s+(\w+)`)
+	listItemRe := regexp.MustCompile(`^\s*[-*]\s+(.+)`)
+	paramRe := regexp.MustCompile("^\\s+[-*]\\s*`([^`]+)`\\s*(?:\\(([^)]*)\\))?\\s*:?\\s*(.*)$")
+	nestedItemRe := regexp.MustCompile(`^\s+[-*]\s+\S`)
 	codeBlockRe := regexp.MustCompile("^```")
 
 	inCodeBlock := false
-	lineNum := 0
+	lineNum := offset
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -93,9 +178,10 @@ func ParseSKILLMD(path string) (*Skill, error) {
 			headerLower := strings.ToLower(header)
 
 			// 顶级标题是技能名称
-			if strings.HasPrefix(line, "# ") && skill.Name == "" {
-				skill.Name = header
-				skill.ID = sanitizeID(header)
+			if strings.HasPrefix(line, "# ") && s.Name == "" {
+				s.Name = header
+				s.ID = sanitizeID(header)
+				s.lines["name"] = lineNum
 				continue
 			}
 
@@ -103,28 +189,65 @@ func ParseSKILLMD(path string) (*Skill, error) {
 			if strings.HasPrefix(line, "## ") {
 				currentSection = headerLower
 				toolParamSection = false
+				if currentSection == "permissions" {
+					s.lines["permissions"] = lineNum
+				}
 				continue
 			}
 
 			// 三级标题可能是工具定义
 			if strings.HasPrefix(line, "### ") {
 				if currentSection == "tools" || currentSection == "tool definitions" {
-					if m := toolHeaderRe.FindStringSubmatch(line); m != nil {
-						tool := ToolSpec{Name: m[1]}
-						skill.Tools = append(skill.Tools, tool)
-						currentTool = &skill.Tools[len(skill.Tools)-1]
+					currentTool = nil
+					toolParamSection = false
+					// 名称为标题的第一个词，或反引号中的全部内容
+					name := strings.Fields(header)[0]
+					if strings.HasPrefix(header, "`") {
+						name, _, _ = strings.Cut(header[1:], "`")
 					}
+					if !toolNameRe.MatchString(name) {
+						s.issue(lineNum, "invalid tool name %q (use letters, digits, '_' and '-')", name)
+						continue
+					}
+					s.Tools = append(s.Tools, ToolSpec{Name: name})
+					currentTool = &s.Tools[len(s.Tools)-1]
+					s.lines["tool:"+name] = lineNum
 				}
 				continue
 			}
 			continue
 		}
 
+		// 工具参数：- `name` (type, required): description
+		if toolParamSection && currentTool != nil {
+			if m := paramRe.FindStringSubmatch(line); m != nil {
+				addParameter(currentTool, m[1], m[2], m[3])
+				continue
+			}
+			if nestedItemRe.MatchString(line) {
+				s.issue(lineNum, "tool %s: cannot parse parameter (want: - `name` (type, required): description)", currentTool.Name)
+				continue
+			}
+		}
+
+		// 权限列表：- network 或 - **network**: 说明
+		if currentSection == "permissions" {
+			if m := listItemRe.FindStringSubmatch(line); m != nil {
+				name, _, _ := strings.Cut(m[1], ":")
+				if name = strings.Trim(strings.TrimSpace(name), "*`"); name != "" {
+					s.Permissions = append(s.Permissions, strings.ToLower(name))
+				}
+			}
+			continue
+		}
+
 		// 解析键值对
 		var key, value string
+		bold := false
 		if m := kvRe.FindStringSubmatch(line); m != nil {
 			key = strings.ToLower(strings.TrimSpace(m[1]))
 			value = strings.TrimSpace(m[2])
+			bold = true
 		} else if m := kvRe2.FindStringSubmatch(line); m != nil {
 			key = strings.ToLower(strings.TrimSpace(m[1]))
 			value = strings.TrimSpace(m[2])
@@ -135,18 +258,18 @@ func ParseSKILLMD(path string) (*Skill, error) {
 			case "metadata", "info", "":
 				switch key {
 				case "version":
-					skill.Version = value
+					s.Version = value
 				case "author":
-					skill.Author = value
+					s.Author = value
 				case "description":
-					skill.Description = value
+					s.Description = value
 				case "id":
-					skill.ID = value
+					s.ID = value
+					explicitID = true
 				}
-			case "permissions":
-				skill.Permissions = append(skill.Permissions, value)
+				s.lines[key] = lineNum
 			case "config", "configuration":
-				skill.Config[key] = value
+				s.Config[key] = value
 			case "tools", "tool definitions":
 				if currentTool != nil {
 					switch key {
@@ -155,66 +278,197 @@ func ParseSKILLMD(path string) (*Skill, error) {
 					case "binary":
 						currentTool.Binary = value
 					case "command":
-						currentTool.Command = value
+						currentTool.Command = strings.Trim(value, "`")
+					case "input":
+						currentTool.Input = strings.ToLower(strings.Trim(value, "`"))
+					default:
+						if bold {
+							s.issue(lineNum, "tool %s: unknown field %q", currentTool.Name, key)
+						}
 					}
 				}
 			}
 		}
 
 		// 检查工具参数节
-		if strings.Contains(strings.ToLower(line), "parameters") && currentTool != nil {
-			toolParamSection = true
+		if currentTool != nil && (currentSection == "tools" || currentSection == "tool definitions") {
+			if strings.Contains(strings.ToLower(line), "parameters") {
+				toolParamSection = true
+				s.lines["tool:"+currentTool.Name+".parameters"] = lineNum
+			}
 		}
 
 		// 解析 description 段落
-		if currentSection == "description" && strings.TrimSpace(line) != "" && skill.Description == "" {
-			skill.Description = strings.TrimSpace(line)
+		if currentSection == "description" && strings.TrimSpace(line) != "" && s.Description == "" {
+			s.Description = strings.TrimSpace(line)
 		}
-
-		_ = toolParamSection // 将来扩展参数解析
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scan skill file: %w", err)
+		return false, fmt.Errorf("scan skill file: %w", err)
 	}
+	return explicitID, nil
+}
 
-	// 验证必要字段
-	if skill.Name == "" {
-		return nil, fmt.Errorf("skill name not found in %s", path)
+// applyFrontMatter 解析 front-matter 并覆盖 markdown 中的同名信息；工具与权限合并
+func (s *Skill) applyFrontMatter(path string, front []byte, explicitID bool) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(front, &doc); err != nil {
+		return yamlError(path, err)
 	}
-	if skill.ID == "" {
-		skill.ID = sanitizeID(skill.Name)
+	if len(doc.Content) == 0 {
+		return nil // 空的 front-matter
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return &ValidationError{File: path, Line: root.Line + 1, Message: "front-matter must be a mapping"}
 	}
+	var fm frontMatter
+	if err := root.Decode(&fm); err != nil {
+		return yamlError(path, err)
+	}
+	s.recordFrontMatterLines(root, fm.Tools)
 
-	return skill, nil
+	if fm.Name != "" {
+		s.Name = fm.Name
+		if !explicitID {
+			s.ID = sanitizeID(fm.Name)
+		}
+	}
+	if fm.ID != "" {
+		s.ID = fm.ID
+	}
+	if fm.Description != "" {
+		s.Description = fm.Description
+	}
+	if fm.Version != "" {
+		s.Version = fm.Version
+	}
+	if fm.Author != "" {
+		s.Author = fm.Author
+	}
+	for key, value := range fm.Config {
+		s.Config[key] = value
+	}
+	for _, p := range fm.Permissions {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if !s.HasPermission(p) {
+			s.Permissions = append(s.Permissions, p)
+		}
+	}
+	for i := range fm.Tools {
+		fm.Tools[i].Input = strings.ToLower(fm.Tools[i].Input)
+	}
+	s.Tools = append(fm.Tools, s.Tools...)
+	s.Requires = fm.Requires
+	s.Install = fm.Install
+	return nil
 }
 
-// sanitizeID 将名称转换为合法的ID
-func sanitizeID(name string) string {
-	id := strings.ToLower(name)
-	id = strings.ReplaceAll(id, " ", "-")
-	id = regexp.MustCompile(`[^a-z0-9\-]`).ReplaceAllString(id, "")
-	return id
+// recordFrontMatterLines 记录 front-matter 字段的行号 (文件第一行是 "---")，并报告未知字段
+func (s *Skill) recordFrontMatterLines(root *yaml.Node, tools []ToolSpec) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		s.lines[key.Value] = key.Line + 1
+		if !frontMatterFields[key.Value] {
+			s.issue(key.Line+1, "unknown front-matter field %q", key.Value)
+		}
+
+		switch key.Value {
+		case "tools":
+			for j, item := range value.Content {
+				if j >= len(tools) {
+					break
+				}
+				name := tools[j].Name
+				s.lines["tool:"+name] = item.Line + 1
+				for k := 0; k+1 < len(item.Content); k += 2 {
+					field := item.Content[k]
+					if field.Value == "parameters" {
+						s.lines["tool:"+name+".parameters"] = field.Line + 1
+					}
+					if !toolFields[field.Value] {
+						s.issue(field.Line+1, "tool %s: unknown field %q", name, field.Value)
+					}
+				}
+			}
+		case "install":
+			for j, item := range value.Content {
+				s.lines[fmt.Sprintf("install.%d", j)] = item.Line + 1
+			}
+		}
+	}
+}
+
+// issue 记录解析时发现的问题，由 Validate 报告
+func (s *Skill) issue(line int, format string, args ...interface{}) {
+	s.issues = append(s.issues, ValidationError{Line: line, Message: fmt.Sprintf(format, args...)})
 }
 
-// Validate 验证技能配置
-func (s *Skill) Validate() error {
-	if s.ID == "" {
-		return fmt.Errorf("skill id is required")
+// splitFrontMatter 拆分开头的 YAML front-matter，没有时 front 为 nil
+func splitFrontMatter(data []byte) (front, body []byte) {
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	if !ok {
+		if rest, ok = bytes.CutPrefix(data, []byte("---\r\n")); !ok {
+			return nil, data
+		}
 	}
-	if s.Name == "" {
-		return fmt.Errorf("skill name is required")
+	for offset := 0; offset < len(rest); {
+		end := bytes.IndexByte(rest[offset:], '\n')
+		line := rest[offset:]
+		if end >= 0 {
+			line = rest[offset : offset+end]
+		}
+		if string(bytes.TrimRight(line, "\r")) == "---" {
+			if end < 0 {
+				return rest[:offset], nil
+			}
+			return rest[:offset], rest[offset+end+1:]
+		}
+		if end < 0 {
+			break
+		}
+		offset += end + 1
 	}
-	if s.Path == "" {
-		return fmt.Errorf("skill path is required")
+	return nil, data // 没有结束标记，按普通 markdown 处理
+}
+
+// addParameter 将一行参数说明加入工具的 JSON schema
+func addParameter(tool *ToolSpec, name, attrs, description string) {
+	if tool.Parameters == nil {
+		tool.Parameters = map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{},
+		}
 	}
-	
-	// 检查路径存在
-	if _, err := os.Stat(s.Path); err != nil {
-		return fmt.Errorf("skill path not found: %s", s.Path)
+	prop := map[string]interface{}{"type": "string"}
+	if description = strings.TrimSpace(description); description != "" {
+		prop["description"] = description
 	}
-	
-	return nil
+	for _, attr := range strings.Split(attrs, ",") {
+		switch attr = strings.ToLower(strings.TrimSpace(attr)); attr {
+		case "":
+		case "required":
+			required, _ := tool.Parameters["required"].([]string)
+			tool.Parameters["required"] = append(required, name)
+		case "optional":
+		case "int":
+			prop["type"] = "integer"
+		case "bool":
+			prop["type"] = "boolean"
+		default:
+			prop["type"] = attr
+		}
+	}
+	tool.Parameters["properties"].(map[string]interface{})[name] = prop
+}
+
+// sanitizeID 将名称转换为合法的ID
+func sanitizeID(name string) string {
+	id := strings.ToLower(name)
+	id = strings.ReplaceAll(id, " ", "-")
+	id = regexp.MustCompile(`[^a-z0-9\-]`).ReplaceAllString(id, "")
+	return id
 }
 
 // GetBinary 获取工具的可执行文件路径
@@ -237,3 +491,45 @@ func (s *Skill) GetBinary(toolName string) (string, error) {
 	}
 	return "", fmt.Errorf("tool not found: %s", toolName)
 }
+
+// MissingPermissions 返回技能声明但未被授予的权限
+func (s *Skill) MissingPermissions(granted []string) []string {
+	allowed := make(map[string]bool, len(granted))
+	for _, p := range granted {
+		allowed[strings.ToLower(strings.TrimSpace(p))] = true
+	}
+	var missing []string
+	for _, p := range s.Permissions {
+		if !allowed[strings.ToLower(strings.TrimSpace(p))] {
+			missing = append(missing, p)
+		}
+	}
+	return missing
+}
+
+// HasPermission 技能是否声明了指定权限
+func (s *Skill) HasPermission(permission string) bool {
+	for _, p := range s.Permissions {
+		if strings.EqualFold(strings.TrimSpace(p), permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// Guidance 读取 SKILL.md 全文，作为使用说明注入 system prompt
+func (s *Skill) Guidance() string {
+	if s.Path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(s.Path, "SKILL.md"))
+	if err != nil {
+		return ""
+	}
+	_, body := splitFrontMatter(data)
+	guidance := strings.TrimSpace(string(body))
+	if runes := []rune(guidance); len(runes) > maxGuidanceChars {
+		guidance = string(runes[:maxGuidanceChars]) + "\n..."
+	}
+	return guidance
+}
@@ -0,0 +1,356 @@
+package skills
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// toolNameRe 工具名称；模型 API 要求完整名称 <skillID>__<tool> 不超过 64 个字符
+	toolNameRe  = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	skillIDRe   = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	versionRe   = regexp.MustCompile(`^v?\d+(\.\d+){0,2}([-+][0-9A-Za-z.-]+)?$`)
+	yamlLineRe  = regexp.MustCompile(`line (\d+): (.*)`)
+	placeholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+)
+
+// maxToolNameLen 模型 API 允许的工具名称长度
+const maxToolNameLen = 64
+
+// knownPermissions 可声明的权限
+var knownPermissions = map[string]bool{
+	PermissionNetwork: true, PermissionFilesystem: true, PermissionExec: true, PermissionEnv: true,
+	PermissionBrowser: true, PermissionCamera: true, PermissionLocation: true,
+}
+
+// ValidationError SKILL.md 中的问题，Line 为 0 时表示没有具体位置
+type ValidationError struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case e.File == "":
+		return e.Message
+	case e.Line == 0:
+		return e.File + ": " + e.Message
+	default:
+		return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+	}
+}
+
+// ValidationErrors Validate 发现的所有问题，按行号排序
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i := range e {
+		lines[i] = e[i].Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yamlError 将 yaml 的错误转换为带文件行号的错误 (front-matter 从文件第二行开始)
+func yamlError(path string, err error) error {
+	var typeErr *yaml.TypeError
+	msg := err.Error()
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+	if m := yamlLineRe.FindStringSubmatch(msg); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &ValidationError{File: path, Line: line + 1, Message: "front-matter: " + m[2]}
+	}
+	return &ValidationError{File: path, Line: 1, Message: "front-matter: " + strings.TrimPrefix(msg, "yaml: ")}
+}
+
+// Validate 检查技能定义，返回 ValidationErrors (带 SKILL.md 的行号)；没有问题时返回 nil
+func (s *Skill) Validate() error {
+	file := filepath.Join(s.Path, "SKILL.md")
+	var errs ValidationErrors
+	add := func(key, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{File: file, Line: s.lines[key], Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, issue := range s.issues {
+		issue.File = file
+		errs = append(errs, issue)
+	}
+
+	if s.Name == "" {
+		add("name", "skill name is required")
+	}
+	if s.ID == "" {
+		add("id", "skill id is required")
+	} else if !skillIDRe.MatchString(s.ID) {
+		add("id", "invalid skill id %q (use lowercase letters, digits and '-')", s.ID)
+	}
+	if s.Path == "" {
+		add("", "skill path is required")
+	} else if _, err := os.Stat(s.Path); err != nil {
+		add("", "skill path not found: %s", s.Path)
+	}
+	if s.Version != "" && !versionRe.MatchString(s.Version) {
+		add("version", "invalid version %q (want e.g. 1.2.0)", s.Version)
+	}
+
+	for _, p := range s.Permissions {
+		if !knownPermissions[p] {
+			add("permissions", "unknown permission %q", p)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, tool := range s.Tools {
+		key := "tool:" + tool.Name
+		if seen[tool.Name] {
+			add(key, "duplicate tool %q", tool.Name)
+			continue
+		}
+		seen[tool.Name] = true
+		s.validateTool(tool, func(format string, args ...interface{}) { add(key, format, args...) },
+			func(format string, args ...interface{}) { add(key+".parameters", format, args...) })
+	}
+
+	if req := s.Requires; req != nil && req.MinVersion != "" && !versionRe.MatchString(req.MinVersion) {
+		add("requires", "requires.minVersion: invalid version %q", req.MinVersion)
+	}
+	for i, spec := range s.Install {
+		if msg := validateInstall(spec); msg != "" {
+			add(fmt.Sprintf("install.%d", i), "install[%d]: %s", i, msg)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return errs
+}
+
+// validateTool 检查工具定义；schema 问题通过 addParam 报告在 parameters 的位置
+func (s *Skill) validateTool(tool ToolSpec, add, addParam func(format string, args ...interface{})) {
+	switch {
+	case tool.Name == "":
+		add("tool name is required")
+		return
+	case !toolNameRe.MatchString(tool.Name):
+		add("invalid tool name %q (use letters, digits, '_' and '-')", tool.Name)
+	case len(s.ID)+2+len(tool.Name) > maxToolNameLen:
+		add("tool %s: name %s__%s exceeds %d characters", tool.Name, s.ID, tool.Name, maxToolNameLen)
+	}
+
+	switch {
+	case tool.Binary == "" && tool.Command == "":
+		add("tool %s: binary or command is required", tool.Name)
+	case tool.Binary != "" && tool.Command != "":
+		add("tool %s: binary and command are mutually exclusive", tool.Name)
+	}
+	if tool.Input != "" && tool.Input != InputStdin && tool.Input != InputArgs {
+		add("tool %s: input must be %q or %q, got %q", tool.Name, InputStdin, InputArgs, tool.Input)
+	}
+
+	var props map[string]interface{}
+	if tool.Parameters != nil {
+		if tool.Parameters["type"] != "object" {
+			addParam("tool %s: parameters: type must be \"object\"", tool.Name)
+		}
+		for _, msg := range schemaErrors(tool.Parameters, "parameters") {
+			addParam("tool %s: %s", tool.Name, msg)
+		}
+		props, _ = tool.Parameters["properties"].(map[string]interface{})
+	}
+	for _, m := range placeholder.FindAllStringSubmatch(tool.Command, -1) {
+		if _, ok := props[m[1]]; !ok {
+			add("tool %s: command uses {%s} but no such parameter is declared", tool.Name, m[1])
+		}
+	}
+}
+
+// validateInstall 检查安装方式，返回问题描述
+func validateInstall(spec InstallSpec) string {
+	switch spec.Kind {
+	case InstallGo, InstallBrew, InstallApt, InstallNpm, InstallPip:
+		if spec.Package == "" {
+			return spec.Kind + ": package is required"
+		}
+	case InstallDownload:
+		if spec.URL == "" || spec.SHA256 == "" || len(spec.Bins) == 0 {
+			return "download: url, sha256 and bins are required"
+		}
+	case "":
+		return "kind is required"
+	default:
+		return fmt.Sprintf("unknown kind %q", spec.Kind)
+	}
+	return ""
+}
+
+// schemaTypes JSON Schema 的类型
+var schemaTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "object": true, "array": true, "null": true,
+}
+
+// schemaKeywords 无需检查取值的关键字
+var schemaKeywords = map[string]bool{
+	"default": true, "const": true, "examples": true, "format": true, "uniqueItems": true,
+	"$schema": true, "$id": true, "$ref": true, "$defs": true, "definitions": true, "$comment": true,
+}
+
+// schemaErrors 检查 JSON Schema 的结构 (关键字及其取值类型)，返回 "path: 问题" 形式的描述
+func schemaErrors(schema map[string]interface{}, path string) []string {
+	var errs []string
+	add := func(format string, args ...interface{}) {
+		errs = append(errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	props, _ := schema["properties"].(map[string]interface{})
+	for _, key := range keys {
+		value := schema[key]
+		switch key {
+		case "type":
+			for _, t := range stringList(value) {
+				if !schemaTypes[t] {
+					add("unknown type %q", t)
+				}
+			}
+			if stringList(value) == nil {
+				add("type must be a string or a list of strings")
+			}
+		case "title", "description":
+			if _, ok := value.(string); !ok {
+				add("%s must be a string", key)
+			}
+		case "properties":
+			if props == nil {
+				add("properties must be an object")
+				continue
+			}
+			names := make([]string, 0, len(props))
+			for name := range props {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				sub, ok := props[name].(map[string]interface{})
+				if !ok {
+					add("property %q must be a schema object", name)
+					continue
+				}
+				errs = append(errs, schemaErrors(sub, path+"."+name)...)
+			}
+		case "required":
+			required := stringList(value)
+			if required == nil {
+				add("required must be a list of strings")
+			}
+			for _, name := range required {
+				if _, ok := props[name]; !ok {
+					add("required property %q is not defined", name)
+				}
+			}
+		case "items", "additionalProperties", "not":
+			if _, ok := value.(bool); ok && key == "additionalProperties" {
+				continue
+			}
+			sub, ok := value.(map[string]interface{})
+			if !ok {
+				add("%s must be a schema object", key)
+				continue
+			}
+			errs = append(errs, schemaErrors(sub, path+"."+key)...)
+		case "anyOf", "oneOf", "allOf":
+			list, ok := value.([]interface{})
+			if !ok || len(list) == 0 {
+				add("%s must be a non-empty list of schemas", key)
+				continue
+			}
+			for i, item := range list {
+				sub, ok := item.(map[string]interface{})
+				if !ok {
+					add("%s[%d] must be a schema object", key, i)
+					continue
+				}
+				errs = append(errs, schemaErrors(sub, fmt.Sprintf("%s.%s[%d]", path, key, i))...)
+			}
+		case "enum":
+			if list, ok := value.([]interface{}); !ok || len(list) == 0 {
+				if strs, ok := value.([]string); !ok || len(strs) == 0 {
+					add("enum must be a non-empty list")
+				}
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			if _, ok := schemaNumber(value); !ok {
+				add("%s must be a number", key)
+			}
+		case "minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties":
+			if n, ok := schemaNumber(value); !ok || n < 0 || n != float64(int64(n)) {
+				add("%s must be a non-negative integer", key)
+			}
+		case "pattern":
+			p, ok := value.(string)
+			if !ok {
+				add("pattern must be a string")
+			} else if _, err := regexp.Compile(p); err != nil {
+				add("invalid pattern %q", p)
+			}
+		default:
+			if !schemaKeywords[key] {
+				add("unknown keyword %q", key)
+			}
+		}
+	}
+	return errs
+}
+
+// stringList 接受字符串或字符串列表 (JSON 解码为 []interface{}，markdown 解析为 []string)
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil
+			}
+			list = append(list, s)
+		}
+		return list
+	}
+	return nil
+}
+
+// schemaNumber 接受 JSON (float64) 和 YAML (int) 解码出的数字
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
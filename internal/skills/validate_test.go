@@ -0,0 +1,180 @@
+package skills
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func parseSkill(t *testing.T, content string) (*Skill, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "SKILL.md")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	skill, err := ParseSKILLMD(path)
+	if err != nil {
+		t.Fatalf("ParseSKILLMD failed: %v", err)
+	}
+	return skill, path
+}
+
+func TestParseSKILLMD_FrontMatterTools(t *testing.T) {
+	skill, _ := parseSkill(t, `---
+name: Weather Lookup
+description: Current weather by city
+version: 1.2.0
+permissions: [network]
+tools:
+  - name: forecast
+    description: Get the forecast
+    binary: bin/forecast
+    input: args
+    parameters:
+      type: object
+      properties:
+        city: {type: string, description: City name}
+        days: {type: integer, minimum: 1, maximum: 7}
+      required: [city]
+---
+# Ignored Heading
+
+## Tools
+
+### legacy
+
+- **Command**: `+"`echo hi`"+`
+`)
+
+	if skill.Name != "Weather Lookup" || skill.ID != "weather-lookup" || skill.Version != "1.2.0" {
+		t.Errorf("metadata = %q %q %q", skill.Name, skill.ID, skill.Version)
+	}
+	if len(skill.Tools) != 2 || skill.Tools[0].Name != "forecast" || skill.Tools[1].Name != "legacy" {
+		t.Fatalf("tools = %+v", skill.Tools)
+	}
+	props := skill.Tools[0].Parameters["properties"].(map[string]interface{})
+	if days := props["days"].(map[string]interface{}); days["type"] != "integer" {
+		t.Errorf("days = %v", days)
+	}
+	if !skill.HasPermission(PermissionNetwork) {
+		t.Errorf("permissions = %v", skill.Permissions)
+	}
+	if err := skill.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestSkill_ValidateReportsLines(t *testing.T) {
+	skill, path := parseSkill(t, "---\n"+
+		"versoin: 1.0\n"+ // 2
+		"tools:\n"+
+		"  - name: search\n"+ // 4
+		"    command: grep {pattern} {dir}\n"+
+		"    parameters:\n"+ // 6
+		"      type: object\n"+
+		"      properties:\n"+
+		"        pattern: {type: text}\n"+
+		"      required: [pattern, limit]\n"+
+		"---\n"+
+		"# Broken Skill\n"+ // 12
+		"\n"+
+		"## Permissions\n"+ // 14
+		"\n"+
+		"- teleport\n"+
+		"\n"+
+		"## Tools\n"+
+		"\n"+
+		"### `bad name`\n"+ // 20
+		"\n"+
+		"### run\n"+ // 22
+		"\n"+
+		"- **Binary**: run.sh\n"+
+		"- **Inptu**: args\n"+ // 25
+		"- **Parameters**:\n"+
+		"  - query: missing backticks\n") // 27
+
+	err := skill.Validate()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+
+	want := map[string]int{
+		`unknown front-matter field "versoin"`:                 2,
+		"command uses {dir} but no such parameter is declared": 4,
+		`parameters.pattern: unknown type "text"`:              6,
+		`required property "limit" is not defined`:             6,
+		`unknown permission "teleport"`:                        14,
+		`invalid tool name "bad name"`:                         20,
+		`tool run: unknown field "inptu"`:                      25,
+		"tool run: cannot parse parameter":                     27,
+	}
+	for msg, line := range want {
+		found := false
+		for _, e := range errs {
+			if strings.Contains(e.Message, msg) {
+				found = true
+				if e.Line != line || e.File != path {
+					t.Errorf("%q reported at %s:%d, want line %d", msg, e.File, e.Line, line)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("missing error %q in:\n%v", msg, err)
+		}
+	}
+	if !strings.HasPrefix(errs[0].Error(), path+":2: ") {
+		t.Errorf("errors should be sorted and formatted as file:line, got %q", errs[0].Error())
+	}
+}
+
+func TestParseSKILLMD_FrontMatterSyntaxError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SKILL.md")
+	os.WriteFile(path, []byte("---\nname: Demo\nversion: 1.0.0\nauthor: : me\n---\n# Demo\n"), 0644)
+
+	_, err := ParseSKILLMD(path)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if verr.File != path || verr.Line != 4 {
+		t.Errorf("error position = %s:%d (%s)", verr.File, verr.Line, verr.Message)
+	}
+}
+
+func TestSchemaErrors(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"tags":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "strng"}},
+			"name":  map[string]interface{}{"type": "string", "pattern": "(", "minLength": -1},
+			"count": map[string]interface{}{"type": []interface{}{"integer", "null"}, "maximum": "ten"},
+			"mode":  map[string]interface{}{"enum": []interface{}{}, "requried": true},
+		},
+	}
+	got := strings.Join(schemaErrors(schema, "parameters"), "\n")
+	for _, want := range []string{
+		`parameters.tags.items: unknown type "strng"`,
+		`parameters.name: invalid pattern "("`,
+		"parameters.name: minLength must be a non-negative integer",
+		"parameters.count: maximum must be a number",
+		"parameters.mode: enum must be a non-empty list",
+		`parameters.mode: unknown keyword "requried"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+
+	valid := map[string]interface{}{
+		"type":                 "object",
+		"properties":           map[string]interface{}{"q": map[string]interface{}{"type": "string", "default": "x"}},
+		"required":             []string{"q"},
+		"additionalProperties": false,
+	}
+	if errs := schemaErrors(valid, "parameters"); len(errs) != 0 {
+		t.Errorf("valid schema reported %v", errs)
+	}
+}
@@ -0,0 +1,639 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 技能来源类型
+const (
+	SourceGitHub  = "github"
+	SourceGit     = "git"     // git+<url 或本地仓库路径>
+	SourceTarball = "tarball" // https://.../skill.tar.gz#sha256=<hex>
+	SourceLocal   = "local"   // 本地目录，以符号链接安装
+)
+
+const (
+	lockFileName = "skills.lock.json"
+	versionsDir  = ".versions" // <skillDir>/.versions/<id>/<commit 或 sha256 前 12 位>
+	fetchTimeout = 10 * time.Minute
+)
+
+// LockEntry 技能锁定记录：来源、ref 与解析出的确切版本
+type LockEntry struct {
+	ID          string    `json:"id"`
+	Source      string    `json:"source"` // 安装时给出的来源
+	Kind        string    `json:"kind"`
+	URL         string    `json:"url"`
+	Ref         string    `json:"ref,omitempty"`
+	Pinned      bool      `json:"pinned,omitempty"` // 指定了 ref，更新时不跟随分支
+	Commit      string    `json:"commit,omitempty"`
+	SHA256      string    `json:"sha256,omitempty"`
+	Version     string    `json:"version,omitempty"` // SKILL.md 中的版本
+	Path        string    `json:"path"`              // 当前版本目录
+	Previous    string    `json:"previous,omitempty"`
+	InstalledAt time.Time `json:"installedAt"`
+}
+
+// Lockfile 所有技能的锁定记录 (<skillDir>/skills.lock.json)
+type Lockfile struct {
+	Skills map[string]LockEntry `json:"skills"`
+}
+
+// UpdateOptions 更新选项
+type UpdateOptions struct {
+	Ref    string // 切换到指定 ref 并固定 (git/github)
+	Source string // 替换来源，如新的 tarball URL
+}
+
+// UpdatePlan 更新预览，ApplyUpdate 之前展示给用户
+type UpdatePlan struct {
+	ID          string   `json:"id"`
+	FromVersion string   `json:"fromVersion,omitempty"`
+	ToVersion   string   `json:"toVersion,omitempty"`
+	FromCommit  string   `json:"fromCommit,omitempty"`
+	ToCommit    string   `json:"toCommit,omitempty"`
+	Changelog   []string `json:"changelog,omitempty"` // 新增的提交 (git) 或版本变化
+	Diff        string   `json:"diff,omitempty"`      // 文件变化统计
+	UpToDate    bool     `json:"upToDate"`
+
+	entry LockEntry // 已下载但尚未切换的版本
+}
+
+// sourceSpec 解析后的来源
+type sourceSpec struct {
+	kind   string
+	url    string
+	ref    string
+	sha256 string
+}
+
+// parseSource 解析安装来源
+func parseSource(source string) (sourceSpec, error) {
+	switch {
+	case strings.HasPrefix(source, "git+"):
+		url, ref := splitRef(strings.TrimPrefix(source, "git+"))
+		if strings.HasPrefix(url, "~") {
+			home, _ := os.UserHomeDir()
+			url = filepath.Join(home, url[1:])
+		}
+		return sourceSpec{kind: SourceGit, url: url, ref: ref}, nil
+	case (strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")) && !strings.Contains(source, "github.com/"):
+		url, fragment, _ := strings.Cut(source, "#")
+		if !strings.HasSuffix(url, ".tar.gz") && !strings.HasSuffix(url, ".tgz") {
+			return sourceSpec{}, fmt.Errorf("unsupported url %q (want a .tar.gz or .tgz archive)", url)
+		}
+		sha, ok := strings.CutPrefix(fragment, "sha256=")
+		if !ok || sha == "" {
+			return sourceSpec{}, fmt.Errorf("tarball source requires #sha256=<hex>")
+		}
+		return sourceSpec{kind: SourceTarball, url: url, sha256: sha}, nil
+	case strings.HasPrefix(source, "/") || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") || strings.HasPrefix(source, "~"):
+		return sourceSpec{kind: SourceLocal, url: source}, nil
+	default:
+		repo := strings.TrimPrefix(strings.TrimPrefix(source, "https://"), "github.com/")
+		repo, ref := splitRef(repo)
+		if i := strings.Index(repo, "#"); i >= 0 { // 兼容 user/repo#branch
+			repo, ref = repo[:i], repo[i+1:]
+		}
+		parts := strings.Split(strings.TrimSuffix(repo, ".git"), "/")
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return sourceSpec{}, fmt.Errorf("invalid github source: %s", source)
+		}
+		return sourceSpec{kind: SourceGitHub, url: fmt.Sprintf("https://github.com/%s/%s.git", parts[0], parts[1]), ref: ref}, nil
+	}
+}
+
+// splitRef 拆分 "<url>@<ref>"，只识别最后一个 "/" 之后的 "@"
+func splitRef(s string) (string, string) {
+	i := strings.LastIndex(s, "@")
+	if i < 0 || i < strings.LastIndex(s, "/") {
+		return s, ""
+	}
+	return s[:i], s[i+1:]
+}
+
+// installVersioned 下载到版本目录并切换；SKILL.md 解析失败时删除下载内容，不影响已安装的版本
+func (l *Loader) installVersioned(source string, spec sourceSpec) (*Skill, error) {
+	entry, err := l.stage(spec)
+	if err != nil {
+		return nil, err
+	}
+	entry.Source = source
+	entry.Pinned = spec.ref != ""
+	return l.activate(entry)
+}
+
+// stage 下载来源到 .versions/<id>/<version>，返回尚未启用的锁定记录
+func (l *Loader) stage(spec sourceSpec) (LockEntry, error) {
+	root := filepath.Join(l.skillDirs[0], versionsDir)
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return LockEntry{}, err
+	}
+	staging, err := os.MkdirTemp(root, ".staging-")
+	if err != nil {
+		return LockEntry{}, err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.RemoveAll(staging)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	entry := LockEntry{Kind: spec.kind, URL: spec.url, Ref: spec.ref}
+	var version string
+	switch spec.kind {
+	case SourceGit, SourceGitHub:
+		if err := gitClone(ctx, spec.url, spec.ref, staging); err != nil {
+			return LockEntry{}, err
+		}
+		out, err := gitOutput(ctx, staging, "rev-parse", "HEAD")
+		if err != nil {
+			return LockEntry{}, err
+		}
+		entry.Commit = strings.TrimSpace(out)
+		version = entry.Commit
+	case SourceTarball:
+		data, err := fetchVerified(ctx, spec.url, spec.sha256)
+		if err != nil {
+			return LockEntry{}, err
+		}
+		if err := extractArchive(data, staging); err != nil {
+			return LockEntry{}, err
+		}
+		entry.SHA256 = strings.ToLower(spec.sha256)
+		version = entry.SHA256
+	default:
+		return LockEntry{}, fmt.Errorf("unsupported source kind %q", spec.kind)
+	}
+
+	skill, err := ParseSKILLMD(filepath.Join(staging, "SKILL.md"))
+	if err != nil {
+		return LockEntry{}, fmt.Errorf("parse skill (not installed): %w", err)
+	}
+	// id 会用作目录名，必须先校验
+	if err := skill.Validate(); err != nil {
+		return LockEntry{}, fmt.Errorf("invalid skill (not installed): %w", err)
+	}
+	entry.ID = skill.ID
+	entry.Version = skill.Version
+
+	dir := filepath.Join(root, filepath.Base(skill.ID), shortVersion(version))
+	if _, err := os.Stat(dir); err == nil {
+		entry.Path = dir // 同一版本已下载过
+		return entry, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return LockEntry{}, err
+	}
+	if err := os.Rename(staging, dir); err != nil {
+		return LockEntry{}, err
+	}
+	keep = true
+	entry.Path = dir
+	return entry, nil
+}
+
+// activate 原子地将 <skillDir>/<id> 指向新版本，更新锁文件并清理旧版本
+func (l *Loader) activate(entry LockEntry) (*Skill, error) {
+	link := filepath.Join(l.skillDirs[0], filepath.Base(entry.ID))
+	var legacy string
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		// 旧版本直接 clone 到了技能目录，移入版本目录作为上一个版本
+		legacy = filepath.Join(filepath.Dir(entry.Path), "legacy")
+		os.RemoveAll(legacy)
+		if err := os.Rename(link, legacy); err != nil {
+			return nil, fmt.Errorf("move legacy install: %w", err)
+		}
+	}
+
+	tmp := filepath.Join(l.skillDirs[0], "."+filepath.Base(entry.ID)+".link")
+	os.Remove(tmp)
+	if err := os.Symlink(entry.Path, tmp); err != nil {
+		return nil, fmt.Errorf("create symlink: %w", err)
+	}
+	if err := os.Rename(tmp, link); err != nil {
+		os.Remove(tmp)
+		return nil, fmt.Errorf("switch version: %w", err)
+	}
+
+	skill, err := ParseSKILLMD(filepath.Join(link, "SKILL.md"))
+	if err != nil {
+		return nil, fmt.Errorf("parse skill: %w", err)
+	}
+	skill.Source = SkillSource{Kind: entry.Kind, URL: entry.URL, Ref: entry.Ref, Commit: entry.Commit}
+
+	lock := l.readLock()
+	if old, ok := lock.Skills[entry.ID]; ok && old.Path != entry.Path {
+		entry.Previous = old.Path
+	} else if legacy != "" {
+		entry.Previous = legacy
+	}
+	entry.InstalledAt = time.Now()
+	lock.Skills[entry.ID] = entry
+	if err := l.writeLock(lock); err != nil {
+		return nil, err
+	}
+	pruneVersions(filepath.Dir(entry.Path), entry.Path, entry.Previous)
+	return skill, nil
+}
+
+// PlanUpdate 下载新版本并返回变化，不切换；之后调用 ApplyUpdate 应用
+func (l *Loader) PlanUpdate(id string, opts UpdateOptions) (*UpdatePlan, error) {
+	l.installMu.Lock()
+	defer l.installMu.Unlock()
+
+	skill, ok := l.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("skill not found: %s", id)
+	}
+	current, ok := l.readLock().Skills[id]
+	if !ok {
+		// 锁文件出现之前安装的技能
+		current = LockEntry{ID: id, Kind: skill.Source.Kind, URL: skill.Source.URL, Ref: skill.Source.Ref, Commit: skill.Source.Commit, Version: skill.Version, Path: skill.Path}
+		if current.Kind == SourceGitHub && !strings.HasSuffix(current.URL, ".git") {
+			current.URL += ".git"
+		}
+	}
+
+	spec := sourceSpec{kind: current.Kind, url: current.URL, ref: current.Ref, sha256: current.SHA256}
+	source := current.Source
+	if opts.Source != "" {
+		var err error
+		if spec, err = parseSource(opts.Source); err != nil {
+			return nil, err
+		}
+		source = opts.Source
+	}
+	if opts.Ref != "" {
+		spec.ref = opts.Ref
+	} else if opts.Source == "" && !current.Pinned {
+		spec.ref = "" // 跟随默认分支
+	}
+	switch spec.kind {
+	case SourceGit, SourceGitHub, SourceTarball:
+	case SourceLocal:
+		return nil, fmt.Errorf("skill %s is linked from a local directory; edit it in place", id)
+	default:
+		return nil, fmt.Errorf("skill %s has no updatable source", id)
+	}
+
+	next, err := l.stage(spec)
+	if err != nil {
+		return nil, err
+	}
+	if next.ID != id {
+		return nil, fmt.Errorf("source provides skill %q, not %q", next.ID, id)
+	}
+	next.Source = source
+	next.Pinned = spec.ref != "" && (current.Pinned || opts.Ref != "")
+	if spec.kind == SourceTarball {
+		next.Pinned = false
+	}
+
+	plan := &UpdatePlan{
+		ID:          id,
+		FromVersion: current.Version,
+		ToVersion:   next.Version,
+		FromCommit:  current.Commit,
+		ToCommit:    next.Commit,
+		UpToDate:    next.Path == current.Path || (next.Commit != "" && next.Commit == current.Commit),
+		entry:       next,
+	}
+	if !plan.UpToDate {
+		plan.Changelog, plan.Diff = describeChanges(current, next)
+	}
+
+	l.mu.Lock()
+	l.pending[id] = plan
+	l.mu.Unlock()
+	return plan, nil
+}
+
+// ApplyUpdate 应用 PlanUpdate 下载的版本
+func (l *Loader) ApplyUpdate(id string) (*Skill, error) {
+	l.installMu.Lock()
+	defer l.installMu.Unlock()
+
+	l.mu.Lock()
+	plan, ok := l.pending[id]
+	delete(l.pending, id)
+	l.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no pending update for %s", id)
+	}
+
+	skill, err := l.activate(plan.entry)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if old, ok := l.skills[id]; ok {
+		skill.Enabled = old.Enabled
+	}
+	l.applyConfig(skill)
+	l.skills[id] = skill
+	l.saveState()
+	return skill, nil
+}
+
+// PendingUpdate 返回 PlanUpdate 下载但尚未应用的更新
+func (l *Loader) PendingUpdate(id string) (*UpdatePlan, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	plan, ok := l.pending[id]
+	return plan, ok
+}
+
+// Lock 返回锁文件内容
+func (l *Loader) Lock() Lockfile {
+	l.installMu.Lock()
+	defer l.installMu.Unlock()
+	return l.readLock()
+}
+
+func (l *Loader) lockPath() string {
+	if l.lockFile != "" {
+		return l.lockFile
+	}
+	return filepath.Join(l.skillDirs[0], lockFileName)
+}
+
+func (l *Loader) readLock() Lockfile {
+	lock := Lockfile{Skills: make(map[string]LockEntry)}
+	if data, err := os.ReadFile(l.lockPath()); err == nil {
+		json.Unmarshal(data, &lock)
+		if lock.Skills == nil {
+			lock.Skills = make(map[string]LockEntry)
+		}
+	}
+	return lock
+}
+
+func (l *Loader) writeLock(lock Lockfile) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := l.lockPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write lockfile: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeLocked 删除技能的锁定记录和所有版本目录
+func (l *Loader) removeLocked(id string) error {
+	lock := l.readLock()
+	if _, ok := lock.Skills[id]; !ok {
+		return nil
+	}
+	delete(lock.Skills, id)
+	os.RemoveAll(filepath.Join(l.skillDirs[0], versionsDir, filepath.Base(id)))
+	return l.writeLock(lock)
+}
+
+// describeChanges 生成更新的变更记录与文件变化
+func describeChanges(from, to LockEntry) ([]string, string) {
+	var changelog []string
+	if from.Version != to.Version {
+		changelog = append(changelog, fmt.Sprintf("version %s -> %s", orNone(from.Version), orNone(to.Version)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if from.Commit != "" && to.Commit != "" {
+		if out, err := gitOutput(ctx, to.Path, "log", "--oneline", "--no-decorate", from.Commit+".."+to.Commit); err == nil {
+			for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+				if line != "" {
+					changelog = append(changelog, line)
+				}
+			}
+			if diff, err := gitOutput(ctx, to.Path, "diff", "--stat", from.Commit, to.Commit); err == nil {
+				return changelog, strings.TrimRight(diff, "\n")
+			}
+		}
+	}
+	return changelog, diffDirs(from.Path, to.Path)
+}
+
+// diffDirs 比较两个目录的文件列表 (A 新增 / M 修改 / D 删除)
+func diffDirs(from, to string) string {
+	a, b := hashFiles(from), hashFiles(to)
+	var lines []string
+	for name, sum := range b {
+		if old, ok := a[name]; !ok {
+			lines = append(lines, "A "+name)
+		} else if old != sum {
+			lines = append(lines, "M "+name)
+		}
+	}
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			lines = append(lines, "D "+name)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][2:] < lines[j][2:] })
+	return strings.Join(lines, "\n")
+}
+
+func hashFiles(dir string) map[string]string {
+	files := make(map[string]string)
+	if dir == "" {
+		return files
+	}
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, path)
+		sum := sha256.Sum256(data)
+		files[filepath.ToSlash(rel)] = hex.EncodeToString(sum[:])
+		return nil
+	})
+	return files
+}
+
+// pruneVersions 只保留当前和上一个版本
+func pruneVersions(dir string, keep ...string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !containsPath(keep, path) {
+			os.RemoveAll(path)
+		}
+	}
+}
+
+func containsPath(list []string, path string) bool {
+	for _, p := range list {
+		if p != "" && filepath.Clean(p) == filepath.Clean(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func gitClone(ctx context.Context, url, ref, dir string) error {
+	if out, err := exec.CommandContext(ctx, "git", "clone", "--quiet", url, dir).CombinedOutput(); err != nil {
+		return fmt.Errorf("git clone %s: %v: %s", url, err, strings.TrimSpace(string(out)))
+	}
+	if ref != "" {
+		if _, err := gitOutput(ctx, dir, "checkout", "--quiet", ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func gitOutput(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// archiveFile 压缩包中的条目
+type archiveFile struct {
+	name string // 清理后的相对路径
+	dir  bool
+	mode os.FileMode
+	data []byte
+}
+
+// extractArchive 解压 tar.gz 到 dir；所有文件位于同一顶层目录时去掉该层
+func extractArchive(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer gz.Close()
+
+	var files []archiveFile
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("read archive: %w", err)
+		}
+		name := filepath.ToSlash(filepath.Clean(hdr.Name))
+		if name == "." {
+			continue
+		}
+		if name == ".." || strings.HasPrefix(name, "../") || strings.HasPrefix(name, "/") {
+			return fmt.Errorf("archive entry %q escapes the target directory", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			files = append(files, archiveFile{name: name, dir: true})
+		case tar.TypeReg:
+			content, err := io.ReadAll(io.LimitReader(tr, maxDownloadSize))
+			if err != nil {
+				return err
+			}
+			files = append(files, archiveFile{name: name, mode: os.FileMode(hdr.Mode).Perm(), data: content})
+		}
+	}
+
+	root := archiveRoot(files)
+	for _, f := range files {
+		if f.name == root {
+			continue
+		}
+		name := f.name
+		if root != "" {
+			name = strings.TrimPrefix(name, root+"/")
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if f.dir {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		mode := f.mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(path, f.data, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveRoot 所有条目都在同一顶层目录下时返回该目录名，否则返回空
+func archiveRoot(files []archiveFile) string {
+	root := ""
+	for _, f := range files {
+		first, _, nested := strings.Cut(f.name, "/")
+		if !nested && !f.dir {
+			return "" // 顶层文件
+		}
+		if root != "" && first != root {
+			return ""
+		}
+		root = first
+	}
+	return root
+}
+
+func shortVersion(v string) string {
+	if len(v) > 12 {
+		return v[:12]
+	}
+	return v
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,249 @@
+package skills
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSource(t *testing.T) {
+	tests := []struct {
+		source string
+		kind   string
+		url    string
+		ref    string
+	}{
+		{"user/repo", SourceGitHub, "https://github.com/user/repo.git", ""},
+		{"github.com/user/repo@v1.2.0", SourceGitHub, "https://github.com/user/repo.git", "v1.2.0"},
+		{"user/repo#main", SourceGitHub, "https://github.com/user/repo.git", "main"},
+		{"git+https://git.example.com/skills/video.git@abc123", SourceGit, "https://git.example.com/skills/video.git", "abc123"},
+		{"git+git@example.com:skills/video.git", SourceGit, "git@example.com:skills/video.git", ""},
+		{"https://example.com/video.tar.gz#sha256=abcd", SourceTarball, "https://example.com/video.tar.gz", ""},
+		{"./skills/video", SourceLocal, "./skills/video", ""},
+	}
+	for _, tt := range tests {
+		spec, err := parseSource(tt.source)
+		if err != nil {
+			t.Errorf("parseSource(%q) failed: %v", tt.source, err)
+			continue
+		}
+		if spec.kind != tt.kind || spec.url != tt.url || spec.ref != tt.ref {
+			t.Errorf("parseSource(%q) = %+v", tt.source, spec)
+		}
+	}
+
+	for _, source := range []string{"https://example.com/video.tar.gz", "https://example.com/video.zip#sha256=ab", "invalid"} {
+		if _, err := parseSource(source); err == nil {
+			t.Errorf("parseSource(%q) should fail", source)
+		}
+	}
+}
+
+// gitRepo 创建包含技能的本地 git 仓库
+func gitRepo(t *testing.T) (string, func(version, message string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	run("init", "--quiet")
+	commit := func(version, message string) {
+		content := "# Demo\n\n- **Version**: " + version + "\n"
+		if version == "" {
+			content = "no heading\n"
+		}
+		os.WriteFile(filepath.Join(dir, "SKILL.md"), []byte(content), 0644)
+		run("add", "-A")
+		run("commit", "--quiet", "-m", message)
+	}
+	commit("1.0.0", "initial")
+	run("tag", "v1.0.0")
+	return dir, commit
+}
+
+func TestLoader_InstallAndUpdateGit(t *testing.T) {
+	repo, commit := gitRepo(t)
+	tmpDir := t.TempDir()
+	skillDir := filepath.Join(tmpDir, "skills")
+	loader := NewLoader(LoaderConfig{SkillDirs: []string{skillDir}, StateFile: filepath.Join(tmpDir, "state.json")})
+
+	skill, err := loader.Install("git+" + repo)
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if skill.ID != "demo" || skill.Version != "1.0.0" {
+		t.Fatalf("unexpected skill: %+v", skill)
+	}
+	entry, ok := loader.Lock().Skills["demo"]
+	if !ok || entry.Commit == "" || entry.Pinned {
+		t.Fatalf("lock entry = %+v", entry)
+	}
+	if target, err := os.Readlink(filepath.Join(skillDir, "demo")); err != nil || target != entry.Path {
+		t.Fatalf("skill link = %q, %v", target, err)
+	}
+
+	commit("1.1.0", "add feature")
+	plan, err := loader.PlanUpdate("demo", UpdateOptions{})
+	if err != nil {
+		t.Fatalf("PlanUpdate failed: %v", err)
+	}
+	if plan.UpToDate || plan.FromVersion != "1.0.0" || plan.ToVersion != "1.1.0" {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if !strings.Contains(strings.Join(plan.Changelog, "\n"), "add feature") || !strings.Contains(plan.Diff, "SKILL.md") {
+		t.Errorf("plan should describe changes: %+v", plan)
+	}
+	if got, _ := loader.Get("demo"); got.Version != "1.0.0" {
+		t.Error("PlanUpdate should not switch versions")
+	}
+
+	updated, err := loader.ApplyUpdate("demo")
+	if err != nil {
+		t.Fatalf("ApplyUpdate failed: %v", err)
+	}
+	if updated.Version != "1.1.0" {
+		t.Errorf("version = %s", updated.Version)
+	}
+	if next := loader.Lock().Skills["demo"]; next.Previous != entry.Path {
+		t.Errorf("previous = %q, want %q", next.Previous, entry.Path)
+	}
+
+	// SKILL.md 损坏的版本不会被安装
+	commit("", "break skill")
+	if _, err := loader.PlanUpdate("demo", UpdateOptions{}); err == nil {
+		t.Fatal("expected broken update to fail")
+	}
+	reloaded := NewLoader(LoaderConfig{SkillDirs: []string{skillDir}, StateFile: filepath.Join(tmpDir, "state.json")})
+	reloaded.LoadAll()
+	if got, ok := reloaded.Get("demo"); !ok || got.Version != "1.1.0" {
+		t.Errorf("current version should survive a broken update: %+v", got)
+	}
+
+	// 固定 ref 后更新不跟随分支
+	if err := loader.Update("demo"); err == nil {
+		t.Fatal("expected update to the broken head to fail")
+	}
+	plan, err = loader.PlanUpdate("demo", UpdateOptions{Ref: "v1.0.0"})
+	if err != nil {
+		t.Fatalf("PlanUpdate with ref failed: %v", err)
+	}
+	if _, err := loader.ApplyUpdate("demo"); err != nil {
+		t.Fatalf("ApplyUpdate failed: %v", err)
+	}
+	pinned := loader.Lock().Skills["demo"]
+	if !pinned.Pinned || pinned.Ref != "v1.0.0" || plan.ToVersion != "1.0.0" {
+		t.Errorf("pinned entry = %+v", pinned)
+	}
+	if plan, err := loader.PlanUpdate("demo", UpdateOptions{}); err != nil || !plan.UpToDate {
+		t.Errorf("pinned skill should stay up to date: %+v, %v", plan, err)
+	}
+
+	if err := loader.Uninstall("demo"); err != nil {
+		t.Fatalf("Uninstall failed: %v", err)
+	}
+	if _, ok := loader.Lock().Skills["demo"]; ok {
+		t.Error("lock entry should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(skillDir, versionsDir, "demo")); !os.IsNotExist(err) {
+		t.Error("version directories should be removed")
+	}
+	if _, err := os.Stat(filepath.Join(repo, "SKILL.md")); err != nil {
+		t.Error("source repository should be untouched")
+	}
+}
+
+func TestLoader_InstallTarball(t *testing.T) {
+	content := []byte("# Packed\n\n- **Version**: 2.0.0\n")
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "packed-2.0.0/", Mode: 0755, Typeflag: tar.TypeDir})
+	tw.WriteHeader(&tar.Header{Name: "packed-2.0.0/SKILL.md", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(archive.Bytes())
+	}))
+	defer srv.Close()
+	sum := sha256.Sum256(archive.Bytes())
+
+	tmpDir := t.TempDir()
+	loader := NewLoader(LoaderConfig{SkillDirs: []string{tmpDir}, StateFile: filepath.Join(tmpDir, "state.json")})
+
+	if _, err := loader.Install(srv.URL + "/packed.tar.gz#sha256=" + strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected checksum error, got %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(tmpDir, "packed")); !os.IsNotExist(err) {
+		t.Error("nothing should be installed on checksum mismatch")
+	}
+
+	skill, err := loader.Install(srv.URL + "/packed.tar.gz#sha256=" + hex.EncodeToString(sum[:]))
+	if err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if skill.ID != "packed" || skill.Version != "2.0.0" {
+		t.Errorf("unexpected skill: %+v", skill)
+	}
+	if entry := loader.Lock().Skills["packed"]; entry.SHA256 != hex.EncodeToString(sum[:]) || entry.Kind != SourceTarball {
+		t.Errorf("lock entry = %+v", entry)
+	}
+}
+
+func TestLoader_InstallRejectsInvalidID(t *testing.T) {
+	for _, id := range []string{"..", "."} {
+		content := []byte("---\nname: Escape\nid: " + id + "\n---\n# Escape\n")
+		var archive bytes.Buffer
+		gz := gzip.NewWriter(&archive)
+		tw := tar.NewWriter(gz)
+		tw.WriteHeader(&tar.Header{Name: "SKILL.md", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write(content)
+		tw.Close()
+		gz.Close()
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write(archive.Bytes())
+		}))
+		sum := sha256.Sum256(archive.Bytes())
+
+		tmpDir := t.TempDir()
+		skillDir := filepath.Join(tmpDir, "skills")
+		loader := NewLoader(LoaderConfig{SkillDirs: []string{skillDir}, StateFile: filepath.Join(tmpDir, "state.json")})
+		_, err := loader.Install(srv.URL + "/escape.tar.gz#sha256=" + hex.EncodeToString(sum[:]))
+		srv.Close()
+		if err == nil || !strings.Contains(err.Error(), "invalid skill id") {
+			t.Fatalf("id %q: expected validation error, got %v", id, err)
+		}
+		if _, err := os.Stat(filepath.Join(skillDir, lockFileName)); !os.IsNotExist(err) {
+			t.Errorf("id %q: lock file should not be written", id)
+		}
+		entries, _ := os.ReadDir(skillDir)
+		for _, e := range entries {
+			if e.Name() != versionsDir {
+				t.Errorf("id %q: unexpected entry %s in skill dir", id, e.Name())
+			}
+		}
+	}
+}
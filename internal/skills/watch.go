@@ -0,0 +1,155 @@
+package skills
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// Reload 重新扫描技能目录，返回新增、删除或 SKILL.md 有变化的技能 ID
+func (l *Loader) Reload() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	old := l.skills
+	l.skills = make(map[string]*Skill, len(old))
+	for id, s := range old {
+		l.skills[id] = &Skill{ID: id, Enabled: s.Enabled, Source: s.Source}
+	}
+	for _, dir := range l.skillDirs {
+		if err := l.scanDirectory(dir); err != nil {
+			log.Warn().Err(err).Str("dir", dir).Msg("Failed to scan skill directory")
+		}
+	}
+
+	var changed []string
+	for id, s := range l.skills {
+		prev, existed := old[id]
+		if s.Path == "" && existed && prev.Path != "" {
+			// 目录已删除
+			delete(l.skills, id)
+			changed = append(changed, id)
+			continue
+		}
+		if s.Path == "" && existed {
+			l.skills[id] = prev // 只在状态文件中的技能保持不变
+			continue
+		}
+		if !existed || !sameSkill(prev, s) {
+			changed = append(changed, id)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// sameSkill 比较两次解析的结果，忽略加载时间
+func sameSkill(a, b *Skill) bool {
+	x, y := *a, *b
+	x.LoadedAt, y.LoadedAt = time.Time{}, time.Time{}
+	return reflect.DeepEqual(x, y)
+}
+
+// Watch 监听技能目录与各技能的 SKILL.md，变化时重新加载并调用 onChange，直到 ctx 结束
+func (l *Loader) Watch(ctx context.Context, onChange func(changed []string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	l.watchDirs(watcher)
+
+	go func() {
+		defer watcher.Close()
+
+		// 合并短时间内的多次写入 (如 git checkout、切换版本链接)
+		var timer *time.Timer
+		trigger := make(chan struct{}, 1)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !l.skillEvent(event.Name) {
+					continue
+				}
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(300*time.Millisecond, func() {
+					select {
+					case trigger <- struct{}{}:
+					default:
+					}
+				})
+			case <-trigger:
+				changed := l.Reload()
+				l.watchDirs(watcher) // 新增的技能目录
+				if len(changed) > 0 {
+					log.Info().Strs("skills", changed).Msg("Skills reloaded")
+					if onChange != nil {
+						onChange(changed)
+					}
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Error().Err(err).Msg("Skill watcher error")
+			}
+		}
+	}()
+
+	return nil
+}
+
+// watchDirs 监听技能目录及其中每个技能的目录 (重复添加无副作用)
+func (l *Loader) watchDirs(watcher *fsnotify.Watcher) {
+	for _, dir := range l.skillDirs {
+		if err := watcher.Add(dir); err != nil {
+			log.Debug().Err(err).Str("dir", dir).Msg("Skills: cannot watch directory")
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				watcher.Add(path)
+			}
+		}
+	}
+}
+
+// skillEvent 只关心技能目录的增删和 SKILL.md 的变化，忽略锁文件、临时文件等
+func (l *Loader) skillEvent(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	if name == "SKILL.md" {
+		return true
+	}
+	parent := filepath.Dir(path)
+	for _, dir := range l.skillDirs {
+		if filepath.Clean(dir) == parent {
+			return name != lockFileName && !strings.HasSuffix(name, ".tmp")
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package skills
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeSkill(t *testing.T, dir, name, version string) {
+	t.Helper()
+	os.MkdirAll(filepath.Join(dir, strings.ToLower(name)), 0755)
+	content := "# " + name + "\n\n- **Version**: " + version + "\n"
+	if err := os.WriteFile(filepath.Join(dir, strings.ToLower(name), "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoader_Reload(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSkill(t, tmpDir, "Alpha", "1.0.0")
+	writeSkill(t, tmpDir, "Beta", "1.0.0")
+
+	loader := NewLoader(LoaderConfig{SkillDirs: []string{tmpDir}, StateFile: filepath.Join(tmpDir, ".state.json")})
+	loader.LoadAll()
+	loader.Enable("alpha")
+
+	if changed := loader.Reload(); len(changed) != 0 {
+		t.Errorf("nothing changed, got %v", changed)
+	}
+
+	writeSkill(t, tmpDir, "Alpha", "1.1.0")
+	writeSkill(t, tmpDir, "Gamma", "0.1.0")
+	os.RemoveAll(filepath.Join(tmpDir, "beta"))
+
+	changed := loader.Reload()
+	if strings.Join(changed, ",") != "alpha,beta,gamma" {
+		t.Errorf("changed = %v", changed)
+	}
+	alpha, _ := loader.Get("alpha")
+	if alpha.Version != "1.1.0" || !alpha.Enabled {
+		t.Errorf("alpha should be updated and stay enabled: %+v", alpha)
+	}
+	if _, ok := loader.Get("beta"); ok {
+		t.Error("removed skill should be dropped")
+	}
+}
+
+func TestLoader_Watch(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeSkill(t, tmpDir, "Alpha", "1.0.0")
+
+	loader := NewLoader(LoaderConfig{SkillDirs: []string{tmpDir}, StateFile: filepath.Join(tmpDir, ".state.json")})
+	loader.LoadAll()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan []string, 4)
+	if err := loader.Watch(ctx, func(changed []string) { changes <- changed }); err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+
+	wait := func(want string) {
+		t.Helper()
+		select {
+		case changed := <-changes:
+			if strings.Join(changed, ",") != want {
+				t.Errorf("changed = %v, want %s", changed, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no reload for %s", want)
+		}
+	}
+
+	writeSkill(t, tmpDir, "Alpha", "2.0.0")
+	wait("alpha")
+	if alpha, _ := loader.Get("alpha"); alpha.Version != "2.0.0" {
+		t.Errorf("version = %s", alpha.Version)
+	}
+
+	// 新增的技能目录也会被监听
+	writeSkill(t, tmpDir, "Beta", "1.0.0")
+	wait("beta")
+	writeSkill(t, tmpDir, "Beta", "1.0.1")
+	wait("beta")
+}